@@ -1,36 +1,72 @@
 package action
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/observiq/bindplane-op-action/action/catalog"
+	"github.com/observiq/bindplane-op-action/action/freeze"
+	"github.com/observiq/bindplane-op-action/action/notify"
+	"github.com/observiq/bindplane-op-action/action/otelconfig"
 	"github.com/observiq/bindplane-op-action/action/state"
-	"github.com/observiq/bindplane-op-action/internal/client"
-	"github.com/observiq/bindplane-op-action/internal/client/config"
-	"github.com/observiq/bindplane-op-action/internal/client/model"
-	"github.com/observiq/bindplane-op-action/internal/client/version"
+	"github.com/observiq/bindplane-op-action/internal/github"
+	"github.com/observiq/bindplane-op-action/internal/logging"
 	"github.com/observiq/bindplane-op-action/internal/repo"
+	"github.com/observiq/bindplane-op-action/internal/workflow"
+	"github.com/observiq/bindplane-op-action/pkg/client"
+	"github.com/observiq/bindplane-op-action/pkg/client/config"
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"github.com/observiq/bindplane-op-action/pkg/client/version"
 	"gopkg.in/yaml.v3"
 
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing/object"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 const (
 	BugError = "This is a bug with the action, please reach out to support or file an issue on Github https://github.com/observIQ/bindplane-op-action/issues"
 )
 
+// DefaultFailOnStatuses are the resource statuses which fail the action
+// when WithFailOnStatuses is not set. Every status which does not indicate
+// a successful apply is included.
+var DefaultFailOnStatuses = []model.UpdateStatus{
+	model.StatusDeleted,
+	model.StatusNotFound,
+	model.StatusInvalid,
+	model.StatusError,
+	model.StatusInUse,
+	model.StatusForbidden,
+	model.StatusDeprecated,
+}
+
 type rType string
 
 // Option is a function that configures an Action option
 type Option func(*Action)
 
+// WithContext sets the context for BindPlane and GitHub requests. Action
+// spans are children of the span in the context, if any.
+func WithContext(ctx context.Context) Option {
+	return func(a *Action) {
+		a.ctx = ctx
+	}
+}
+
 // WithBindPlaneRemoteURL sets the remote URL for the BindPlane client
 func WithBindPlaneRemoteURL(u string) Option {
 	return func(a *Action) {
@@ -59,7 +95,22 @@ func WithBindPlanePassword(p string) Option {
 	}
 }
 
-// WithTLSCACert sets the certificate authority for the BindPlane client
+// WithBindPlaneAccountID sets the account ID for the BindPlane client
+func WithBindPlaneAccountID(id string) Option {
+	return func(a *Action) {
+		a.config.Auth.AccountID = id
+	}
+}
+
+// WithBindPlaneProjectID sets the project ID for the BindPlane client
+func WithBindPlaneProjectID(id string) Option {
+	return func(a *Action) {
+		a.config.Auth.ProjectID = id
+	}
+}
+
+// WithTLSCACert sets the certificate authority for the BindPlane client. It can
+// be PEM encoded content, a file path, or a directory of PEM encoded files.
 func WithTLSCACert(c string) Option {
 	return func(a *Action) {
 		if c == "" {
@@ -69,6 +120,43 @@ func WithTLSCACert(c string) Option {
 	}
 }
 
+// WithTLSCert sets the client certificate used for mutual TLS. It can
+// be PEM encoded content or a file path.
+func WithTLSCert(c string) Option {
+	return func(a *Action) {
+		a.config.Network.TLS.Certificate = c
+	}
+}
+
+// WithTLSKey sets the client private key used for mutual TLS. It can
+// be PEM encoded content or a file path.
+func WithTLSKey(k string) Option {
+	return func(a *Action) {
+		a.config.Network.TLS.PrivateKey = k
+	}
+}
+
+// WithInsecureSkipVerify sets the flag to skip TLS server certificate verification
+func WithInsecureSkipVerify(b bool) Option {
+	return func(a *Action) {
+		a.config.Network.TLS.InsecureSkipVerify = b
+	}
+}
+
+// WithTLSMinVersion sets the minimum TLS version for the BindPlane client
+func WithTLSMinVersion(v string) Option {
+	return func(a *Action) {
+		a.config.Network.TLS.MinVersion = v
+	}
+}
+
+// WithTLSCipherSuites sets the TLS 1.2 cipher suites for the BindPlane client
+func WithTLSCipherSuites(suites []string) Option {
+	return func(a *Action) {
+		a.config.Network.TLS.CipherSuites = suites
+	}
+}
+
 // WithDestinationPath sets the path to write resources to
 func WithDestinationPath(p string) Option {
 	return func(a *Action) {
@@ -90,214 +178,1562 @@ func WithProcessorPath(p string) Option {
 	}
 }
 
-// WithConfigurationPath sets the path to read configuration from
-func WithConfigurationPath(p string) Option {
-	return func(a *Action) {
-		a.configurationPath = p
+// WithAgentVersionPath sets the path to read agent versions from
+func WithAgentVersionPath(p string) Option {
+	return func(a *Action) {
+		a.agentVersionPath = p
+	}
+}
+
+// WithConfigurationPath sets the path to read configuration from
+func WithConfigurationPath(p string) Option {
+	return func(a *Action) {
+		a.configurationPath = p
+	}
+}
+
+// WithOTELConfigWriteBack sets the flag to enable writing back configuration
+func WithOTELConfigWriteBack(b bool) Option {
+	return func(a *Action) {
+		a.enableWriteBack = b
+	}
+}
+
+// WithConfigurationOutputDir sets the directory to write back configuration to
+func WithConfigurationOutputDir(d string) Option {
+	return func(a *Action) {
+		a.configurationOutputDir = d
+	}
+}
+
+// WithGithubToken sets the token to authenticate with GitHub
+func WithGithubToken(t string) Option {
+	return func(a *Action) {
+		a.githubToken = t
+	}
+}
+
+// WithGithubURL sets the URL to the GitHub repository
+func WithGithubURL(u string) Option {
+	return func(a *Action) {
+		a.githubURL = u
+	}
+}
+
+// WithAutoRollout sets the flag to enable auto rollout
+func WithAutoRollout(b bool) Option {
+	return func(a *Action) {
+		a.autoRollout = b
+	}
+}
+
+// WithConfigurationOutputBranch sets the branch to write back the configuration to
+func WithConfigurationOutputBranch(b string) Option {
+	return func(a *Action) {
+		a.configurationOutputBranch = b
+	}
+}
+
+// WithEnvironment sets the environment used to resolve variables
+func WithEnvironment(e string) Option {
+	return func(a *Action) {
+		a.environment = e
+	}
+}
+
+// WithVariablesPath sets the path to the environment variables file
+func WithVariablesPath(p string) Option {
+	return func(a *Action) {
+		a.variablesPath = p
+	}
+}
+
+// WithRetryMaxAttempts sets the maximum number of attempts for BindPlane API requests
+func WithRetryMaxAttempts(n int) Option {
+	return func(a *Action) {
+		a.retryMaxAttempts = n
+	}
+}
+
+// WithRetryMaxElapsedTime sets the maximum amount of time spent retrying BindPlane API requests
+func WithRetryMaxElapsedTime(d time.Duration) Option {
+	return func(a *Action) {
+		a.retryMaxElapsedTime = d
+	}
+}
+
+// WithRetryStatusCodes sets the HTTP status codes that will be retried
+func WithRetryStatusCodes(codes []int) Option {
+	return func(a *Action) {
+		a.retryStatusCodes = codes
+	}
+}
+
+// WithApplyTimeout sets the maximum amount of time an apply or delete
+// request to BindPlane may take, including retries
+func WithApplyTimeout(d time.Duration) Option {
+	return func(a *Action) {
+		a.applyTimeout = d
+	}
+}
+
+// WithFetchTimeout sets the maximum amount of time a request which reads
+// configurations or other resources from BindPlane may take, including retries
+func WithFetchTimeout(d time.Duration) Option {
+	return func(a *Action) {
+		a.fetchTimeout = d
+	}
+}
+
+// WithFreezeWindowsPath sets the path to the freeze windows file
+func WithFreezeWindowsPath(p string) Option {
+	return func(a *Action) {
+		a.freezeWindowsPath = p
+	}
+}
+
+// WithFreezeOverride sets the flag to allow running during a freeze window
+func WithFreezeOverride(b bool) Option {
+	return func(a *Action) {
+		a.freezeOverride = b
+	}
+}
+
+// WithMinBindPlaneVersion sets the minimum BindPlane server version
+// the action will run against, such as v1.50.0. Empty disables the check.
+func WithMinBindPlaneVersion(v string) Option {
+	return func(a *Action) {
+		a.minBindPlaneVersion = v
+	}
+}
+
+// WithValidateRenderedConfig sets the flag to validate the rendered
+// OpenTelemetry configuration of each applied configuration
+func WithValidateRenderedConfig(b bool) Option {
+	return func(a *Action) {
+		a.validateRenderedConfig = b
+	}
+}
+
+// WithValidatePipelines sets the flag to check that the sources and
+// destinations of configurations have a telemetry type in common,
+// before applying
+func WithValidatePipelines(b bool) Option {
+	return func(a *Action) {
+		a.validatePipelines = b
+	}
+}
+
+// WithSkipUnchanged sets the flag to compare resources with the server
+// before applying, and skip resources which are unchanged
+func WithSkipUnchanged(b bool) Option {
+	return func(a *Action) {
+		a.skipUnchanged = b
+	}
+}
+
+// WithStampLabels sets the flag to add the managed-by, source-repo, and
+// content-hash labels to every resource before it is applied
+func WithStampLabels(b bool) Option {
+	return func(a *Action) {
+		a.stampLabels = b
+	}
+}
+
+// WithExportRaw sets the flag to write the raw OpenTelemetry configuration
+// of each configuration when exporting, see Export
+func WithExportRaw(b bool) Option {
+	return func(a *Action) {
+		a.exportRaw = b
+	}
+}
+
+// WithRawConfigDir sets the directory the raw configuration of each
+// applied configuration is saved to, see SaveRawConfigurations
+func WithRawConfigDir(dir string) Option {
+	return func(a *Action) {
+		a.rawConfigDir = dir
+	}
+}
+
+// WithRenderedDiff sets the flag to diff the rendered configuration of
+// each configuration before and after apply, see RenderedDiffs
+func WithRenderedDiff(b bool) Option {
+	return func(a *Action) {
+		a.renderedDiff = b
+	}
+}
+
+// WithLock sets the flag to lock the repository's configurations while
+// they are applied and rolled out, so concurrent runs do not interleave
+func WithLock(b bool) Option {
+	return func(a *Action) {
+		a.lock = b
+	}
+}
+
+// WithLockTimeout sets how long to wait for configurations locked by
+// another run. Values less than or equal to zero are ignored.
+func WithLockTimeout(d time.Duration) Option {
+	return func(a *Action) {
+		if d > 0 {
+			a.lockTimeout = d
+		}
+	}
+}
+
+// WithPrune sets the flag to delete server resources which match the
+// prune selector but are not in the repository
+func WithPrune(b bool) Option {
+	return func(a *Action) {
+		a.prune = b
+	}
+}
+
+// WithPruneSelector sets the label selector, such as managed-by=gitops,
+// which identifies server resources managed by the repository
+func WithPruneSelector(s string) Option {
+	return func(a *Action) {
+		a.pruneSelector = s
+	}
+}
+
+// WithPruneConfirm sets the flag to delete resources when pruning. When
+// false, the resources which would be deleted are only logged.
+func WithPruneConfirm(b bool) Option {
+	return func(a *Action) {
+		a.pruneConfirm = b
+	}
+}
+
+// WithProtectedResources sets the names of resources the action must not
+// modify or delete. Each is a name, or a kind and name such as
+// Destination/prod-otlp.
+func WithProtectedResources(names []string) Option {
+	return func(a *Action) {
+		a.protectedResources = names
+	}
+}
+
+// WithProtectedSelector sets the label selector which identifies
+// resources the action must not modify or delete
+func WithProtectedSelector(s string) Option {
+	return func(a *Action) {
+		a.protectedSelector = s
+	}
+}
+
+// WithRolloutAllPending sets the flag to start rollouts for every
+// configuration with a pending version, including configurations which
+// are not in the repository
+func WithRolloutAllPending(b bool) Option {
+	return func(a *Action) {
+		a.rolloutAllPending = b
+	}
+}
+
+// WithRemoteResourceHeaders sets headers, such as an authorization header,
+// which are sent when downloading resource files from https URLs
+func WithRemoteResourceHeaders(headers map[string]string) Option {
+	return func(a *Action) {
+		a.remoteHeaders = headers
+	}
+}
+
+// WithOverlaysDir sets the overlays directory, which contains a directory
+// of overlay files for each environment. Overlays are deep merged into the
+// resources with the same kind and name before they are applied.
+func WithOverlaysDir(dir string) Option {
+	return func(a *Action) {
+		a.overlaysDir = dir
+	}
+}
+
+// WithPatchesPath sets the path or glob of patch files, which target
+// resources by kind and name with a strategic merge patch or JSON 6902
+// operations. Patches are applied after overlays.
+func WithPatchesPath(path string) Option {
+	return func(a *Action) {
+		a.patchesPath = path
+	}
+}
+
+// WithResourceNameEnvironment adds the environment to resource names,
+// as a prefix or suffix, so the resources of several environments can
+// be applied to the same project. References between resources in the
+// repository are renamed to match.
+func WithResourceNameEnvironment(position string) Option {
+	return func(a *Action) {
+		a.resourceNameEnvironment = position
+	}
+}
+
+// WithChangedFiles sets the files changed by the commits being applied,
+// relative to the working directory. When set, only resources read from
+// a changed file are applied. Nil applies every resource.
+func WithChangedFiles(files []string) Option {
+	return func(a *Action) {
+		a.changedFiles = files
+	}
+}
+
+// WithOCIArtifact sets the reference of an OCI artifact, such as
+// ghcr.io/my-org/bindplane@sha256:<digest>, which contains resources
+// that are applied with the resources read from the resource paths
+func WithOCIArtifact(ref string) Option {
+	return func(a *Action) {
+		a.ociArtifact = ref
+	}
+}
+
+// WithOCICredentials sets the username and password, such as a token,
+// used to pull the OCI artifact from the registry
+func WithOCICredentials(username, password string) Option {
+	return func(a *Action) {
+		a.ociUsername = username
+		a.ociPassword = password
+	}
+}
+
+// WithRolloutSelector sets the label selector, such as team=payments, of
+// configurations which are rolled out in addition to the configurations
+// in the repository
+func WithRolloutSelector(s string) Option {
+	return func(a *Action) {
+		a.rolloutSelector = s
+	}
+}
+
+// WithSlackWebhookURL sets the Slack incoming webhook URL which
+// is notified when a rollout the action waits on finishes
+func WithSlackWebhookURL(u string) Option {
+	return func(a *Action) {
+		a.slackWebhookURL = u
+	}
+}
+
+// WithWebhookURL sets the URL which is sent lifecycle events
+func WithWebhookURL(u string) Option {
+	return func(a *Action) {
+		a.webhookURL = u
+	}
+}
+
+// WithWebhookTemplate sets the Go template used to render webhook
+// payloads. When empty, the event is sent as JSON.
+func WithWebhookTemplate(t string) Option {
+	return func(a *Action) {
+		a.webhookTemplate = t
+	}
+}
+
+// WithWebhookEvents sets the lifecycle events sent to the
+// webhook. When empty, all events are sent.
+func WithWebhookEvents(events []string) Option {
+	return func(a *Action) {
+		a.webhookEvents = events
+	}
+}
+
+// WithGithubDeploymentEnvironment sets the environment of the GitHub
+// deployment created for each run. When empty, deployments are not created.
+func WithGithubDeploymentEnvironment(env string) Option {
+	return func(a *Action) {
+		a.deploymentEnvironment = env
+	}
+}
+
+// WithCommitStatusPrefix sets the context prefix of the commit status
+// set for each rolled out configuration. When empty, commit statuses
+// are not set.
+func WithCommitStatusPrefix(prefix string) Option {
+	return func(a *Action) {
+		a.commitStatusPrefix = prefix
+	}
+}
+
+// WithRolloutWait sets the flag to wait for started rollouts to finish
+func WithRolloutWait(b bool) Option {
+	return func(a *Action) {
+		a.rolloutWait = b
+	}
+}
+
+// WithRolloutTimeout sets the maximum amount of time to wait for rollouts.
+// Values less than or equal to zero are ignored.
+func WithRolloutTimeout(d time.Duration) Option {
+	return func(a *Action) {
+		if d > 0 {
+			a.rolloutTimeout = d
+		}
+	}
+}
+
+// WithRolloutPollInterval sets how often rollout status is polled while
+// waiting. Values less than or equal to zero are ignored.
+func WithRolloutPollInterval(d time.Duration) Option {
+	return func(a *Action) {
+		if d > 0 {
+			a.rolloutPollInterval = d
+		}
+	}
+}
+
+// WithRolloutPollJitter sets the percentage of the poll interval, from 0
+// to 100, randomly added to each poll. Defaults to DefaultRolloutPollJitter,
+// 0 disables jitter. Negative values are ignored.
+func WithRolloutPollJitter(percent int) Option {
+	return func(a *Action) {
+		if percent >= 0 {
+			a.rolloutPollJitter = percent
+		}
+	}
+}
+
+// WithMaxRolloutErrors sets the number of errored agents a rollout may have
+// before the action stops waiting and fails. When nil, errored agents do not
+// fail the action until the rollout itself errors.
+func WithMaxRolloutErrors(t *ErrorThreshold) Option {
+	return func(a *Action) {
+		a.maxRolloutErrors = t
+	}
+}
+
+// WithRolloutPauseOnErrors sets the flag to pause a rollout which
+// exceeds the max rollout errors
+func WithRolloutPauseOnErrors(b bool) Option {
+	return func(a *Action) {
+		a.rolloutPauseOnErrors = b
+	}
+}
+
+// WithCanarySelector sets the labels of canary agents, such as canary=true.
+// When set, rollouts are first rolled out to the canary agents, and only
+// continue to every agent once the canary agents are healthy.
+func WithCanarySelector(s string) Option {
+	return func(a *Action) {
+		a.canarySelector = s
+	}
+}
+
+// WithCanaryWait sets how long canary agents must stay healthy after they
+// are configured, before the rollout continues to every agent
+func WithCanaryWait(d time.Duration) Option {
+	return func(a *Action) {
+		a.canaryWait = d
+	}
+}
+
+// WithAgentCheck counts the agents matching a configuration before its
+// rollout starts. With warn, an unexpected count is logged. With fail, the
+// rollout is not started. An empty check does not count agents.
+func WithAgentCheck(s string) Option {
+	return func(a *Action) {
+		a.agentCheck = s
+	}
+}
+
+// WithExpectedAgents sets the number of agents each configuration is
+// expected to match, see WithAgentCheck. When nil, at least one agent
+// must match.
+func WithExpectedAgents(r *AgentRange) Option {
+	return func(a *Action) {
+		a.expectedAgents = r
+	}
+}
+
+// WithAgentWait waits, once the rollouts are complete, for every agent
+// matching each configuration to be connected and running the rolled out
+// version. Requires WithRolloutWait.
+func WithAgentWait(wait bool) Option {
+	return func(a *Action) {
+		a.agentWait = wait
+	}
+}
+
+// WithAgentWaitTimeout sets how long to wait for agents to run the rolled
+// out configurations. Values less than or equal to zero use
+// DefaultAgentWaitTimeout.
+func WithAgentWaitTimeout(d time.Duration) Option {
+	return func(a *Action) {
+		a.agentWaitTimeout = d
+	}
+}
+
+// WithAgentCleanupAge deletes agents disconnected for longer than d
+// before rollouts are started. Zero does not delete agents.
+func WithAgentCleanupAge(d time.Duration) Option {
+	return func(a *Action) {
+		a.agentCleanupAge = d
+	}
+}
+
+// WithMinThroughputPercent verifies, once rollouts are complete, that the
+// throughput of each rolled out configuration is at least this percent of
+// its throughput before the rollout. Zero does not verify throughput.
+// Requires WithRolloutWait.
+func WithMinThroughputPercent(percent int) Option {
+	return func(a *Action) {
+		a.minThroughputPercent = percent
+	}
+}
+
+// WithThroughputWait sets how long to wait after rollouts complete before
+// throughput is measured, so agents report metrics of the new
+// configuration. Values less than or equal to zero use
+// DefaultThroughputWait.
+func WithThroughputWait(d time.Duration) Option {
+	return func(a *Action) {
+		a.throughputWait = d
+	}
+}
+
+// WithFailOnStatuses sets the resource statuses which fail the action. Statuses
+// which did not succeed and are not in the list are logged as warnings. An
+// empty list is ignored and DefaultFailOnStatuses is used.
+func WithFailOnStatuses(statuses []model.UpdateStatus) Option {
+	return func(a *Action) {
+		if len(statuses) > 0 {
+			a.failOnStatuses = statuses
+		}
+	}
+}
+
+// WithApplyConcurrency sets the number of batches resources of the same
+// kind are split into and applied concurrently. Values less than or equal
+// to one apply resources in a single request.
+func WithApplyConcurrency(n int) Option {
+	return func(a *Action) {
+		a.applyConcurrency = n
+	}
+}
+
+// WithApplyStrategy sets how resources are applied when one fails. With
+// fail_fast, the default, applying stops at the first failure. With
+// continue, every resource possible is applied and every failure is
+// returned at the end. With rollback, applying stops at the first failure
+// and the resources which were changed are restored.
+func WithApplyStrategy(s string) Option {
+	return func(a *Action) {
+		a.applyStrategy = s
+	}
+}
+
+// WithApplyMaxPayloadSize sets the maximum size, in bytes, of an apply
+// request body. Larger batches are applied in multiple requests. Values
+// less than or equal to zero do not limit the payload size.
+func WithApplyMaxPayloadSize(n int) Option {
+	return func(a *Action) {
+		a.applyMaxPayloadSize = n
+	}
+}
+
+// WithRateLimit sets the maximum number of requests per second sent to BindPlane
+func WithRateLimit(rps float64) Option {
+	return func(a *Action) {
+		a.rateLimit = rps
+	}
+}
+
+// WithMaxIdleConns sets the maximum number of idle connections kept open to BindPlane
+func WithMaxIdleConns(n int) Option {
+	return func(a *Action) {
+		a.maxIdleConns = n
+	}
+}
+
+// WithIdleConnTimeout sets how long an idle connection to BindPlane is kept open
+func WithIdleConnTimeout(d time.Duration) Option {
+	return func(a *Action) {
+		a.idleConnTimeout = d
+	}
+}
+
+// WithDisableKeepAlives sets the flag to open a new connection for every request
+func WithDisableKeepAlives(b bool) Option {
+	return func(a *Action) {
+		a.disableKeepAlives = b
+	}
+}
+
+// WithDNSServer sets the DNS server which resolves the BindPlane hostname
+func WithDNSServer(addr string) Option {
+	return func(a *Action) {
+		a.dnsServer = addr
+	}
+}
+
+// WithIPFamily sets the IP family, ipv4 or ipv6, connected to first
+func WithIPFamily(f string) Option {
+	return func(a *Action) {
+		a.ipFamily = f
+	}
+}
+
+// WithDisableAdaptiveThrottling disables slowing requests when BindPlane
+// reports its rate limit is almost exhausted
+func WithDisableAdaptiveThrottling(b bool) Option {
+	return func(a *Action) {
+		a.disableAdaptiveThrottling = b
+	}
+}
+
+// WithProxyURL sets the HTTP or SOCKS5 proxy requests to BindPlane are sent through
+func WithProxyURL(u string) Option {
+	return func(a *Action) {
+		a.proxyURL = u
+	}
+}
+
+// WithHTTPTrace sets the flag to enable HTTP request and response trace logging
+func WithHTTPTrace(b bool) Option {
+	return func(a *Action) {
+		a.httpTrace = b
+	}
+}
+
+// WithHTTPHeaders sets headers which are sent with every BindPlane API request
+func WithHTTPHeaders(headers map[string]string) Option {
+	return func(a *Action) {
+		a.httpHeaders = headers
+	}
+}
+
+// WithAPIVersion sets the BindPlane API version, either auto, v1, or v2.
+// When auto, the newest version served by BindPlane is used.
+func WithAPIVersion(v string) Option {
+	return func(a *Action) {
+		a.apiVersion = v
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every BindPlane API
+// request. Defaults to bindplane-op-action/<version>.
+func WithUserAgent(ua string) Option {
+	return func(a *Action) {
+		a.userAgent = ua
+	}
+}
+
+// WithAuditRecordPath sets the path the audit record of the run is written
+// to. When set, every API call and the versions of changed resources are
+// recorded.
+func WithAuditRecordPath(path string) Option {
+	return func(a *Action) {
+		a.auditRecordPath = path
+	}
+}
+
+// WithStatusReportPath sets the path the status of every applied
+// resource is written to
+func WithStatusReportPath(path string) Option {
+	return func(a *Action) {
+		a.statusReportPath = path
+	}
+}
+
+// New creates a new Action with a configured bindPlane client
+func New(logger *zap.Logger, opts ...Option) (*Action, error) {
+	action := &Action{
+		ctx:          context.Background(),
+		remoteClient: &http.Client{Timeout: remoteTimeout},
+		startTime:    time.Now(),
+
+		rolloutPollJitter: DefaultRolloutPollJitter,
+	}
+	for _, opt := range opts {
+		opt(action)
+	}
+
+	clientOpts := []client.Option{
+		client.WithRetryMaxAttempts(action.retryMaxAttempts),
+		client.WithRetryMaxElapsedTime(action.retryMaxElapsedTime),
+		client.WithRetryStatusCodes(action.retryStatusCodes),
+		client.WithApplyTimeout(action.applyTimeout),
+		client.WithFetchTimeout(action.fetchTimeout),
+		client.WithRateLimit(action.rateLimit),
+		client.WithDisableAdaptiveThrottling(action.disableAdaptiveThrottling),
+		client.WithMaxIdleConns(action.maxIdleConns),
+		client.WithIdleConnTimeout(action.idleConnTimeout),
+		client.WithDisableKeepAlives(action.disableKeepAlives),
+		client.WithDNSServer(action.dnsServer),
+		client.WithIPFamily(action.ipFamily),
+		client.WithProxyURL(action.proxyURL),
+		client.WithHTTPTrace(action.httpTrace),
+		client.WithHeaders(action.httpHeaders),
+		client.WithUserAgent(action.userAgent),
+		client.WithAPIVersion(action.apiVersion),
+	}
+	if action.auditRecordPath != "" {
+		action.recorder = &auditRecorder{previousVersions: map[model.Kind]map[string]int{}}
+		clientOpts = append(clientOpts, client.WithRequestRecorder(action.recorder.record))
+	}
+
+	if err := ValidateApplyStrategy(action.applyStrategy); err != nil {
+		return nil, err
+	}
+	if err := ValidateResourceNameEnvironment(action.resourceNameEnvironment); err != nil {
+		return nil, err
+	}
+	if err := ValidateAgentCheck(action.agentCheck); err != nil {
+		return nil, err
+	}
+	if action.rolloutPollJitter > 100 {
+		return nil, fmt.Errorf("rollout poll jitter %d%% must be between 0 and 100", action.rolloutPollJitter)
+	}
+	if action.canarySelector != "" {
+		set, err := ParseCanarySelector(action.canarySelector)
+		if err != nil {
+			return nil, err
+		}
+		action.canaryLabels = set
+	}
+
+	clientLogger := logger
+	if logger != nil {
+		clientLogger = logger.Named(logging.ComponentClient)
+	}
+	c, err := client.NewBindPlane(&action.config, clientLogger, clientOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create BindPlane client: %w", err)
+	}
+
+	vars, err := catalog.Load(action.variablesPath, action.environment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load variables: %w", err)
+	}
+
+	if action.freezeWindowsPath != "" {
+		calendar, err := freeze.Load(action.freezeWindowsPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load freeze windows: %w", err)
+		}
+		action.freezeCalendar = calendar
+	}
+
+	protection, err := newProtection(action.protectedResources, action.protectedSelector)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load protected resources: %w", err)
+	}
+	action.protection = protection
+
+	if action.slackWebhookURL != "" {
+		action.slack = notify.NewSlack(action.slackWebhookURL)
+	}
+
+	if action.deploymentEnvironment != "" {
+		action.deployments = github.NewFromEnv(action.githubToken)
+	}
+
+	if action.commitStatusPrefix != "" {
+		action.commitStatuses = github.NewFromEnv(action.githubToken)
+	}
+
+	if action.webhookURL != "" {
+		webhook, err := notify.NewWebhook(action.webhookURL, action.webhookTemplate, action.webhookEvents)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create webhook: %w", err)
+		}
+		action.webhook = webhook
+	}
+
+	action.client = c
+	action.Logger = logger
+	action.state = state.NewMemory()
+	action.catalog = vars
+
+	return action, nil
+}
+
+// Action is a struct that contains the BindPlane client
+// and user defined configuration options
+type Action struct {
+	Logger *zap.Logger
+
+	// ctx is the context for requests, and holds the current
+	// span. It defaults to context.Background.
+	ctx context.Context
+
+	// Branch name and paths to read
+	// resources from
+	destinationPath   string
+	sourcePath        string
+	processorPath     string
+	agentVersionPath  string
+	configurationPath string
+
+	// Environment and the variables file used to
+	// resolve references within resource files
+	environment   string
+	variablesPath string
+	catalog       *catalog.Catalog
+
+	// resources holds the decoded resources for each
+	// kind. It is populated by LoadResources.
+	resources map[model.Kind][]*model.AnyResource
+
+	// origins holds the file and line each resource was
+	// decoded from, keyed by resourceKey
+	origins map[string]resourceOrigin
+
+	// remoteHeaders are sent when downloading remote resource files
+	remoteHeaders map[string]string
+	remoteClient  *http.Client
+
+	// overlaysDir contains a directory of overlays for each environment
+	overlaysDir string
+
+	// patchesPath is the path or glob of patch files
+	patchesPath string
+
+	// changedFiles limits apply to resources read from these files
+	changedFiles []string
+
+	// resourceNameEnvironment is prefix or suffix when the
+	// environment is added to resource names
+	resourceNameEnvironment string
+
+	// OCI artifact options. ociAuth is the Authorization
+	// header returned by the registry's challenge.
+	ociArtifact string
+	ociUsername string
+	ociPassword string
+	ociAuth     string
+
+	// Auto rollout options
+	autoRollout bool
+
+	// Freeze window options
+	freezeWindowsPath string
+	freezeOverride    bool
+	freezeCalendar    *freeze.Calendar
+
+	// Write back options
+	enableWriteBack           bool
+	configurationOutputDir    string
+	configurationOutputBranch string
+	githubToken               string
+	githubURL                 string
+
+	// Config holds the following options:
+	// - Remote URL
+	// - API Key
+	// - Username
+	// - Password
+	// - Certificate Authority
+	// - Client Certificate and Private Key
+	// - Insecure Skip Verify
+	// - TLS Min Version and Cipher Suites
+	config config.Config
+
+	// Retry policy options passed to the client
+	retryMaxAttempts    int
+	retryMaxElapsedTime time.Duration
+	retryStatusCodes    []int
+
+	// Operation timeouts passed to the client
+	applyTimeout time.Duration
+	fetchTimeout time.Duration
+
+	// applyConcurrency is the number of concurrent apply requests per kind
+	applyConcurrency int
+
+	// applyStrategy is either fail_fast, continue, or rollback
+	applyStrategy string
+
+	// previousResources are the server versions of the resources applied,
+	// by kind and name, read before the apply when the apply strategy is
+	// rollback. restorable are the resources the apply changed.
+	previousResources map[model.Kind]map[string]*model.AnyResource
+	restorable        []*model.AnyResourceStatus
+
+	// applyMaxPayloadSize is the maximum apply request body size in bytes
+	applyMaxPayloadSize int
+
+	// rateLimit is the requests per second limit passed to the client
+	rateLimit float64
+
+	// disableAdaptiveThrottling is passed to the client
+	disableAdaptiveThrottling bool
+
+	// Connection pool options passed to the client
+	maxIdleConns      int
+	idleConnTimeout   time.Duration
+	disableKeepAlives bool
+
+	// Name resolution options passed to the client
+	dnsServer string
+	ipFamily  string
+
+	// proxyURL is the proxy passed to the client, see client.WithProxyURL
+	proxyURL string
+
+	// httpTrace enables client request and response trace logging
+	httpTrace bool
+
+	// httpHeaders are sent with every client request
+	httpHeaders map[string]string
+
+	// userAgent is the client User-Agent, the client default when empty
+	userAgent string
+
+	// apiVersion is the client API version, auto when empty
+	apiVersion string
+
+	// failOnStatuses are the resource statuses which fail the action
+	failOnStatuses []model.UpdateStatus
+
+	// minBindPlaneVersion is the minimum server version required
+	minBindPlaneVersion string
+
+	// failOnVersionSkew fails the run when the server version is
+	// outside of the versions supported, see CheckVersionSkew
+	failOnVersionSkew bool
+
+	// validateRenderedConfig enables validation of rendered
+	// configurations after they are applied
+	validateRenderedConfig bool
+
+	// validatePipelines enables CheckPipelines before apply
+	validatePipelines bool
+
+	// skipUnchanged skips applying resources which
+	// are the same as the server
+	skipUnchanged bool
+
+	// stampLabels enables stampResources
+	stampLabels bool
+
+	// exportRaw enables exporting raw configurations
+	exportRaw bool
+
+	// rawConfigDir is the directory raw configurations are saved
+	// to after apply, and rawConfigFiles the file of each
+	rawConfigDir   string
+	rawConfigFiles map[string]string
+
+	// renderedDiff enables diffing rendered configurations, and
+	// renderedBefore is each rendered configuration before apply
+	renderedDiff   bool
+	renderedBefore map[string]string
+
+	// lock enables locking configurations, see Lock
+	lock                 bool
+	lockTimeout          time.Duration
+	lockID               string
+	lockedConfigurations []string
+
+	// Prune options
+	prune         bool
+	pruneSelector string
+	pruneConfirm  bool
+
+	// rolloutAllPending includes configurations outside
+	// the repository in auto rollout
+	rolloutAllPending bool
+
+	// rolloutSelector selects configurations outside of
+	// the repository to roll out
+	rolloutSelector string
+
+	// Rollout wait options
+	rolloutWait         bool
+	rolloutTimeout      time.Duration
+	rolloutPollInterval time.Duration
+	rolloutPollJitter   int
+
+	maxRolloutErrors     *ErrorThreshold
+	rolloutPauseOnErrors bool
+
+	// canaryLabels are parsed from canarySelector by New
+	canarySelector string
+	canaryLabels   labels.Set
+
+	// canaryDeadline is set by the first canary wait, and
+	// bounds every canary wait of the run
+	canaryDeadline time.Time
+	canaryWait     time.Duration
+
+	agentCheck     string
+	expectedAgents *AgentRange
+
+	agentWait        bool
+	agentWaitTimeout time.Duration
+
+	agentCleanupAge time.Duration
+
+	// throughputBaselines are recorded by startRollout
+	minThroughputPercent int
+	throughputWait       time.Duration
+	throughputBaselines  map[string]map[string]float64
+
+	// slack is created by New when slackWebhookURL is set
+	slackWebhookURL string
+	slack           *notify.Slack
+
+	// deployments is created by New when deploymentEnvironment is set,
+	// and deploymentID is the deployment created by Run
+	deploymentEnvironment string
+	deployments           *github.Client
+	deploymentID          int64
+
+	// commitStatuses is created by New when commitStatusPrefix is set
+	commitStatusPrefix string
+	commitStatuses     *github.Client
+
+	// webhook is created by New when webhookURL is set
+	webhookURL      string
+	webhookTemplate string
+	webhookEvents   []string
+	webhook         *notify.Webhook
+
+	// startedRollouts are the configurations the action started rollouts for
+	startedRollouts []string
+
+	// Protected resource options, parsed into protection by New
+	protectedResources []string
+	protectedSelector  string
+	protection         *protection
+
+	client *client.BindPlane
+
+	// State holds the current state of the action
+	state state.State
+
+	// bindplaneVersion is the server version, set by TestConnection
+	bindplaneVersion version.Version
+
+	// rolloutConfiguration is the name of the configuration
+	// progressed by RunRollout
+	rolloutConfiguration string
+
+	// startTime is when the action was created, audit log
+	// entries are queried from this time
+	startTime time.Time
+
+	// recorder is created by New when auditRecordPath is set
+	auditRecordPath string
+	recorder        *auditRecorder
+
+	statusReportPath string
+
+	// snapshotPath is the file the server version of each resource is
+	// written to before apply, and read from by Restore
+	snapshotPath string
+}
+
+// CheckHealth returns an error if BindPlane is unreachable or reports that
+// it is unhealthy, so connectivity problems are found before any resources
+// are applied. Servers without a health endpoint are assumed to be healthy.
+func (a *Action) CheckHealth() error {
+	err := a.client.Health(a.ctx)
+	if errors.Is(err, client.ErrNotFound) {
+		a.Logger.Debug("BindPlane does not have a health endpoint, skipping health check")
+		return nil
+	}
+
+	var statusErr *client.StatusError
+	switch {
+	case errors.As(err, &statusErr):
+		return fmt.Errorf("BindPlane at %s is unhealthy: %w", a.config.Network.RemoteURL, err)
+	case err != nil:
+		return fmt.Errorf("BindPlane at %s is unreachable: %w", a.config.Network.RemoteURL, err)
+	}
+	return nil
+}
+
+// TestConnection negotiates the API version, and wraps the
+// BindPlane client's Version method
+func (a *Action) TestConnection() (version.Version, error) {
+	apiVersion, err := a.client.Negotiate(a.ctx)
+	if err != nil {
+		return version.Version{}, fmt.Errorf("failed to test connection: %w", err)
+	}
+	a.Logger.Debug("Using BindPlane API version", zap.String("api_version", apiVersion))
+
+	v, err := a.client.Version(a.ctx)
+	if err != nil {
+		return version.Version{}, fmt.Errorf("failed to test connection: %w", err)
+	}
+	a.bindplaneVersion = v
+	return v, err
+}
+
+// CheckVersion returns an error if the BindPlane server version, found by
+// TestConnection, is older than the configured minimum version.
+func (a *Action) CheckVersion() error {
+	if a.minBindPlaneVersion == "" {
+		return nil
+	}
+
+	ok, err := a.bindplaneVersion.AtLeast(a.minBindPlaneVersion)
+	if err != nil {
+		return fmt.Errorf("compare BindPlane version: %w", err)
+	}
+
+	if !ok {
+		return fmt.Errorf("BindPlane server version %s is older than the minimum supported version %s, upgrade the server or lower min_bindplane_version", a.bindplaneVersion.Tag, a.minBindPlaneVersion)
+	}
+
+	return nil
+}
+
+// Run executes the action
+func (a *Action) Run() (err error) {
+	if err := a.checkFreeze(time.Now()); err != nil {
+		return err
+	}
+
+	if a.deployments != nil {
+		a.startDeployment()
+		defer func() { a.finishDeployment(err) }()
+	}
+
+	if err := a.CheckResourceTypes(); err != nil {
+		return fmt.Errorf("failed to validate resource types: %w", err)
+	}
+
+	if err := a.CheckReferences(); err != nil {
+		return fmt.Errorf("invalid resource references: %w", err)
+	}
+
+	if a.validatePipelines {
+		if err := a.CheckPipelines(); err != nil {
+			return fmt.Errorf("invalid configuration pipelines: %w", err)
+		}
+	}
+
+	if err := a.CheckProtected(); err != nil {
+		return fmt.Errorf("refusing to apply protected resources: %w", err)
+	}
+
+	if a.lock {
+		defer a.Unlock()
+		if err := a.Lock(); err != nil {
+			return fmt.Errorf("failed to lock configurations: %w", err)
+		}
+	}
+
+	if err := a.snapshotVersions(); err != nil {
+		return err
+	}
+
+	if err := a.snapshotRendered(); err != nil {
+		return err
+	}
+
+	if err := a.snapshotPrevious(); err != nil {
+		return err
+	}
+
+	if a.snapshotPath != "" {
+		if err := a.WriteSnapshot(); err != nil {
+			return fmt.Errorf("failed to write snapshot: %w", err)
+		}
+	}
+
+	if a.lock {
+		if err := a.VerifyLocks(); err != nil {
+			return fmt.Errorf("failed to lock configurations: %w", err)
+		}
+	}
+
+	if err := a.Apply(); err != nil {
+		err = fmt.Errorf("failed to apply resources: %w", err)
+		if a.applyStrategy == ApplyStrategyRollback {
+			return errors.Join(err, a.restoreApplied())
+		}
+		return err
+	}
+	a.notifyApplyComplete()
+	a.reportRenderedDiffs()
+
+	if a.validateRenderedConfig {
+		if err := a.ValidateRenderedConfigurations(); err != nil {
+			return fmt.Errorf("failed to validate rendered configuration: %w", err)
+		}
+	}
+
+	if a.prune {
+		if err := a.Prune(); err != nil {
+			return fmt.Errorf("failed to prune resources: %w", err)
+		}
+	}
+
+	if a.agentCleanupAge > 0 {
+		if err := a.CleanupAgents(); err != nil {
+			return fmt.Errorf("failed to delete stale agents: %w", err)
+		}
+	}
+
+	if a.autoRollout {
+		if err := a.AutoRollout(); err != nil {
+			return fmt.Errorf("failed to rollout configuration: %s", err)
+		}
+	}
+
+	if a.rolloutWait {
+		if err := a.WaitForRollouts(); err != nil {
+			return fmt.Errorf("failed waiting for rollout: %w", err)
+		}
+	}
+
+	if a.rawConfigDir != "" {
+		if err := a.SaveRawConfigurations(); err != nil {
+			return fmt.Errorf("failed to save raw configurations: %w", err)
+		}
+	}
+
+	if a.enableWriteBack {
+		if err := a.WriteBack(); err != nil {
+			return fmt.Errorf("failed to write back configuration: %s", err)
+		}
+	}
+
+	return nil
+}
+
+// ValidateRenderedConfigurations retrieves the rendered OpenTelemetry
+// configuration of each applied configuration and validates its structure,
+// so broken pipelines are found before a rollout is started. Invalid
+// configurations are annotated on the configuration file.
+func (a *Action) ValidateRenderedConfigurations() error {
+	names := a.state.ConfigurationNames()
+	sort.Strings(names)
+
+	errs := []error{}
+	for _, name := range names {
+		raw, err := a.client.RawConfiguration(a.ctx, name)
+		if err != nil {
+			return fmt.Errorf("get configuration %s: %w", name, err)
+		}
+
+		if err := otelconfig.Validate(raw); err != nil {
+			a.annotateResource(workflow.Error, string(model.KindConfiguration), name, "Invalid rendered configuration", err.Error())
+			errs = append(errs, fmt.Errorf("configuration %s: %w", name, err))
+			continue
+		}
+
+		a.Logger.Info("Rendered configuration is valid", zap.String("name", name))
 	}
+
+	return errors.Join(errs...)
 }
 
-// WithOTELConfigWriteBack sets the flag to enable writing back configuration
-func WithOTELConfigWriteBack(b bool) Option {
-	return func(a *Action) {
-		a.enableWriteBack = b
+// RunRollout progresses a rollout for a configuration. The configuration
+// may be a versioned name, such as gateway:3, to pin the rollout to a version.
+func (a *Action) RunRollout(config string) error {
+	name, version, err := parseVersionedName(config)
+	if err != nil {
+		return err
 	}
+	return a.RunRolloutVersion(name, version)
 }
 
-// WithConfigurationOutputDir sets the directory to write back configuration to
-func WithConfigurationOutputDir(d string) Option {
-	return func(a *Action) {
-		a.configurationOutputDir = d
+// RunRolloutVersion progresses the rollout of a specific version of a
+// configuration, instead of its latest pending version, so a version
+// validated elsewhere is rolled out even if a newer version was applied
+// since. A version of 0 progresses the latest pending version.
+func (a *Action) RunRolloutVersion(config string, version int) error {
+	if err := a.checkFreeze(time.Now()); err != nil {
+		return err
 	}
-}
 
-// WithGithubToken sets the token to authenticate with GitHub
-func WithGithubToken(t string) Option {
-	return func(a *Action) {
-		a.githubToken = t
+	a.rolloutConfiguration = config
+
+	if version > 0 {
+		r, err := a.client.ConfigurationVersion(a.ctx, config, version)
+		if errors.Is(err, client.ErrNotFound) || (err == nil && r == nil) {
+			return fmt.Errorf("configuration %s does not have version %d", config, version)
+		}
+		if err != nil {
+			return fmt.Errorf("get version %d of configuration %s: %w", version, config, err)
+		}
+		a.Logger.Info("Starting rollout of configuration version", zap.String("name", config), zap.Int("version", version))
 	}
-}
 
-// WithGithubURL sets the URL to the GitHub repository
-func WithGithubURL(u string) Option {
-	return func(a *Action) {
-		a.githubURL = u
+	if err := a.startRollout(config, version); err != nil {
+		return err
 	}
-}
 
-// WithAutoRollout sets the flag to enable auto rollout
-func WithAutoRollout(b bool) Option {
-	return func(a *Action) {
-		a.autoRollout = b
+	if a.rolloutWait {
+		if err := a.WaitForRollouts(); err != nil {
+			return fmt.Errorf("failed waiting for rollout: %w", err)
+		}
 	}
+
+	return nil
 }
 
-// WithConfigurationOutputBranch sets the branch to write back the configuration to
-func WithConfigurationOutputBranch(b string) Option {
-	return func(a *Action) {
-		a.configurationOutputBranch = b
+// parseVersionedName splits a configuration name, such as gateway, or a
+// versioned name, such as gateway:3, into the name and version. The
+// version is 0 when it is not set.
+func parseVersionedName(config string) (string, int, error) {
+	name, v, ok := strings.Cut(config, ":")
+	if !ok {
+		return config, 0, nil
 	}
+	version, err := strconv.Atoi(v)
+	if err != nil || version < 1 {
+		return "", 0, fmt.Errorf("configuration %s must be a name, or a name and version such as %s:3", config, name)
+	}
+	return name, version, nil
 }
 
-// New creates a new Action with a configured bindPlane client
-func New(logger *zap.Logger, opts ...Option) (*Action, error) {
-	action := &Action{}
-	for _, opt := range opts {
-		opt(action)
+// checkFreeze returns an error if a freeze window is active at time t,
+// unless the freeze override is enabled.
+func (a *Action) checkFreeze(t time.Time) error {
+	w := a.freezeCalendar.Active(t)
+	if w == nil {
+		return nil
 	}
 
-	c, err := client.NewBindPlane(&action.config, logger)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create BindPlane client: %w", err)
+	until := w.Until(t).Format(time.RFC3339)
+	if a.freezeOverride {
+		a.Logger.Warn(
+			"Freeze window is active, continuing because freeze override is enabled",
+			zap.String("window", w.Name),
+			zap.String("until", until),
+		)
+		return nil
 	}
 
-	action.client = c
-	action.Logger = logger
-	action.state = state.NewMemory()
+	return fmt.Errorf("freeze window '%s' is active until %s, set freeze_override to run anyway", w.Name, until)
+}
 
-	return action, nil
+// resourceFile describes a resource file that will be applied
+type resourceFile struct {
+	kind  model.Kind
+	path  string
+	label string
 }
 
-// Action is a struct that contains the BindPlane client
-// and user defined configuration options
-type Action struct {
-	Logger *zap.Logger
+// resourceFiles returns the resource files in the order they should
+// be applied.
+func (a *Action) resourceFiles() []resourceFile {
+	return []resourceFile{
+		{model.KindDestination, a.destinationPath, "destinations"},
+		{model.KindSource, a.sourcePath, "sources"},
+		{model.KindProcessor, a.processorPath, "processors"},
+		{model.KindAgentVersion, a.agentVersionPath, "agent versions"},
+		{model.KindConfiguration, a.configurationPath, "configuration"},
+	}
+}
 
-	// Branch name and paths to read
-	// resources from
-	destinationPath   string
-	sourcePath        string
-	processorPath     string
-	configurationPath string
+// rolloutLogger returns the logger of rollout, canary, and agent progress
+func (a *Action) rolloutLogger() *zap.Logger {
+	return a.Logger.Named(logging.ComponentRollout)
+}
 
-	// Auto rollout options
-	autoRollout bool
+// loaderLogger returns the logger of resource file loading
+func (a *Action) loaderLogger() *zap.Logger {
+	return a.Logger.Named(logging.ComponentLoader)
+}
 
-	// Write back options
-	enableWriteBack           bool
-	configurationOutputDir    string
-	configurationOutputBranch string
-	githubToken               string
-	githubURL                 string
+// LoadResources reads and decodes all resource files, resolving variable
+// and secret references. It does not make any API calls, which allows
+// every file to be validated before resources are applied. All errors
+// are returned, not just the first.
+func (a *Action) LoadResources() error {
+	resources := map[model.Kind][]*model.AnyResource{}
+	origins := map[string]resourceOrigin{}
+	errs := []error{}
+
+	for _, f := range a.resourceFiles() {
+		if f.path == "" {
+			continue
+		}
 
-	// Config holds the following options:
-	// - Remote URL
-	// - API Key
-	// - Username
-	// - Password
-	// - Certificate Authority
-	config config.Config
+		var (
+			r   []*model.AnyResource
+			o   []resourceOrigin
+			err error
+		)
+		if IsRemotePath(f.path) {
+			r, o, err = a.decodeRemoteResourceFile(f.path)
+		} else {
+			r, o, err = decodeAnyResourceFile(f.path, a.catalog)
+		}
+		if err != nil {
+			var fe *fileError
+			if errors.As(err, &fe) {
+				annotateFile(workflow.Error, fe.resourceOrigin, "Resource file error", err.Error())
+			}
+			errs = append(errs, fmt.Errorf("%s: %w", f.label, err))
+			continue
+		}
+		resources[f.kind] = r
 
-	client *client.BindPlane
+		for i, resource := range r {
+			origins[resourceKey(resource.Kind, resource.Metadata.Name)] = o[i]
+		}
+	}
 
-	// State holds the current state of the action
-	state state.State
-}
+	// Artifact resources are grouped by their kind, so they are
+	// applied in the same order as resources read from paths
+	if a.ociArtifact != "" {
+		r, o, err := a.decodeOCIArtifact(a.ociArtifact)
+		if err != nil {
+			var fe *fileError
+			if errors.As(err, &fe) {
+				annotateFile(workflow.Error, fe.resourceOrigin, "Resource file error", err.Error())
+			}
+			errs = append(errs, fmt.Errorf("OCI artifact: %w", err))
+		}
 
-// TestConnection wraps the BindPlane client's Version method
-func (a *Action) TestConnection() (version.Version, error) {
-	v, err := a.client.Version(context.Background())
-	if err != nil {
-		return version.Version{}, fmt.Errorf("failed to test connection: %w", err)
+		for i, resource := range r {
+			kind := model.Kind(resource.Kind)
+			resources[kind] = append(resources[kind], resource)
+			origins[resourceKey(resource.Kind, resource.Metadata.Name)] = o[i]
+		}
 	}
-	return v, err
-}
 
-// Run executes the action
-func (a *Action) Run() error {
-	if err := a.Apply(); err != nil {
-		return fmt.Errorf("failed to apply resources: %w", err)
+	if err := errors.Join(errs...); err != nil {
+		return err
 	}
 
-	if a.autoRollout {
-		if err := a.AutoRollout(); err != nil {
-			return fmt.Errorf("failed to rollout configuration: %s", err)
+	if err := a.applyOverlays(resources); err != nil {
+		var fe *fileError
+		if errors.As(err, &fe) {
+			annotateFile(workflow.Error, fe.resourceOrigin, "Overlay error", err.Error())
 		}
+		return fmt.Errorf("overlays: %w", err)
 	}
 
-	if a.enableWriteBack {
-		if err := a.WriteBack(); err != nil {
-			return fmt.Errorf("failed to write back configuration: %s", err)
+	if err := a.applyPatches(resources); err != nil {
+		var fe *fileError
+		if errors.As(err, &fe) {
+			annotateFile(workflow.Error, fe.resourceOrigin, "Patch error", err.Error())
 		}
+		return fmt.Errorf("patches: %w", err)
+	}
+
+	if err := a.renameResources(resources, origins); err != nil {
+		return fmt.Errorf("rename resources: %w", err)
 	}
+	a.stampResources(resources)
 
+	a.resources = resources
+	a.origins = origins
 	return nil
 }
 
-// RunRollout progresses a rollout for a configuration
-func (a *Action) RunRollout(config string) error {
-	if err := a.client.StartRollout(config); err != nil {
-		return fmt.Errorf("start rollout: %w", err)
-	}
+// resourceKey returns a key which uniquely identifies a resource
+func resourceKey(kind, name string) string {
+	return fmt.Sprintf("%s/%s", kind, name)
+}
 
-	return nil
+// annotateFunc creates a workflow annotation, such as workflow.Error
+type annotateFunc func(file string, line int, title, message string)
+
+// annotateResource creates an annotation on the file the resource was
+// decoded from. If the file is unknown, the annotation is not associated
+// with a file.
+func (a *Action) annotateResource(annotate annotateFunc, kind, name, title, message string) {
+	annotateFile(annotate, a.origins[resourceKey(kind, name)], title, message)
 }
 
-// Apply applies destinations, sources, processors, and configurations
-// in that order. It is important to apply destinations first, followed
-// by resource library sources and processors. Configurations should be
-// applied last because they will reference other resources.
-func (a *Action) Apply() error {
-	if a.destinationPath != "" {
-		a.Logger.Info("Applying resources", zap.String("Kind", string(model.KindDestination)), zap.String("file", a.destinationPath))
-		err := a.apply(a.destinationPath)
-		if err != nil {
-			return fmt.Errorf("destinations: %w", err)
+// annotateFile creates an annotation on the origin's file. Absolute paths
+// are made relative to the workspace so they match the paths GitHub uses
+// for the repository. Remote files and OCI artifacts are not in the
+// repository, so their annotations are not associated with a file.
+func annotateFile(annotate annotateFunc, origin resourceOrigin, title, message string) {
+	file := origin.file
+	if IsRemotePath(file) || strings.HasPrefix(file, ociScheme) {
+		annotate("", 0, title, message)
+		return
+	}
+	if workspace := os.Getenv("GITHUB_WORKSPACE"); workspace != "" && filepath.IsAbs(file) {
+		if rel, err := filepath.Rel(workspace, file); err == nil {
+			file = rel
 		}
-	} else {
-		a.Logger.Info("No destination path provided, skipping destinations")
 	}
+	annotate(filepath.ToSlash(file), origin.line, title, message)
+}
 
-	if a.sourcePath != "" {
-		a.Logger.Info("Applying resources", zap.String("Kind", string(model.KindSource)), zap.String("file", a.sourcePath))
-		err := a.apply(a.sourcePath)
-		if err != nil {
-			return fmt.Errorf("sources: %w", err)
+// Apply applies destinations, sources, processors, agent versions, and
+// configurations in that order. It is important to apply destinations
+// first, followed by resource library sources and processors. Configurations
+// should be applied last because they will reference other resources.
+func (a *Action) Apply() (err error) {
+	end := a.startSpan("apply")
+	defer func() { end(err) }()
+
+	if a.resources == nil {
+		if err := a.LoadResources(); err != nil {
+			return fmt.Errorf("load resources: %w", err)
 		}
-	} else {
-		a.Logger.Info("No source path provided, skipping sources")
 	}
 
-	if a.processorPath != "" {
-		a.Logger.Info("Applying resources", zap.String("Kind", string(model.KindProcessor)), zap.String("file", a.processorPath))
-		err := a.apply(a.processorPath)
+	changed := a.changedFileSet()
+
+	errs := []error{}
+	for _, f := range a.resourceFiles() {
+		if f.path == "" {
+			a.Logger.Info(fmt.Sprintf("No %s path provided, skipping %s", strings.ToLower(string(f.kind)), f.label))
+			continue
+		}
+
+		resources, skipped := a.changedResources(a.resources[f.kind], changed)
+		if len(skipped) > 0 {
+			a.Logger.Info("Skipping resources in unchanged files", zap.String("Kind", string(f.kind)), zap.Int("skipped", len(skipped)))
+			if err := a.recordUnchangedFiles(f.kind, skipped); err != nil {
+				err = fmt.Errorf("%s: %w", f.label, err)
+				if !a.continueOnError() {
+					return err
+				}
+				errs = append(errs, err)
+			}
+			if len(resources) == 0 {
+				continue
+			}
+		}
+
+		resources, err := a.unchangedResources(f.kind, resources)
 		if err != nil {
-			return fmt.Errorf("processors: %w", err)
+			err = fmt.Errorf("%s: %w", f.label, err)
+			if !a.continueOnError() {
+				return err
+			}
+			errs = append(errs, err)
+			continue
+		}
+		if len(resources) == 0 {
+			continue
 		}
-	} else {
-		a.Logger.Info("No processor path provided, skipping processors")
-	}
 
-	if a.configurationPath != "" {
-		a.Logger.Info("Applying resources", zap.String("Kind", string(model.KindConfiguration)), zap.String("file", a.configurationPath))
-		err := a.apply(a.configurationPath)
+		a.Logger.Info("Applying resources", zap.String("Kind", string(f.kind)), zap.String("file", f.path))
+		start := time.Now()
+		endKind := a.startSpan("apply.kind",
+			attribute.String("bindplane.kind", string(f.kind)),
+			attribute.Int("bindplane.resources", len(resources)),
+		)
+		err = a.apply(resources)
+		endKind(err)
+		a.recordApplyDuration(f.kind, start, err)
 		if err != nil {
-			return fmt.Errorf("configuration: %w", err)
+			err = fmt.Errorf("%s: %w", f.label, err)
+			if !a.continueOnError() {
+				return err
+			}
+			a.Logger.Error("Failed to apply resources, continuing with the remaining resources", zap.String("kind", string(f.kind)), zap.Error(err))
+			errs = append(errs, err)
 		}
-	} else {
-		a.Logger.Info("No configuration path provided, skipping configuration")
 	}
 
-	return nil
+	return errors.Join(errs...)
 }
 
-// apply takes a file path and applies it to the BindPlane API. If an
-// error is found in the response status, it will be returned
-func (a *Action) apply(path string) error {
-	resources, err := decodeAnyResourceFile(path)
-	if err != nil {
-		return fmt.Errorf("decode resources: %w", err)
-	}
-
-	resp, err := a.client.Apply(context.Background(), resources)
-	if err != nil {
-		return fmt.Errorf("client error: %w", err)
-	}
-
-	if resp == nil {
-		return fmt.Errorf("nil response from client: %s", BugError)
-	}
+// apply takes a list of resources and applies them to the BindPlane API. If an
+// error is found in the response status, it will be returned. When the apply
+// strategy is continue, every status is checked and every error is returned.
+func (a *Action) apply(resources []*model.AnyResource) error {
+	// Statuses from successful batches are recorded
+	// before a failed batch is reported.
+	resp, batchErr := a.applyBatches(resources)
+	a.trackApplied(resp)
 
+	errs := []error{}
 	for _, s := range resp {
 		name := s.Resource.Metadata.Name
 		id := s.Resource.Metadata.ID
@@ -311,6 +1747,10 @@ func (a *Action) apply(path string) error {
 			zap.String("status", string(status)),
 		)
 
+		a.state.AddResourceStatus(*s)
+		a.resourceSpan(s)
+		a.recordResourceApplied(s)
+
 		// Attach the configuration resource to the state
 		// so we can use it for auto rollout
 		if kind == string(model.KindConfiguration) {
@@ -318,29 +1758,105 @@ func (a *Action) apply(path string) error {
 			a.Logger.Debug("Configuration resource added to state", zap.String("name", name))
 		}
 
-		switch status {
-		case model.StatusUnchanged, model.StatusConfigured, model.StatusCreated:
-			a.Logger.Info("Applied resource", zap.String("name", name), zap.String("status", string(status)))
-			continue
-		case model.StatusInvalid:
-			return fmt.Errorf("invalid resource: %s: %s", name, s.Reason)
-		case model.StatusError:
-			return fmt.Errorf("error: %s: %s", name, s.Reason)
-		case model.StatusForbidden:
-			return fmt.Errorf("forbidden: %s: %s", name, s.Reason)
-		default:
-			return fmt.Errorf("unexpected status: %s", status)
+		if err := a.checkStatus(s); err != nil {
+			if !a.continueOnError() {
+				return err
+			}
+			errs = append(errs, err)
 		}
 	}
 
-	return nil
+	if batchErr != nil {
+		errs = append(errs, batchErr)
+	}
+	return errors.Join(errs...)
+}
+
+// checkStatus logs and annotates the status of an applied resource, and
+// returns an error if the status fails the action
+func (a *Action) checkStatus(s *model.AnyResourceStatus) error {
+	name := s.Resource.Metadata.Name
+	kind := s.Resource.Kind
+	status := s.Status
+
+	switch {
+	case status.Succeeded():
+		a.Logger.Info("Applied resource", zap.String("name", name), zap.String("status", string(status)))
+		return nil
+	case !status.Valid():
+		return fmt.Errorf("unexpected status: %s", status)
+	}
+
+	title, message := statusDescription(s)
+	if !a.failOnStatus(status) {
+		a.Logger.Warn(
+			"Resource was not applied",
+			zap.String("name", name),
+			zap.String("kind", kind),
+			zap.String("status", string(status)),
+			zap.String("reason", s.Reason),
+		)
+		a.annotateResource(workflow.Warning, kind, name, title, message)
+		return nil
+	}
+
+	a.annotateResource(workflow.Error, kind, name, title, message)
+	switch status {
+	case model.StatusInvalid:
+		return fmt.Errorf("invalid resource: %s: %s", name, s.Reason)
+	case model.StatusError:
+		return fmt.Errorf("error: %s: %s", name, s.Reason)
+	case model.StatusForbidden:
+		return fmt.Errorf("forbidden: %s: %s", name, s.Reason)
+	default:
+		return fmt.Errorf("%s: %s: %s", status, name, s.Reason)
+	}
+}
+
+// failOnStatus returns true if the status should fail the action
+func (a *Action) failOnStatus(status model.UpdateStatus) bool {
+	statuses := a.failOnStatuses
+	if len(statuses) == 0 {
+		statuses = DefaultFailOnStatuses
+	}
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// statusDescription returns an annotation title and message
+// describing a resource status
+func statusDescription(s *model.AnyResourceStatus) (title, message string) {
+	kind := s.Resource.Kind
+	name := s.Resource.Metadata.Name
+	switch s.Status {
+	case model.StatusInvalid:
+		return "Invalid resource", fmt.Sprintf("%s %s is invalid: %s", kind, name, s.Reason)
+	case model.StatusError:
+		return "Resource error", fmt.Sprintf("%s %s failed to apply: %s", kind, name, s.Reason)
+	case model.StatusForbidden:
+		return "Forbidden resource", fmt.Sprintf("%s %s is forbidden: %s", kind, name, s.Reason)
+	default:
+		return fmt.Sprintf("Resource %s", s.Status), fmt.Sprintf("%s %s status is %s: %s", kind, name, s.Status, s.Reason)
+	}
 }
 
 // AutoRollout TODO
-func (a *Action) AutoRollout() error {
+func (a *Action) AutoRollout() (err error) {
+	end := a.startSpan("rollout.start")
+	defer func() { end(err) }()
+
+	names, err := a.rolloutCandidates()
+	if err != nil {
+		return err
+	}
+
 	configurations := []model.Configuration{}
-	for _, name := range a.state.ConfigurationNames() {
-		configuration, err := a.client.Configuration(context.Background(), name)
+	for _, name := range names {
+		configuration, err := a.client.Configuration(a.ctx, name)
 		if err != nil {
 			return fmt.Errorf("get configuration %s: %w", name, err)
 		}
@@ -351,22 +1867,29 @@ func (a *Action) AutoRollout() error {
 	}
 
 	for _, c := range configurations {
-		status, err := a.client.RolloutStatus(c.Metadata.Name)
+		status, err := a.client.RolloutStatus(a.ctx, c.Metadata.Name)
 		if err != nil {
 			return fmt.Errorf("rollout status: %w", err)
 		}
 
-		if status.Status.Rollout.Status == model.RolloutStatusPending {
-			a.Logger.Info("Pending rollout", zap.String("name", c.Metadata.Name))
-		} else {
-			a.Logger.Info("No pending rollout", zap.String("name", c.Metadata.Name))
+		switch status.Status.Rollout.Status {
+		case model.RolloutStatusPending:
+			a.rolloutLogger().Info("Pending rollout", zap.String("name", c.Metadata.Name))
+		case model.RolloutStatusStarted:
+			// The rollout was started by an earlier run, such
+			// as one which failed while waiting and was re-run
+			a.rolloutLogger().Info("Rollout is already in progress, resuming", zap.String("name", c.Metadata.Name))
+			a.startedRollouts = append(a.startedRollouts, c.Metadata.Name)
+			continue
+		default:
+			a.rolloutLogger().Info("No pending rollout", zap.String("name", c.Metadata.Name))
 			continue
 		}
 
-		a.Logger.Info("Starting rollout", zap.String("name", c.Metadata.Name))
+		a.rolloutLogger().Info("Starting rollout", zap.String("name", c.Metadata.Name))
 
-		if err := a.client.StartRollout(c.Metadata.Name); err != nil {
-			return fmt.Errorf("start rollout: %w", err)
+		if err := a.startRollout(c.Metadata.Name, 0); err != nil {
+			return err
 		}
 	}
 
@@ -388,39 +1911,11 @@ func (a *Action) WriteBack() error {
 		return fmt.Errorf("get worktree: %w", err)
 	}
 
-	rawConfigs := make(map[string]string)
 	for _, name := range a.state.ConfigurationNames() {
-		rawConfig, err := a.client.RawConfiguration(context.Background(), name)
-		if err != nil {
-			return fmt.Errorf("get configuration %s: %w", name, err)
-		}
-		if rawConfig == "" {
-			return fmt.Errorf("configuration '%s' is empty: %s", name, BugError)
-		}
-		rawConfigs[name] = rawConfig
-	}
-
-	for name, rawConfig := range rawConfigs {
 		path := fmt.Sprintf("./out_repo/%s/%s.yaml", a.configurationOutputDir, name)
-
-		// Create the directory if it doesn't exist. MkdirAll will return
-		// nil if the directory already exists. Returns an error if something
-		// goes wrong.
-		dir, _ := filepath.Split(path)
-		if err := os.MkdirAll(dir, 0750); err != nil {
-			return fmt.Errorf("create directory %s: %w", dir, err)
+		if err := a.writeRawConfiguration(name, path); err != nil {
+			return err
 		}
-
-		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0600) // #nosec G304 user defined filepath
-		if err != nil {
-			return fmt.Errorf("open file %s: %w", path, err)
-		}
-		defer f.Close()
-
-		if _, err := f.WriteString(rawConfig); err != nil {
-			return fmt.Errorf("write file %s: %w", path, err)
-		}
-
 		a.Logger.Info("Raw configuration written to file", zap.String("name", name), zap.String("path", path))
 	}
 
@@ -469,48 +1964,154 @@ func (a *Action) WriteBack() error {
 	return nil
 }
 
+// writeRawConfiguration streams the raw configuration of a configuration
+// to path, so large configurations are not held in memory. The directory
+// of path is created if it does not exist.
+func (a *Action) writeRawConfiguration(name, path string) error {
+	r, err := a.client.RawConfigurationReader(a.ctx, name)
+	if err != nil {
+		return fmt.Errorf("get configuration %s: %w", name, err)
+	}
+	defer r.Close()
+
+	// MkdirAll returns nil if the directory already exists
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return fmt.Errorf("create directory %s: %w", dir, err)
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600) // #nosec G304 user defined filepath
+	if err != nil {
+		return fmt.Errorf("open file %s: %w", path, err)
+	}
+	defer f.Close()
+
+	n, err := io.Copy(f, r)
+	if err != nil {
+		return fmt.Errorf("write file %s: %w", path, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("configuration '%s' is empty: %s", name, BugError)
+	}
+	return f.Close()
+}
+
+// resourceOrigin is the file and line a resource was decoded from
+type resourceOrigin struct {
+	file string
+	line int
+}
+
+// fileError is an error caused by the contents of a resource file
+type fileError struct {
+	resourceOrigin
+	err error
+}
+
+func (e *fileError) Error() string { return e.err.Error() }
+func (e *fileError) Unwrap() error { return e.err }
+
+// yamlLinePattern matches the line number in yaml error messages
+var yamlLinePattern = regexp.MustCompile(`line (\d+)`)
+
 // decodeAnyResourceFile takes a file path and decodes it into a slice of
 // model.AnyResource. If the file is empty, it will return an error.
 // This function supports globbing, but does not gaurantee ordering. This
 // function should not be passed multiple files with differing resource
-// types such as Destinations and Configurations.
-func decodeAnyResourceFile(path string) ([]*model.AnyResource, error) {
+// types such as Destinations and Configurations. When vars is not nil,
+// variable and secret references are resolved before decoding.
+//
+// The origin of each resource is returned in the same order as the
+// resources. Errors caused by the contents of a file are returned as
+// a *fileError.
+func decodeAnyResourceFile(path string, vars *catalog.Catalog) ([]*model.AnyResource, []resourceOrigin, error) {
 	// Glob will return nil matches if there are IO errors. Glob only returns
 	// an error if an invalid pattern is given.
 	matches, err := filepath.Glob(path) // #nosec G304 user defined filepath
 	if err != nil {
-		return nil, fmt.Errorf("glob path %s: %w", path, err)
+		return nil, nil, fmt.Errorf("glob path %s: %w", path, err)
 	}
 	if matches == nil {
-		return nil, fmt.Errorf("no matching files found when globbing %s", path)
+		return nil, nil, fmt.Errorf("no matching files found when globbing %s", path)
 	}
 
 	resources := []*model.AnyResource{}
+	origins := []resourceOrigin{}
 
 	for _, match := range matches {
-		f, err := os.Open(match) // #nosec G304 user defined filepath
+		data, err := os.ReadFile(match) // #nosec G304 user defined filepath
 		if err != nil {
-			return nil, fmt.Errorf("unable to read file at path %s: %w", path, err)
+			return nil, nil, fmt.Errorf("unable to read file at path %s: %w", path, err)
 		}
-		defer f.Close()
 
-		decoder := yaml.NewDecoder(f)
-		for {
-			resource := &model.AnyResource{}
-			if err := decoder.Decode(resource); err != nil {
-				if errors.Is(err, io.EOF) {
-					break
-				}
-				// TODO(jsirianni): Should we continue and report the error after?
-				return nil, fmt.Errorf("resource file %s is malformed, failed to unmarshal yaml: %w", path, err)
-			}
-			resources = append(resources, resource)
+		r, o, err := decodeResources(path, match, data, vars)
+		if err != nil {
+			return nil, nil, err
 		}
+		resources = append(resources, r...)
+		origins = append(origins, o...)
 	}
 
 	if len(resources) == 0 {
-		return nil, fmt.Errorf("no resources found in file: %s", path)
+		return nil, nil, fmt.Errorf("no resources found in file: %s", path)
+	}
+
+	return resources, origins, nil
+}
+
+// decodeResources decodes the resources in data, which was read from file.
+// Path is the resource path the file matched, and is used in errors.
+func decodeResources(path, file string, data []byte, vars *catalog.Catalog) ([]*model.AnyResource, []resourceOrigin, error) {
+	resources := []*model.AnyResource{}
+	origins := []resourceOrigin{}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		node := &yaml.Node{}
+		resource := &model.AnyResource{}
+		err := decoder.Decode(node)
+		if err == nil && vars != nil {
+			// References are resolved in the decoded values, so a value
+			// cannot change the structure of the document
+			if err := vars.ResolveNode(node); err != nil {
+				origin := resourceOrigin{file: file, line: node.Line}
+				if len(node.Content) > 0 {
+					origin.line = node.Content[0].Line
+				}
+				return nil, nil, &fileError{
+					origin,
+					fmt.Errorf("resolve references in file %s: %w", file, err),
+				}
+			}
+		}
+		if err == nil {
+			err = node.Decode(resource)
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			origin := resourceOrigin{file: file}
+			if m := yamlLinePattern.FindStringSubmatch(err.Error()); m != nil {
+				origin.line, _ = strconv.Atoi(m[1])
+			}
+
+			// TODO(jsirianni): Should we continue and report the error after?
+			return nil, nil, &fileError{
+				origin,
+				fmt.Errorf("resource file %s is malformed, failed to unmarshal yaml: %w", path, err),
+			}
+		}
+
+		line := node.Line
+		if len(node.Content) > 0 {
+			line = node.Content[0].Line
+		}
+
+		resources = append(resources, resource)
+		origins = append(origins, resourceOrigin{file: file, line: line})
 	}
 
-	return resources, nil
+	return resources, origins, nil
 }
@@ -1,9 +1,20 @@
 package action
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"syscall"
 	"testing"
+	"time"
 
-	"github.com/observiq/bindplane-op-action/internal/client/config"
+	"github.com/observiq/bindplane-op-action/action/freeze"
+	"github.com/observiq/bindplane-op-action/internal/workflow"
+	"github.com/observiq/bindplane-op-action/pkg/client/config"
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"github.com/observiq/bindplane-op-action/pkg/client/version"
 
 	"go.uber.org/zap"
 
@@ -138,6 +149,20 @@ func TestWithBindPlanePassword(t *testing.T) {
 	}
 }
 
+func TestWithBindPlaneAccountProject(t *testing.T) {
+	a := &Action{}
+	WithBindPlaneAccountID("account-1")(a)
+	WithBindPlaneProjectID("project-1")(a)
+	require.Equal(t, &Action{
+		config: config.Config{
+			Auth: config.Auth{
+				AccountID: "account-1",
+				ProjectID: "project-1",
+			},
+		},
+	}, a)
+}
+
 func TestWithTLSCACert(t *testing.T) {
 	cases := []struct {
 		name   string
@@ -172,6 +197,52 @@ func TestWithTLSCACert(t *testing.T) {
 	}
 }
 
+func TestWithTLSClientCertificate(t *testing.T) {
+	a := &Action{}
+	WithTLSCert("client.crt")(a)
+	WithTLSKey("client.key")(a)
+	require.Equal(t, &Action{
+		config: config.Config{
+			Network: config.Network{
+				TLS: config.TLS{
+					Certificate: "client.crt",
+					PrivateKey:  "client.key",
+				},
+			},
+		},
+	}, a)
+}
+
+func TestWithInsecureSkipVerify(t *testing.T) {
+	a := &Action{}
+	WithInsecureSkipVerify(true)(a)
+	require.Equal(t, &Action{
+		config: config.Config{
+			Network: config.Network{
+				TLS: config.TLS{
+					InsecureSkipVerify: true,
+				},
+			},
+		},
+	}, a)
+}
+
+func TestWithTLSVersion(t *testing.T) {
+	a := &Action{}
+	WithTLSMinVersion("1.2")(a)
+	WithTLSCipherSuites([]string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"})(a)
+	require.Equal(t, &Action{
+		config: config.Config{
+			Network: config.Network{
+				TLS: config.TLS{
+					MinVersion:   "1.2",
+					CipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"},
+				},
+			},
+		},
+	}, a)
+}
+
 func TestWithDestinationPath(t *testing.T) {
 	cases := []struct {
 		name   string
@@ -247,6 +318,12 @@ func TestWithProcessorPath(t *testing.T) {
 	}
 }
 
+func TestWithAgentVersionPath(t *testing.T) {
+	a := &Action{}
+	WithAgentVersionPath("/tmp")(a)
+	require.Equal(t, &Action{agentVersionPath: "/tmp"}, a)
+}
+
 func TestWithConfigurationPath(t *testing.T) {
 	cases := []struct {
 		name   string
@@ -443,6 +520,173 @@ func TestWithGithubURL(t *testing.T) {
 	}
 }
 
+func TestWithEnvironment(t *testing.T) {
+	cases := []struct {
+		name   string
+		intput string
+		expect *Action
+	}{
+		{
+			"Set environment",
+			"production",
+			&Action{
+				environment: "production",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			a := &Action{}
+			opt := WithEnvironment(tc.intput)
+			opt(a)
+			require.Equal(t, tc.expect, a)
+		})
+	}
+}
+
+func TestWithVariablesPath(t *testing.T) {
+	cases := []struct {
+		name   string
+		intput string
+		expect *Action
+	}{
+		{
+			"Set variables path",
+			"variables.yaml",
+			&Action{
+				variablesPath: "variables.yaml",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			a := &Action{}
+			opt := WithVariablesPath(tc.intput)
+			opt(a)
+			require.Equal(t, tc.expect, a)
+		})
+	}
+}
+
+func TestWithRetryPolicy(t *testing.T) {
+	a := &Action{}
+	WithRetryMaxAttempts(3)(a)
+	WithRetryMaxElapsedTime(time.Minute)(a)
+	WithRetryStatusCodes([]int{429, 503})(a)
+	require.Equal(t, &Action{
+		retryMaxAttempts:    3,
+		retryMaxElapsedTime: time.Minute,
+		retryStatusCodes:    []int{429, 503},
+	}, a)
+}
+
+func TestWithFreezeOptions(t *testing.T) {
+	a := &Action{}
+	WithFreezeWindowsPath("freeze.yaml")(a)
+	WithFreezeOverride(true)(a)
+	require.Equal(t, &Action{
+		freezeWindowsPath: "freeze.yaml",
+		freezeOverride:    true,
+	}, a)
+}
+
+func TestCheckFreeze(t *testing.T) {
+	calendar, err := freeze.Load("freeze/testdata/windows.yaml")
+	require.NoError(t, err)
+
+	frozen := time.Date(2026, 11, 27, 12, 0, 0, 0, time.UTC)
+	thawed := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+
+	a := &Action{Logger: zap.NewNop()}
+	require.NoError(t, a.checkFreeze(frozen), "nil calendar should never freeze")
+
+	a.freezeCalendar = calendar
+	require.NoError(t, a.checkFreeze(thawed))
+
+	err = a.checkFreeze(frozen)
+	require.Error(t, err)
+	require.Equal(t, "freeze window 'black-friday' is active until 2026-12-01T00:00:00Z, set freeze_override to run anyway", err.Error())
+
+	a.freezeOverride = true
+	require.NoError(t, a.checkFreeze(frozen))
+}
+
+func TestWithHTTPTrace(t *testing.T) {
+	a := &Action{}
+	WithHTTPTrace(true)(a)
+	require.Equal(t, &Action{httpTrace: true}, a)
+}
+
+func TestWithHTTPHeaders(t *testing.T) {
+	a := &Action{}
+	WithHTTPHeaders(map[string]string{"X-Tenant": "payments"})(a)
+	require.Equal(t, &Action{httpHeaders: map[string]string{"X-Tenant": "payments"}}, a)
+}
+
+func TestWithAPIVersion(t *testing.T) {
+	a := &Action{}
+	WithAPIVersion("v2")(a)
+	require.Equal(t, &Action{apiVersion: "v2"}, a)
+}
+
+func TestWithUserAgent(t *testing.T) {
+	a := &Action{}
+	WithUserAgent("bindplane-op-action/v1.2.3 (gitlab)")(a)
+	require.Equal(t, &Action{userAgent: "bindplane-op-action/v1.2.3 (gitlab)"}, a)
+}
+
+func TestWithFailOnStatuses(t *testing.T) {
+	a := &Action{}
+	WithFailOnStatuses(nil)(a)
+	require.Nil(t, a.failOnStatuses)
+	require.True(t, a.failOnStatus(model.StatusInUse))
+
+	WithFailOnStatuses([]model.UpdateStatus{model.StatusError})(a)
+	require.Equal(t, []model.UpdateStatus{model.StatusError}, a.failOnStatuses)
+	require.True(t, a.failOnStatus(model.StatusError))
+	require.False(t, a.failOnStatus(model.StatusInUse))
+}
+
+func TestCheckVersion(t *testing.T) {
+	a := &Action{bindplaneVersion: version.Version{Tag: "v1.40.0"}}
+	require.NoError(t, a.CheckVersion(), "no minimum")
+
+	WithMinBindPlaneVersion("v1.40.0")(a)
+	require.NoError(t, a.CheckVersion())
+
+	WithMinBindPlaneVersion("v1.50.0")(a)
+	require.EqualError(t, a.CheckVersion(), "BindPlane server version v1.40.0 is older than the minimum supported version v1.50.0, upgrade the server or lower min_bindplane_version")
+
+	a.bindplaneVersion.Tag = "unknown"
+	require.EqualError(t, a.CheckVersion(), "compare BindPlane version: version 'unknown' is not a semantic version such as v1.50.0")
+}
+
+func TestCheckHealth(t *testing.T) {
+	healthy := true
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /health", func(w http.ResponseWriter, _ *http.Request) {
+		if !healthy {
+			http.Error(w, "database unavailable", http.StatusServiceUnavailable)
+		}
+	})
+	a := newTestAction(t, mux)
+	require.NoError(t, a.CheckHealth())
+
+	healthy = false
+	require.EqualError(t, a.CheckHealth(), "BindPlane at "+a.config.Network.RemoteURL+" is unhealthy: BindPlane API returned status 503: database unavailable")
+
+	// Servers without a health endpoint are not checked
+	a = newTestAction(t, http.NewServeMux())
+	require.NoError(t, a.CheckHealth())
+
+	a = newTestAction(t, mux, WithBindPlaneRemoteURL("http://127.0.0.1:1"))
+	err := a.CheckHealth()
+	require.ErrorContains(t, err, "BindPlane at http://127.0.0.1:1 is unreachable: ")
+	require.ErrorIs(t, err, syscall.ECONNREFUSED)
+}
+
 func TestNew(t *testing.T) {
 	cases := []struct {
 		name   string
@@ -474,6 +718,16 @@ func TestNew(t *testing.T) {
 			},
 			"",
 		},
+		{
+			"Undefined environment",
+			[]Option{
+				WithBindPlaneRemoteURL("http://localhost:3001"),
+				WithEnvironment("missing"),
+				WithVariablesPath("testdata/variables/variables.yaml"),
+			},
+			nil,
+			"environment 'missing' is not defined",
+		},
 	}
 
 	for _, tc := range cases {
@@ -491,6 +745,20 @@ func TestNew(t *testing.T) {
 			a.client = nil
 			a.Logger = nil
 			a.state = nil // TODO(jsirianni): Add state tests
+			a.catalog = nil
+
+			// The context defaults to context.Background
+			require.Equal(t, context.Background(), a.ctx)
+			a.ctx = nil
+
+			require.Equal(t, remoteTimeout, a.remoteClient.Timeout)
+			a.remoteClient = nil
+
+			require.WithinDuration(t, time.Now(), a.startTime, time.Minute)
+			a.startTime = time.Time{}
+
+			require.Equal(t, DefaultRolloutPollJitter, a.rolloutPollJitter)
+			a.rolloutPollJitter = 0
 
 			require.NoError(t, err)
 			require.Equal(t, tc.expect, a)
@@ -500,7 +768,7 @@ func TestNew(t *testing.T) {
 }
 
 func TestDecodeAnyResourceFile(t *testing.T) {
-	resources, err := decodeAnyResourceFile("testdata/configuration.yaml")
+	resources, _, err := decodeAnyResourceFile("testdata/configuration.yaml", nil)
 	require.NoError(t, err)
 	require.NotNil(t, resources)
 	require.Len(t, resources, 3)
@@ -524,7 +792,7 @@ func TestDecodeAnyResourceFile(t *testing.T) {
 }
 
 func TestDecodeAnyResourceFileGlob(t *testing.T) {
-	resources, err := decodeAnyResourceFile("testdata/*.yaml")
+	resources, _, err := decodeAnyResourceFile("testdata/*.yaml", nil)
 	require.NoError(t, err)
 	require.NotNil(t, resources)
 	require.Len(t, resources, 4)
@@ -548,7 +816,7 @@ func TestDecodeAnyResourceFileGlob(t *testing.T) {
 }
 
 func TestDecodeAnyResourceFileGlobMatchOne(t *testing.T) {
-	resources, err := decodeAnyResourceFile("testdata/config*.yaml")
+	resources, _, err := decodeAnyResourceFile("testdata/config*.yaml", nil)
 	require.NoError(t, err)
 	require.NotNil(t, resources)
 	require.Len(t, resources, 3)
@@ -570,3 +838,221 @@ func TestDecodeAnyResourceFileGlobMatchOne(t *testing.T) {
 		require.Contains(t, platforms, v, "Expected platform label to be one of %v, got %s", platforms, v)
 	}
 }
+
+func TestLoadResourcesUndefinedVariables(t *testing.T) {
+	a, err := New(
+		zap.NewNop(),
+		WithBindPlaneRemoteURL("http://localhost:3001"),
+		WithDestinationPath("testdata/variables/destination.yaml"),
+		WithEnvironment("staging"),
+		WithVariablesPath("testdata/variables/variables.yaml"),
+	)
+	require.NoError(t, err)
+
+	err = a.LoadResources()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "undefined references for environment 'staging': secret.OTLP_TOKEN, var.otlp_port")
+}
+
+func TestLoadResourcesVariables(t *testing.T) {
+	t.Setenv("BINDPLANE_SECRET_OTLP_TOKEN", "token")
+
+	a, err := New(
+		zap.NewNop(),
+		WithBindPlaneRemoteURL("http://localhost:3001"),
+		WithDestinationPath("testdata/variables/destination.yaml"),
+		WithEnvironment("production"),
+		WithVariablesPath("testdata/variables/variables.yaml"),
+	)
+	require.NoError(t, err)
+	require.NoError(t, a.LoadResources())
+
+	resources := a.resources[model.KindDestination]
+	require.Len(t, resources, 1)
+
+	params, ok := resources[0].Spec["parameters"].([]any)
+	require.True(t, ok)
+	require.Equal(t, map[string]any{"name": "hostname", "value": "otlp.mycorp.net"}, params[0])
+	require.Equal(t, map[string]any{"name": "port", "value": 4317}, params[1])
+	require.Equal(t, map[string]any{"name": "token", "value": "token"}, params[2])
+}
+
+func TestDecodeAnyResourceFileOrigins(t *testing.T) {
+	resources, origins, err := decodeAnyResourceFile("testdata/configuration.yaml", nil)
+	require.NoError(t, err)
+	require.Len(t, origins, len(resources))
+	require.Equal(t, []resourceOrigin{
+		{file: "testdata/configuration.yaml", line: 2},
+		{file: "testdata/configuration.yaml", line: 52},
+		{file: "testdata/configuration.yaml", line: 106},
+	}, origins)
+}
+
+func TestDecodeAnyResourceFileMalformed(t *testing.T) {
+	_, _, err := decodeAnyResourceFile("testdata/malformed/destination.yaml", nil)
+	require.Error(t, err)
+
+	var fe *fileError
+	require.ErrorAs(t, err, &fe)
+	require.Equal(t, "testdata/malformed/destination.yaml", fe.file)
+	require.Greater(t, fe.line, 0)
+}
+
+func TestApplyAnnotations(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/apply", func(w http.ResponseWriter, _ *http.Request) {
+		status := model.AnyResourceStatus{
+			Status: model.StatusInvalid,
+			Reason: "missing parameter",
+		}
+		status.Resource.Kind = "Configuration"
+		status.Resource.Metadata.Name = "k8s-node"
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(model.ApplyResponseClientSide{
+			Updates: []*model.AnyResourceStatus{&status},
+		})
+	})
+
+	buf := &bytes.Buffer{}
+	workflow.Output = buf
+	defer func() { workflow.Output = os.Stdout }()
+
+	a := newTestAction(t, mux, WithConfigurationPath("testdata/configuration.yaml"))
+	err := a.Apply()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid resource: k8s-node: missing parameter")
+	require.Equal(t, "::error file=testdata/configuration.yaml,line=106,title=Invalid resource::Configuration k8s-node is invalid: missing parameter\n", buf.String())
+}
+
+func TestApplyFailOnStatuses(t *testing.T) {
+	cases := []struct {
+		name        string
+		statuses    []model.UpdateStatus
+		status      model.UpdateStatus
+		expectErr   string
+		expectLevel string
+	}{
+		{
+			name:        "default fails on invalid",
+			status:      model.StatusInvalid,
+			expectErr:   "invalid resource: k8s-node: reason",
+			expectLevel: "::error",
+		},
+		{
+			name:        "default fails on deprecated",
+			status:      model.StatusDeprecated,
+			expectErr:   "deprecated: k8s-node: reason",
+			expectLevel: "::error",
+		},
+		{
+			name:        "deprecated not in list warns",
+			statuses:    []model.UpdateStatus{model.StatusInvalid, model.StatusError},
+			status:      model.StatusDeprecated,
+			expectLevel: "::warning",
+		},
+		{
+			name:        "invalid not in list warns",
+			statuses:    []model.UpdateStatus{model.StatusError},
+			status:      model.StatusInvalid,
+			expectLevel: "::warning",
+		},
+		{
+			name:     "success never annotates",
+			statuses: []model.UpdateStatus{model.StatusError},
+			status:   model.StatusCreated,
+		},
+		{
+			name:      "unknown status fails",
+			statuses:  []model.UpdateStatus{model.StatusError},
+			status:    model.UpdateStatus("bogus"),
+			expectErr: "unexpected status: bogus",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/v1/apply", func(w http.ResponseWriter, _ *http.Request) {
+				status := model.AnyResourceStatus{
+					Status: tc.status,
+					Reason: "reason",
+				}
+				status.Resource.Kind = "Configuration"
+				status.Resource.Metadata.Name = "k8s-node"
+				w.Header().Set("Content-Type", "application/json")
+				_ = json.NewEncoder(w).Encode(model.ApplyResponseClientSide{
+					Updates: []*model.AnyResourceStatus{&status},
+				})
+			})
+
+			buf := &bytes.Buffer{}
+			workflow.Output = buf
+			defer func() { workflow.Output = os.Stdout }()
+
+			a := newTestAction(t, mux,
+				WithConfigurationPath("testdata/configuration.yaml"),
+				WithFailOnStatuses(tc.statuses),
+			)
+			err := a.Apply()
+			if tc.expectErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.expectErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			if tc.expectLevel == "" {
+				require.Empty(t, buf.String())
+				return
+			}
+			require.Regexp(t, "^"+tc.expectLevel+" file=testdata/configuration.yaml,line=106,", buf.String())
+		})
+	}
+}
+
+func TestApplyAgentVersions(t *testing.T) {
+	var applied model.ApplyPayload
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/apply", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&applied))
+		updates := []*model.AnyResourceStatus{}
+		for _, resource := range applied.Resources {
+			updates = append(updates, &model.AnyResourceStatus{Resource: *resource, Status: model.StatusCreated})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(model.ApplyResponseClientSide{Updates: updates})
+	})
+
+	a := newTestAction(t, mux, WithAgentVersionPath("testdata/agent_versions/agent_version.yaml"))
+	require.NoError(t, a.Apply())
+	require.Len(t, applied.Resources, 1)
+	require.Equal(t, string(model.KindAgentVersion), applied.Resources[0].Kind)
+	require.Equal(t, "observiq-otel-collector-v1.50.0", applied.Resources[0].Metadata.Name)
+	require.Len(t, a.state.ResourceStatuses(), 1)
+}
+
+func TestValidateRenderedConfigurations(t *testing.T) {
+	raw := map[string]string{
+		"valid":   "receivers:\n  otlp: {}\nexporters:\n  logging: {}\nservice:\n  pipelines:\n    logs:\n      receivers: [otlp]\n      exporters: [logging]\n",
+		"invalid": "receivers:\n  otlp: {}\nservice:\n  pipelines:\n    logs:\n      receivers: [otlp]\n",
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/configurations/{name}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(model.ConfigurationResponse{Raw: raw[r.PathValue("name")]})
+	})
+
+	buf := &bytes.Buffer{}
+	workflow.Output = buf
+	defer func() { workflow.Output = os.Stdout }()
+
+	a := newTestAction(t, mux)
+	a.state.SetConfiguration("valid", model.AnyResource{})
+	require.NoError(t, a.ValidateRenderedConfigurations())
+
+	a.state.SetConfiguration("invalid", model.AnyResource{})
+	err := a.ValidateRenderedConfigurations()
+	require.EqualError(t, err, "configuration invalid: pipeline logs: must have at least one exporter")
+	require.Equal(t, "::error title=Invalid rendered configuration::pipeline logs: must have at least one exporter\n", buf.String())
+}
@@ -0,0 +1,209 @@
+package action
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Agent checks, see WithAgentCheck
+const (
+	// AgentCheckWarn logs a warning when the agent count is unexpected
+	AgentCheckWarn = "warn"
+
+	// AgentCheckFail does not start a rollout when the agent count is unexpected
+	AgentCheckFail = "fail"
+)
+
+// ValidateAgentCheck returns an error if s is not an agent check.
+// An empty check does not count agents.
+func ValidateAgentCheck(s string) error {
+	switch s {
+	case "", AgentCheckWarn, AgentCheckFail:
+		return nil
+	}
+	return fmt.Errorf("invalid agent check %s, must be %s or %s", s, AgentCheckWarn, AgentCheckFail)
+}
+
+// AgentRange is the number of agents a configuration is expected
+// to match. A max of 0 does not limit the number of agents.
+type AgentRange struct {
+	min int
+	max int
+}
+
+// ParseAgentRange parses a minimum such as 10, or a range such as 10-50
+func ParseAgentRange(s string) (*AgentRange, error) {
+	s = strings.TrimSpace(s)
+	lower, upper, bounded := strings.Cut(s, "-")
+
+	minimum, err := strconv.Atoi(strings.TrimSpace(lower))
+	if err != nil || minimum < 0 {
+		return nil, fmt.Errorf("%s is not a non-negative integer or range such as 10-50", s)
+	}
+	if !bounded {
+		return &AgentRange{min: minimum}, nil
+	}
+
+	maximum, err := strconv.Atoi(strings.TrimSpace(upper))
+	if err != nil || maximum < minimum || maximum == 0 {
+		return nil, fmt.Errorf("%s is not a range such as 10-50, the maximum must be greater than or equal to the minimum", s)
+	}
+	return &AgentRange{min: minimum, max: maximum}, nil
+}
+
+// Contains returns true if n agents are within the range
+func (r *AgentRange) Contains(n int) bool {
+	return n >= r.min && (r.max == 0 || n <= r.max)
+}
+
+// String returns the range as it was configured
+func (r *AgentRange) String() string {
+	if r.max == 0 {
+		return "at least " + strconv.Itoa(r.min)
+	}
+	return strconv.Itoa(r.min) + "-" + strconv.Itoa(r.max)
+}
+
+// checkAgents counts the agents matching the selector of a configuration
+// before its rollout starts, so a rollout to no agents, such as when a
+// label is misspelled, is caught. Without an expected range, at least one
+// agent must match.
+func (a *Action) checkAgents(name string) error {
+	if a.agentCheck == "" {
+		return nil
+	}
+
+	c, err := a.client.Configuration(a.ctx, name)
+	if err != nil {
+		return fmt.Errorf("get configuration %s: %w", name, err)
+	}
+	if c == nil {
+		return fmt.Errorf("configuration '%s' is nil: %s", name, BugError)
+	}
+
+	selector := agentSelector(c)
+	agents, err := a.client.AgentsBySelector(a.ctx, selector.String())
+	if err != nil {
+		return fmt.Errorf("get agents of configuration %s: %w", name, err)
+	}
+
+	expected := a.expectedAgents
+	if expected == nil {
+		expected = &AgentRange{min: 1}
+	}
+	if expected.Contains(len(agents)) {
+		a.rolloutLogger().Info("Agents match configuration", zap.String("name", name), zap.String("selector", selector.String()), zap.Int("agents", len(agents)))
+		return nil
+	}
+
+	err = fmt.Errorf("%d agents match selector %s of configuration %s, expected %s", len(agents), selector, name, expected)
+	if a.agentCheck == AgentCheckWarn {
+		a.rolloutLogger().Warn("Unexpected number of agents, starting rollout", zap.String("name", name), zap.Error(err))
+		return nil
+	}
+	return err
+}
+
+// agentSelector returns the labels of the agents a configuration is
+// rolled out to. BindPlane selects agents by the configuration label
+// when the configuration does not have a selector.
+func agentSelector(c *model.Configuration) labels.Set {
+	if len(c.Spec.Selector.MatchLabels) == 0 {
+		return labels.Set{"configuration": c.Metadata.Name}
+	}
+	return labels.Set(c.Spec.Selector.MatchLabels)
+}
+
+// waitForAgents waits until every agent matching each completed rollout
+// is connected and running the rolled out configuration version. A stable
+// rollout does not mean the agents are healthy, an agent may fail or
+// disconnect after it is configured.
+func (a *Action) waitForAgents(names []string) error {
+	timeout := a.agentWaitTimeout
+	if timeout <= 0 {
+		timeout = DefaultAgentWaitTimeout
+	}
+	interval := a.rolloutPollInterval
+	if interval <= 0 {
+		interval = DefaultRolloutPollInterval
+	}
+
+	// Only stable rollouts were rolled out to every agent
+	versions := map[string]string{}
+	selectors := map[string]labels.Set{}
+	for _, name := range names {
+		c, err := a.client.RolloutStatus(a.ctx, name)
+		if err != nil {
+			return fmt.Errorf("rollout status %s: %w", name, err)
+		}
+		if c == nil {
+			return fmt.Errorf("rollout status '%s' is nil: %s", name, BugError)
+		}
+		if c.Status.Rollout.Status != model.RolloutStatusStable {
+			continue
+		}
+		versions[name] = fmt.Sprintf("%s:%d", name, c.Metadata.Version)
+		selectors[name] = agentSelector(c)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		waiting := []string{}
+		for _, name := range names {
+			if _, ok := versions[name]; !ok {
+				continue
+			}
+			agents, err := a.client.AgentsBySelector(a.ctx, selectors[name].String())
+			if err != nil {
+				return fmt.Errorf("get agents of configuration %s: %w", name, err)
+			}
+
+			ready := 0
+			for _, agent := range agents {
+				if agent.Status == model.AgentStatusConnected && agent.ConfigurationStatus.Current == versions[name] {
+					ready++
+				}
+			}
+			a.rolloutLogger().Info("Agent configuration progress",
+				zap.String("name", name),
+				zap.String("version", versions[name]),
+				zap.Int("ready", ready),
+				zap.Int("agents", len(agents)),
+			)
+			if ready < len(agents) {
+				waiting = append(waiting, name)
+				continue
+			}
+			delete(versions, name)
+		}
+
+		if len(waiting) == 0 {
+			return nil
+		}
+		wait := a.pollJitter(interval)
+		if time.Now().Add(wait).After(deadline) {
+			return fmt.Errorf("timed out after %s waiting for agents to run configurations: %v", timeout, waiting)
+		}
+		time.Sleep(wait)
+	}
+}
+
+// AgentConfiguration returns the raw configuration served to an agent by
+// ID, rendered for the agent, so what a single problem agent runs can be
+// verified. The configuration version the agent is served is logged.
+func (a *Action) AgentConfiguration(id string) (string, error) {
+	r, err := a.client.AgentConfiguration(a.ctx, id)
+	if err != nil {
+		return "", fmt.Errorf("get configuration of agent %s: %w", id, err)
+	}
+	if r.Configuration != nil {
+		a.Logger.Info("Agent configuration", zap.String("agent", id), zap.String("name", r.Configuration.Metadata.Name), zap.Int("version", r.Configuration.Metadata.Version))
+	}
+	return r.Raw, nil
+}
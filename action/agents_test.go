@@ -0,0 +1,160 @@
+package action
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAgentRange(t *testing.T) {
+	cases := []struct {
+		input     string
+		expect    *AgentRange
+		expectErr bool
+	}{
+		{"10", &AgentRange{min: 10}, false},
+		{"10-50", &AgentRange{min: 10, max: 50}, false},
+		{" 0 - 5 ", &AgentRange{min: 0, max: 5}, false},
+		{"50-10", nil, true},
+		{"0-0", nil, true},
+		{"-1", nil, true},
+		{"ten", nil, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.input, func(t *testing.T) {
+			r, err := ParseAgentRange(tc.input)
+			if tc.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, r)
+		})
+	}
+}
+
+func TestRunRolloutAgentCheck(t *testing.T) {
+	cases := []struct {
+		name          string
+		check         string
+		expected      *AgentRange
+		matchLabels   model.MatchLabels
+		agents        int
+		expectStarted bool
+		expectErr     string
+	}{
+		{"agents match", AgentCheckFail, nil, nil, 2, true, ""},
+		{"no agents", AgentCheckFail, nil, nil, 0, false, "0 agents match selector configuration=gateway of configuration gateway, expected at least 1"},
+		{"no agents warn", AgentCheckWarn, nil, nil, 0, true, ""},
+		{"outside range", AgentCheckFail, &AgentRange{min: 10, max: 50}, model.MatchLabels{"configuration": "gateway", "env": "prod"}, 3, false, "3 agents match selector configuration=gateway,env=prod of configuration gateway, expected 10-50"},
+		{"zero allowed", AgentCheckFail, &AgentRange{min: 0, max: 5}, nil, 0, true, ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			selector := ""
+			started := false
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /v1/configurations/{name}", func(w http.ResponseWriter, r *http.Request) {
+				c := model.Configuration{}
+				c.Metadata.Name = r.PathValue("name")
+				c.Spec.Selector.MatchLabels = tc.matchLabels
+				w.Header().Set("Content-Type", "application/json")
+				_ = json.NewEncoder(w).Encode(model.ConfigurationResponse{Configuration: &c})
+			})
+			mux.HandleFunc("GET /v1/agents", func(w http.ResponseWriter, r *http.Request) {
+				selector = r.URL.Query().Get("selector")
+				resp := model.AgentsResponse{Agents: []*model.Agent{}}
+				for range tc.agents {
+					resp.Agents = append(resp.Agents, &model.Agent{})
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_ = json.NewEncoder(w).Encode(resp)
+			})
+			mux.HandleFunc("POST /v1/rollouts/{name}/start", func(w http.ResponseWriter, _ *http.Request) {
+				started = true
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{}`))
+			})
+
+			a := newTestAction(t, mux,
+				WithAgentCheck(tc.check),
+				WithExpectedAgents(tc.expected),
+			)
+			err := a.RunRollout("gateway")
+			if tc.expectErr != "" {
+				require.EqualError(t, err, tc.expectErr)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tc.expectStarted, started)
+			require.NotEmpty(t, selector)
+		})
+	}
+
+	_, err := New(nil, WithAgentCheck("error"))
+	require.EqualError(t, err, "invalid agent check error, must be warn or fail")
+}
+
+func TestWaitForRolloutsAgents(t *testing.T) {
+	current := &model.Agent{Status: model.AgentStatusConnected, ConfigurationStatus: model.AgentConfigurationStatus{Current: "gateway:3"}}
+	previous := &model.Agent{Status: model.AgentStatusConnected, ConfigurationStatus: model.AgentConfigurationStatus{Current: "gateway:2"}}
+	failed := &model.Agent{Status: model.AgentStatusError, ConfigurationStatus: model.AgentConfigurationStatus{Current: "gateway:3"}}
+
+	cases := []struct {
+		name      string
+		rollout   model.RolloutStatus
+		polls     [][]*model.Agent
+		expectErr string
+	}{
+		{"agents configured", model.RolloutStatusStable, [][]*model.Agent{{current, previous}, {current, current}}, ""},
+		{"no agents", model.RolloutStatusStable, [][]*model.Agent{{}}, ""},
+		{"agent errored", model.RolloutStatusStable, [][]*model.Agent{{current, failed}}, "timed out after 20ms waiting for agents to run configurations: [gateway]"},
+		{"rollout paused", model.RolloutStatusPaused, [][]*model.Agent{{previous}}, ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			polls := 0
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /v1/rollouts/{name}/status", func(w http.ResponseWriter, r *http.Request) {
+				c := model.Configuration{}
+				c.Metadata.Name = r.PathValue("name")
+				c.Metadata.Version = 3
+				c.Status.Rollout.Status = tc.rollout
+				w.Header().Set("Content-Type", "application/json")
+				_ = json.NewEncoder(w).Encode(model.ConfigurationResponse{Configuration: &c})
+			})
+			mux.HandleFunc("GET /v1/agents", func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, "configuration=gateway", r.URL.Query().Get("selector"))
+				agents := tc.polls[min(polls, len(tc.polls)-1)]
+				polls++
+				w.Header().Set("Content-Type", "application/json")
+				_ = json.NewEncoder(w).Encode(model.AgentsResponse{Agents: agents})
+			})
+
+			a := newTestAction(t, mux,
+				WithRolloutWait(true),
+				WithRolloutPollInterval(5*time.Millisecond),
+				WithAgentWait(true),
+				WithAgentWaitTimeout(20*time.Millisecond),
+			)
+			a.startedRollouts = []string{"gateway"}
+
+			err := a.WaitForRollouts()
+			if tc.expectErr != "" {
+				require.EqualError(t, err, tc.expectErr)
+			} else {
+				require.NoError(t, err)
+			}
+			if tc.rollout == model.RolloutStatusPaused {
+				require.Zero(t, polls)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package action
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/observiq/bindplane-op-action/internal/workflow"
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+)
+
+// auditClockSkew is subtracted from the start of the run when querying
+// the audit log, so entries are not missed when the runner's clock is
+// ahead of the server's clock
+const auditClockSkew = time.Minute
+
+// WriteAuditSummary appends the audit log entries recorded by BindPlane
+// for the resources applied, deleted, or rolled out by this run to the
+// job summary, so reviewers can confirm what changed on the server.
+func (a *Action) WriteAuditSummary() error {
+	events, err := a.AuditEvents()
+	if err != nil {
+		return err
+	}
+	if err := workflow.AppendSummary(auditSummary(a.config.Network.RemoteURL, events)); err != nil {
+		return fmt.Errorf("write audit summary: %w", err)
+	}
+	return nil
+}
+
+// AuditEvents returns the audit log entries recorded since the action
+// started for the resources applied, deleted, or rolled out by this run,
+// sorted by time
+func (a *Action) AuditEvents() ([]*model.AuditEvent, error) {
+	names := map[string]struct{}{}
+	for _, s := range a.state.ResourceStatuses() {
+		names[s.Resource.Metadata.Name] = struct{}{}
+	}
+	for _, name := range a.startedRollouts {
+		names[name] = struct{}{}
+	}
+	if len(names) == 0 {
+		return []*model.AuditEvent{}, nil
+	}
+
+	all, err := a.client.AuditEvents(a.ctx, a.startTime.Add(-auditClockSkew))
+	if err != nil {
+		return nil, fmt.Errorf("get audit events: %w", err)
+	}
+
+	events := []*model.AuditEvent{}
+	for _, e := range all {
+		if _, ok := names[e.ResourceName]; ok {
+			events = append(events, e)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].Timestamp == nil || events[j].Timestamp == nil {
+			return events[j].Timestamp == nil && events[i].Timestamp != nil
+		}
+		return events[i].Timestamp.Before(*events[j].Timestamp)
+	})
+	return events, nil
+}
+
+// auditSummary formats the audit events of the server at remoteURL as a
+// markdown table. The server is named, because each profile writes a summary.
+func auditSummary(remoteURL string, events []*model.AuditEvent) string {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "### BindPlane audit log for %s\n\n", remoteURL)
+	if len(events) == 0 {
+		b.WriteString("No audit log entries were recorded for the resources changed by this run.\n")
+		return b.String()
+	}
+
+	b.WriteString("| Time | User | Action | Kind | Name | Configuration |\n")
+	b.WriteString("| ---- | ---- | ------ | ---- | ---- | ------------- |\n")
+	for _, e := range events {
+		timestamp := ""
+		if e.Timestamp != nil {
+			timestamp = e.Timestamp.UTC().Format(time.RFC3339)
+		}
+		cells := []string{timestamp, e.User, string(e.Action), string(e.ResourceKind), e.ResourceName, e.Configuration}
+		for i, c := range cells {
+			cells[i] = strings.ReplaceAll(c, "|", `\|`)
+		}
+		fmt.Fprintf(b, "| %s |\n", strings.Join(cells, " | "))
+	}
+	return b.String()
+}
@@ -0,0 +1,77 @@
+package action
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteAuditSummary(t *testing.T) {
+	requests := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/audit-events", func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"auditEvents":[
+			{"id":"3","timestamp":"2024-05-01T12:00:09Z","resourceName":"gateway","resourceKind":"Configuration","configuration":"gateway","action":"RolloutStarted","user":"ci"},
+			{"id":"2","timestamp":"2024-05-01T12:00:05Z","resourceName":"otlp","resourceKind":"Destination","action":"Modified","user":"ci|bot"},
+			{"id":"1","timestamp":"2024-05-01T12:00:01Z","resourceName":"other","resourceKind":"Configuration","configuration":"other","action":"Modified","user":"alice"}
+		]}`))
+	})
+
+	path := filepath.Join(t.TempDir(), "summary")
+	t.Setenv("GITHUB_STEP_SUMMARY", path)
+
+	a := newTestAction(t, mux)
+	require.NoError(t, a.WriteAuditSummary())
+	require.Equal(t, 0, requests, "the audit log is not queried when nothing changed")
+
+	dest := model.AnyResourceStatus{Status: model.StatusConfigured}
+	dest.Resource.Kind = string(model.KindDestination)
+	dest.Resource.Metadata.Name = "otlp"
+	a.state.AddResourceStatus(dest)
+	a.startedRollouts = []string{"gateway"}
+
+	events, err := a.AuditEvents()
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	require.Equal(t, "2", events[0].ID)
+	require.Equal(t, "3", events[1].ID)
+
+	require.NoError(t, a.WriteAuditSummary())
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	heading := "### BindPlane audit log for " + a.config.Network.RemoteURL + "\n\n"
+	require.Equal(t, heading+
+		"No audit log entries were recorded for the resources changed by this run.\n\n"+
+		heading+
+		"| Time | User | Action | Kind | Name | Configuration |\n"+
+		"| ---- | ---- | ------ | ---- | ---- | ------------- |\n"+
+		"| 2024-05-01T12:00:05Z | ci\\|bot | Modified | Destination | otlp |  |\n"+
+		"| 2024-05-01T12:00:09Z | ci | RolloutStarted | Configuration | gateway | gateway |\n\n",
+		string(data))
+}
+
+func TestAuditEventsStartTime(t *testing.T) {
+	minDate := ""
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/audit-events", func(w http.ResponseWriter, r *http.Request) {
+		minDate = r.URL.Query().Get("minDate")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"auditEvents":[]}`))
+	})
+
+	a := newTestAction(t, mux)
+	a.startTime = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	a.startedRollouts = []string{"gateway"}
+
+	events, err := a.AuditEvents()
+	require.NoError(t, err)
+	require.Empty(t, events)
+	require.Equal(t, "2024-05-01T11:59:00Z", minDate)
+}
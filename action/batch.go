@@ -0,0 +1,179 @@
+package action
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+)
+
+const (
+	// ApplyStrategyFailFast stops applying resources at the first
+	// resource or request which fails
+	ApplyStrategyFailFast = "fail_fast"
+
+	// ApplyStrategyContinue applies every resource possible, and
+	// reports every failure once all resources are applied
+	ApplyStrategyContinue = "continue"
+
+	// ApplyStrategyRollback stops applying resources at the first
+	// failure, like fail_fast, and restores the resources which
+	// were changed to the versions they had before the apply
+	ApplyStrategyRollback = "rollback"
+)
+
+// ValidateApplyStrategy returns an error if s is not an apply strategy.
+// An empty strategy is the default, fail_fast.
+func ValidateApplyStrategy(s string) error {
+	switch s {
+	case "", ApplyStrategyFailFast, ApplyStrategyContinue, ApplyStrategyRollback:
+		return nil
+	}
+	return fmt.Errorf("invalid apply strategy %s, must be %s, %s, or %s", s, ApplyStrategyFailFast, ApplyStrategyContinue, ApplyStrategyRollback)
+}
+
+// continueOnError returns true if applying continues after a failure
+func (a *Action) continueOnError() bool {
+	return a.applyStrategy == ApplyStrategyContinue
+}
+
+// applyBatches splits resources into batches and applies them concurrently.
+// Each batch is applied in one or more requests, bounded by the max payload
+// size. The statuses of every batch are returned in the order the resources
+// were given, even when a batch fails. The first batch error is returned,
+// or every batch error when the apply strategy is continue.
+func (a *Action) applyBatches(resources []*model.AnyResource) ([]*model.AnyResourceStatus, error) {
+	batches := splitBatches(resources, a.applyConcurrency)
+
+	results := make([][]*model.AnyResourceStatus, len(batches))
+	errs := make([]error, len(batches))
+
+	var wg sync.WaitGroup
+	for i, batch := range batches {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = a.applyChunks(batch)
+		}()
+	}
+	wg.Wait()
+
+	// The statuses of a failed batch are only returned when the apply
+	// strategy is continue. Otherwise, the chunks applied before the
+	// failure are only tracked, so they can be restored.
+	statuses := []*model.AnyResourceStatus{}
+	batchErrs := []error{}
+	for i := range batches {
+		if errs[i] != nil {
+			batchErrs = append(batchErrs, fmt.Errorf("client error: %w", errs[i]))
+			if !a.continueOnError() {
+				a.trackApplied(results[i])
+				continue
+			}
+		}
+		statuses = append(statuses, results[i]...)
+	}
+
+	switch {
+	case len(batchErrs) == 0:
+		return statuses, nil
+	case a.continueOnError():
+		return statuses, errors.Join(batchErrs...)
+	default:
+		return statuses, batchErrs[0]
+	}
+}
+
+// applyChunks applies resources in chunks bounded by the max payload size,
+// stopping at the first chunk which fails. When the apply strategy is
+// continue, every chunk is applied. The statuses of the chunks which
+// were applied are returned with the error, or every error.
+func (a *Action) applyChunks(resources []*model.AnyResource) ([]*model.AnyResourceStatus, error) {
+	chunks, err := splitChunks(resources, a.applyMaxPayloadSize)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := []*model.AnyResourceStatus{}
+	errs := []error{}
+	for _, chunk := range chunks {
+		resp, err := a.client.Apply(a.ctx, chunk)
+		if err == nil && resp == nil {
+			err = fmt.Errorf("nil response from client: %s", BugError)
+		}
+		if err != nil {
+			if !a.continueOnError() {
+				return statuses, err
+			}
+			errs = append(errs, err)
+			continue
+		}
+		statuses = append(statuses, resp...)
+	}
+	return statuses, errors.Join(errs...)
+}
+
+// payloadOverhead is the size of the apply payload without any resources
+var payloadOverhead = len(`{"resources":[]}`)
+
+// splitChunks splits resources into contiguous chunks whose apply payload
+// does not exceed limit bytes. A resource larger than limit is applied on
+// its own. A single chunk is returned when limit is less than or equal to zero.
+func splitChunks(resources []*model.AnyResource, limit int) ([][]*model.AnyResource, error) {
+	if limit <= 0 || len(resources) == 0 {
+		return [][]*model.AnyResource{resources}, nil
+	}
+
+	chunks := [][]*model.AnyResource{}
+	chunk := []*model.AnyResource{}
+	size := payloadOverhead
+	for _, r := range resources {
+		data, err := json.Marshal(r)
+		if err != nil {
+			return nil, fmt.Errorf("marshal resource %s: %w", r.Metadata.Name, err)
+		}
+
+		// Resources after the first are separated by a comma
+		n := len(data)
+		if len(chunk) > 0 {
+			n++
+		}
+
+		if len(chunk) > 0 && size+n > limit {
+			chunks = append(chunks, chunk)
+			chunk = []*model.AnyResource{}
+			size = payloadOverhead
+			n = len(data)
+		}
+
+		chunk = append(chunk, r)
+		size += n
+	}
+	return append(chunks, chunk), nil
+}
+
+// splitBatches splits resources into at most n contiguous batches of
+// similar size. A single batch is returned when n is less than two.
+func splitBatches(resources []*model.AnyResource, n int) [][]*model.AnyResource {
+	if n > len(resources) {
+		n = len(resources)
+	}
+	if n < 2 {
+		return [][]*model.AnyResource{resources}
+	}
+
+	batches := make([][]*model.AnyResource, 0, n)
+	size, extra := len(resources)/n, len(resources)%n
+	start := 0
+	for i := 0; i < n; i++ {
+		end := start + size
+		if i < extra {
+			end++
+		}
+		batches = append(batches, resources[start:end])
+		start = end
+	}
+	return batches
+}
@@ -0,0 +1,244 @@
+package action
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestSplitBatches(t *testing.T) {
+	resources := func(n int) []*model.AnyResource {
+		r := []*model.AnyResource{}
+		for i := 0; i < n; i++ {
+			r = append(r, testResource(model.KindSource, fmt.Sprintf("source-%d", i), nil))
+		}
+		return r
+	}
+
+	cases := []struct {
+		name        string
+		resources   int
+		n           int
+		expectSizes []int
+	}{
+		{"Serial", 5, 1, []int{5}},
+		{"Unset", 5, 0, []int{5}},
+		{"Even", 6, 3, []int{2, 2, 2}},
+		{"Uneven", 7, 3, []int{3, 2, 2}},
+		{"More workers than resources", 2, 5, []int{1, 1}},
+		{"Empty", 0, 4, []int{0}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := resources(tc.resources)
+			batches := splitBatches(r, tc.n)
+
+			sizes := []int{}
+			flattened := []*model.AnyResource{}
+			for _, b := range batches {
+				sizes = append(sizes, len(b))
+				flattened = append(flattened, b...)
+			}
+			require.Equal(t, tc.expectSizes, sizes)
+			require.Equal(t, r, flattened)
+		})
+	}
+}
+
+func TestSplitChunks(t *testing.T) {
+	resources := []*model.AnyResource{}
+	for i := 0; i < 5; i++ {
+		resources = append(resources, testResource(model.KindSource, fmt.Sprintf("source-%d", i), nil))
+	}
+
+	data, err := json.Marshal(resources[0])
+	require.NoError(t, err)
+	size := len(data)
+
+	cases := []struct {
+		name        string
+		limit       int
+		expectSizes []int
+	}{
+		{"Unlimited", 0, []int{5}},
+		{"All fit", payloadOverhead + 5*size + 4, []int{5}},
+		{"Two per chunk", payloadOverhead + 2*size + 1, []int{2, 2, 1}},
+		{"Smaller than a resource", 1, []int{1, 1, 1, 1, 1}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			chunks, err := splitChunks(resources, tc.limit)
+			require.NoError(t, err)
+
+			sizes := []int{}
+			flattened := []*model.AnyResource{}
+			for _, c := range chunks {
+				sizes = append(sizes, len(c))
+				flattened = append(flattened, c...)
+
+				if tc.limit > size+payloadOverhead {
+					payload, err := json.Marshal(model.ApplyPayload{Resources: c})
+					require.NoError(t, err)
+					require.LessOrEqual(t, len(payload), tc.limit)
+				}
+			}
+			require.Equal(t, tc.expectSizes, sizes)
+			require.Equal(t, resources, flattened)
+		})
+	}
+}
+
+func TestApplyConcurrency(t *testing.T) {
+	var mu sync.Mutex
+	requests := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/apply", func(w http.ResponseWriter, r *http.Request) {
+		payload := model.ApplyPayload{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+
+		mu.Lock()
+		requests++
+		mu.Unlock()
+
+		updates := []*model.AnyResourceStatus{}
+		for _, resource := range payload.Resources {
+			if resource.Metadata.Name == "fail" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			updates = append(updates, &model.AnyResourceStatus{Resource: *resource, Status: model.StatusCreated})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(model.ApplyResponseClientSide{Updates: updates})
+	})
+
+	resources := []*model.AnyResource{}
+	for i := 0; i < 10; i++ {
+		resources = append(resources, testResource(model.KindSource, fmt.Sprintf("source-%d", i), nil))
+	}
+
+	a := newTestAction(t, mux, WithApplyConcurrency(4))
+	require.NoError(t, a.apply(resources))
+	require.Equal(t, 4, requests)
+
+	statuses := a.state.ResourceStatuses()
+	require.Len(t, statuses, 10)
+	for i, s := range statuses {
+		require.Equal(t, fmt.Sprintf("source-%d", i), s.Resource.Metadata.Name)
+	}
+
+	// Statuses from successful batches are kept when a batch fails
+	resources[9].Metadata.Name = "fail"
+	a = newTestAction(t, mux, WithApplyConcurrency(2))
+	err := a.apply(resources)
+	require.ErrorContains(t, err, "client error: BindPlane API returned status 400")
+	require.Len(t, a.state.ResourceStatuses(), 5)
+}
+
+func TestApplyMaxPayloadSize(t *testing.T) {
+	requests := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/apply", func(w http.ResponseWriter, r *http.Request) {
+		require.LessOrEqual(t, r.ContentLength, int64(1000))
+		payload := model.ApplyPayload{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		requests++
+
+		updates := []*model.AnyResourceStatus{}
+		for _, resource := range payload.Resources {
+			updates = append(updates, &model.AnyResourceStatus{Resource: *resource, Status: model.StatusCreated})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(model.ApplyResponseClientSide{Updates: updates})
+	})
+
+	resources := []*model.AnyResource{}
+	for i := 0; i < 20; i++ {
+		resources = append(resources, testResource(model.KindSource, fmt.Sprintf("source-%d", i), nil))
+	}
+
+	a := newTestAction(t, mux, WithApplyMaxPayloadSize(1000))
+	require.NoError(t, a.apply(resources))
+	require.Greater(t, requests, 1)
+	require.Len(t, a.state.ResourceStatuses(), 20)
+}
+
+func TestValidateApplyStrategy(t *testing.T) {
+	require.NoError(t, ValidateApplyStrategy(""))
+	require.NoError(t, ValidateApplyStrategy(ApplyStrategyFailFast))
+	require.NoError(t, ValidateApplyStrategy(ApplyStrategyContinue))
+	require.NoError(t, ValidateApplyStrategy(ApplyStrategyRollback))
+	require.EqualError(t, ValidateApplyStrategy("best_effort"), "invalid apply strategy best_effort, must be fail_fast, continue, or rollback")
+
+	_, err := New(zap.NewNop(), WithApplyStrategy("best_effort"))
+	require.EqualError(t, err, "invalid apply strategy best_effort, must be fail_fast, continue, or rollback")
+}
+
+func TestApplyStrategy(t *testing.T) {
+	dir := t.TempDir()
+	writeResources := func(name, kind string, names ...string) string {
+		path := filepath.Join(dir, name)
+		data := ""
+		for _, n := range names {
+			data += fmt.Sprintf("---\napiVersion: bindplane.observiq.com/v1\nkind: %s\nmetadata:\n  name: %s\n", kind, n)
+		}
+		require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+		return path
+	}
+	destinations := writeResources("destinations.yaml", "Destination", "otlp", "broken")
+	sources := writeResources("sources.yaml", "Source", "host", "invalid", "file")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/apply", func(w http.ResponseWriter, r *http.Request) {
+		payload := model.ApplyPayload{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+
+		updates := []*model.AnyResourceStatus{}
+		for _, resource := range payload.Resources {
+			switch resource.Metadata.Name {
+			case "broken":
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			case "invalid":
+				updates = append(updates, &model.AnyResourceStatus{Resource: *resource, Status: model.StatusInvalid, Reason: "reason"})
+			default:
+				updates = append(updates, &model.AnyResourceStatus{Resource: *resource, Status: model.StatusCreated})
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(model.ApplyResponseClientSide{Updates: updates})
+	})
+
+	applied := func(a *Action) []string {
+		names := []string{}
+		for _, s := range a.state.ResourceStatuses() {
+			names = append(names, s.Resource.Metadata.Name)
+		}
+		return names
+	}
+
+	// Each resource is applied in its own request
+	opts := []Option{WithDestinationPath(destinations), WithSourcePath(sources), WithApplyMaxPayloadSize(1)}
+
+	a := newTestAction(t, mux, append(opts, WithApplyStrategy(ApplyStrategyFailFast))...)
+	err := a.Apply()
+	require.ErrorContains(t, err, "destinations: client error: BindPlane API returned status 400")
+	require.NotContains(t, err.Error(), "sources")
+	require.Empty(t, applied(a))
+
+	a = newTestAction(t, mux, append(opts, WithApplyStrategy(ApplyStrategyContinue))...)
+	err = a.Apply()
+	require.ErrorContains(t, err, "destinations: client error: BindPlane API returned status 400")
+	require.ErrorContains(t, err, "sources: invalid resource: invalid: reason")
+	require.Equal(t, []string{"otlp", "host", "invalid", "file"}, applied(a))
+}
@@ -0,0 +1,188 @@
+package action
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Rollout stage names of a canary rollout
+const (
+	canaryStage = "canary"
+	allStage    = "all"
+)
+
+// ParseCanarySelector parses a canary selector, such as canary=true. Agents
+// are matched by BindPlane, which only supports equality, so set based
+// selectors are not allowed.
+func ParseCanarySelector(s string) (labels.Set, error) {
+	set, err := labels.ConvertSelectorToLabelsMap(s)
+	if err != nil {
+		return nil, fmt.Errorf("canary selector %s must be a list of label=value pairs: %w", s, err)
+	}
+	if len(set) == 0 {
+		return nil, fmt.Errorf("canary selector %s does not select any labels", s)
+	}
+	return set, nil
+}
+
+// startRollout starts the rollout of a configuration version. A version of 0
+// starts the latest pending version. When a canary selector is set, the
+// rollout is staged, and only continues to every agent once the canary
+// agents are healthy. When an agent check is set, the agents matching the
+// configuration are counted first, and when throughput is verified, the
+// throughput before the rollout is recorded. A rollout which is already in
+// progress, such as when a workflow is re-run, is resumed instead.
+func (a *Action) startRollout(name string, version int) error {
+	if err := a.checkAgents(name); err != nil {
+		return err
+	}
+	if err := a.recordThroughput(name); err != nil {
+		return err
+	}
+
+	if a.canaryLabels == nil {
+		if err := a.client.StartRolloutVersion(a.ctx, name, version); err != nil {
+			return a.resumeRollout(name, version, fmt.Errorf("start rollout: %w", err))
+		}
+		a.startedRollouts = append(a.startedRollouts, name)
+		a.notifyRolloutStarted(name)
+		return nil
+	}
+
+	stages := []model.RolloutStage{
+		{Name: canaryStage, Labels: model.Labels{Set: a.canaryLabels}},
+		{Name: allStage},
+	}
+	if err := a.client.StartRolloutStages(a.ctx, name, version, stages); err != nil {
+		if err := a.resumeRollout(name, version, fmt.Errorf("start canary rollout: %w", err)); err != nil {
+			return err
+		}
+	} else {
+		a.startedRollouts = append(a.startedRollouts, name)
+		a.notifyRolloutStarted(name)
+	}
+
+	if err := a.runCanary(name); err != nil {
+		return fmt.Errorf("canary rollout %s: %w", name, err)
+	}
+	return nil
+}
+
+// resumeRollout is called when a rollout could not be started. If the
+// rollout of the configuration version is already in progress, it is
+// added to the started rollouts so it is waited on, otherwise startErr is
+// returned. A version of 0 matches any version.
+func (a *Action) resumeRollout(name string, version int, startErr error) error {
+	c, err := a.client.RolloutStatus(a.ctx, name)
+	if err != nil || c == nil {
+		return startErr
+	}
+	if c.Status.Rollout.Status != model.RolloutStatusStarted || (version > 0 && c.Metadata.Version != version) {
+		return startErr
+	}
+
+	a.rolloutLogger().Info("Rollout is already in progress, resuming",
+		zap.String("name", name),
+		zap.Int("version", c.Metadata.Version),
+	)
+	a.startedRollouts = append(a.startedRollouts, name)
+	return nil
+}
+
+// runCanary waits for the canary stage of a rollout to finish, then for the
+// canary wait, and resumes the rollout so it continues to every agent. The
+// rollout is paused, and an error is returned, if a canary agent errors
+// more than the max rollout errors allow, or no agents match the selector.
+// The canary waits of every configuration share one rollout timeout, which
+// starts with the first canary wait, so canaries of many configurations
+// do not wait for a rollout timeout each.
+func (a *Action) runCanary(name string) error {
+	timeout := a.rolloutTimeout
+	if timeout <= 0 {
+		timeout = DefaultRolloutTimeout
+	}
+	if a.canaryDeadline.IsZero() {
+		a.canaryDeadline = time.Now().Add(timeout)
+	}
+	interval := a.rolloutPollInterval
+	if interval <= 0 {
+		interval = DefaultRolloutPollInterval
+	}
+	threshold := a.maxRolloutErrors
+	if threshold == nil {
+		threshold = &ErrorThreshold{}
+	}
+
+	var finished time.Time
+	for {
+		c, err := a.client.RolloutStatus(a.ctx, name)
+		if err != nil {
+			return fmt.Errorf("rollout status: %w", err)
+		}
+		if c == nil {
+			return fmt.Errorf("rollout status '%s' is nil: %s", name, BugError)
+		}
+
+		rollout := c.Status.Rollout
+		if len(rollout.Stages) == 0 {
+			return fmt.Errorf("rollout does not have stages, the BindPlane server may not support staged rollouts")
+		}
+		if rollout.Stage > 0 || rollout.Status == model.RolloutStatusStable {
+			a.rolloutLogger().Info("Canary stage complete", zap.String("name", name))
+			return nil
+		}
+
+		progress := rollout.Stages[0].Progress
+		a.rolloutLogger().Info("Canary progress",
+			zap.String("name", name),
+			zap.String("status", rollout.Status.String()),
+			zap.Int("completed", progress.Completed),
+			zap.Int("errors", progress.Errors),
+			zap.Int("pending", progress.Pending),
+			zap.Int("waiting", progress.Waiting),
+		)
+
+		switch {
+		case rollout.Status == model.RolloutStatusError || threshold.Exceeded(progress):
+			return a.abortCanary(name, fmt.Errorf("%d canary agents errored, exceeding max_rollout_errors %s", progress.Errors, threshold))
+		case rollout.Status == model.RolloutStatusReplaced:
+			a.rolloutLogger().Warn("Rollout was replaced during the canary stage", zap.String("name", name))
+			return nil
+		case progress.Pending > 0 || progress.Waiting > 0:
+			// The canary stage is in progress
+		case progress.Completed+progress.Errors == 0:
+			return a.abortCanary(name, fmt.Errorf("no agents match the canary selector %s", a.canaryLabels))
+		case finished.IsZero():
+			finished = time.Now()
+			a.rolloutLogger().Info("Canary agents configured, waiting before continuing the rollout", zap.String("name", name), zap.Duration("canary_wait", a.canaryWait))
+		}
+
+		if !finished.IsZero() && time.Since(finished) >= a.canaryWait && rollout.Status == model.RolloutStatusPaused {
+			if err := a.client.ResumeRollout(a.ctx, name); err != nil {
+				return fmt.Errorf("resume rollout: %w", err)
+			}
+			a.rolloutLogger().Info("Canary agents are healthy, resumed rollout", zap.String("name", name))
+			return nil
+		}
+
+		wait := a.pollJitter(interval)
+		if time.Now().Add(wait).After(a.canaryDeadline) {
+			return a.abortCanary(name, fmt.Errorf("timed out after %s waiting for the canary stages", timeout))
+		}
+		time.Sleep(wait)
+	}
+}
+
+// abortCanary pauses a rollout whose canary stage failed,
+// so it does not continue to every agent
+func (a *Action) abortCanary(name string, err error) error {
+	if pauseErr := a.client.PauseRollout(a.ctx, name); pauseErr != nil {
+		return fmt.Errorf("%w: pause rollout: %w", err, pauseErr)
+	}
+	a.rolloutLogger().Warn("Paused rollout, canary failed", zap.String("name", name), zap.Error(err))
+	return err
+}
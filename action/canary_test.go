@@ -0,0 +1,133 @@
+package action
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCanarySelector(t *testing.T) {
+	set, err := ParseCanarySelector("canary=true, region=us")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"canary": "true", "region": "us"}, map[string]string(set))
+
+	_, err = ParseCanarySelector("canary in (true)")
+	require.Error(t, err)
+
+	_, err = ParseCanarySelector("")
+	require.EqualError(t, err, "canary selector  does not select any labels")
+}
+
+func TestRunRolloutCanary(t *testing.T) {
+	inProgress := model.Rollout{
+		Status: model.RolloutStatusStarted,
+		Stages: []model.RolloutStage{{Name: canaryStage, Progress: model.RolloutProgress{Completed: 1, Pending: 1}}, {Name: allStage}},
+	}
+	healthy := model.Rollout{
+		Status: model.RolloutStatusPaused,
+		Stages: []model.RolloutStage{{Name: canaryStage, Progress: model.RolloutProgress{Completed: 2}}, {Name: allStage}},
+	}
+	errored := model.Rollout{
+		Status: model.RolloutStatusStarted,
+		Stages: []model.RolloutStage{{Name: canaryStage, Progress: model.RolloutProgress{Completed: 1, Errors: 1}}, {Name: allStage}},
+	}
+	empty := model.Rollout{
+		Status: model.RolloutStatusPaused,
+		Stages: []model.RolloutStage{{Name: canaryStage}, {Name: allStage}},
+	}
+
+	cases := []struct {
+		name         string
+		rollouts     []model.Rollout
+		expectResume bool
+		expectPause  bool
+		expectErr    string
+	}{
+		{"healthy", []model.Rollout{inProgress, healthy}, true, false, ""},
+		{"next stage started", []model.Rollout{inProgress, {Status: model.RolloutStatusStarted, Stage: 1, Stages: healthy.Stages}}, false, false, ""},
+		{"canary errors", []model.Rollout{inProgress, errored}, false, true, "canary rollout gateway: 1 canary agents errored, exceeding max_rollout_errors 0"},
+		{"no canary agents", []model.Rollout{empty}, false, true, "canary rollout gateway: no agents match the canary selector canary=true"},
+		{"not staged", []model.Rollout{{Status: model.RolloutStatusStarted}}, false, false, "canary rollout gateway: rollout does not have stages, the BindPlane server may not support staged rollouts"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			polls := 0
+			resumed, paused := false, false
+			body := model.StartRolloutPayload{}
+			mux := http.NewServeMux()
+			mux.HandleFunc("POST /v1/rollouts/{name}/start", func(w http.ResponseWriter, r *http.Request) {
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{}`))
+			})
+			mux.HandleFunc("GET /v1/rollouts/{name}/status", func(w http.ResponseWriter, r *http.Request) {
+				c := model.Configuration{}
+				c.Metadata.Name = r.PathValue("name")
+				c.Status.Rollout = tc.rollouts[min(polls, len(tc.rollouts)-1)]
+				polls++
+				w.Header().Set("Content-Type", "application/json")
+				_ = json.NewEncoder(w).Encode(model.ConfigurationResponse{Configuration: &c})
+			})
+			mux.HandleFunc("PUT /v1/rollouts/{name}/resume", func(_ http.ResponseWriter, _ *http.Request) {
+				resumed = true
+			})
+			mux.HandleFunc("PUT /v1/rollouts/{name}/pause", func(_ http.ResponseWriter, _ *http.Request) {
+				paused = true
+			})
+
+			a := newTestAction(t, mux,
+				WithCanarySelector("canary=true"),
+				WithRolloutPollInterval(time.Millisecond),
+			)
+			err := a.RunRollout("gateway")
+			if tc.expectErr != "" {
+				require.EqualError(t, err, tc.expectErr)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tc.expectResume, resumed)
+			require.Equal(t, tc.expectPause, paused)
+			require.Equal(t, []string{"gateway"}, a.startedRollouts)
+
+			require.Len(t, body.Options.Stages, 2)
+			require.Equal(t, canaryStage, body.Options.Stages[0].Name)
+			require.Equal(t, "true", body.Options.Stages[0].Labels.Get("canary"))
+		})
+	}
+
+	_, err := New(nil, WithCanarySelector("canary!=true"))
+	require.Error(t, err)
+}
+
+func TestRunCanaryTimeout(t *testing.T) {
+	polls := map[string]int{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/rollouts/{name}/status", func(w http.ResponseWriter, r *http.Request) {
+		polls[r.PathValue("name")]++
+		c := model.Configuration{}
+		c.Status.Rollout = model.Rollout{
+			Status: model.RolloutStatusStarted,
+			Stages: []model.RolloutStage{{Name: canaryStage, Progress: model.RolloutProgress{Pending: 1}}, {Name: allStage}},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(model.ConfigurationResponse{Configuration: &c})
+	})
+	mux.HandleFunc("PUT /v1/rollouts/{name}/pause", func(_ http.ResponseWriter, _ *http.Request) {})
+
+	a := newTestAction(t, mux,
+		WithCanarySelector("canary=true"),
+		WithRolloutTimeout(50*time.Millisecond),
+		WithRolloutPollInterval(time.Millisecond),
+	)
+	require.EqualError(t, a.runCanary("gateway"), "timed out after 50ms waiting for the canary stages")
+	require.Greater(t, polls["gateway"], 1)
+
+	// The second canary does not wait for another rollout timeout
+	require.EqualError(t, a.runCanary("edge"), "timed out after 50ms waiting for the canary stages")
+	require.Equal(t, 1, polls["edge"])
+}
@@ -0,0 +1,214 @@
+// Package catalog resolves environment scoped variable and secret
+// references found in resource files.
+package catalog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// SecretEnvPrefix is the environment variable prefix used when resolving
+	// secret references. The reference ${secret.NAME} is resolved from the
+	// environment variable BINDPLANE_SECRET_NAME.
+	SecretEnvPrefix = "BINDPLANE_SECRET_"
+
+	refTypeVar    = "var"
+	refTypeSecret = "secret"
+)
+
+// referencePattern matches ${var.NAME} and ${secret.NAME} references
+var referencePattern = regexp.MustCompile(`\$\{(var|secret)\.([A-Za-z0-9_]+)\}`)
+
+// Secrets returns the values of all secrets defined in the process
+// environment, whether or not they are referenced.
+func Secrets() []string {
+	secrets := []string{}
+	for _, env := range os.Environ() {
+		name, value, ok := strings.Cut(env, "=")
+		if !ok || !strings.HasPrefix(name, SecretEnvPrefix) || value == "" {
+			continue
+		}
+		secrets = append(secrets, value)
+	}
+	return secrets
+}
+
+// Catalog holds the variables for a single environment and resolves
+// secrets from the process environment.
+type Catalog struct {
+	environment string
+	variables   map[string]string
+
+	// lookupEnv is used to resolve secrets. It is
+	// os.LookupEnv outside of tests.
+	lookupEnv func(string) (string, bool)
+}
+
+// New creates a catalog for the given environment and variables
+func New(environment string, variables map[string]string) *Catalog {
+	if variables == nil {
+		variables = map[string]string{}
+	}
+	return &Catalog{
+		environment: environment,
+		variables:   variables,
+		lookupEnv:   os.LookupEnv,
+	}
+}
+
+// Load reads a variables file and returns a catalog for the given
+// environment. The file is a map of environment names to variables.
+//
+//	staging:
+//	  otlp_endpoint: otlp.staging.corp.net:4317
+//	production:
+//	  otlp_endpoint: otlp.corp.net:4317
+//
+// If path is empty, a catalog without variables is returned. Secrets
+// can still be resolved.
+func Load(path, environment string) (*Catalog, error) {
+	if path == "" {
+		return New(environment, nil), nil
+	}
+
+	data, err := os.ReadFile(path) // #nosec G304 user defined filepath
+	if err != nil {
+		return nil, fmt.Errorf("read variables file %s: %w", path, err)
+	}
+
+	environments := map[string]map[string]string{}
+	if err := yaml.Unmarshal(data, &environments); err != nil {
+		return nil, fmt.Errorf("variables file %s is malformed, failed to unmarshal yaml: %w", path, err)
+	}
+
+	variables, ok := environments[environment]
+	if !ok {
+		return nil, fmt.Errorf("environment '%s' is not defined in variables file %s", environment, path)
+	}
+
+	return New(environment, variables), nil
+}
+
+// Environment returns the name of the environment
+func (c *Catalog) Environment() string {
+	return c.environment
+}
+
+// Resolve replaces all variable and secret references in the YAML
+// documents in data, and returns the documents encoded again. Data without
+// references is returned unchanged. If any reference cannot be resolved,
+// an error listing every undefined reference is returned.
+func (c *Catalog) Resolve(data []byte) ([]byte, error) {
+	if !referencePattern.Match(data) {
+		return data, nil
+	}
+
+	missing := map[string]struct{}{}
+	buf := &bytes.Buffer{}
+	enc := yaml.NewEncoder(buf)
+	enc.SetIndent(2)
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		node := &yaml.Node{}
+		if err := decoder.Decode(node); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("failed to unmarshal yaml: %w", err)
+		}
+		c.resolveNode(node, missing)
+		if err := enc.Encode(node); err != nil {
+			return nil, fmt.Errorf("failed to marshal yaml: %w", err)
+		}
+	}
+	if err := c.undefined(missing); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("failed to marshal yaml: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// ResolveNode replaces all variable and secret references in the scalar
+// values of a decoded YAML node. Values are substituted after the YAML is
+// parsed, so a value containing YAML syntax, such as ": ", "#", or a
+// newline, is part of the scalar and cannot change the document. Plain
+// scalars are typed by their resolved value, so a port variable is an
+// integer, while quoted scalars are always strings. If any reference
+// cannot be resolved, an error listing every undefined reference is
+// returned.
+func (c *Catalog) ResolveNode(node *yaml.Node) error {
+	missing := map[string]struct{}{}
+	c.resolveNode(node, missing)
+	return c.undefined(missing)
+}
+
+// resolveNode resolves the references in node and its children, adding
+// the references which cannot be resolved to missing. Aliases are not
+// followed, because the node they refer to is resolved where it is defined.
+func (c *Catalog) resolveNode(node *yaml.Node, missing map[string]struct{}) {
+	switch node.Kind {
+	case yaml.AliasNode:
+		return
+	case yaml.ScalarNode:
+		if !referencePattern.MatchString(node.Value) {
+			return
+		}
+		node.Value = referencePattern.ReplaceAllStringFunc(node.Value, func(match string) string {
+			parts := referencePattern.FindStringSubmatch(match)
+			refType, name := parts[1], parts[2]
+
+			value, ok := c.lookup(refType, name)
+			if !ok {
+				missing[fmt.Sprintf("%s.%s", refType, name)] = struct{}{}
+				return match
+			}
+			return value
+		})
+		// The tag of plain scalars is resolved again from the new value
+		if node.Style&(yaml.TaggedStyle|yaml.SingleQuotedStyle|yaml.DoubleQuotedStyle|yaml.LiteralStyle|yaml.FoldedStyle) == 0 {
+			node.Tag = ""
+		}
+	default:
+		for _, n := range node.Content {
+			c.resolveNode(n, missing)
+		}
+	}
+}
+
+// undefined returns an error listing the missing references, or
+// nil when every reference was resolved
+func (c *Catalog) undefined(missing map[string]struct{}) error {
+	if len(missing) == 0 {
+		return nil
+	}
+	refs := make([]string, 0, len(missing))
+	for ref := range missing {
+		refs = append(refs, ref)
+	}
+	sort.Strings(refs)
+	return fmt.Errorf("undefined references for environment '%s': %s", c.environment, strings.Join(refs, ", "))
+}
+
+func (c *Catalog) lookup(refType, name string) (string, bool) {
+	switch refType {
+	case refTypeVar:
+		v, ok := c.variables[name]
+		return v, ok
+	case refTypeSecret:
+		return c.lookupEnv(SecretEnvPrefix + name)
+	default:
+		return "", false
+	}
+}
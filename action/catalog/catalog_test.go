@@ -0,0 +1,146 @@
+package catalog
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestResolve(t *testing.T) {
+	cases := []struct {
+		name      string
+		variables map[string]string
+		secrets   map[string]string
+		input     string
+		expect    string
+		errStr    string
+	}{
+		{
+			"No references",
+			nil,
+			nil,
+			"value: ${env:FOO}",
+			"value: ${env:FOO}",
+			"",
+		},
+		{
+			"Variable",
+			map[string]string{"region": "us-east1"},
+			nil,
+			"region: ${var.region}",
+			"region: us-east1\n",
+			"",
+		},
+		{
+			"Secret",
+			nil,
+			map[string]string{"BINDPLANE_SECRET_TOKEN": "abc"},
+			"token: ${secret.TOKEN}",
+			"token: abc\n",
+			"",
+		},
+		{
+			"Undefined references",
+			map[string]string{"region": "us-east1"},
+			nil,
+			"a: ${var.missing}\nb: ${secret.TOKEN}\nc: ${var.region}\nd: ${var.missing}",
+			"",
+			"undefined references for environment 'test': secret.TOKEN, var.missing",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := New("test", tc.variables)
+			c.lookupEnv = func(k string) (string, bool) {
+				v, ok := tc.secrets[k]
+				return v, ok
+			}
+
+			out, err := c.Resolve([]byte(tc.input))
+			if tc.errStr != "" {
+				require.Error(t, err)
+				require.Equal(t, tc.errStr, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, string(out))
+		})
+	}
+}
+
+func TestResolveYAMLValues(t *testing.T) {
+	cases := []struct {
+		name   string
+		value  string
+		input  string
+		expect map[string]any
+	}{
+		{"comment", "p@ss #word", "token: ${secret.TOKEN}\nother: a", map[string]any{"token": "p@ss #word", "other": "a"}},
+		{"mapping", "a: b\nother: injected", "token: ${secret.TOKEN}\nother: a", map[string]any{"token": "a: b\nother: injected", "other": "a"}},
+		{"newline", "line1\nline2", "token: ${secret.TOKEN}\nother: a", map[string]any{"token": "line1\nline2", "other": "a"}},
+		{"alias indicator", "*abc", "token: ${secret.TOKEN}", map[string]any{"token": "*abc"}},
+		{"double quoted", `a"b\`, `token: "${secret.TOKEN}"`, map[string]any{"token": `a"b\`}},
+		{"part of a value", "a: b", "token: prefix-${secret.TOKEN}", map[string]any{"token": "prefix-a: b"}},
+		{"plain integer", "4317", "token: ${secret.TOKEN}", map[string]any{"token": 4317}},
+		{"quoted integer", "4317", "token: '${secret.TOKEN}'", map[string]any{"token": "4317"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := New("test", nil)
+			c.lookupEnv = func(string) (string, bool) { return tc.value, true }
+
+			out, err := c.Resolve([]byte(tc.input))
+			require.NoError(t, err)
+			actual := map[string]any{}
+			require.NoError(t, yaml.Unmarshal(out, &actual))
+			require.Equal(t, tc.expect, actual)
+
+			node := &yaml.Node{}
+			require.NoError(t, yaml.Unmarshal([]byte(tc.input), node))
+			require.NoError(t, c.ResolveNode(node))
+			actual = map[string]any{}
+			require.NoError(t, node.Decode(&actual))
+			require.Equal(t, tc.expect, actual)
+		})
+	}
+}
+
+func TestResolveNodeUndefined(t *testing.T) {
+	node := &yaml.Node{}
+	require.NoError(t, yaml.Unmarshal([]byte("a: ${var.missing}\nb: \"${var.region}\""), node))
+	require.EqualError(t, New("test", nil).ResolveNode(node), "undefined references for environment 'test': var.missing, var.region")
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join("..", "testdata", "variables", "variables.yaml")
+
+	c, err := Load(path, "production")
+	require.NoError(t, err)
+	require.Equal(t, "production", c.Environment())
+	require.Equal(t, "otlp.mycorp.net", c.variables["otlp_hostname"])
+
+	_, err = Load(path, "development")
+	require.Error(t, err)
+
+	_, err = Load("missing.yaml", "production")
+	require.Error(t, err)
+
+	c, err = Load("", "")
+	require.NoError(t, err)
+	require.Empty(t, c.variables)
+}
+
+func TestSecrets(t *testing.T) {
+	t.Setenv("BINDPLANE_SECRET_A", "a")
+	t.Setenv("BINDPLANE_SECRET_EMPTY", "")
+	t.Setenv("NOT_A_SECRET", "b")
+
+	secrets := Secrets()
+	require.Contains(t, secrets, "a")
+	require.NotContains(t, secrets, "b")
+	require.NotContains(t, secrets, "")
+}
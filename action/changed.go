@@ -0,0 +1,128 @@
+package action
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"go.uber.org/zap"
+)
+
+// changedFileSet returns the set of changed files resources are applied
+// from, or nil when every resource is applied. Every resource is applied
+// when changed files are not set, or when a changed file is shared by
+// every resource, such as the variables file, an overlay, or a patch.
+func (a *Action) changedFileSet() map[string]struct{} {
+	if a.changedFiles == nil {
+		return nil
+	}
+
+	changed := map[string]struct{}{}
+	for _, f := range a.changedFiles {
+		f = filepath.Clean(f)
+		if a.sharedFile(f) {
+			a.loaderLogger().Info("Shared file changed, applying every resource", zap.String("file", f))
+			return nil
+		}
+		changed[f] = struct{}{}
+	}
+
+	a.loaderLogger().Info("Applying resources in changed files", zap.Int("changed_files", len(changed)))
+	return changed
+}
+
+// sharedFile returns true if file can change every resource
+func (a *Action) sharedFile(file string) bool {
+	if a.variablesPath != "" && file == filepath.Clean(a.variablesPath) {
+		return true
+	}
+	if a.overlaysDir != "" {
+		if rel, err := filepath.Rel(a.overlaysDir, file); err == nil && !strings.HasPrefix(rel, "..") {
+			return true
+		}
+	}
+	if a.patchesPath != "" {
+		if ok, _ := filepath.Match(filepath.Clean(a.patchesPath), file); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ReasonSkippedFileUnchanged is the reason of configurations which were
+// not applied because the file they are read from did not change
+const ReasonSkippedFileUnchanged = "skipped, file unchanged"
+
+// changedResources returns the resources read from a changed file, and
+// the resources which were skipped. Remote and OCI artifact resources are
+// always returned, because they are not part of the repository.
+func (a *Action) changedResources(resources []*model.AnyResource, changed map[string]struct{}) (out, skipped []*model.AnyResource) {
+	if changed == nil {
+		return resources, nil
+	}
+
+	out = []*model.AnyResource{}
+	for _, r := range resources {
+		file := a.origins[resourceKey(r.Kind, r.Metadata.Name)].file
+		if IsRemotePath(file) || strings.HasPrefix(file, ociScheme) {
+			out = append(out, r)
+			continue
+		}
+		if _, ok := changed[relativePath(file)]; ok {
+			out = append(out, r)
+			continue
+		}
+		skipped = append(skipped, r)
+	}
+	return out, skipped
+}
+
+// recordUnchangedFiles records the server's current version of the skipped
+// configurations in state. A change to a resource they reference gives
+// them a new pending version, so they are rolled out, and are part of the
+// outputs and write back, as if they were applied. Other kinds are not
+// recorded.
+func (a *Action) recordUnchangedFiles(kind model.Kind, skipped []*model.AnyResource) error {
+	if kind != model.KindConfiguration || len(skipped) == 0 {
+		return nil
+	}
+
+	remote, err := a.client.Resources(a.ctx, kind)
+	if err != nil {
+		return fmt.Errorf("get %s: %w", kind, err)
+	}
+	remoteByName := map[string]*model.AnyResource{}
+	for _, r := range remote {
+		remoteByName[r.Metadata.Name] = r
+	}
+
+	for _, r := range skipped {
+		server, ok := remoteByName[r.Metadata.Name]
+		if !ok {
+			a.Logger.Warn("Configuration in an unchanged file does not exist on the server, it is applied when its file changes", zap.String("name", r.Metadata.Name))
+			continue
+		}
+		a.state.AddResourceStatus(model.AnyResourceStatus{Resource: *server, Status: model.StatusUnchanged, Reason: ReasonSkippedFileUnchanged})
+		a.state.SetConfiguration(server.Metadata.Name, *server)
+	}
+	return nil
+}
+
+// relativePath returns path relative to the working directory,
+// which changed files are relative to
+func relativePath(path string) string {
+	if !filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(wd, path)
+	if err != nil {
+		return path
+	}
+	return rel
+}
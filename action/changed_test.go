@@ -0,0 +1,100 @@
+package action
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyChangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	require.NoError(t, os.MkdirAll("configurations", 0o750))
+	for name, content := range map[string]string{
+		"destinations.yaml": `apiVersion: bindplane.observiq.com/v1
+kind: Destination
+metadata:
+  name: otlp
+spec:
+  type: otlp_grpc
+`,
+		"configurations/gateway.yaml": `apiVersion: bindplane.observiq.com/v1
+kind: Configuration
+metadata:
+  name: gateway
+`,
+		"configurations/edge.yaml": `apiVersion: bindplane.observiq.com/v1
+kind: Configuration
+metadata:
+  name: edge
+`,
+		"variables.yaml": "production:\n  otlp_port: 4317\n",
+	} {
+		require.NoError(t, os.WriteFile(name, []byte(content), 0o600))
+	}
+
+	cases := []struct {
+		name          string
+		changed       []string
+		expect        []string
+		expectSkipped []string
+	}{
+		{"every resource", nil, []string{"otlp", "edge", "gateway"}, nil},
+		{"changed configuration", []string{"configurations/gateway.yaml", "README.md"}, []string{"gateway"}, []string{"edge"}},
+		{"unclean path", []string{"./destinations.yaml"}, []string{"otlp"}, []string{"edge", "gateway"}},
+		{"variables changed", []string{"configurations/edge.yaml", "variables.yaml"}, []string{"otlp", "edge", "gateway"}, nil},
+		{"nothing changed", []string{}, []string{}, []string{"edge", "gateway"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			applied := []string{}
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /v1/configurations", func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"configurations":[
+					{"kind":"Configuration","metadata":{"id":"01","name":"edge","version":2}},
+					{"kind":"Configuration","metadata":{"id":"02","name":"gateway","version":3}}
+				]}`))
+			})
+			mux.HandleFunc("POST /v1/apply", func(w http.ResponseWriter, r *http.Request) {
+				payload := model.ApplyPayload{}
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+				resp := model.ApplyResponseClientSide{}
+				for _, res := range payload.Resources {
+					applied = append(applied, res.Metadata.Name)
+					resp.Updates = append(resp.Updates, &model.AnyResourceStatus{Resource: *res, Status: model.StatusUnchanged})
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_ = json.NewEncoder(w).Encode(resp)
+			})
+
+			a := newTestAction(t, mux,
+				WithDestinationPath("destinations.yaml"),
+				WithConfigurationPath(filepath.Join(dir, "configurations", "*.yaml")),
+				WithVariablesPath("variables.yaml"),
+				WithEnvironment("production"),
+				WithChangedFiles(tc.changed),
+			)
+			require.NoError(t, a.Apply())
+			require.Equal(t, tc.expect, applied)
+
+			// Configurations in unchanged files are rolled out, because a
+			// changed resource they reference gives them a pending version
+			require.ElementsMatch(t, []string{"edge", "gateway"}, a.state.ConfigurationNames())
+			skipped := []string{}
+			for _, s := range a.state.ResourceStatuses() {
+				if s.Reason == ReasonSkippedFileUnchanged {
+					skipped = append(skipped, s.Resource.Metadata.Name)
+				}
+			}
+			require.ElementsMatch(t, tc.expectSkipped, skipped)
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package action
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"go.uber.org/zap"
+)
+
+// CleanupAgents deletes agents which have been disconnected for longer
+// than the agent cleanup age. Ephemeral collectors, such as Kubernetes
+// pods, leave disconnected agents behind, which are counted in rollout
+// progress. Agents which reconnect are added back by BindPlane.
+func (a *Action) CleanupAgents() error {
+	agents, err := a.client.Agents(a.ctx)
+	if err != nil {
+		return fmt.Errorf("get agents: %w", err)
+	}
+
+	cutoff := time.Now().Add(-a.agentCleanupAge)
+	stale := []string{}
+	for _, agent := range agents {
+		if agent.Status != model.AgentStatusDisconnected || agent.DisconnectedAt == nil {
+			continue
+		}
+		if agent.DisconnectedAt.Before(cutoff) {
+			stale = append(stale, agent.ID)
+		}
+	}
+
+	if len(stale) == 0 {
+		a.Logger.Info("No stale agents to delete", zap.Duration("age", a.agentCleanupAge))
+		return nil
+	}
+
+	deleted, err := a.client.DeleteAgents(a.ctx, stale)
+	if err != nil {
+		return err
+	}
+	a.Logger.Info("Deleted stale agents", zap.Int("count", len(deleted)), zap.Duration("age", a.agentCleanupAge))
+	return nil
+}
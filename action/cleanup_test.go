@@ -0,0 +1,60 @@
+package action
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCleanupAgents(t *testing.T) {
+	old := time.Now().Add(-10 * 24 * time.Hour)
+	recent := time.Now().Add(-time.Hour)
+
+	cases := []struct {
+		name          string
+		agents        []*model.Agent
+		expectDeleted []string
+	}{
+		{
+			"stale agents",
+			[]*model.Agent{
+				{ID: "old", Status: model.AgentStatusDisconnected, DisconnectedAt: &old},
+				{ID: "recent", Status: model.AgentStatusDisconnected, DisconnectedAt: &recent},
+				{ID: "reconnected", Status: model.AgentStatusConnected, DisconnectedAt: &old},
+				{ID: "never connected", Status: model.AgentStatusDisconnected},
+			},
+			[]string{"old"},
+		},
+		{
+			"no stale agents",
+			[]*model.Agent{{ID: "recent", Status: model.AgentStatusDisconnected, DisconnectedAt: &recent}},
+			nil,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var deleted []string
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /v1/agents", func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_ = json.NewEncoder(w).Encode(model.AgentsResponse{Agents: tc.agents})
+			})
+			mux.HandleFunc("DELETE /v1/agents", func(w http.ResponseWriter, r *http.Request) {
+				payload := model.DeleteAgentsPayload{}
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+				deleted = payload.IDs
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"agents":[]}`))
+			})
+
+			a := newTestAction(t, mux, WithAgentCleanupAge(7*24*time.Hour))
+			require.NoError(t, a.CleanupAgents())
+			require.Equal(t, tc.expectDeleted, deleted)
+		})
+	}
+}
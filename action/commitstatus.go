@@ -0,0 +1,34 @@
+package action
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/observiq/bindplane-op-action/internal/github"
+	"go.uber.org/zap"
+)
+
+// setCommitStatus sets the commit status of a configuration, using the
+// context <prefix>/<name>. Failing to set the status is logged and does
+// not fail the action.
+func (a *Action) setCommitStatus(name, state, description, targetURL string) {
+	if a.commitStatuses == nil {
+		return
+	}
+
+	sha := os.Getenv("GITHUB_SHA")
+	if sha == "" {
+		a.Logger.Warn("Skipping commit status, GITHUB_SHA is not set", zap.String("name", name))
+		return
+	}
+
+	err := a.commitStatuses.CreateCommitStatus(a.ctx, sha, github.CommitStatus{
+		State:       state,
+		TargetURL:   targetURL,
+		Description: description,
+		Context:     fmt.Sprintf("%s/%s", a.commitStatusPrefix, name),
+	})
+	if err != nil {
+		a.Logger.Warn("Failed to set commit status", zap.String("name", name), zap.String("state", state), zap.Error(err))
+	}
+}
@@ -0,0 +1,64 @@
+package action
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/observiq/bindplane-op-action/internal/github"
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommitStatus(t *testing.T) {
+	statuses := []github.CommitStatus{}
+	gh := http.NewServeMux()
+	gh.HandleFunc("POST /repos/org/repo/statuses/abc123", func(w http.ResponseWriter, r *http.Request) {
+		s := github.CommitStatus{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&s))
+		statuses = append(statuses, s)
+	})
+	ghServer := httptest.NewServer(gh)
+	defer ghServer.Close()
+
+	t.Setenv("GITHUB_API_URL", ghServer.URL)
+	t.Setenv("GITHUB_REPOSITORY", "org/repo")
+	t.Setenv("GITHUB_SHA", "abc123")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/rollouts/{name}/status", func(w http.ResponseWriter, r *http.Request) {
+		c := model.Configuration{}
+		c.Metadata.Name = r.PathValue("name")
+		c.Status.Rollout.Status = model.RolloutStatusStable
+		if c.Metadata.Name == "failing" {
+			c.Status.Rollout.Status = model.RolloutStatusError
+			c.Status.Rollout.Progress.Errors = 2
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(model.ConfigurationResponse{Configuration: &c})
+	})
+
+	a := newTestAction(t, mux,
+		WithCommitStatusPrefix("bindplane/prod"),
+		WithRolloutWait(true),
+		WithRolloutPollInterval(time.Millisecond),
+	)
+
+	a.notifyRolloutStarted("k8s-node")
+	a.startedRollouts = []string{"k8s-node"}
+	require.NoError(t, a.WaitForRollouts())
+
+	a.startedRollouts = []string{"failing"}
+	require.Error(t, a.WaitForRollouts())
+
+	require.Len(t, statuses, 3)
+	require.Equal(t, "bindplane/prod/k8s-node", statuses[0].Context)
+	require.Equal(t, github.StatusPending, statuses[0].State)
+	require.Equal(t, "bindplane/prod/k8s-node", statuses[1].Context)
+	require.Equal(t, github.StatusSuccess, statuses[1].State)
+	require.Equal(t, "bindplane/prod/failing", statuses[2].Context)
+	require.Equal(t, github.StatusFailure, statuses[2].State)
+	require.Equal(t, "rollout failing failed with 2 agent errors", statuses[2].Description)
+}
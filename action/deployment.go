@@ -0,0 +1,61 @@
+package action
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/observiq/bindplane-op-action/action/notify"
+	"github.com/observiq/bindplane-op-action/internal/github"
+	"go.uber.org/zap"
+)
+
+// startDeployment creates a GitHub deployment for the deployment
+// environment and marks it in progress. Failing to create the deployment
+// is logged and does not fail the action.
+func (a *Action) startDeployment() {
+	ref := os.Getenv("GITHUB_SHA")
+	if ref == "" {
+		ref = os.Getenv("GITHUB_REF")
+	}
+
+	id, err := a.deployments.CreateDeployment(a.ctx, github.Deployment{
+		Ref:         ref,
+		Environment: a.deploymentEnvironment,
+		Description: "Apply BindPlane resources",
+	})
+	if err != nil {
+		a.Logger.Warn("Failed to create GitHub deployment", zap.String("environment", a.deploymentEnvironment), zap.Error(err))
+		return
+	}
+	a.deploymentID = id
+	a.Logger.Info("Created GitHub deployment", zap.String("environment", a.deploymentEnvironment), zap.Int64("id", id))
+
+	a.setDeploymentStatus(github.StateInProgress, "Applying resources and rolling out configurations")
+}
+
+// finishDeployment marks the deployment successful,
+// or failed when err is not nil
+func (a *Action) finishDeployment(err error) {
+	if err != nil {
+		a.setDeploymentStatus(github.StateFailure, err.Error())
+		return
+	}
+	a.setDeploymentStatus(github.StateSuccess, "Resources applied")
+}
+
+// setDeploymentStatus sets the deployment status, if a deployment was created
+func (a *Action) setDeploymentStatus(state, description string) {
+	if a.deploymentID == 0 {
+		return
+	}
+
+	err := a.deployments.CreateDeploymentStatus(a.ctx, a.deploymentID, github.DeploymentStatus{
+		State:          state,
+		LogURL:         notify.RunURL(),
+		EnvironmentURL: a.config.Network.RemoteURL,
+		Description:    description,
+	})
+	if err != nil {
+		a.Logger.Warn("Failed to set GitHub deployment status", zap.String("state", state), zap.Error(fmt.Errorf("deployment %d: %w", a.deploymentID, err)))
+	}
+}
@@ -0,0 +1,77 @@
+package action
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeployment(t *testing.T) {
+	cases := []struct {
+		name        string
+		runErr      error
+		expectState string
+		expectDesc  string
+	}{
+		{"Success", nil, "success", "Resources applied"},
+		{"Failure", errors.New(strings.Repeat("x", 200)), "failure", strings.Repeat("x", 137) + "..."},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			statuses := []map[string]string{}
+			mux := http.NewServeMux()
+			mux.HandleFunc("POST /repos/org/repo/deployments", func(w http.ResponseWriter, r *http.Request) {
+				d := map[string]any{}
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&d))
+				require.Equal(t, "production", d["environment"])
+				require.Equal(t, "abc123", d["ref"])
+				_, _ = w.Write([]byte(`{"id": 7}`))
+			})
+			mux.HandleFunc("POST /repos/org/repo/deployments/7/statuses", func(w http.ResponseWriter, r *http.Request) {
+				s := map[string]string{}
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&s))
+				statuses = append(statuses, s)
+			})
+			github := httptest.NewServer(mux)
+			defer github.Close()
+
+			t.Setenv("GITHUB_API_URL", github.URL)
+			t.Setenv("GITHUB_REPOSITORY", "org/repo")
+			t.Setenv("GITHUB_SHA", "abc123")
+
+			a := newTestAction(t, http.NewServeMux(), WithGithubDeploymentEnvironment("production"))
+			a.startDeployment()
+			require.Equal(t, int64(7), a.deploymentID)
+
+			a.finishDeployment(tc.runErr)
+			require.Len(t, statuses, 2)
+			require.Equal(t, "in_progress", statuses[0]["state"])
+			require.Equal(t, tc.expectState, statuses[1]["state"])
+			require.Equal(t, tc.expectDesc, statuses[1]["description"])
+			require.Equal(t, a.config.Network.RemoteURL, statuses[1]["environment_url"])
+		})
+	}
+}
+
+func TestDeploymentCreateFailure(t *testing.T) {
+	github := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message": "Resource not accessible by integration"}`, http.StatusForbidden)
+	}))
+	defer github.Close()
+
+	t.Setenv("GITHUB_API_URL", github.URL)
+	t.Setenv("GITHUB_REPOSITORY", "org/repo")
+
+	// Failing to create a deployment does not set
+	// statuses, or fail the action
+	a := newTestAction(t, http.NewServeMux(), WithGithubDeploymentEnvironment("production"))
+	a.startDeployment()
+	require.Zero(t, a.deploymentID)
+	a.finishDeployment(nil)
+}
@@ -0,0 +1,253 @@
+package action
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/observiq/bindplane-op-action/internal/workflow"
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"go.uber.org/zap"
+)
+
+// DriftChange describes how a resource on the server differs from the repository
+type DriftChange string
+
+const (
+	// DriftAdded is a resource in the repository which does not exist on the server
+	DriftAdded DriftChange = "added"
+
+	// DriftChanged is a resource which differs between the repository and the server
+	DriftChanged DriftChange = "changed"
+
+	// DriftRemoved is a resource on the server which does not exist in the repository
+	DriftRemoved DriftChange = "removed"
+)
+
+// Drift is a difference between a repository resource and the server
+type Drift struct {
+	Kind   string      `json:"kind"`
+	Name   string      `json:"name"`
+	Change DriftChange `json:"change"`
+
+	// Fields are the differing fields of a changed resource,
+	// such as metadata.labels or spec.parameters
+	Fields []string `json:"fields,omitempty"`
+}
+
+// RunDrift compares the repository resources with the server, without
+// modifying anything. Each difference is logged and annotated, and the
+// list of differences is written to the drift output. When fail is true,
+// an error is returned if drift is detected.
+func (a *Action) RunDrift(fail bool) error {
+	drift, err := a.DetectDrift()
+	if err != nil {
+		return fmt.Errorf("detect drift: %w", err)
+	}
+
+	for _, d := range drift {
+		a.Logger.Warn(
+			"Drift detected",
+			zap.String("kind", d.Kind),
+			zap.String("name", d.Name),
+			zap.String("change", string(d.Change)),
+			zap.Strings("fields", d.Fields),
+		)
+
+		annotate := workflow.Warning
+		if fail {
+			annotate = workflow.Error
+		}
+		a.annotateResource(annotate, d.Kind, d.Name, "Drift detected", d.message())
+	}
+
+	data, err := json.Marshal(drift)
+	if err != nil {
+		return fmt.Errorf("marshal drift: %w", err)
+	}
+	if err := workflow.SetOutput(OutputDrift, string(data)); err != nil {
+		return fmt.Errorf("set output %s: %w", OutputDrift, err)
+	}
+
+	if len(drift) == 0 {
+		a.Logger.Info("No drift detected")
+		return nil
+	}
+
+	if fail {
+		return fmt.Errorf("drift detected for %d resources", len(drift))
+	}
+	return nil
+}
+
+func (d Drift) message() string {
+	switch d.Change {
+	case DriftAdded:
+		return fmt.Sprintf("%s %s is in the repository but does not exist on the BindPlane server", d.Kind, d.Name)
+	case DriftRemoved:
+		return fmt.Sprintf("%s %s exists on the BindPlane server but is not in the repository", d.Kind, d.Name)
+	default:
+		return fmt.Sprintf("%s %s differs from the BindPlane server: %v", d.Kind, d.Name, d.Fields)
+	}
+}
+
+// DetectDrift compares the repository resources with the server and returns
+// every difference, sorted by kind and name. Only kinds with a configured
+// path are compared, and agent versions are not compared. Resources on the
+// server which are not in the repository are reported as removed.
+func (a *Action) DetectDrift() ([]Drift, error) {
+	if a.resources == nil {
+		if err := a.LoadResources(); err != nil {
+			return nil, fmt.Errorf("load resources: %w", err)
+		}
+	}
+
+	drift := []Drift{}
+	for _, f := range a.resourceFiles() {
+		if f.path == "" || f.kind == model.KindAgentVersion {
+			continue
+		}
+
+		remote, err := a.client.Resources(a.ctx, f.kind)
+		if err != nil {
+			return nil, fmt.Errorf("get %s: %w", f.label, err)
+		}
+
+		drift = append(drift, compareResources(a.resources[f.kind], remote)...)
+	}
+
+	sort.SliceStable(drift, func(i, j int) bool {
+		if drift[i].Kind != drift[j].Kind {
+			return drift[i].Kind < drift[j].Kind
+		}
+		return drift[i].Name < drift[j].Name
+	})
+	return drift, nil
+}
+
+// compareResources returns the differences between local and
+// remote resources of the same kind, matched by name
+func compareResources(local, remote []*model.AnyResource) []Drift {
+	remoteByName := map[string]*model.AnyResource{}
+	for _, r := range remote {
+		remoteByName[r.Metadata.Name] = r
+	}
+
+	drift := []Drift{}
+	seen := map[string]struct{}{}
+	for _, l := range local {
+		seen[l.Metadata.Name] = struct{}{}
+
+		r, ok := remoteByName[l.Metadata.Name]
+		if !ok {
+			drift = append(drift, Drift{Kind: l.Kind, Name: l.Metadata.Name, Change: DriftAdded})
+			continue
+		}
+
+		if fields := diffFields(l, r); len(fields) > 0 {
+			drift = append(drift, Drift{Kind: l.Kind, Name: l.Metadata.Name, Change: DriftChanged, Fields: fields})
+		}
+	}
+
+	for _, r := range remote {
+		if _, ok := seen[r.Metadata.Name]; !ok {
+			drift = append(drift, Drift{Kind: r.Kind, Name: r.Metadata.Name, Change: DriftRemoved})
+		}
+	}
+
+	return drift
+}
+
+// diffFields returns the user editable fields which differ between two
+// resources, sorted by name. The spec is compared field by field.
+func diffFields(local, remote *model.AnyResource) []string {
+	fields := []string{}
+
+	if local.Metadata.DisplayName != remote.Metadata.DisplayName {
+		fields = append(fields, "metadata.displayName")
+	}
+	if local.Metadata.Description != remote.Metadata.Description {
+		fields = append(fields, "metadata.description")
+	}
+	if !reflect.DeepEqual(normalize(withoutLockLabels(local.Metadata.Labels)), normalize(withoutLockLabels(remote.Metadata.Labels))) {
+		fields = append(fields, "metadata.labels")
+	}
+
+	l, _ := normalize(local.Spec).(map[string]any)
+	r, _ := normalize(remote.Spec).(map[string]any)
+	keys := map[string]struct{}{}
+	for k := range l {
+		keys[k] = struct{}{}
+	}
+	for k := range r {
+		keys[k] = struct{}{}
+	}
+	for k := range keys {
+		if !reflect.DeepEqual(l[k], r[k]) {
+			fields = append(fields, "spec."+k)
+		}
+	}
+
+	sort.Strings(fields)
+	return fields
+}
+
+// normalize converts v to its JSON representation, so values decoded from
+// YAML and JSON can be compared, and removes empty values and IDs. The
+// server fills in empty defaults and generates IDs, neither of which are
+// differences the repository cares about.
+func normalize(v any) any {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+
+	var out any
+	if err := json.Unmarshal(data, &out); err != nil {
+		return v
+	}
+	return prune(out)
+}
+
+// prune recursively removes empty values and id fields
+func prune(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, val := range t {
+			val = prune(val)
+			if k == "id" || isEmpty(val) {
+				delete(t, k)
+				continue
+			}
+			t[k] = val
+		}
+		if len(t) == 0 {
+			return nil
+		}
+		return t
+	case []any:
+		for i := range t {
+			t[i] = prune(t[i])
+		}
+		if len(t) == 0 {
+			return nil
+		}
+		return t
+	default:
+		return v
+	}
+}
+
+func isEmpty(v any) bool {
+	switch t := v.(type) {
+	case nil:
+		return true
+	case string:
+		return t == ""
+	case bool:
+		return !t
+	default:
+		return false
+	}
+}
@@ -0,0 +1,84 @@
+package action
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/observiq/bindplane-op-action/internal/workflow"
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"github.com/stretchr/testify/require"
+)
+
+func testResource(kind model.Kind, name string, spec map[string]any) *model.AnyResource {
+	r := &model.AnyResource{Spec: spec}
+	r.Kind = string(kind)
+	r.Metadata.Name = name
+	return r
+}
+
+func TestCompareResources(t *testing.T) {
+	local := []*model.AnyResource{
+		testResource(model.KindDestination, "same", map[string]any{
+			"type": "otlp",
+			"parameters": []any{
+				map[string]any{"name": "port", "value": 4317},
+			},
+		}),
+		testResource(model.KindDestination, "changed", map[string]any{"type": "otlp"}),
+		testResource(model.KindDestination, "added", map[string]any{"type": "otlp"}),
+	}
+	local[1].Metadata.Labels = map[string]string{"env": "prod"}
+
+	remote := []*model.AnyResource{
+		// Server generated IDs and empty defaults are ignored
+		testResource(model.KindDestination, "same", map[string]any{
+			"type":     "otlp",
+			"disabled": false,
+			"parameters": []any{
+				map[string]any{"id": "abc", "name": "port", "value": float64(4317)},
+			},
+		}),
+		testResource(model.KindDestination, "changed", map[string]any{"type": "logging"}),
+		testResource(model.KindDestination, "removed", map[string]any{"type": "otlp"}),
+	}
+	remote[0].Metadata.ID = "server-id"
+
+	require.Equal(t, []Drift{
+		{Kind: "Destination", Name: "changed", Change: DriftChanged, Fields: []string{"metadata.labels", "spec.type"}},
+		{Kind: "Destination", Name: "added", Change: DriftAdded},
+		{Kind: "Destination", Name: "removed", Change: DriftRemoved},
+	}, compareResources(local, remote))
+}
+
+func TestRunDrift(t *testing.T) {
+	a := &Action{configurationPath: "testdata/configuration.yaml"}
+	require.NoError(t, a.LoadResources())
+	configurations := a.resources[model.KindConfiguration]
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/configurations", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"configurations": configurations[:2]})
+	})
+
+	buf := &bytes.Buffer{}
+	workflow.Output = buf
+	defer func() { workflow.Output = os.Stdout }()
+
+	path := filepath.Join(t.TempDir(), "output")
+	t.Setenv("GITHUB_OUTPUT", path)
+
+	a = newTestAction(t, mux, WithConfigurationPath("testdata/configuration.yaml"))
+	require.NoError(t, a.RunDrift(false))
+	require.Equal(t, "::warning file=testdata/configuration.yaml,line=106,title=Drift detected::Configuration k8s-node is in the repository but does not exist on the BindPlane server\n", buf.String())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(data), `[{"kind":"Configuration","name":"k8s-node","change":"added"}]`)
+
+	require.EqualError(t, a.RunDrift(true), "drift detected for 1 resources")
+}
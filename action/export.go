@@ -0,0 +1,117 @@
+package action
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// exportDirs maps each exported resource kind to the directory, relative
+// to the export directory, its resources are written to. Kinds are
+// exported in the order they should be applied.
+var exportDirs = []struct {
+	kind model.Kind
+	dir  string
+}{
+	{model.KindDestination, "destinations"},
+	{model.KindSource, "sources"},
+	{model.KindProcessor, "processors"},
+	{model.KindConfiguration, "configurations"},
+}
+
+// Export retrieves every destination, source, processor, and configuration
+// from the BindPlane server and writes each to its own YAML file. Files are
+// written to a directory for each kind within dir, for example
+// dir/configurations/<name>.yaml. Fields managed by the server, such as the
+// version and hash, are omitted so the files can be applied as-is. When
+// WithExportRaw is set, the raw configuration of each configuration is
+// also written to dir/raw/<name>.yaml.
+func (a *Action) Export(dir string) error {
+	for _, e := range exportDirs {
+		resources, err := a.client.Resources(a.ctx, e.kind)
+		if err != nil {
+			return fmt.Errorf("get %s: %w", e.dir, err)
+		}
+
+		kindDir := filepath.Join(dir, e.dir)
+		if err := os.MkdirAll(kindDir, 0750); err != nil {
+			return fmt.Errorf("create directory %s: %w", kindDir, err)
+		}
+
+		for _, r := range resources {
+			path, err := exportPath(kindDir, r.Metadata.Name)
+			if err != nil {
+				return err
+			}
+
+			if err := writeResource(path, r); err != nil {
+				return err
+			}
+		}
+
+		a.Logger.Info("Exported resources", zap.String("kind", string(e.kind)), zap.Int("count", len(resources)), zap.String("dir", kindDir))
+
+		if e.kind == model.KindConfiguration && a.exportRaw {
+			if err := a.exportRawConfigurations(filepath.Join(dir, "raw"), resources); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// exportRawConfigurations writes the raw OpenTelemetry configuration of
+// each configuration to dir. Raw configurations are streamed to disk.
+func (a *Action) exportRawConfigurations(dir string, configurations []*model.AnyResource) error {
+	for _, c := range configurations {
+		path, err := exportPath(dir, c.Metadata.Name)
+		if err != nil {
+			return err
+		}
+		if err := a.writeRawConfiguration(c.Metadata.Name, path); err != nil {
+			return err
+		}
+	}
+
+	a.Logger.Info("Exported raw configurations", zap.Int("count", len(configurations)), zap.String("dir", dir))
+	return nil
+}
+
+// exportPath returns the file a resource is exported to. Names which
+// would write outside of dir are rejected.
+func exportPath(dir, name string) (string, error) {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("resource name '%s' cannot be used as a file name", name)
+	}
+	return filepath.Join(dir, name+".yaml"), nil
+}
+
+// writeResource writes a resource to path as YAML, omitting
+// fields managed by the server
+func writeResource(path string, r *model.AnyResource) error {
+	r.Metadata.Hash = ""
+	r.Metadata.Version = 0
+	r.Metadata.DateModified = nil
+
+	buf := &bytes.Buffer{}
+	enc := yaml.NewEncoder(buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(r); err != nil {
+		return fmt.Errorf("marshal %s %s: %w", r.Kind, r.Metadata.Name, err)
+	}
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("marshal %s %s: %w", r.Kind, r.Metadata.Name, err)
+	}
+
+	if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		return fmt.Errorf("write file %s: %w", path, err)
+	}
+	return nil
+}
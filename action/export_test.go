@@ -0,0 +1,109 @@
+package action
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExport(t *testing.T) {
+	modified := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	resource := func(kind model.Kind, name string) *model.AnyResource {
+		r := &model.AnyResource{Spec: map[string]any{"type": "otlp"}}
+		r.APIVersion = "bindplane.observiq.com/v1"
+		r.Kind = string(kind)
+		r.Metadata.ID = name + "-id"
+		r.Metadata.Name = name
+		r.Metadata.Version = 4
+		r.Metadata.Hash = "abc"
+		r.Metadata.DateModified = &modified
+		return r
+	}
+
+	list := func(field string, resources ...*model.AnyResource) http.HandlerFunc {
+		return func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]any{field: resources})
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/destinations", list("destinations", resource(model.KindDestination, "gateway")))
+	mux.HandleFunc("/v1/sources", list("sources"))
+	mux.HandleFunc("/v1/processors", list("processors", resource(model.KindProcessor, "batch")))
+	mux.HandleFunc("/v1/configurations", list("configurations", resource(model.KindConfiguration, "k8s-node"), resource(model.KindConfiguration, "k8s-gateway")))
+
+	a := newTestAction(t, mux)
+	dir := t.TempDir()
+	require.NoError(t, a.Export(dir))
+
+	data, err := os.ReadFile(filepath.Join(dir, "configurations", "k8s-node.yaml"))
+	require.NoError(t, err)
+	require.Equal(t, `apiVersion: bindplane.observiq.com/v1
+kind: Configuration
+metadata:
+  id: k8s-node-id
+  name: k8s-node
+spec:
+  type: otlp
+`, string(data))
+
+	for _, path := range []string{"destinations/gateway.yaml", "processors/batch.yaml", "configurations/k8s-gateway.yaml"} {
+		require.FileExists(t, filepath.Join(dir, path))
+	}
+	require.DirExists(t, filepath.Join(dir, "sources"))
+
+	// Exported files can be applied
+	resources, _, err := decodeAnyResourceFile(filepath.Join(dir, "configurations", "*.yaml"), nil)
+	require.NoError(t, err)
+	require.Len(t, resources, 2)
+}
+
+func TestExportPath(t *testing.T) {
+	path, err := exportPath("out", "k8s-node")
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join("out", "k8s-node.yaml"), path)
+
+	for _, name := range []string{"", ".", "..", "../etc/passwd", `a\b`} {
+		_, err := exportPath("out", name)
+		require.Error(t, err, name)
+	}
+}
+
+func TestExportRaw(t *testing.T) {
+	configuration := &model.AnyResource{}
+	configuration.Kind = string(model.KindConfiguration)
+	configuration.Metadata.Name = "k8s-gateway"
+
+	list := func(field string, resources ...*model.AnyResource) http.HandlerFunc {
+		return func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]any{field: resources})
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/destinations", list("destinations"))
+	mux.HandleFunc("/v1/sources", list("sources"))
+	mux.HandleFunc("/v1/processors", list("processors"))
+	mux.HandleFunc("/v1/configurations", list("configurations", configuration))
+	mux.HandleFunc("GET /v1/configurations/{name}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(model.ConfigurationResponse{Raw: "receivers:\n  otlp: {}\n"})
+	})
+
+	dir := t.TempDir()
+	require.NoError(t, newTestAction(t, mux).Export(dir))
+	require.NoDirExists(t, filepath.Join(dir, "raw"))
+
+	require.NoError(t, newTestAction(t, mux, WithExportRaw(true)).Export(dir))
+	data, err := os.ReadFile(filepath.Join(dir, "raw", "k8s-gateway.yaml"))
+	require.NoError(t, err)
+	require.Equal(t, "receivers:\n  otlp: {}\n", string(data))
+}
@@ -0,0 +1,140 @@
+package freeze
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// schedule is a parsed five field cron expression:
+// minute hour day-of-month month day-of-week
+type schedule struct {
+	minute [60]bool
+	hour   [24]bool
+	dom    [32]bool
+	month  [13]bool
+	dow    [7]bool
+
+	// domAny and dowAny track if the day fields are unrestricted. When
+	// both are restricted, a time matches if either field matches.
+	domAny bool
+	dowAny bool
+}
+
+// parseSchedule parses a standard five field cron expression. Each field
+// supports '*', single values, ranges (1-5), lists (1,3,5), and steps (*/15).
+func parseSchedule(expr string) (*schedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("schedule '%s' must have 5 fields, got %d", expr, len(fields))
+	}
+
+	s := &schedule{
+		domAny: fields[2] == "*",
+		dowAny: fields[4] == "*",
+	}
+
+	if err := parseField(fields[0], 0, 59, s.minute[:]); err != nil {
+		return nil, fmt.Errorf("minute: %w", err)
+	}
+	if err := parseField(fields[1], 0, 23, s.hour[:]); err != nil {
+		return nil, fmt.Errorf("hour: %w", err)
+	}
+	if err := parseField(fields[2], 1, 31, s.dom[:]); err != nil {
+		return nil, fmt.Errorf("day of month: %w", err)
+	}
+	if err := parseField(fields[3], 1, 12, s.month[:]); err != nil {
+		return nil, fmt.Errorf("month: %w", err)
+	}
+
+	// Day of week allows 7 as an alias for Sunday
+	dow := make([]bool, 8)
+	if err := parseField(fields[4], 0, 7, dow); err != nil {
+		return nil, fmt.Errorf("day of week: %w", err)
+	}
+	copy(s.dow[:], dow[:7])
+	s.dow[0] = s.dow[0] || dow[7]
+
+	return s, nil
+}
+
+// parseField sets the values matched by a single cron field
+func parseField(field string, min, max int, out []bool) error {
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid step in '%s'", part)
+			}
+			step = n
+			part = part[:i]
+		}
+
+		lo, hi := min, max
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if lo, err = strconv.Atoi(bounds[0]); err != nil {
+				return fmt.Errorf("invalid range '%s'", part)
+			}
+			if hi, err = strconv.Atoi(bounds[1]); err != nil {
+				return fmt.Errorf("invalid range '%s'", part)
+			}
+		default:
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				return fmt.Errorf("invalid value '%s'", part)
+			}
+			lo, hi = n, n
+			if step > 1 {
+				hi = max
+			}
+		}
+
+		if lo < min || hi > max || lo > hi {
+			return fmt.Errorf("'%s' is out of range %d-%d", part, min, max)
+		}
+
+		for v := lo; v <= hi; v += step {
+			out[v] = true
+		}
+	}
+	return nil
+}
+
+// matches returns true if t matches the schedule, to the minute
+func (s *schedule) matches(t time.Time) bool {
+	if !s.minute[t.Minute()] || !s.hour[t.Hour()] || !s.month[int(t.Month())] {
+		return false
+	}
+
+	dom := s.dom[t.Day()]
+	dow := s.dow[int(t.Weekday())]
+	switch {
+	case s.domAny && s.dowAny:
+		return true
+	case s.domAny:
+		return dow
+	case s.dowAny:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+// activeSince returns the most recent time the schedule matched within
+// the duration before t. If the schedule did not match, false is returned.
+func (s *schedule) activeSince(t time.Time, d time.Duration) (time.Time, bool) {
+	start := t.Truncate(time.Minute)
+	earliest := t.Add(-d)
+	for c := start; c.After(earliest); c = c.Add(-time.Minute) {
+		if s.matches(c) {
+			return c, true
+		}
+	}
+	return time.Time{}, false
+}
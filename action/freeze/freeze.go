@@ -0,0 +1,137 @@
+// Package freeze implements maintenance freeze windows during which
+// resources should not be applied or rolled out.
+package freeze
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Calendar is a list of freeze windows
+type Calendar struct {
+	Windows []*Window `yaml:"windows"`
+}
+
+// Window is a period of time during which deploys are frozen. A window
+// is either a fixed period, defined by Start and End, or a recurring
+// period, defined by a cron Schedule and Duration.
+type Window struct {
+	// Name is a friendly name for the window, used in log and error messages
+	Name string `yaml:"name"`
+
+	// Start and End define a fixed window
+	Start time.Time `yaml:"start"`
+	End   time.Time `yaml:"end"`
+
+	// Schedule is a five field cron expression which defines when a
+	// recurring window starts. Duration is how long the window lasts.
+	Schedule string        `yaml:"schedule"`
+	Duration time.Duration `yaml:"duration"`
+
+	// Timezone is the IANA timezone the Schedule is evaluated
+	// in. Defaults to UTC.
+	Timezone string `yaml:"timezone"`
+
+	schedule *schedule
+	location *time.Location
+}
+
+// Load reads and validates a freeze calendar file
+func Load(path string) (*Calendar, error) {
+	data, err := os.ReadFile(path) // #nosec G304 user defined filepath
+	if err != nil {
+		return nil, fmt.Errorf("read freeze windows file %s: %w", path, err)
+	}
+
+	calendar := &Calendar{}
+	if err := yaml.Unmarshal(data, calendar); err != nil {
+		return nil, fmt.Errorf("freeze windows file %s is malformed, failed to unmarshal yaml: %w", path, err)
+	}
+
+	for i, w := range calendar.Windows {
+		if err := w.init(); err != nil {
+			return nil, fmt.Errorf("freeze window %d (%s): %w", i, w.Name, err)
+		}
+	}
+
+	return calendar, nil
+}
+
+// Active returns the first window that is active at time t. If no
+// window is active, nil is returned.
+func (c *Calendar) Active(t time.Time) *Window {
+	if c == nil {
+		return nil
+	}
+
+	for _, w := range c.Windows {
+		if _, ok := w.activeAt(t); ok {
+			return w
+		}
+	}
+	return nil
+}
+
+// Until returns the time the window ends, relative to time t
+func (w *Window) Until(t time.Time) time.Time {
+	end, _ := w.activeAt(t)
+	return end
+}
+
+// init validates the window and parses its schedule
+func (w *Window) init() error {
+	if w.Schedule == "" {
+		if w.Start.IsZero() || w.End.IsZero() {
+			return fmt.Errorf("either start and end or schedule and duration are required")
+		}
+		if !w.End.After(w.Start) {
+			return fmt.Errorf("end must be after start")
+		}
+		return nil
+	}
+
+	if !w.Start.IsZero() || !w.End.IsZero() {
+		return fmt.Errorf("start and end cannot be combined with schedule")
+	}
+
+	if w.Duration <= 0 {
+		return fmt.Errorf("duration is required when schedule is set")
+	}
+
+	s, err := parseSchedule(w.Schedule)
+	if err != nil {
+		return fmt.Errorf("schedule: %w", err)
+	}
+	w.schedule = s
+
+	w.location = time.UTC
+	if w.Timezone != "" {
+		loc, err := time.LoadLocation(w.Timezone)
+		if err != nil {
+			return fmt.Errorf("timezone: %w", err)
+		}
+		w.location = loc
+	}
+
+	return nil
+}
+
+// activeAt returns the end of the window and true if the
+// window is active at time t
+func (w *Window) activeAt(t time.Time) (time.Time, bool) {
+	if w.schedule == nil {
+		if !t.Before(w.Start) && t.Before(w.End) {
+			return w.End, true
+		}
+		return time.Time{}, false
+	}
+
+	start, ok := w.schedule.activeSince(t.In(w.location), w.Duration)
+	if !ok {
+		return time.Time{}, false
+	}
+	return start.Add(w.Duration), true
+}
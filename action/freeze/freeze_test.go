@@ -0,0 +1,183 @@
+package freeze
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSchedule(t *testing.T) {
+	cases := []struct {
+		name   string
+		expr   string
+		time   time.Time
+		expect bool
+		errStr string
+	}{
+		{
+			"Every minute",
+			"* * * * *",
+			time.Date(2026, 1, 1, 12, 30, 0, 0, time.UTC),
+			true,
+			"",
+		},
+		{
+			"Specific minute and hour",
+			"30 12 * * *",
+			time.Date(2026, 1, 1, 12, 30, 0, 0, time.UTC),
+			true,
+			"",
+		},
+		{
+			"Minute does not match",
+			"31 12 * * *",
+			time.Date(2026, 1, 1, 12, 30, 0, 0, time.UTC),
+			false,
+			"",
+		},
+		{
+			"Step",
+			"*/15 * * * *",
+			time.Date(2026, 1, 1, 12, 45, 0, 0, time.UTC),
+			true,
+			"",
+		},
+		{
+			"Range and list",
+			"0 9-17 * 1,6,12 *",
+			time.Date(2026, 12, 1, 10, 0, 0, 0, time.UTC),
+			true,
+			"",
+		},
+		{
+			"Sunday as 7",
+			"0 0 * * 7",
+			time.Date(2026, 1, 4, 0, 0, 0, 0, time.UTC),
+			true,
+			"",
+		},
+		{
+			"Day of month or day of week",
+			"0 0 15 * 1",
+			time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC), // Monday the 5th
+			true,
+			"",
+		},
+		{
+			"Wrong field count",
+			"* * * *",
+			time.Time{},
+			false,
+			"schedule '* * * *' must have 5 fields, got 4",
+		},
+		{
+			"Out of range",
+			"60 * * * *",
+			time.Time{},
+			false,
+			"minute: '60' is out of range 0-59",
+		},
+		{
+			"Invalid step",
+			"*/0 * * * *",
+			time.Time{},
+			false,
+			"minute: invalid step in '*/0'",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := parseSchedule(tc.expr)
+			if tc.errStr != "" {
+				require.Error(t, err)
+				require.Equal(t, tc.errStr, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, s.matches(tc.time))
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	calendar, err := Load("testdata/windows.yaml")
+	require.NoError(t, err)
+	require.Len(t, calendar.Windows, 2)
+
+	_, err = Load("testdata/invalid.yaml")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "duration is required when schedule is set")
+
+	_, err = Load("testdata/missing.yaml")
+	require.Error(t, err)
+}
+
+func TestActive(t *testing.T) {
+	calendar, err := Load("testdata/windows.yaml")
+	require.NoError(t, err)
+
+	ny, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	cases := []struct {
+		name       string
+		time       time.Time
+		expectName string
+		expectEnd  time.Time
+	}{
+		{
+			"Fixed window",
+			time.Date(2026, 11, 27, 12, 0, 0, 0, time.UTC),
+			"black-friday",
+			time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			"Fixed window end is exclusive",
+			time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC),
+			"",
+			time.Time{},
+		},
+		{
+			"Recurring window start",
+			time.Date(2026, 10, 16, 17, 0, 0, 0, ny), // Friday
+			"weekend",
+			time.Date(2026, 10, 19, 8, 0, 0, 0, ny),
+		},
+		{
+			"Recurring window weekend",
+			time.Date(2026, 10, 18, 12, 0, 0, 0, ny), // Sunday
+			"weekend",
+			time.Date(2026, 10, 19, 8, 0, 0, 0, ny),
+		},
+		{
+			"Recurring window ended",
+			time.Date(2026, 10, 19, 8, 0, 0, 0, ny), // Monday
+			"",
+			time.Time{},
+		},
+		{
+			"Before recurring window",
+			time.Date(2026, 10, 16, 16, 59, 0, 0, ny),
+			"",
+			time.Time{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := calendar.Active(tc.time)
+			if tc.expectName == "" {
+				require.Nil(t, w)
+				return
+			}
+			require.NotNil(t, w)
+			require.Equal(t, tc.expectName, w.Name)
+			require.True(t, tc.expectEnd.Equal(w.Until(tc.time)), "expected %s, got %s", tc.expectEnd, w.Until(tc.time))
+		})
+	}
+
+	var nilCalendar *Calendar
+	require.Nil(t, nilCalendar.Active(time.Now()))
+}
@@ -0,0 +1,198 @@
+package action
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/observiq/bindplane-op-action/internal/workflow"
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"github.com/pmezard/go-difflib/difflib"
+	"go.uber.org/zap"
+)
+
+// GoldenChange describes how a rendered configuration differs from its golden file
+type GoldenChange string
+
+const (
+	// GoldenAdded is a configuration which does not have a golden file
+	GoldenAdded GoldenChange = "added"
+
+	// GoldenChanged is a configuration whose rendered configuration
+	// differs from its golden file
+	GoldenChanged GoldenChange = "changed"
+
+	// GoldenRemoved is a golden file of a configuration which is
+	// not in the repository
+	GoldenRemoved GoldenChange = "removed"
+)
+
+// GoldenDiff is a difference between a rendered configuration and its golden file
+type GoldenDiff struct {
+	Name   string       `json:"name"`
+	Path   string       `json:"path"`
+	Change GoldenChange `json:"change"`
+
+	// Diff is the unified diff from the golden file to
+	// the rendered configuration of a changed configuration
+	Diff string `json:"diff,omitempty"`
+
+	rendered string
+}
+
+// RunGolden compares the rendered OpenTelemetry configuration of each
+// configuration in the repository with its golden file in dir, named
+// <configuration>.yaml. Each difference is logged and annotated, and the
+// list of differences is written to the golden output. An error is
+// returned if there are differences. When update is true, the golden
+// files are written instead, and differences do not fail the action.
+func (a *Action) RunGolden(dir string, update bool) error {
+	diffs, err := a.CompareGolden(dir)
+	if err != nil {
+		return fmt.Errorf("compare golden files: %w", err)
+	}
+
+	for _, d := range diffs {
+		if update {
+			if err := d.Update(); err != nil {
+				return err
+			}
+			a.Logger.Info("Updated golden file", zap.String("name", d.Name), zap.String("path", d.Path), zap.String("change", string(d.Change)))
+			continue
+		}
+
+		a.Logger.Error("Rendered configuration differs from golden file", zap.String("name", d.Name), zap.String("path", d.Path), zap.String("change", string(d.Change)))
+		if d.Diff != "" {
+			fmt.Fprint(workflow.Output, d.Diff)
+		}
+		a.annotateResource(workflow.Error, string(model.KindConfiguration), d.Name, "Golden file mismatch", d.message())
+	}
+
+	data, err := json.Marshal(diffs)
+	if err != nil {
+		return fmt.Errorf("marshal golden differences: %w", err)
+	}
+	if err := workflow.SetOutput(OutputGolden, string(data)); err != nil {
+		return fmt.Errorf("set output %s: %w", OutputGolden, err)
+	}
+
+	if len(diffs) == 0 {
+		a.Logger.Info("Rendered configurations match golden files")
+		return nil
+	}
+	if update {
+		return nil
+	}
+	return fmt.Errorf("%d rendered configurations differ from golden files, set golden_update to accept the changes", len(diffs))
+}
+
+func (d GoldenDiff) message() string {
+	switch d.Change {
+	case GoldenAdded:
+		return fmt.Sprintf("Configuration %s does not have a golden file at %s", d.Name, d.Path)
+	case GoldenRemoved:
+		return fmt.Sprintf("Golden file %s is for configuration %s, which is not in the repository", d.Path, d.Name)
+	default:
+		return fmt.Sprintf("The rendered configuration of %s differs from the golden file %s", d.Name, d.Path)
+	}
+}
+
+// Update writes or removes the golden file so it matches
+// the rendered configuration
+func (d GoldenDiff) Update() error {
+	if d.Change == GoldenRemoved {
+		if err := os.Remove(d.Path); err != nil {
+			return fmt.Errorf("remove golden file %s: %w", d.Path, err)
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(d.Path), 0750); err != nil {
+		return fmt.Errorf("create directory %s: %w", filepath.Dir(d.Path), err)
+	}
+	if err := os.WriteFile(d.Path, []byte(d.rendered), 0600); err != nil {
+		return fmt.Errorf("write golden file %s: %w", d.Path, err)
+	}
+	return nil
+}
+
+// CompareGolden retrieves the rendered OpenTelemetry configuration of
+// each configuration in the repository and returns every difference with
+// the golden files in dir, sorted by name. Configurations must exist on
+// the server, so they are usually applied to a test server first.
+func (a *Action) CompareGolden(dir string) ([]GoldenDiff, error) {
+	if a.resources == nil {
+		if err := a.LoadResources(); err != nil {
+			return nil, fmt.Errorf("load resources: %w", err)
+		}
+	}
+
+	diffs := []GoldenDiff{}
+	names := map[string]struct{}{}
+	for _, c := range a.resources[model.KindConfiguration] {
+		name := c.Metadata.Name
+		names[name] = struct{}{}
+		path := filepath.Join(dir, name+".yaml")
+
+		rendered, err := a.client.RawConfiguration(a.ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("get configuration %s: %w", name, err)
+		}
+		if rendered == "" {
+			return nil, fmt.Errorf("configuration '%s' is empty: %s", name, BugError)
+		}
+
+		golden, err := os.ReadFile(path) // #nosec G304 user defined filepath
+		if errors.Is(err, os.ErrNotExist) {
+			diffs = append(diffs, GoldenDiff{Name: name, Path: path, Change: GoldenAdded, rendered: rendered})
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read golden file %s: %w", path, err)
+		}
+		if string(golden) == rendered {
+			continue
+		}
+
+		diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        splitLines(string(golden)),
+			B:        splitLines(rendered),
+			FromFile: path,
+			ToFile:   name + " (rendered)",
+			Context:  3,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("diff golden file %s: %w", path, err)
+		}
+		diffs = append(diffs, GoldenDiff{Name: name, Path: path, Change: GoldenChanged, Diff: diff, rendered: rendered})
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return nil, fmt.Errorf("glob golden files: %w", err)
+	}
+	for _, path := range files {
+		name := strings.TrimSuffix(filepath.Base(path), ".yaml")
+		if _, ok := names[name]; !ok {
+			diffs = append(diffs, GoldenDiff{Name: name, Path: path, Change: GoldenRemoved})
+		}
+	}
+
+	sort.SliceStable(diffs, func(i, j int) bool {
+		return diffs[i].Name < diffs[j].Name
+	})
+	return diffs, nil
+}
+
+// splitLines splits s into lines which keep their newline
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
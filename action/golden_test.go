@@ -0,0 +1,93 @@
+package action
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/observiq/bindplane-op-action/internal/workflow"
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunGolden(t *testing.T) {
+	dir := t.TempDir()
+	configurations := filepath.Join(dir, "configurations.yaml")
+	require.NoError(t, os.WriteFile(configurations, []byte(`apiVersion: bindplane.observiq.com/v1
+kind: Configuration
+metadata:
+  name: gateway
+---
+apiVersion: bindplane.observiq.com/v1
+kind: Configuration
+metadata:
+  name: edge
+---
+apiVersion: bindplane.observiq.com/v1
+kind: Configuration
+metadata:
+  name: node
+`), 0o600))
+
+	raw := map[string]string{
+		"gateway": "receivers:\n  otlp: {}\n",
+		"edge":    "receivers:\n  otlp: {}\nexporters:\n  otlp: {}\n",
+		"node":    "receivers:\n  hostmetrics: {}\n",
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/configurations/{name}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(model.ConfigurationResponse{Raw: raw[r.PathValue("name")]})
+	})
+
+	golden := filepath.Join(dir, "golden")
+	require.NoError(t, os.MkdirAll(golden, 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(golden, "gateway.yaml"), []byte(raw["gateway"]), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(golden, "edge.yaml"), []byte("receivers:\n  otlp: {}\nexporters:\n  logging: {}\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(golden, "old.yaml"), []byte(raw["gateway"]), 0o600))
+
+	buf := &bytes.Buffer{}
+	workflow.Output = buf
+	defer func() { workflow.Output = os.Stdout }()
+
+	a := newTestAction(t, mux, WithConfigurationPath(configurations))
+	diffs, err := a.CompareGolden(golden)
+	require.NoError(t, err)
+	require.Equal(t, []GoldenDiff{
+		{
+			Name:   "edge",
+			Path:   filepath.Join(golden, "edge.yaml"),
+			Change: GoldenChanged,
+			Diff: "--- " + filepath.Join(golden, "edge.yaml") + "\n" +
+				"+++ edge (rendered)\n" +
+				"@@ -1,4 +1,4 @@\n" +
+				" receivers:\n" +
+				"   otlp: {}\n" +
+				" exporters:\n" +
+				"-  logging: {}\n" +
+				"+  otlp: {}\n",
+			rendered: raw["edge"],
+		},
+		{Name: "node", Path: filepath.Join(golden, "node.yaml"), Change: GoldenAdded, rendered: raw["node"]},
+		{Name: "old", Path: filepath.Join(golden, "old.yaml"), Change: GoldenRemoved},
+	}, diffs)
+
+	require.EqualError(t, a.RunGolden(golden, false), "3 rendered configurations differ from golden files, set golden_update to accept the changes")
+	require.Contains(t, buf.String(), "-  logging: {}\n+  otlp: {}\n")
+	require.Contains(t, buf.String(), "line=11,title=Golden file mismatch::Configuration node does not have a golden file at "+filepath.Join(golden, "node.yaml"))
+
+	require.NoError(t, a.RunGolden(golden, true))
+	for name, content := range raw {
+		data, err := os.ReadFile(filepath.Join(golden, name+".yaml"))
+		require.NoError(t, err)
+		require.Equal(t, content, string(data))
+	}
+	require.NoFileExists(t, filepath.Join(golden, "old.yaml"))
+
+	diffs, err = a.CompareGolden(golden)
+	require.NoError(t, err)
+	require.Empty(t, diffs)
+}
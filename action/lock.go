@@ -0,0 +1,260 @@
+package action
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/observiq/bindplane-op-action/pkg/client"
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"go.uber.org/zap"
+)
+
+// Labels of the lock a run holds on a configuration, see WithLock
+const (
+	// LabelLockHolder identifies the run holding the lock
+	LabelLockHolder = "lock-holder"
+
+	// LabelLockExpires is the Unix time the lock expires at, so a lock
+	// which was not released, such as when a runner is lost, is not
+	// held forever
+	LabelLockExpires = "lock-expires"
+)
+
+const (
+	// DefaultLockTimeout is the default amount of time to wait
+	// for configurations locked by another run
+	DefaultLockTimeout = 10 * time.Minute
+
+	// lockLease is how long a lock is held before it expires
+	lockLease = time.Hour
+)
+
+// lockLabels are ignored when comparing resources with the server
+var lockLabels = []string{LabelLockHolder, LabelLockExpires}
+
+// newLockID returns a random ID which identifies the locks of a run
+func newLockID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Lock locks every configuration in the repository which exists on the
+// server, so concurrent runs do not apply and roll out the same
+// configuration. The lock is a lease stored in the labels of the
+// configuration, and is taken with a compare-and-set on the version of
+// the configuration, see lockConfiguration. Configurations locked by
+// another run are waited on until the lock timeout. The lock labels are
+// added to the repository's configurations, so they are kept when the
+// configurations are applied.
+func (a *Action) Lock() error {
+	if a.lockID == "" {
+		a.lockID = newLockID()
+	}
+
+	timeout := a.lockTimeout
+	if timeout <= 0 {
+		timeout = DefaultLockTimeout
+	}
+	interval := a.rolloutPollInterval
+	if interval <= 0 {
+		interval = DefaultRolloutPollInterval
+	}
+
+	deadline := time.Now().Add(timeout)
+	expires := strconv.FormatInt(time.Now().Add(lockLease).Unix(), 10)
+	for _, r := range a.resources[model.KindConfiguration] {
+		name := r.Metadata.Name
+		for {
+			holder, err := a.lockConfiguration(name, expires)
+			if err != nil {
+				return fmt.Errorf("lock configuration %s: %w", name, err)
+			}
+			if holder == "" {
+				break
+			}
+
+			wait := a.pollJitter(interval)
+			if time.Now().Add(wait).After(deadline) {
+				return fmt.Errorf("timed out after %s waiting for configuration %s, locked by %s", timeout, name, holder)
+			}
+			a.Logger.Info("Configuration is locked by another run, waiting", zap.String("name", name), zap.String("holder", holder))
+			time.Sleep(wait)
+		}
+
+		if r.Metadata.Labels == nil {
+			r.Metadata.Labels = map[string]string{}
+		}
+		r.Metadata.Labels[LabelLockHolder] = a.lockID
+		r.Metadata.Labels[LabelLockExpires] = expires
+	}
+	return nil
+}
+
+// lockConfiguration locks a configuration, and returns the holder of the
+// lock if it is locked by another run. Configurations which do not exist
+// yet have nothing to lock.
+//
+// The lock labels are applied with the version and hash of the
+// configuration which was read, so a server which rejects stale versions
+// fails the apply with a conflict when another run changed the
+// configuration in between. Of two runs which read the same version, only
+// the first to apply takes the lock. Servers which accept stale versions
+// keep the lock of the last run to apply, which VerifyLocks finds.
+func (a *Action) lockConfiguration(name, expires string) (string, error) {
+	r, err := a.client.GetResource(a.ctx, model.KindConfiguration, name)
+	if errors.Is(err, client.ErrNotFound) || (err == nil && r == nil) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if holder := lockHolder(r, time.Now()); holder != "" && holder != a.lockID {
+		return holder, nil
+	}
+
+	if r.Metadata.Labels == nil {
+		r.Metadata.Labels = map[string]string{}
+	}
+	r.Metadata.Labels[LabelLockHolder] = a.lockID
+	r.Metadata.Labels[LabelLockExpires] = expires
+	err = a.applyLock(r)
+	if errors.Is(err, client.ErrConflict) {
+		return a.conflictingHolder(name)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// The lock labels are read back, so a server which applied
+	// the labels but did not keep them is found
+	r, err = a.client.GetResource(a.ctx, model.KindConfiguration, name)
+	if err != nil {
+		return "", err
+	}
+	if r == nil {
+		return "", fmt.Errorf("configuration '%s' is nil: %s", name, BugError)
+	}
+	switch holder := lockHolder(r, time.Now()); holder {
+	case a.lockID:
+	case "":
+		return "", errors.New("the server did not save the lock labels")
+	default:
+		return holder, nil
+	}
+
+	a.Logger.Info("Locked configuration", zap.String("name", name), zap.String("holder", a.lockID))
+	a.lockedConfigurations = append(a.lockedConfigurations, name)
+	return "", nil
+}
+
+// VerifyLocks reads the lock of every configuration locked by the run
+// again, and returns an error if another run holds one of them. It is
+// called after every configuration is locked, right before applying. A
+// server which does not reject stale versions lets the last of two runs
+// which read the same version overwrite the lock of the first, and the
+// first run finds it here instead of applying.
+func (a *Action) VerifyLocks() error {
+	for _, name := range a.lockedConfigurations {
+		r, err := a.client.GetResource(a.ctx, model.KindConfiguration, name)
+		if err != nil {
+			return fmt.Errorf("verify lock of configuration %s: %w", name, err)
+		}
+		if r == nil {
+			return fmt.Errorf("configuration '%s' is nil: %s", name, BugError)
+		}
+		if holder := lockHolder(r, time.Now()); holder != a.lockID {
+			if holder == "" {
+				holder = "no run"
+			}
+			return fmt.Errorf("lock of configuration %s was lost, it is held by %s", name, holder)
+		}
+	}
+	return nil
+}
+
+// conflictingHolder returns the holder of a configuration's lock after
+// applying the lock failed with a conflict. The configuration was changed
+// by another run since it was read, which may not hold a lock, so the
+// lock is tried again once the wait between attempts has passed.
+func (a *Action) conflictingHolder(name string) (string, error) {
+	r, err := a.client.GetResource(a.ctx, model.KindConfiguration, name)
+	if err != nil {
+		return "", err
+	}
+	if r != nil {
+		if holder := lockHolder(r, time.Now()); holder != "" && holder != a.lockID {
+			return holder, nil
+		}
+	}
+	return "a concurrent run", nil
+}
+
+// Unlock releases the locks held by the run. Locks which cannot be
+// released are logged, and expire once their lease ends.
+func (a *Action) Unlock() {
+	for _, name := range a.lockedConfigurations {
+		r, err := a.client.GetResource(a.ctx, model.KindConfiguration, name)
+		if err != nil || r == nil {
+			a.Logger.Warn("Failed to release configuration lock", zap.String("name", name), zap.Error(err))
+			continue
+		}
+		if r.Metadata.Labels[LabelLockHolder] != a.lockID {
+			continue
+		}
+
+		for _, k := range lockLabels {
+			delete(r.Metadata.Labels, k)
+		}
+		if err := a.applyLock(r); err != nil {
+			a.Logger.Warn("Failed to release configuration lock", zap.String("name", name), zap.Error(err))
+			continue
+		}
+		a.Logger.Info("Released configuration lock", zap.String("name", name))
+	}
+	a.lockedConfigurations = nil
+}
+
+// applyLock applies a configuration whose lock labels were changed. The
+// version and hash of the configuration are sent as they were read, and
+// the returned error matches client.ErrConflict when they are stale.
+func (a *Action) applyLock(r *model.AnyResource) error {
+	statuses, err := a.client.Apply(a.ctx, []*model.AnyResource{r})
+	if err != nil {
+		return err
+	}
+	for _, s := range statuses {
+		switch s.Status {
+		case model.StatusInvalid, model.StatusError, model.StatusForbidden:
+			return fmt.Errorf("apply lock labels: %s %s", s.Status, s.Reason)
+		}
+	}
+	return nil
+}
+
+// lockHolder returns the holder of a configuration's lock,
+// or an empty string if it is not locked or the lock expired
+func lockHolder(r *model.AnyResource, now time.Time) string {
+	holder := r.Metadata.Labels[LabelLockHolder]
+	expires, err := strconv.ParseInt(r.Metadata.Labels[LabelLockExpires], 10, 64)
+	if holder == "" || err != nil || now.Unix() >= expires {
+		return ""
+	}
+	return holder
+}
+
+// withoutLockLabels returns a copy of labels without the lock labels
+func withoutLockLabels(labels map[string]string) map[string]string {
+	out := map[string]string{}
+	for k, v := range labels {
+		out[k] = v
+	}
+	for _, k := range lockLabels {
+		delete(out, k)
+	}
+	return out
+}
@@ -0,0 +1,206 @@
+package action
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLock(t *testing.T) {
+	future := strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+	past := strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10)
+
+	cases := []struct {
+		name      string
+		labels    map[string]string
+		expectErr string
+	}{
+		{"unlocked", map[string]string{"team": "platform"}, ""},
+		{"expired", map[string]string{"team": "platform", LabelLockHolder: "other", LabelLockExpires: past}, ""},
+		{"locked", map[string]string{"team": "platform", LabelLockHolder: "other", LabelLockExpires: future}, "timed out after 5ms waiting for configuration gateway, locked by other"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := &model.AnyResource{}
+			server.Kind = string(model.KindConfiguration)
+			server.Metadata.Name = "gateway"
+			server.Metadata.Labels = tc.labels
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /v1/configurations/{name}", func(w http.ResponseWriter, r *http.Request) {
+				if r.PathValue("name") != "gateway" {
+					http.NotFound(w, r)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_ = json.NewEncoder(w).Encode(map[string]any{"configuration": server})
+			})
+			mux.HandleFunc("POST /v1/apply", func(w http.ResponseWriter, r *http.Request) {
+				payload := model.ApplyPayload{}
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+				server = payload.Resources[0]
+				w.Header().Set("Content-Type", "application/json")
+				_ = json.NewEncoder(w).Encode(model.ApplyResponseClientSide{
+					Updates: []*model.AnyResourceStatus{{Resource: *server, Status: model.StatusConfigured}},
+				})
+			})
+
+			gateway, created := &model.AnyResource{}, &model.AnyResource{}
+			gateway.Metadata.Name = "gateway"
+			created.Metadata.Name = "new"
+
+			a := newTestAction(t, mux, WithLock(true), WithLockTimeout(5*time.Millisecond), WithRolloutPollInterval(time.Millisecond))
+			a.resources = map[model.Kind][]*model.AnyResource{model.KindConfiguration: {gateway, created}}
+
+			err := a.Lock()
+			if tc.expectErr != "" {
+				require.EqualError(t, err, tc.expectErr)
+				require.Empty(t, a.lockedConfigurations)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, []string{"gateway"}, a.lockedConfigurations)
+			require.Equal(t, a.lockID, server.Metadata.Labels[LabelLockHolder])
+			require.Equal(t, "platform", server.Metadata.Labels["team"])
+			require.Equal(t, a.lockID, gateway.Metadata.Labels[LabelLockHolder], "the lock is kept when the configuration is applied")
+			require.Empty(t, diffFields(gateway, &model.AnyResource{}), "lock labels are not differences")
+
+			a.Unlock()
+			require.Empty(t, a.lockedConfigurations)
+			require.Equal(t, map[string]string{"team": "platform"}, server.Metadata.Labels)
+		})
+	}
+}
+
+func TestLockConcurrent(t *testing.T) {
+	// The server rejects applies of a stale version, like BindPlane
+	var mu sync.Mutex
+	server := &model.AnyResource{}
+	server.Kind = string(model.KindConfiguration)
+	server.Metadata.Name = "gateway"
+	server.Metadata.Version = 1
+
+	get := func() model.AnyResource {
+		mu.Lock()
+		defer mu.Unlock()
+		r := *server
+		r.Metadata.Labels = maps.Clone(server.Metadata.Labels)
+		return r
+	}
+
+	// The first read of run A is answered after run B locked the
+	// configuration, so both runs read the same version
+	var b *Action
+	var gets int
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/configurations/{name}", func(w http.ResponseWriter, _ *http.Request) {
+		r := get()
+		mu.Lock()
+		gets++
+		first := gets == 1
+		mu.Unlock()
+		if first {
+			holder, err := b.lockConfiguration("gateway", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+			require.NoError(t, err)
+			require.Empty(t, holder)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"configuration": r})
+	})
+	mux.HandleFunc("POST /v1/apply", func(w http.ResponseWriter, r *http.Request) {
+		payload := model.ApplyPayload{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		mu.Lock()
+		defer mu.Unlock()
+		if payload.Resources[0].Metadata.Version != server.Metadata.Version {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		server = payload.Resources[0]
+		server.Metadata.Version++
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(model.ApplyResponseClientSide{
+			Updates: []*model.AnyResourceStatus{{Resource: *server, Status: model.StatusConfigured}},
+		})
+	})
+
+	opts := []Option{WithLock(true), WithLockTimeout(5 * time.Millisecond), WithRolloutPollInterval(time.Millisecond)}
+	a := newTestAction(t, mux, opts...)
+	b = newTestAction(t, mux, opts...)
+	a.lockID, b.lockID = "a", "b"
+
+	gateway := &model.AnyResource{}
+	gateway.Metadata.Name = "gateway"
+	a.resources = map[model.Kind][]*model.AnyResource{model.KindConfiguration: {gateway}}
+
+	require.EqualError(t, a.Lock(), "timed out after 5ms waiting for configuration gateway, locked by b")
+	require.Empty(t, a.lockedConfigurations)
+	require.Equal(t, []string{"gateway"}, b.lockedConfigurations)
+	require.Equal(t, "b", server.Metadata.Labels[LabelLockHolder])
+}
+
+func TestVerifyLocks(t *testing.T) {
+	// The server does not reject applies of a stale version, so the last
+	// of two runs which read the same version overwrites the first's lock
+	var mu sync.Mutex
+	server := &model.AnyResource{}
+	server.Kind = string(model.KindConfiguration)
+	server.Metadata.Name = "gateway"
+
+	// The first read of run A is answered after run B locked the
+	// configuration, so both runs read it unlocked
+	var b *Action
+	var gets int
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/configurations/{name}", func(w http.ResponseWriter, _ *http.Request) {
+		mu.Lock()
+		r := *server
+		r.Metadata.Labels = maps.Clone(server.Metadata.Labels)
+		gets++
+		first := gets == 1
+		mu.Unlock()
+		if first {
+			holder, err := b.lockConfiguration("gateway", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+			require.NoError(t, err)
+			require.Empty(t, holder)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"configuration": r})
+	})
+	mux.HandleFunc("POST /v1/apply", func(w http.ResponseWriter, r *http.Request) {
+		payload := model.ApplyPayload{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		mu.Lock()
+		defer mu.Unlock()
+		server = payload.Resources[0]
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(model.ApplyResponseClientSide{
+			Updates: []*model.AnyResourceStatus{{Resource: *server, Status: model.StatusConfigured}},
+		})
+	})
+
+	opts := []Option{WithLock(true), WithLockTimeout(5 * time.Millisecond), WithRolloutPollInterval(time.Millisecond)}
+	a := newTestAction(t, mux, opts...)
+	b = newTestAction(t, mux, opts...)
+	a.lockID, b.lockID = "a", "b"
+
+	gateway := &model.AnyResource{}
+	gateway.Metadata.Name = "gateway"
+	a.resources = map[model.Kind][]*model.AnyResource{model.KindConfiguration: {gateway}}
+
+	// Both runs believe they hold the lock, only the last to apply keeps it
+	require.NoError(t, a.Lock())
+	require.Equal(t, []string{"gateway"}, a.lockedConfigurations)
+	require.Equal(t, []string{"gateway"}, b.lockedConfigurations)
+
+	require.NoError(t, a.VerifyLocks())
+	require.EqualError(t, b.VerifyLocks(), "lock of configuration gateway was lost, it is held by a")
+}
@@ -0,0 +1,88 @@
+package action
+
+import (
+	"time"
+
+	"github.com/observiq/bindplane-op-action/action/notify"
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// meter creates run metrics. Metrics are only exported when a meter
+// provider is registered with otel.SetMeterProvider.
+var meter = otel.Meter("github.com/observiq/bindplane-op-action/action")
+
+// Run metrics. Instrument names are constant and valid,
+// so creating them does not fail.
+var (
+	resourcesApplied, _ = meter.Int64Counter(
+		"bindplane.resources_applied",
+		metric.WithDescription("Number of resources applied, by kind and status"),
+		metric.WithUnit("{resource}"),
+	)
+	applyDuration, _ = meter.Float64Histogram(
+		"bindplane.apply_duration",
+		metric.WithDescription("Time taken to apply the resources of a kind"),
+		metric.WithUnit("s"),
+	)
+	rolloutDuration, _ = meter.Float64Histogram(
+		"bindplane.rollout_duration",
+		metric.WithDescription("Time from the action first observing a rollout until it finished"),
+		metric.WithUnit("s"),
+	)
+	rolloutErrors, _ = meter.Int64Counter(
+		"bindplane.rollout_errors",
+		metric.WithDescription("Number of agents which failed to apply a rollout"),
+		metric.WithUnit("{agent}"),
+	)
+)
+
+// metricAttributes returns the attributes of every run metric, with
+// attrs appended. The remote URL distinguishes servers when profiles
+// are used.
+func (a *Action) metricAttributes(attrs ...attribute.KeyValue) metric.MeasurementOption {
+	return metric.WithAttributes(append(
+		[]attribute.KeyValue{attribute.String("bindplane.remote_url", a.config.Network.RemoteURL)},
+		attrs...,
+	)...)
+}
+
+// recordResourceApplied counts an applied resource by its kind and status
+func (a *Action) recordResourceApplied(s *model.AnyResourceStatus) {
+	resourcesApplied.Add(a.ctx, 1, a.metricAttributes(
+		attribute.String("bindplane.kind", s.Resource.Kind),
+		attribute.String("bindplane.status", string(s.Status)),
+	))
+}
+
+// recordApplyDuration records the time taken to apply the resources of a kind
+func (a *Action) recordApplyDuration(kind model.Kind, start time.Time, err error) {
+	applyDuration.Record(a.ctx, time.Since(start).Seconds(), a.metricAttributes(
+		attribute.String("bindplane.kind", string(kind)),
+		attribute.String("result", runResult(err)),
+	))
+}
+
+// recordRollout records the duration and errored agents of a finished rollout
+func (a *Action) recordRollout(name string, seen *rolloutSeen, result string) {
+	if seen == nil {
+		return
+	}
+
+	opt := a.metricAttributes(
+		attribute.String("bindplane.configuration", name),
+		attribute.String("result", result),
+	)
+	rolloutDuration.Record(a.ctx, time.Since(seen.first).Seconds(), opt)
+	rolloutErrors.Add(a.ctx, int64(seen.last.Status.Rollout.Progress.Errors), opt)
+}
+
+// runResult returns the result attribute for an operation's error
+func runResult(err error) string {
+	if err != nil {
+		return notify.ResultFailed
+	}
+	return notify.ResultSucceeded
+}
@@ -0,0 +1,105 @@
+package action
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestMetrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+
+	polls := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/apply", func(w http.ResponseWriter, r *http.Request) {
+		payload := model.ApplyPayload{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+
+		updates := []*model.AnyResourceStatus{}
+		for _, resource := range payload.Resources {
+			updates = append(updates, &model.AnyResourceStatus{Resource: *resource, Status: model.StatusCreated})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(model.ApplyResponseClientSide{Updates: updates})
+	})
+	mux.HandleFunc("/v1/rollouts/{name}/status", func(w http.ResponseWriter, r *http.Request) {
+		polls++
+		c := model.Configuration{}
+		c.Metadata.Name = r.PathValue("name")
+		c.Status.Rollout.Status = model.RolloutStatusStarted
+		if polls > 1 {
+			c.Status.Rollout.Status = model.RolloutStatusStable
+		}
+		c.Status.Rollout.Progress = model.RolloutProgress{Completed: 3, Errors: 2}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(model.ConfigurationResponse{Configuration: &c})
+	})
+
+	a := newTestAction(t, mux, WithSourcePath("sources.yaml"), WithRolloutWait(true), WithRolloutPollInterval(time.Millisecond))
+	a.resources = map[model.Kind][]*model.AnyResource{
+		model.KindSource: {
+			testResource(model.KindSource, "a", nil),
+			testResource(model.KindSource, "b", nil),
+		},
+	}
+	require.NoError(t, a.Apply())
+
+	a.startedRollouts = []string{"my-config"}
+	require.NoError(t, a.WaitForRollouts())
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+
+	metrics := map[string]metricdata.Aggregation{}
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		metrics[m.Name] = m.Data
+	}
+
+	remoteURL := attribute.String("bindplane.remote_url", a.config.Network.RemoteURL)
+
+	applied := metrics["bindplane.resources_applied"].(metricdata.Sum[int64]).DataPoints
+	require.Len(t, applied, 1)
+	require.Equal(t, int64(2), applied[0].Value)
+	require.Equal(t, attribute.NewSet(
+		remoteURL,
+		attribute.String("bindplane.kind", "Source"),
+		attribute.String("bindplane.status", "created"),
+	), applied[0].Attributes)
+
+	apply := metrics["bindplane.apply_duration"].(metricdata.Histogram[float64]).DataPoints
+	require.Len(t, apply, 1)
+	require.Equal(t, uint64(1), apply[0].Count)
+	require.Equal(t, attribute.NewSet(
+		remoteURL,
+		attribute.String("bindplane.kind", "Source"),
+		attribute.String("result", "succeeded"),
+	), apply[0].Attributes)
+
+	rolloutAttrs := attribute.NewSet(
+		remoteURL,
+		attribute.String("bindplane.configuration", "my-config"),
+		attribute.String("result", "succeeded"),
+	)
+
+	rollout := metrics["bindplane.rollout_duration"].(metricdata.Histogram[float64]).DataPoints
+	require.Len(t, rollout, 1)
+	require.Equal(t, uint64(1), rollout[0].Count)
+	require.Greater(t, rollout[0].Sum, 0.0)
+	require.Equal(t, rolloutAttrs, rollout[0].Attributes)
+
+	errors := metrics["bindplane.rollout_errors"].(metricdata.Sum[int64]).DataPoints
+	require.Len(t, errors, 1)
+	require.Equal(t, int64(2), errors[0].Value)
+	require.Equal(t, rolloutAttrs, errors[0].Attributes)
+}
@@ -0,0 +1,134 @@
+package action
+
+import (
+	"time"
+
+	"github.com/observiq/bindplane-op-action/action/notify"
+	"github.com/observiq/bindplane-op-action/internal/github"
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"go.uber.org/zap"
+)
+
+// rolloutSeen tracks the rollout status observed while waiting on a rollout
+type rolloutSeen struct {
+	// previousVersion is the version agents were using
+	// when the action first observed the rollout
+	previousVersion int
+
+	// first is when the action first observed the rollout
+	first time.Time
+
+	// last is the most recently observed status
+	last *model.Configuration
+}
+
+// update records an observed status, returning the updated
+// rolloutSeen. A nil rolloutSeen is created.
+func (s *rolloutSeen) update(c *model.Configuration) *rolloutSeen {
+	if s == nil {
+		s = &rolloutSeen{previousVersion: c.Status.CurrentVersion, first: time.Now()}
+	}
+	s.last = c
+	return s
+}
+
+// notifyRollout records the result of a rollout, sends it to the configured
+// notifiers, and sets the configuration's commit status.
+// Failing to notify is logged and does not fail the action.
+func (a *Action) notifyRollout(name string, seen *rolloutSeen, result string, err error) {
+	a.recordRollout(name, seen, result)
+
+	if a.slack == nil && a.webhook == nil && a.commitStatuses == nil {
+		return
+	}
+
+	r := a.rolloutNotification(name)
+	r.Result = result
+	if err != nil {
+		r.Message = err.Error()
+	}
+
+	if seen != nil && seen.last != nil {
+		c := seen.last
+		r.Version = c.Metadata.Version
+		if c.Status.PendingVersion > 0 {
+			r.Version = c.Status.PendingVersion
+		}
+		r.PreviousVersion = seen.previousVersion
+		r.Status = c.Status.Rollout.Status.String()
+		r.Completed = c.Status.Rollout.Progress.Completed
+		r.Errors = c.Status.Rollout.Progress.Errors
+		r.Pending = c.Status.Rollout.Progress.Pending
+		r.Waiting = c.Status.Rollout.Progress.Waiting
+	}
+
+	if a.slack != nil {
+		if err := a.slack.Rollout(a.ctx, r); err != nil {
+			a.Logger.Warn("Failed to send Slack notification", zap.String("name", name), zap.Error(err))
+		}
+	}
+
+	event, state, description := notify.EventRolloutSucceeded, github.StatusSuccess, "Rollout succeeded"
+	if result != notify.ResultSucceeded {
+		event, state, description = notify.EventRolloutFailed, github.StatusFailure, r.Message
+	}
+	a.setCommitStatus(name, state, description, r.RunURL)
+
+	e := notify.NewEvent(event)
+	e.Rollout = &r
+	a.sendEvent(e)
+}
+
+// notifyRolloutStarted sends the rollout_started event, and sets
+// the configuration's commit status to pending
+func (a *Action) notifyRolloutStarted(name string) {
+	r := a.rolloutNotification(name)
+	a.setCommitStatus(name, github.StatusPending, "Rollout started", r.RunURL)
+	if a.webhook == nil {
+		return
+	}
+
+	e := notify.NewEvent(notify.EventRolloutStarted)
+	e.Rollout = &r
+	a.sendEvent(e)
+}
+
+// notifyApplyComplete sends the apply_complete event
+// with the status of every applied resource
+func (a *Action) notifyApplyComplete() {
+	if a.webhook == nil {
+		return
+	}
+
+	e := notify.NewEvent(notify.EventApplyComplete)
+	e.Resources = []notify.Resource{}
+	for _, s := range a.state.ResourceStatuses() {
+		e.Resources = append(e.Resources, notify.Resource{
+			Kind:   s.Resource.Kind,
+			Name:   s.Resource.Metadata.Name,
+			Status: string(s.Status),
+		})
+	}
+	a.sendEvent(e)
+}
+
+// rolloutNotification returns a rollout notification with its links set
+func (a *Action) rolloutNotification(name string) notify.Rollout {
+	return notify.Rollout{
+		Name:             name,
+		RunURL:           notify.RunURL(),
+		ConfigurationURL: notify.ConfigurationURL(a.config.Network.RemoteURL, name),
+	}
+}
+
+// sendEvent sends an event to the webhook. Failing to
+// send is logged and does not fail the action.
+func (a *Action) sendEvent(e notify.Event) {
+	if a.webhook == nil {
+		return
+	}
+
+	if err := a.webhook.Send(a.ctx, e); err != nil {
+		a.Logger.Warn("Failed to send webhook event", zap.String("event", e.Event), zap.Error(err))
+	}
+}
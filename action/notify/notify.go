@@ -0,0 +1,56 @@
+// Package notify sends notifications about rollouts to external services
+package notify
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/observiq/bindplane-op-action/internal/ci"
+)
+
+// Rollout result values
+const (
+	ResultSucceeded = "succeeded"
+	ResultFailed    = "failed"
+)
+
+// Rollout describes a started or finished rollout
+type Rollout struct {
+	// Name is the configuration name
+	Name string `json:"name"`
+
+	// Version is the configuration version rolled out, and
+	// PreviousVersion is the version agents were using before
+	// the rollout. PreviousVersion is zero when unknown.
+	Version         int `json:"version,omitempty"`
+	PreviousVersion int `json:"previous_version,omitempty"`
+
+	// Result is ResultSucceeded or ResultFailed, Status is the last
+	// observed rollout status, and Message describes a failure.
+	// Result is empty when the rollout has started but not finished.
+	Result  string `json:"result,omitempty"`
+	Status  string `json:"status,omitempty"`
+	Message string `json:"message,omitempty"`
+
+	// Agent counts
+	Completed int `json:"completed"`
+	Errors    int `json:"errors"`
+	Pending   int `json:"pending"`
+	Waiting   int `json:"waiting"`
+
+	// RunURL links to the workflow run, and ConfigurationURL
+	// links to the configuration in BindPlane
+	RunURL           string `json:"run_url,omitempty"`
+	ConfigurationURL string `json:"configuration_url,omitempty"`
+}
+
+// RunURL returns the URL of the current workflow run or CI pipeline,
+// or an empty string when not running in a known CI system
+func RunURL() string {
+	return ci.Detect().RunURL
+}
+
+// ConfigurationURL returns the URL of a configuration in the BindPlane UI
+func ConfigurationURL(remoteURL, name string) string {
+	return fmt.Sprintf("%s/configurations/%s", strings.TrimSuffix(remoteURL, "/"), name)
+}
@@ -0,0 +1,99 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// slackTimeout is the timeout for posting a message to Slack
+const slackTimeout = 10 * time.Second
+
+// Slack posts messages to a Slack incoming webhook
+type Slack struct {
+	webhookURL string
+	client     *http.Client
+}
+
+// NewSlack returns a Slack notifier for the incoming webhook URL
+func NewSlack(webhookURL string) *Slack {
+	return &Slack{
+		webhookURL: webhookURL,
+		client:     &http.Client{Timeout: slackTimeout},
+	}
+}
+
+// slackMessage is the body of an incoming webhook request
+type slackMessage struct {
+	Text string `json:"text"`
+}
+
+// Rollout posts a message describing the result of a rollout
+func (s *Slack) Rollout(ctx context.Context, r Rollout) error {
+	data, err := json.Marshal(slackMessage{Text: slackRolloutText(r)})
+	if err != nil {
+		return fmt.Errorf("marshal slack message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.webhookURL, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("create slack request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("post slack message: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("slack returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// slackRolloutText formats a rollout as Slack mrkdwn
+func slackRolloutText(r Rollout) string {
+	icon := ":white_check_mark:"
+	if r.Result != ResultSucceeded {
+		icon = ":x:"
+	}
+
+	version := ""
+	switch {
+	case r.PreviousVersion > 0 && r.PreviousVersion != r.Version:
+		version = fmt.Sprintf(" version %d (from %d)", r.Version, r.PreviousVersion)
+	case r.Version > 0:
+		version = fmt.Sprintf(" version %d", r.Version)
+	}
+
+	lines := []string{
+		fmt.Sprintf("%s Rollout of configuration *%s*%s %s", icon, r.Name, version, r.Result),
+	}
+	if r.Message != "" {
+		lines = append(lines, r.Message)
+	}
+	if r.Status != "" {
+		lines = append(lines, fmt.Sprintf("Status: %s, agents completed: %d, errors: %d, pending: %d, waiting: %d", r.Status, r.Completed, r.Errors, r.Pending, r.Waiting))
+	}
+
+	links := []string{}
+	if r.RunURL != "" {
+		links = append(links, fmt.Sprintf("<%s|Workflow run>", r.RunURL))
+	}
+	if r.ConfigurationURL != "" {
+		links = append(links, fmt.Sprintf("<%s|BindPlane configuration>", r.ConfigurationURL))
+	}
+	if len(links) > 0 {
+		lines = append(lines, strings.Join(links, " | "))
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,101 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlackRolloutText(t *testing.T) {
+	cases := []struct {
+		name    string
+		rollout Rollout
+		expect  string
+	}{
+		{
+			"Succeeded",
+			Rollout{
+				Name:             "k8s-node",
+				Version:          3,
+				PreviousVersion:  2,
+				Result:           ResultSucceeded,
+				Status:           "stable",
+				Completed:        10,
+				RunURL:           "https://github.com/org/repo/actions/runs/1",
+				ConfigurationURL: "https://bindplane.example.com/configurations/k8s-node",
+			},
+			":white_check_mark: Rollout of configuration *k8s-node* version 3 (from 2) succeeded\n" +
+				"Status: stable, agents completed: 10, errors: 0, pending: 0, waiting: 0\n" +
+				"<https://github.com/org/repo/actions/runs/1|Workflow run> | <https://bindplane.example.com/configurations/k8s-node|BindPlane configuration>",
+		},
+		{
+			"Failed",
+			Rollout{
+				Name:    "k8s-node",
+				Version: 3,
+				Result:  ResultFailed,
+				Status:  "error",
+				Message: "rollout k8s-node failed with 4 agent errors",
+				Errors:  4,
+				Waiting: 6,
+			},
+			":x: Rollout of configuration *k8s-node* version 3 failed\n" +
+				"rollout k8s-node failed with 4 agent errors\n" +
+				"Status: error, agents completed: 0, errors: 4, pending: 0, waiting: 6",
+		},
+		{
+			"Unknown status",
+			Rollout{
+				Name:    "k8s-node",
+				Result:  ResultFailed,
+				Message: "rollout status k8s-node: connection refused",
+			},
+			":x: Rollout of configuration *k8s-node* failed\n" +
+				"rollout status k8s-node: connection refused",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expect, slackRolloutText(tc.rollout))
+		})
+	}
+}
+
+func TestSlackRollout(t *testing.T) {
+	var received slackMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		if r.URL.Path != "/hook" {
+			http.Error(w, "invalid_token", http.StatusForbidden)
+			return
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+	}))
+	defer server.Close()
+
+	r := Rollout{Name: "k8s-node", Result: ResultSucceeded}
+	require.NoError(t, NewSlack(server.URL+"/hook").Rollout(context.Background(), r))
+	require.Equal(t, slackRolloutText(r), received.Text)
+
+	err := NewSlack(server.URL+"/invalid").Rollout(context.Background(), r)
+	require.EqualError(t, err, "slack returned status 403: invalid_token")
+}
+
+func TestURLs(t *testing.T) {
+	t.Setenv("GITHUB_ACTIONS", "true")
+	t.Setenv("GITHUB_SERVER_URL", "https://github.com/")
+	t.Setenv("GITHUB_REPOSITORY", "org/repo")
+	t.Setenv("GITHUB_RUN_ID", "42")
+	require.Equal(t, "https://github.com/org/repo/actions/runs/42", RunURL())
+
+	t.Setenv("GITHUB_RUN_ID", "")
+	require.Equal(t, "", RunURL())
+
+	require.Equal(t, "https://bindplane.example.com/configurations/k8s-node", ConfigurationURL("https://bindplane.example.com/", "k8s-node"))
+}
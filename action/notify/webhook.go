@@ -0,0 +1,165 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Webhook lifecycle events
+const (
+	EventApplyComplete    = "apply_complete"
+	EventRolloutStarted   = "rollout_started"
+	EventRolloutSucceeded = "rollout_succeeded"
+	EventRolloutFailed    = "rollout_failed"
+)
+
+// Events are the webhook events, in lifecycle order
+var Events = []string{
+	EventApplyComplete,
+	EventRolloutStarted,
+	EventRolloutSucceeded,
+	EventRolloutFailed,
+}
+
+// webhookTimeout is the timeout for posting an event to a webhook
+const webhookTimeout = 10 * time.Second
+
+// Resource is the status of an applied resource
+type Resource struct {
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	Status string `json:"status"`
+}
+
+// Event is sent to webhooks, and is the data available to payload templates
+type Event struct {
+	Event      string `json:"event"`
+	Repository string `json:"repository"`
+	Ref        string `json:"ref"`
+	SHA        string `json:"sha"`
+	RunURL     string `json:"run_url"`
+
+	// Resources is set for apply_complete events
+	Resources []Resource `json:"resources,omitempty"`
+
+	// Rollout is set for rollout events
+	Rollout *Rollout `json:"rollout,omitempty"`
+}
+
+// NewEvent returns an event with the workflow run details
+// from the GitHub runner environment
+func NewEvent(name string) Event {
+	return Event{
+		Event:      name,
+		Repository: os.Getenv("GITHUB_REPOSITORY"),
+		Ref:        os.Getenv("GITHUB_REF"),
+		SHA:        os.Getenv("GITHUB_SHA"),
+		RunURL:     RunURL(),
+	}
+}
+
+// Webhook posts events to an arbitrary URL. The payload is the event
+// encoded as JSON, or the output of a user defined template.
+type Webhook struct {
+	url      string
+	events   []string
+	template *template.Template
+	client   *http.Client
+}
+
+// NewWebhook returns a webhook for the URL which is sent the given events,
+// or all events when empty. When tmpl is set, it is a Go template executed
+// with the Event to produce the JSON payload.
+func NewWebhook(url, tmpl string, events []string) (*Webhook, error) {
+	for _, e := range events {
+		if !slices.Contains(Events, e) {
+			return nil, fmt.Errorf("unknown webhook event %s, must be one of %s", e, strings.Join(Events, ", "))
+		}
+	}
+
+	w := &Webhook{
+		url:    url,
+		events: events,
+		client: &http.Client{Timeout: webhookTimeout},
+	}
+
+	if tmpl != "" {
+		t, err := template.New("webhook").Option("missingkey=error").Funcs(template.FuncMap{
+			"json": toJSON,
+		}).Parse(tmpl)
+		if err != nil {
+			return nil, fmt.Errorf("parse webhook template: %w", err)
+		}
+		w.template = t
+	}
+
+	return w, nil
+}
+
+// Send posts the event to the webhook, if the webhook is subscribed to it
+func (w *Webhook) Send(ctx context.Context, e Event) error {
+	if len(w.events) > 0 && !slices.Contains(w.events, e.Event) {
+		return nil
+	}
+
+	payload, err := w.payload(e)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("post webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("webhook returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// payload renders the event. Templated payloads must be valid JSON.
+func (w *Webhook) payload(e Event) ([]byte, error) {
+	if w.template == nil {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return nil, fmt.Errorf("marshal webhook event: %w", err)
+		}
+		return data, nil
+	}
+
+	buf := &bytes.Buffer{}
+	if err := w.template.Execute(buf, e); err != nil {
+		return nil, fmt.Errorf("execute webhook template: %w", err)
+	}
+	if !json.Valid(buf.Bytes()) {
+		return nil, fmt.Errorf("webhook template for event %s did not produce valid JSON", e.Event)
+	}
+	return buf.Bytes(), nil
+}
+
+// toJSON encodes v as JSON, so template values are quoted and escaped
+func toJSON(v any) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,134 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewWebhook(t *testing.T) {
+	cases := []struct {
+		name   string
+		tmpl   string
+		events []string
+		errStr string
+	}{
+		{"Defaults", "", nil, ""},
+		{"Template and events", `{"text": {{ json .Event }}}`, []string{EventRolloutFailed}, ""},
+		{"Unknown event", "", []string{"deploy"}, "unknown webhook event deploy, must be one of apply_complete, rollout_started, rollout_succeeded, rollout_failed"},
+		{"Invalid template", `{"text": {{ .Event }`, nil, `parse webhook template: template: webhook:1: unexpected "}" in operand`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewWebhook("https://example.com", tc.tmpl, tc.events)
+			if tc.errStr != "" {
+				require.EqualError(t, err, tc.errStr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestWebhookSend(t *testing.T) {
+	rollout := &Rollout{Name: "k8s-node", Result: ResultFailed, Message: `agents "failed"`}
+
+	cases := []struct {
+		name   string
+		tmpl   string
+		events []string
+		event  Event
+		expect string
+		errStr string
+	}{
+		{
+			"Default payload",
+			"",
+			nil,
+			Event{Event: EventApplyComplete, Repository: "org/repo", Resources: []Resource{{Kind: "Destination", Name: "otlp", Status: "created"}}},
+			`{"event":"apply_complete","repository":"org/repo","ref":"","sha":"","run_url":"","resources":[{"kind":"Destination","name":"otlp","status":"created"}]}`,
+			"",
+		},
+		{
+			"Template",
+			`{"summary": {{ json .Rollout.Message }}, "config": "{{ .Rollout.Name }}"}`,
+			nil,
+			Event{Event: EventRolloutFailed, Rollout: rollout},
+			`{"summary": "agents \"failed\"", "config": "k8s-node"}`,
+			"",
+		},
+		{
+			"Filtered event",
+			"",
+			[]string{EventRolloutFailed},
+			Event{Event: EventRolloutStarted, Rollout: rollout},
+			"",
+			"",
+		},
+		{
+			"Invalid JSON",
+			`{"summary": {{ .Rollout.Message }}}`,
+			nil,
+			Event{Event: EventRolloutFailed, Rollout: rollout},
+			"",
+			"webhook template for event rollout_failed did not produce valid JSON",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			received := ""
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+				data, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+				received = string(data)
+			}))
+			defer server.Close()
+
+			w, err := NewWebhook(server.URL, tc.tmpl, tc.events)
+			require.NoError(t, err)
+
+			err = w.Send(context.Background(), tc.event)
+			if tc.errStr != "" {
+				require.EqualError(t, err, tc.errStr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, received)
+		})
+	}
+}
+
+func TestWebhookSendStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad routing key", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	w, err := NewWebhook(server.URL, "", nil)
+	require.NoError(t, err)
+
+	err = w.Send(context.Background(), Event{Event: EventRolloutStarted})
+	require.EqualError(t, err, "webhook returned status 400: bad routing key")
+}
+
+func TestNewEvent(t *testing.T) {
+	t.Setenv("GITHUB_ACTIONS", "true")
+	t.Setenv("GITHUB_SERVER_URL", "https://github.com")
+	t.Setenv("GITHUB_REPOSITORY", "org/repo")
+	t.Setenv("GITHUB_RUN_ID", "42")
+	t.Setenv("GITHUB_REF", "refs/heads/main")
+	t.Setenv("GITHUB_SHA", "0a1b2c3d")
+
+	e := NewEvent(EventRolloutStarted)
+	data, err := json.Marshal(e)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"event":"rollout_started","repository":"org/repo","ref":"refs/heads/main","sha":"0a1b2c3d","run_url":"https://github.com/org/repo/actions/runs/42"}`, string(data))
+}
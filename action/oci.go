@@ -0,0 +1,364 @@
+package action
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"go.uber.org/zap"
+)
+
+// OCI media types and annotations used by oras
+const (
+	ociManifestMediaType = "application/vnd.oci.image.manifest.v1+json"
+	ociTitleAnnotation   = "org.opencontainers.image.title"
+	ociUnpackAnnotation  = "io.deis.oras.content.unpack"
+)
+
+// ociScheme prefixes the origin of resources decoded from an OCI
+// artifact, such as oci://ghcr.io/my-org/bindplane@sha256:.../sources.yaml
+const ociScheme = "oci://"
+
+// ociDigestPattern matches a sha256 digest
+var ociDigestPattern = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+
+// ociChallengePattern matches the parameters of a WWW-Authenticate challenge
+var ociChallengePattern = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// ociReference is an OCI artifact reference pinned to a digest
+type ociReference struct {
+	registry   string
+	repository string
+	digest     string
+}
+
+func (r ociReference) String() string {
+	return fmt.Sprintf("%s/%s@%s", r.registry, r.repository, r.digest)
+}
+
+// ociManifest is an OCI image manifest. Only the fields
+// required to pull the layers are decoded.
+type ociManifest struct {
+	MediaType string          `json:"mediaType"`
+	Layers    []ociDescriptor `json:"layers"`
+}
+
+// ociDescriptor describes a blob
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+// ValidateOCIReference returns an error if ref is not an OCI artifact
+// reference which includes the registry and is pinned to a digest
+func ValidateOCIReference(ref string) error {
+	_, err := parseOCIReference(ref)
+	return err
+}
+
+// parseOCIReference parses an OCI artifact reference, such as
+// ghcr.io/my-org/bindplane@sha256:<digest>. A tag may be included for
+// readability, such as ghcr.io/my-org/bindplane:v1@sha256:<digest>, but
+// only the digest is pulled so the artifact cannot change after it is
+// promoted.
+func parseOCIReference(ref string) (ociReference, error) {
+	name, digest, ok := strings.Cut(ref, "@")
+	if !ok {
+		return ociReference{}, fmt.Errorf("OCI artifact %s must be pinned to a digest, such as %s@sha256:<digest>", ref, ref)
+	}
+	if !ociDigestPattern.MatchString(digest) {
+		return ociReference{}, fmt.Errorf("OCI artifact %s has invalid digest %s, must be sha256:<64 hex characters>", ref, digest)
+	}
+
+	registry, repository, ok := strings.Cut(name, "/")
+	if !ok || !(strings.ContainsAny(registry, ".:") || registry == "localhost") {
+		return ociReference{}, fmt.Errorf("OCI artifact %s must include the registry, such as ghcr.io/my-org/bindplane@%s", ref, digest)
+	}
+
+	// Drop the tag, which follows the last path component
+	if i := strings.LastIndex(repository, ":"); i > strings.LastIndex(repository, "/") {
+		repository = repository[:i]
+	}
+	if repository == "" {
+		return ociReference{}, fmt.Errorf("OCI artifact %s is missing the repository", ref)
+	}
+
+	return ociReference{registry: registry, repository: repository, digest: digest}, nil
+}
+
+// decodeOCIArtifact pulls an OCI artifact, such as a bundle pushed with
+// oras, and decodes the resources in its yaml layers. Resources of every
+// kind can be in the same artifact. Layers which oras created from a
+// directory are unpacked.
+func (a *Action) decodeOCIArtifact(ref string) ([]*model.AnyResource, []resourceOrigin, error) {
+	r, err := parseOCIReference(ref)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err := a.pullOCI(r, "manifests", r.digest)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	manifest := ociManifest{}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, nil, fmt.Errorf("decode manifest of %s: %w", r, err)
+	}
+	if manifest.MediaType != "" && manifest.MediaType != ociManifestMediaType {
+		return nil, nil, fmt.Errorf("OCI artifact %s has media type %s, must be %s", r, manifest.MediaType, ociManifestMediaType)
+	}
+
+	resources := []*model.AnyResource{}
+	origins := []resourceOrigin{}
+
+	for _, layer := range manifest.Layers {
+		if layer.Size > maxRemoteFileSize {
+			return nil, nil, fmt.Errorf("OCI artifact %s: layer %s is larger than %d bytes", r, layer.Digest, maxRemoteFileSize)
+		}
+
+		title := layer.Annotations[ociTitleAnnotation]
+		if title == "" {
+			title = layer.Digest
+		}
+
+		data, err := a.pullOCI(r, "blobs", layer.Digest)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		files := map[string][]byte{title: data}
+		if layer.Annotations[ociUnpackAnnotation] == "true" {
+			files, err = unpackOCILayer(data)
+			if err != nil {
+				return nil, nil, fmt.Errorf("OCI artifact %s: unpack layer %s: %w", r, title, err)
+			}
+		}
+
+		for _, name := range slices.Sorted(maps.Keys(files)) {
+			data := files[name]
+			if ext := path.Ext(name); ext != ".yaml" && ext != ".yml" {
+				a.loaderLogger().Debug("Skipping OCI artifact file which is not yaml", zap.String("artifact", r.String()), zap.String("file", name))
+				continue
+			}
+
+			file := ociScheme + r.String() + "/" + name
+			res, o, err := decodeResources(file, file, data, a.catalog)
+			if err != nil {
+				return nil, nil, err
+			}
+			resources = append(resources, res...)
+			origins = append(origins, o...)
+		}
+	}
+
+	if len(resources) == 0 {
+		return nil, nil, fmt.Errorf("no resources found in OCI artifact: %s", r)
+	}
+
+	for i, resource := range resources {
+		if !a.isResourceKind(model.Kind(resource.Kind)) {
+			return nil, nil, &fileError{
+				origins[i],
+				fmt.Errorf("resource %s in OCI artifact %s has unsupported kind %q", resource.Metadata.Name, r, resource.Kind),
+			}
+		}
+	}
+
+	return resources, origins, nil
+}
+
+// isResourceKind returns true if kind is one of the kinds applied
+// by the action
+func (a *Action) isResourceKind(kind model.Kind) bool {
+	for _, f := range a.resourceFiles() {
+		if f.kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// pullOCI downloads a manifest or blob from the registry and verifies
+// its digest. When the registry responds with an authentication
+// challenge, credentials are exchanged for a token and the request
+// is retried once.
+func (a *Action) pullOCI(r ociReference, endpoint, digest string) ([]byte, error) {
+	u := fmt.Sprintf("https://%s/v2/%s/%s/%s", r.registry, r.repository, endpoint, digest)
+
+	resp, err := a.getOCI(u)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized && a.ociAuth == "" {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+
+		a.ociAuth, err = a.ociAuthorization(r, challenge)
+		if err != nil {
+			return nil, fmt.Errorf("authenticate to registry %s: %w", r.registry, err)
+		}
+		resp, err = a.getOCI(u)
+		if err != nil {
+			return nil, err
+		}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode > 299 {
+		return nil, fmt.Errorf("pull %s %s: registry returned status %d", r, digest, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxRemoteFileSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("pull %s %s: %w", r, digest, err)
+	}
+	if len(data) > maxRemoteFileSize {
+		return nil, fmt.Errorf("pull %s %s: content is larger than %d bytes", r, digest, maxRemoteFileSize)
+	}
+
+	sum := sha256.Sum256(data)
+	if actual := "sha256:" + hex.EncodeToString(sum[:]); actual != digest {
+		return nil, fmt.Errorf("pull %s %s: content has digest %s", r, digest, actual)
+	}
+	return data, nil
+}
+
+// getOCI sends a registry request, with the authorization from
+// a previous challenge if there was one
+func (a *Action) getOCI(u string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(a.ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request for %s: %w", u, err)
+	}
+	req.Header.Set("Accept", ociManifestMediaType)
+	if a.ociAuth != "" {
+		req.Header.Set("Authorization", a.ociAuth)
+	}
+
+	resp, err := a.remoteClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("pull %s: %w", u, err)
+	}
+	return resp, nil
+}
+
+// ociAuthorization returns the Authorization header value for a
+// WWW-Authenticate challenge. Bearer challenges are answered with a token
+// from the registry's token service, requested anonymously when
+// credentials are not set, which is sufficient for public artifacts.
+func (a *Action) ociAuthorization(r ociReference, challenge string) (string, error) {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	basic := base64.StdEncoding.EncodeToString([]byte(a.ociUsername + ":" + a.ociPassword))
+
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if a.ociUsername == "" {
+			return "", errors.New("registry requires credentials")
+		}
+		return "Basic " + basic, nil
+	case "bearer":
+	default:
+		return "", fmt.Errorf("unsupported authentication challenge %q", challenge)
+	}
+
+	p := map[string]string{}
+	for _, m := range ociChallengePattern.FindAllStringSubmatch(params, -1) {
+		p[strings.ToLower(m[1])] = m[2]
+	}
+	if p["realm"] == "" {
+		return "", fmt.Errorf("authentication challenge %q is missing the realm", challenge)
+	}
+	if p["scope"] == "" {
+		p["scope"] = fmt.Sprintf("repository:%s:pull", r.repository)
+	}
+
+	q := url.Values{}
+	q.Set("scope", p["scope"])
+	if p["service"] != "" {
+		q.Set("service", p["service"])
+	}
+
+	req, err := http.NewRequestWithContext(a.ctx, http.MethodGet, p["realm"]+"?"+q.Encode(), nil)
+	if err != nil {
+		return "", fmt.Errorf("create token request: %w", err)
+	}
+	if a.ociUsername != "" {
+		req.Header.Set("Authorization", "Basic "+basic)
+	}
+
+	resp, err := a.remoteClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("request token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode > 299 {
+		return "", fmt.Errorf("request token: token service returned status %d", resp.StatusCode)
+	}
+
+	token := struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}{}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxRemoteFileSize)).Decode(&token); err != nil {
+		return "", fmt.Errorf("decode token: %w", err)
+	}
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+	if token.Token == "" {
+		return "", errors.New("token service did not return a token")
+	}
+	return "Bearer " + token.Token, nil
+}
+
+// unpackOCILayer returns the regular files in a gzip compressed tar
+// layer, which oras creates when a directory is pushed. File names
+// include the directory.
+func unpackOCILayer(data []byte) (map[string][]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	files := map[string][]byte{}
+	tr := tar.NewReader(io.LimitReader(gz, maxRemoteFileSize))
+	for {
+		h, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if h.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		files[strings.TrimPrefix(path.Clean("/"+h.Name), "/")] = data
+	}
+	return files, nil
+}
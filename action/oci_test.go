@@ -0,0 +1,157 @@
+package action
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseOCIReference(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("a", 64)
+
+	cases := []struct {
+		name      string
+		ref       string
+		expect    ociReference
+		expectErr string
+	}{
+		{
+			"digest",
+			"ghcr.io/my-org/bindplane@" + digest,
+			ociReference{"ghcr.io", "my-org/bindplane", digest},
+			"",
+		},
+		{
+			"tag and digest",
+			"localhost:5000/bindplane:v1@" + digest,
+			ociReference{"localhost:5000", "bindplane", digest},
+			"",
+		},
+		{
+			"tag",
+			"ghcr.io/my-org/bindplane:v1",
+			ociReference{},
+			"OCI artifact ghcr.io/my-org/bindplane:v1 must be pinned to a digest, such as ghcr.io/my-org/bindplane:v1@sha256:<digest>",
+		},
+		{
+			"invalid digest",
+			"ghcr.io/my-org/bindplane@sha256:abc",
+			ociReference{},
+			"OCI artifact ghcr.io/my-org/bindplane@sha256:abc has invalid digest sha256:abc, must be sha256:<64 hex characters>",
+		},
+		{
+			"missing registry",
+			"my-org/bindplane@" + digest,
+			ociReference{},
+			"OCI artifact my-org/bindplane@" + digest + " must include the registry, such as ghcr.io/my-org/bindplane@" + digest,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := parseOCIReference(tc.ref)
+			if tc.expectErr != "" {
+				require.EqualError(t, err, tc.expectErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, r)
+		})
+	}
+}
+
+func TestLoadOCIArtifact(t *testing.T) {
+	destinations := []byte("apiVersion: bindplane.observiq.com/v1\nkind: Destination\nmetadata:\n  name: otlp\nspec:\n  type: otlp_grpc\n")
+	configurations, err := os.ReadFile("testdata/configuration.yaml")
+	require.NoError(t, err)
+
+	// A directory pushed with oras is a gzip compressed tar layer
+	buf := &bytes.Buffer{}
+	gz := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gz)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "bundle/configuration.yaml", Mode: 0o600, Size: int64(len(configurations)), Typeflag: tar.TypeReg}))
+	_, err = tw.Write(configurations)
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+
+	blobs := map[string][]byte{}
+	descriptor := func(data []byte, annotations map[string]string) ociDescriptor {
+		sum := sha256.Sum256(data)
+		d := "sha256:" + hex.EncodeToString(sum[:])
+		blobs[d] = data
+		return ociDescriptor{MediaType: "application/vnd.oci.image.layer.v1.tar", Digest: d, Size: int64(len(data)), Annotations: annotations}
+	}
+
+	manifest, err := json.Marshal(ociManifest{
+		MediaType: ociManifestMediaType,
+		Layers: []ociDescriptor{
+			descriptor(destinations, map[string]string{ociTitleAnnotation: "destination.yaml"}),
+			descriptor([]byte("# Bundle"), map[string]string{ociTitleAnnotation: "README.md"}),
+			descriptor(buf.Bytes(), map[string]string{ociTitleAnnotation: "bundle", ociUnpackAnnotation: "true"}),
+		},
+	})
+	require.NoError(t, err)
+	sum := sha256.Sum256(manifest)
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+	blobs[digest] = manifest
+
+	var server *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "repository:my-org/bindplane:pull", r.URL.Query().Get("scope"))
+		if u, p, _ := r.BasicAuth(); u != "octocat" || p != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"token":"pull-token"}`))
+	})
+	mux.HandleFunc("/v2/my-org/bindplane/{endpoint}/{digest}", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer pull-token" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",service="registry"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		data, ok := blobs[r.PathValue("digest")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(data)
+	})
+	mux.HandleFunc("/v2/my-org/tampered/{endpoint}/{digest}", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("{}"))
+	})
+	server = httptest.NewTLSServer(mux)
+	defer server.Close()
+	registry := strings.TrimPrefix(server.URL, "https://")
+
+	newAction := func(ref, username, password string) *Action {
+		a := newTestAction(t, http.NewServeMux(), WithOCIArtifact(ref), WithOCICredentials(username, password))
+		a.remoteClient = server.Client()
+		return a
+	}
+
+	a := newAction(registry+"/my-org/bindplane:v1@"+digest, "octocat", "secret")
+	require.NoError(t, a.LoadResources())
+	require.Len(t, a.resources[model.KindDestination], 1)
+	require.Len(t, a.resources[model.KindConfiguration], 3)
+	require.Equal(t, resourceOrigin{file: "oci://" + registry + "/my-org/bindplane@" + digest + "/bundle/configuration.yaml", line: 2}, a.origins["Configuration/k8s-cluster"])
+
+	a = newAction(registry+"/my-org/bindplane@"+digest, "", "")
+	require.EqualError(t, a.LoadResources(), "OCI artifact: authenticate to registry "+registry+": request token: token service returned status 401")
+
+	a = newAction(registry+"/my-org/tampered@"+digest, "", "")
+	require.EqualError(t, a.LoadResources(), "OCI artifact: pull "+registry+"/my-org/tampered@"+digest+" "+digest+": content has digest sha256:44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a")
+}
@@ -0,0 +1,133 @@
+// Package otelconfig validates the structure of rendered OpenTelemetry
+// collector configurations.
+package otelconfig
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// pipelineTypes are the signal types a pipeline ID can begin with
+var pipelineTypes = map[string]struct{}{
+	"traces":   {},
+	"metrics":  {},
+	"logs":     {},
+	"profiles": {},
+}
+
+// config is the subset of the collector configuration which is validated.
+// Component configuration is not decoded, only the component IDs.
+type config struct {
+	Receivers  map[string]any `yaml:"receivers"`
+	Processors map[string]any `yaml:"processors"`
+	Exporters  map[string]any `yaml:"exporters"`
+	Connectors map[string]any `yaml:"connectors"`
+	Extensions map[string]any `yaml:"extensions"`
+	Service    struct {
+		Extensions []string             `yaml:"extensions"`
+		Pipelines  map[string]*pipeline `yaml:"pipelines"`
+	} `yaml:"service"`
+}
+
+type pipeline struct {
+	Receivers  []string `yaml:"receivers"`
+	Processors []string `yaml:"processors"`
+	Exporters  []string `yaml:"exporters"`
+}
+
+// Validate checks the structure of a rendered collector configuration, the
+// same way the collector does at startup. Every pipeline must have at least
+// one receiver and exporter, every component referenced by a pipeline or the
+// service must be defined, and every connector used by a pipeline must be
+// used as both an exporter and a receiver. Component specific settings are
+// not validated. All errors are returned, not just the first.
+func Validate(raw string) error {
+	c := config{}
+	if err := yaml.Unmarshal([]byte(raw), &c); err != nil {
+		return fmt.Errorf("failed to unmarshal yaml: %w", err)
+	}
+
+	if len(c.Service.Pipelines) == 0 {
+		return errors.New("service must have at least one pipeline")
+	}
+
+	errs := []error{}
+	for _, id := range c.Service.Extensions {
+		if _, ok := c.Extensions[id]; !ok {
+			errs = append(errs, fmt.Errorf("service references extension %s which is not defined", id))
+		}
+	}
+
+	names := make([]string, 0, len(c.Service.Pipelines))
+	for name := range c.Service.Pipelines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	connectorExporters := map[string]struct{}{}
+	connectorReceivers := map[string]struct{}{}
+	for _, name := range names {
+		p := c.Service.Pipelines[name]
+		if p == nil {
+			p = &pipeline{}
+		}
+
+		signal, _, _ := strings.Cut(name, "/")
+		if _, ok := pipelineTypes[signal]; !ok {
+			errs = append(errs, fmt.Errorf("pipeline %s: unknown signal type %s", name, signal))
+		}
+
+		if len(p.Receivers) == 0 {
+			errs = append(errs, fmt.Errorf("pipeline %s: must have at least one receiver", name))
+		}
+		if len(p.Exporters) == 0 {
+			errs = append(errs, fmt.Errorf("pipeline %s: must have at least one exporter", name))
+		}
+
+		for _, id := range p.Receivers {
+			if _, ok := c.Connectors[id]; ok {
+				connectorReceivers[id] = struct{}{}
+				continue
+			}
+			if _, ok := c.Receivers[id]; !ok {
+				errs = append(errs, fmt.Errorf("pipeline %s: references receiver %s which is not defined", name, id))
+			}
+		}
+
+		for _, id := range p.Processors {
+			if _, ok := c.Processors[id]; !ok {
+				errs = append(errs, fmt.Errorf("pipeline %s: references processor %s which is not defined", name, id))
+			}
+		}
+
+		for _, id := range p.Exporters {
+			if _, ok := c.Connectors[id]; ok {
+				connectorExporters[id] = struct{}{}
+				continue
+			}
+			if _, ok := c.Exporters[id]; !ok {
+				errs = append(errs, fmt.Errorf("pipeline %s: references exporter %s which is not defined", name, id))
+			}
+		}
+	}
+
+	connectors := make([]string, 0, len(c.Connectors))
+	for id := range c.Connectors {
+		connectors = append(connectors, id)
+	}
+	sort.Strings(connectors)
+
+	for _, id := range connectors {
+		_, exporter := connectorExporters[id]
+		_, receiver := connectorReceivers[id]
+		if exporter != receiver {
+			errs = append(errs, fmt.Errorf("connector %s must be used as both an exporter and a receiver", id))
+		}
+	}
+
+	return errors.Join(errs...)
+}
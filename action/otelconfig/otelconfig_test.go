@@ -0,0 +1,51 @@
+package otelconfig
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		name   string
+		path   string
+		errStr string
+	}{
+		{
+			"Valid",
+			"testdata/valid.yaml",
+			"",
+		},
+		{
+			"Invalid",
+			"testdata/invalid.yaml",
+			"service references extension health_check which is not defined\n" +
+				"pipeline logs: references processor batch which is not defined\n" +
+				"pipeline metrics: must have at least one exporter\n" +
+				"pipeline metrics: references receiver hostmetrics which is not defined\n" +
+				"pipeline spans: unknown signal type spans\n" +
+				"connector count must be used as both an exporter and a receiver",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := os.ReadFile(tc.path)
+			require.NoError(t, err)
+
+			err = Validate(string(data))
+			if tc.errStr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, tc.errStr)
+		})
+	}
+}
+
+func TestValidateNoPipelines(t *testing.T) {
+	require.EqualError(t, Validate("receivers: {}"), "service must have at least one pipeline")
+	require.ErrorContains(t, Validate("service: ["), "failed to unmarshal yaml")
+}
@@ -0,0 +1,140 @@
+package action
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/observiq/bindplane-op-action/internal/workflow"
+	"go.uber.org/zap"
+)
+
+// Action outputs, see action.yml
+const (
+	OutputAppliedResources     = "applied_resources"
+	OutputRolloutStatus        = "rollout_status"
+	OutputConfigurationVersion = "configuration_version"
+	OutputBindPlaneVersion     = "bindplane_version"
+	OutputDrift                = "drift"
+	OutputRollouts             = "rollouts"
+	OutputGolden               = "golden"
+	OutputRawConfigurations    = "raw_configurations"
+)
+
+// rolloutStatusUnknown is the rollout status output of configurations
+// whose status could not be read
+const rolloutStatusUnknown = "unknown"
+
+// AppliedResource is the status of an applied resource
+type AppliedResource struct {
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	ID     string `json:"id"`
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// WriteOutputs writes the action outputs so they can be consumed
+// by later steps in the workflow.
+func (a *Action) WriteOutputs() error {
+	outputs, err := a.Outputs()
+	if err != nil {
+		return err
+	}
+
+	// Sort for consistent ordering in the logs
+	names := make([]string, 0, len(outputs))
+	for name := range outputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := workflow.SetOutput(name, outputs[name]); err != nil {
+			return fmt.Errorf("set output %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// Outputs returns the action outputs keyed by output name. Values
+// other than the BindPlane version are JSON encoded. A configuration whose
+// rollout status cannot be read has the unknown status and no version,
+// so the other outputs are still written.
+func (a *Action) Outputs() (map[string]string, error) {
+	applied := a.appliedResources()
+
+	rolloutStatus := map[string]string{}
+	configurationVersion := map[string]int{}
+	for _, name := range a.outputConfigurationNames() {
+		c, err := a.client.RolloutStatus(a.ctx, name)
+		if err != nil {
+			a.Logger.Warn("Failed to get rollout status for outputs", zap.String("name", name), zap.Error(err))
+			rolloutStatus[name] = rolloutStatusUnknown
+			continue
+		}
+		if c == nil {
+			continue
+		}
+		rolloutStatus[name] = c.Status.Rollout.Status.String()
+		configurationVersion[name] = c.Metadata.Version
+	}
+
+	outputs := map[string]string{
+		OutputBindPlaneVersion: a.bindplaneVersion.Tag,
+	}
+
+	for name, v := range map[string]any{
+		OutputAppliedResources:     applied,
+		OutputRolloutStatus:        rolloutStatus,
+		OutputConfigurationVersion: configurationVersion,
+	} {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("marshal output %s: %w", name, err)
+		}
+		outputs[name] = string(data)
+	}
+
+	if a.rawConfigDir != "" {
+		files := a.rawConfigFiles
+		if files == nil {
+			files = map[string]string{}
+		}
+		data, err := json.Marshal(files)
+		if err != nil {
+			return nil, fmt.Errorf("marshal output %s: %w", OutputRawConfigurations, err)
+		}
+		outputs[OutputRawConfigurations] = string(data)
+	}
+
+	return outputs, nil
+}
+
+// appliedResources returns the status of every resource
+// applied during the run, in the order they were applied
+func (a *Action) appliedResources() []AppliedResource {
+	applied := []AppliedResource{}
+	for _, s := range a.state.ResourceStatuses() {
+		applied = append(applied, AppliedResource{
+			Kind:   s.Resource.Kind,
+			Name:   s.Resource.Metadata.Name,
+			ID:     s.Resource.Metadata.ID,
+			Status: string(s.Status),
+			Reason: s.Reason,
+		})
+	}
+	return applied
+}
+
+// outputConfigurationNames returns the names of configurations that
+// were applied or rolled out during this run
+func (a *Action) outputConfigurationNames() []string {
+	names := a.state.ConfigurationNames()
+	if a.rolloutConfiguration != "" {
+		names = append(names, a.rolloutConfiguration)
+	}
+	sort.Strings(names)
+	return names
+}
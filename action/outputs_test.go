@@ -0,0 +1,93 @@
+package action
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"github.com/observiq/bindplane-op-action/pkg/client/version"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newTestAction returns an action configured to use the given handler as the BindPlane API
+func newTestAction(t *testing.T, handler http.Handler, opts ...Option) *Action {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	opts = append([]Option{WithBindPlaneRemoteURL(server.URL), WithRetryMaxAttempts(1)}, opts...)
+	a, err := New(zap.NewNop(), opts...)
+	require.NoError(t, err)
+	return a
+}
+
+func TestOutputs(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/rollouts/{name}/status", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("name") == "broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		c := model.Configuration{}
+		c.Metadata.Name = r.PathValue("name")
+		c.Metadata.Version = 3
+		c.Status.Rollout.Status = model.RolloutStatusStable
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(model.ConfigurationResponse{Configuration: &c})
+	})
+
+	a := newTestAction(t, mux)
+	a.bindplaneVersion = version.Version{Tag: "v1.80.0"}
+
+	dest := model.AnyResourceStatus{Status: model.StatusCreated}
+	dest.Resource.Kind = string(model.KindDestination)
+	dest.Resource.Metadata.Name = "otlp"
+	dest.Resource.Metadata.ID = "1"
+	a.state.AddResourceStatus(dest)
+
+	conf := model.AnyResourceStatus{Status: model.StatusConfigured}
+	conf.Resource.Kind = string(model.KindConfiguration)
+	conf.Resource.Metadata.Name = "gateway"
+	conf.Resource.Metadata.ID = "2"
+	a.state.AddResourceStatus(conf)
+	a.state.SetConfiguration("gateway", conf.Resource)
+
+	// A configuration whose status cannot be read does not
+	// prevent the other outputs from being written
+	broken := model.AnyResource{}
+	broken.Kind = string(model.KindConfiguration)
+	broken.Metadata.Name = "broken"
+	a.state.SetConfiguration("broken", broken)
+
+	path := filepath.Join(t.TempDir(), "output")
+	t.Setenv("GITHUB_OUTPUT", path)
+
+	outputs, err := a.Outputs()
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		OutputBindPlaneVersion:     "v1.80.0",
+		OutputAppliedResources:     `[{"kind":"Destination","name":"otlp","id":"1","status":"created"},{"kind":"Configuration","name":"gateway","id":"2","status":"configured"}]`,
+		OutputRolloutStatus:        `{"broken":"unknown","gateway":"stable"}`,
+		OutputConfigurationVersion: `{"gateway":3}`,
+	}, outputs)
+
+	require.NoError(t, a.WriteOutputs())
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(data), `{"broken":"unknown","gateway":"stable"}`)
+}
+
+func TestOutputsEmpty(t *testing.T) {
+	a := newTestAction(t, http.NotFoundHandler())
+
+	outputs, err := a.Outputs()
+	require.NoError(t, err)
+	require.Equal(t, "[]", outputs[OutputAppliedResources])
+	require.Equal(t, "{}", outputs[OutputRolloutStatus])
+	require.Equal(t, "{}", outputs[OutputConfigurationVersion])
+}
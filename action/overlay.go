@@ -0,0 +1,193 @@
+package action
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/observiq/bindplane-op-action/action/catalog"
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// overlay is a partial resource which is deep merged into the
+// resource with the same kind and name
+type overlay struct {
+	kind   string
+	name   string
+	values map[string]any
+	origin resourceOrigin
+}
+
+// applyOverlays merges the overlays of the environment, read from the
+// environment's directory in the overlays directory, into the loaded
+// resources. An environment without a directory has no overlays, so
+// only environments which differ from the base resources need one.
+func (a *Action) applyOverlays(resources map[model.Kind][]*model.AnyResource) error {
+	if a.overlaysDir == "" {
+		return nil
+	}
+	if a.environment == "" {
+		return errors.New("an environment is required when an overlays directory is set")
+	}
+
+	dir := filepath.Join(a.overlaysDir, a.environment)
+	if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
+		a.loaderLogger().Debug("Environment does not have overlays", zap.String("dir", dir))
+		return nil
+	}
+
+	overlays, err := decodeOverlayDir(dir, a.catalog)
+	if err != nil {
+		return err
+	}
+
+	for _, o := range overlays {
+		i := slices.IndexFunc(resources[model.Kind(o.kind)], func(r *model.AnyResource) bool {
+			return r.Metadata.Name == o.name
+		})
+		if i < 0 {
+			return &fileError{
+				o.origin,
+				fmt.Errorf("overlay %s: %s/%s is not a resource in the repository", o.origin.file, o.kind, o.name),
+			}
+		}
+
+		merged, err := mergeOverlay(resources[model.Kind(o.kind)][i], o.values)
+		if err != nil {
+			return &fileError{o.origin, fmt.Errorf("overlay %s: %s/%s: %w", o.origin.file, o.kind, o.name, err)}
+		}
+		resources[model.Kind(o.kind)][i] = merged
+		a.loaderLogger().Debug("Applied overlay", zap.String("kind", o.kind), zap.String("name", o.name), zap.String("file", o.origin.file))
+	}
+	return nil
+}
+
+// decodeOverlayDir decodes the overlays in every yaml file in dir,
+// resolving variable and secret references
+func decodeOverlayDir(dir string, vars *catalog.Catalog) ([]overlay, error) {
+	files := []string{}
+	for _, pattern := range []string{"*.yaml", "*.yml"} {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, fmt.Errorf("glob overlays in %s: %w", dir, err)
+		}
+		files = append(files, matches...)
+	}
+	slices.Sort(files)
+
+	overlays := []overlay{}
+	for _, file := range files {
+		data, err := os.ReadFile(file) // #nosec G304 user defined filepath
+		if err != nil {
+			return nil, fmt.Errorf("read overlay %s: %w", file, err)
+		}
+
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		for {
+			node := &yaml.Node{}
+			err := decoder.Decode(node)
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			o := overlay{origin: resourceOrigin{file: file, line: node.Line}}
+			if len(node.Content) > 0 {
+				o.origin.line = node.Content[0].Line
+			}
+			if err == nil && vars != nil {
+				if err := vars.ResolveNode(node); err != nil {
+					return nil, &fileError{o.origin, fmt.Errorf("resolve references in overlay %s: %w", file, err)}
+				}
+			}
+			if err == nil {
+				err = node.Decode(&o.values)
+			}
+			if err != nil {
+				return nil, &fileError{o.origin, fmt.Errorf("overlay %s is malformed, failed to unmarshal yaml: %w", file, err)}
+			}
+
+			meta := model.ResourceMeta{}
+			_ = node.Decode(&meta)
+			o.kind, o.name = meta.Kind, meta.Metadata.Name
+			if o.kind == "" || o.name == "" {
+				return nil, &fileError{o.origin, fmt.Errorf("overlay %s must set kind and metadata.name of the resource it overlays", file)}
+			}
+			overlays = append(overlays, o)
+		}
+	}
+	return overlays, nil
+}
+
+// mergeOverlay returns the resource with the overlay values deep merged
+// into it
+func mergeOverlay(resource *model.AnyResource, values map[string]any) (*model.AnyResource, error) {
+	return transformResource(resource, func(doc map[string]any) (any, error) {
+		return deepMerge(doc, values), nil
+	})
+}
+
+// deepMerge merges overlay into base. Maps are merged recursively, a null
+// value removes the key, and other values replace the base value. Lists
+// of named items, such as parameters and processors, are merged by name,
+// so an overlay only lists the items it changes or adds. Other lists are
+// replaced.
+func deepMerge(base, overlay map[string]any) map[string]any {
+	for k, v := range overlay {
+		if v == nil {
+			delete(base, k)
+			continue
+		}
+
+		switch v := v.(type) {
+		case map[string]any:
+			if b, ok := base[k].(map[string]any); ok {
+				base[k] = deepMerge(b, v)
+				continue
+			}
+		case []any:
+			if b, ok := base[k].([]any); ok && isNamedList(b) && isNamedList(v) {
+				base[k] = mergeNamedList(b, v)
+				continue
+			}
+		}
+		base[k] = v
+	}
+	return base
+}
+
+// isNamedList returns true if every item in list is a map with a name
+func isNamedList(list []any) bool {
+	for _, item := range list {
+		m, ok := item.(map[string]any)
+		if !ok {
+			return false
+		}
+		if _, ok := m["name"].(string); !ok {
+			return false
+		}
+	}
+	return len(list) > 0
+}
+
+// mergeNamedList merges each overlay item into the base item with the
+// same name, and appends items which are not in base
+func mergeNamedList(base, overlay []any) []any {
+	for _, item := range overlay {
+		o := item.(map[string]any)
+		i := slices.IndexFunc(base, func(b any) bool {
+			return b.(map[string]any)["name"] == o["name"]
+		})
+		if i < 0 {
+			base = append(base, o)
+			continue
+		}
+		base[i] = deepMerge(base[i].(map[string]any), o)
+	}
+	return base
+}
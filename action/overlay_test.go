@@ -0,0 +1,122 @@
+package action
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeepMerge(t *testing.T) {
+	cases := []struct {
+		name    string
+		base    map[string]any
+		overlay map[string]any
+		expect  map[string]any
+	}{
+		{
+			"nested maps",
+			map[string]any{"spec": map[string]any{"type": "otlp", "labels": map[string]any{"a": "1"}}},
+			map[string]any{"spec": map[string]any{"labels": map[string]any{"b": "2"}}},
+			map[string]any{"spec": map[string]any{"type": "otlp", "labels": map[string]any{"a": "1", "b": "2"}}},
+		},
+		{
+			"null removes",
+			map[string]any{"spec": map[string]any{"type": "otlp", "disabled": true}},
+			map[string]any{"spec": map[string]any{"disabled": nil}},
+			map[string]any{"spec": map[string]any{"type": "otlp"}},
+		},
+		{
+			"named list",
+			map[string]any{"parameters": []any{
+				map[string]any{"name": "rate", "value": 10},
+				map[string]any{"name": "host", "value": "a"},
+			}},
+			map[string]any{"parameters": []any{
+				map[string]any{"name": "rate", "value": 100},
+				map[string]any{"name": "port", "value": 4317},
+			}},
+			map[string]any{"parameters": []any{
+				map[string]any{"name": "rate", "value": 100},
+				map[string]any{"name": "host", "value": "a"},
+				map[string]any{"name": "port", "value": 4317},
+			}},
+		},
+		{
+			"list replaced",
+			map[string]any{"selector": []any{"a", "b"}},
+			map[string]any{"selector": []any{"c"}},
+			map[string]any{"selector": []any{"c"}},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expect, deepMerge(tc.base, tc.overlay))
+		})
+	}
+}
+
+func TestLoadResourcesOverlays(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name, content string) {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o750))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+
+	writeFile("processors.yaml", `apiVersion: bindplane.observiq.com/v1
+kind: Processor
+metadata:
+  name: sampler
+spec:
+  type: probabilistic_sampler
+  parameters:
+    - name: drop_ratio
+      value: "50"
+    - name: sampling_method
+      value: hash
+`)
+	writeFile("overlays/prod/processors.yaml", `apiVersion: bindplane.observiq.com/v1
+kind: Processor
+metadata:
+  name: sampler
+spec:
+  parameters:
+    - name: drop_ratio
+      value: "90"
+`)
+	writeFile("overlays/dev/processors.yaml", `kind: Processor
+metadata:
+  name: missing
+`)
+
+	newAction := func(environment string) *Action {
+		return newTestAction(t, http.NewServeMux(),
+			WithProcessorPath(filepath.Join(dir, "processors.yaml")),
+			WithOverlaysDir(filepath.Join(dir, "overlays")),
+			WithEnvironment(environment),
+		)
+	}
+
+	a := newAction("prod")
+	require.NoError(t, a.LoadResources())
+	require.Equal(t, []any{
+		map[string]any{"name": "drop_ratio", "value": "90"},
+		map[string]any{"name": "sampling_method", "value": "hash"},
+	}, a.resources[model.KindProcessor][0].Spec["parameters"])
+
+	// Environments without a directory use the base resources
+	a = newAction("staging")
+	require.NoError(t, a.LoadResources())
+	require.Equal(t, "50", a.resources[model.KindProcessor][0].Spec["parameters"].([]any)[0].(map[string]any)["value"])
+
+	a = newAction("dev")
+	require.EqualError(t, a.LoadResources(), "overlays: overlay "+filepath.Join(dir, "overlays/dev/processors.yaml")+": Processor/missing is not a resource in the repository")
+
+	a = newAction("")
+	require.EqualError(t, a.LoadResources(), "overlays: an environment is required when an overlays directory is set")
+}
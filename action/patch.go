@@ -0,0 +1,362 @@
+package action
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/observiq/bindplane-op-action/action/catalog"
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// patch targets a resource by kind and name with a strategic merge
+// patch, JSON 6902 operations, or both. The merge is applied first.
+type patch struct {
+	Target struct {
+		Kind string `yaml:"kind"`
+		Name string `yaml:"name"`
+	} `yaml:"target"`
+	Merge      map[string]any   `yaml:"merge"`
+	Operations []patchOperation `yaml:"operations"`
+
+	origin resourceOrigin
+}
+
+// patchOperation is a JSON 6902 operation
+type patchOperation struct {
+	Op    string `yaml:"op"`
+	Path  string `yaml:"path"`
+	From  string `yaml:"from"`
+	Value any    `yaml:"value"`
+}
+
+// applyPatches applies the patches in the patch files to the loaded
+// resources. Patches are applied in memory, the resource files are
+// not modified.
+func (a *Action) applyPatches(resources map[model.Kind][]*model.AnyResource) error {
+	if a.patchesPath == "" {
+		return nil
+	}
+
+	patches, err := decodePatchFiles(a.patchesPath, a.catalog)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range patches {
+		kind := model.Kind(p.Target.Kind)
+		i := slices.IndexFunc(resources[kind], func(r *model.AnyResource) bool {
+			return r.Metadata.Name == p.Target.Name
+		})
+		if i < 0 {
+			return &fileError{
+				p.origin,
+				fmt.Errorf("patch %s: %s/%s is not a resource in the repository", p.origin.file, p.Target.Kind, p.Target.Name),
+			}
+		}
+
+		patched, err := transformResource(resources[kind][i], p.apply)
+		if err != nil {
+			return &fileError{p.origin, fmt.Errorf("patch %s: %s/%s: %w", p.origin.file, p.Target.Kind, p.Target.Name, err)}
+		}
+		if patched.Kind != p.Target.Kind || patched.Metadata.Name != p.Target.Name {
+			return &fileError{p.origin, fmt.Errorf("patch %s: %s/%s: patches cannot change the kind or name of a resource", p.origin.file, p.Target.Kind, p.Target.Name)}
+		}
+		resources[kind][i] = patched
+		a.loaderLogger().Debug("Applied patch", zap.String("kind", p.Target.Kind), zap.String("name", p.Target.Name), zap.String("file", p.origin.file))
+	}
+	return nil
+}
+
+// apply applies the merge and then the operations to doc
+func (p patch) apply(doc map[string]any) (any, error) {
+	var v any = deepMerge(doc, p.Merge)
+	for i, op := range p.Operations {
+		var err error
+		v, err = op.apply(v)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+	return v, nil
+}
+
+// decodePatchFiles decodes the patches in every file matching path,
+// resolving variable and secret references
+func decodePatchFiles(path string, vars *catalog.Catalog) ([]patch, error) {
+	matches, err := filepath.Glob(path) // #nosec G304 user defined filepath
+	if err != nil {
+		return nil, fmt.Errorf("glob path %s: %w", path, err)
+	}
+	if matches == nil {
+		return nil, fmt.Errorf("no matching files found when globbing %s", path)
+	}
+
+	patches := []patch{}
+	for _, file := range matches {
+		data, err := os.ReadFile(file) // #nosec G304 user defined filepath
+		if err != nil {
+			return nil, fmt.Errorf("read patch %s: %w", file, err)
+		}
+
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		for {
+			node := &yaml.Node{}
+			err := decoder.Decode(node)
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			p := patch{origin: resourceOrigin{file: file, line: node.Line}}
+			if len(node.Content) > 0 {
+				p.origin.line = node.Content[0].Line
+			}
+			if err == nil && vars != nil {
+				if err := vars.ResolveNode(node); err != nil {
+					return nil, &fileError{p.origin, fmt.Errorf("resolve references in patch %s: %w", file, err)}
+				}
+			}
+			if err == nil {
+				err = node.Decode(&p)
+			}
+			if err != nil {
+				return nil, &fileError{p.origin, fmt.Errorf("patch %s is malformed, failed to unmarshal yaml: %w", file, err)}
+			}
+			if err := p.validate(); err != nil {
+				return nil, &fileError{p.origin, fmt.Errorf("patch %s: %w", file, err)}
+			}
+			patches = append(patches, p)
+		}
+	}
+	return patches, nil
+}
+
+// validate returns an error if the patch does not have a target or
+// any changes, or has an unsupported operation
+func (p patch) validate() error {
+	if p.Target.Kind == "" || p.Target.Name == "" {
+		return errors.New("target must set the kind and name of the resource to patch")
+	}
+	if p.Merge == nil && len(p.Operations) == 0 {
+		return errors.New("merge or operations is required")
+	}
+	for i, op := range p.Operations {
+		switch op.Op {
+		case "add", "remove", "replace", "move", "copy", "test":
+		default:
+			return fmt.Errorf("operation %d has unsupported op %q, must be one of add, remove, replace, move, copy, or test", i, op.Op)
+		}
+	}
+	return nil
+}
+
+// apply applies the JSON 6902 operation to doc and returns the result
+func (op patchOperation) apply(doc any) (any, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add", "replace", "remove":
+		return patchAt(doc, path, op.Op, op.Value)
+	case "test":
+		v, err := valueAt(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(v, op.Value) {
+			return nil, fmt.Errorf("value is %v, not %v", v, op.Value)
+		}
+		return doc, nil
+	}
+
+	// move and copy
+	from, err := parsePointer(op.From)
+	if err != nil {
+		return nil, err
+	}
+	v, err := valueAt(doc, from)
+	if err != nil {
+		return nil, fmt.Errorf("from %s: %w", op.From, err)
+	}
+	if op.Op == "move" {
+		if doc, err = patchAt(doc, from, "remove", nil); err != nil {
+			return nil, fmt.Errorf("from %s: %w", op.From, err)
+		}
+	} else {
+		v = copyValue(v)
+	}
+	return patchAt(doc, path, "add", v)
+}
+
+// parsePointer splits a JSON pointer, such as /spec/parameters/0, into
+// its unescaped tokens
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("path %q must start with /", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(t)
+	}
+	return tokens, nil
+}
+
+// patchAt adds, replaces, or removes the value at path in doc, and
+// returns the updated doc
+func patchAt(doc any, path []string, op string, value any) (any, error) {
+	if len(path) == 0 {
+		if op == "remove" {
+			return nil, errors.New("the resource cannot be removed")
+		}
+		return value, nil
+	}
+	token, rest := path[0], path[1:]
+
+	switch d := doc.(type) {
+	case map[string]any:
+		child, ok := d[token]
+		if len(rest) > 0 || op != "add" {
+			if !ok {
+				return nil, fmt.Errorf("%s does not exist", token)
+			}
+		}
+		switch {
+		case len(rest) > 0:
+			v, err := patchAt(child, rest, op, value)
+			if err != nil {
+				return nil, err
+			}
+			d[token] = v
+		case op == "remove":
+			delete(d, token)
+		default:
+			d[token] = value
+		}
+		return d, nil
+
+	case []any:
+		if len(rest) == 0 && op == "add" {
+			if token == "-" {
+				return append(d, value), nil
+			}
+			i, err := listIndex(token, len(d)+1)
+			if err != nil {
+				return nil, err
+			}
+			return slices.Insert(d, i, value), nil
+		}
+
+		i, err := listIndex(token, len(d))
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case len(rest) > 0:
+			v, err := patchAt(d[i], rest, op, value)
+			if err != nil {
+				return nil, err
+			}
+			d[i] = v
+		case op == "remove":
+			return slices.Delete(d, i, i+1), nil
+		default:
+			d[i] = value
+		}
+		return d, nil
+	}
+	return nil, fmt.Errorf("%s does not exist", token)
+}
+
+// valueAt returns the value at path in doc
+func valueAt(doc any, path []string) (any, error) {
+	for _, token := range path {
+		switch d := doc.(type) {
+		case map[string]any:
+			v, ok := d[token]
+			if !ok {
+				return nil, fmt.Errorf("%s does not exist", token)
+			}
+			doc = v
+		case []any:
+			i, err := listIndex(token, len(d))
+			if err != nil {
+				return nil, err
+			}
+			doc = d[i]
+		default:
+			return nil, fmt.Errorf("%s does not exist", token)
+		}
+	}
+	return doc, nil
+}
+
+// listIndex parses a list index, which must be less than n
+func listIndex(token string, n int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i >= n {
+		return 0, fmt.Errorf("index %s is out of range", token)
+	}
+	return i, nil
+}
+
+// copyValue returns a deep copy of a value decoded from yaml
+func copyValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for k, item := range v {
+			m[k] = copyValue(item)
+		}
+		return m
+	case []any:
+		l := make([]any, len(v))
+		for i, item := range v {
+			l[i] = copyValue(item)
+		}
+		return l
+	}
+	return v
+}
+
+// transformResource converts the resource to yaml values, transforms
+// them, and decodes the result back into a resource
+func transformResource(resource *model.AnyResource, transform func(map[string]any) (any, error)) (*model.AnyResource, error) {
+	data, err := yaml.Marshal(resource)
+	if err != nil {
+		return nil, fmt.Errorf("marshal resource: %w", err)
+	}
+	doc := map[string]any{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("unmarshal resource: %w", err)
+	}
+
+	v, err := transform(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err = yaml.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("marshal patched resource: %w", err)
+	}
+	patched := &model.AnyResource{}
+	if err := yaml.Unmarshal(data, patched); err != nil {
+		return nil, fmt.Errorf("unmarshal patched resource: %w", err)
+	}
+	return patched, nil
+}
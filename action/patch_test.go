@@ -0,0 +1,197 @@
+package action
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPatchOperation(t *testing.T) {
+	newDoc := func() any {
+		return map[string]any{
+			"spec": map[string]any{
+				"type": "probabilistic_sampler",
+				"parameters": []any{
+					map[string]any{"name": "drop_ratio", "value": "50"},
+				},
+			},
+		}
+	}
+
+	cases := []struct {
+		name      string
+		op        patchOperation
+		expect    any
+		expectErr string
+	}{
+		{
+			"replace",
+			patchOperation{Op: "replace", Path: "/spec/parameters/0/value", Value: "90"},
+			map[string]any{"spec": map[string]any{"type": "probabilistic_sampler", "parameters": []any{
+				map[string]any{"name": "drop_ratio", "value": "90"},
+			}}},
+			"",
+		},
+		{
+			"add to end of list",
+			patchOperation{Op: "add", Path: "/spec/parameters/-", Value: map[string]any{"name": "seed", "value": 1}},
+			map[string]any{"spec": map[string]any{"type": "probabilistic_sampler", "parameters": []any{
+				map[string]any{"name": "drop_ratio", "value": "50"},
+				map[string]any{"name": "seed", "value": 1},
+			}}},
+			"",
+		},
+		{
+			"insert",
+			patchOperation{Op: "add", Path: "/spec/parameters/0", Value: "first"},
+			map[string]any{"spec": map[string]any{"type": "probabilistic_sampler", "parameters": []any{
+				"first",
+				map[string]any{"name": "drop_ratio", "value": "50"},
+			}}},
+			"",
+		},
+		{
+			"remove",
+			patchOperation{Op: "remove", Path: "/spec/parameters/0"},
+			map[string]any{"spec": map[string]any{"type": "probabilistic_sampler", "parameters": []any{}}},
+			"",
+		},
+		{
+			"move",
+			patchOperation{Op: "move", From: "/spec/type", Path: "/spec/kind"},
+			map[string]any{"spec": map[string]any{"kind": "probabilistic_sampler", "parameters": []any{
+				map[string]any{"name": "drop_ratio", "value": "50"},
+			}}},
+			"",
+		},
+		{
+			"test",
+			patchOperation{Op: "test", Path: "/spec/type", Value: "probabilistic_sampler"},
+			newDoc(),
+			"",
+		},
+		{
+			"test failed",
+			patchOperation{Op: "test", Path: "/spec/type", Value: "batch"},
+			nil,
+			"value is probabilistic_sampler, not batch",
+		},
+		{
+			"replace missing",
+			patchOperation{Op: "replace", Path: "/spec/disabled", Value: true},
+			nil,
+			"disabled does not exist",
+		},
+		{
+			"index out of range",
+			patchOperation{Op: "replace", Path: "/spec/parameters/1/value", Value: "90"},
+			nil,
+			"index 1 is out of range",
+		},
+		{
+			"escaped",
+			patchOperation{Op: "add", Path: "/spec/a~1b", Value: "c"},
+			map[string]any{"spec": map[string]any{"type": "probabilistic_sampler", "a/b": "c", "parameters": []any{
+				map[string]any{"name": "drop_ratio", "value": "50"},
+			}}},
+			"",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := tc.op.apply(newDoc())
+			if tc.expectErr != "" {
+				require.EqualError(t, err, tc.expectErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, out)
+		})
+	}
+}
+
+func TestLoadResourcesPatches(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+
+	writeFile("processors.yaml", `apiVersion: bindplane.observiq.com/v1
+kind: Processor
+metadata:
+  name: sampler
+spec:
+  type: probabilistic_sampler
+  parameters:
+    - name: drop_ratio
+      value: "50"
+    - name: sampling_method
+      value: hash
+`)
+	writeFile("prod.yaml", `target:
+  kind: Processor
+  name: sampler
+merge:
+  metadata:
+    labels:
+      env: prod
+operations:
+  - op: test
+    path: /spec/parameters/0/name
+    value: drop_ratio
+  - op: replace
+    path: /spec/parameters/0/value
+    value: "90"
+`)
+	writeFile("missing.yaml", `target:
+  kind: Processor
+  name: missing
+merge:
+  spec: {}
+`)
+	writeFile("rename.yaml", `target:
+  kind: Processor
+  name: sampler
+operations:
+  - op: replace
+    path: /metadata/name
+    value: other
+`)
+	writeFile("invalid.yaml", `target:
+  kind: Processor
+  name: sampler
+operations:
+  - op: increment
+    path: /spec
+`)
+
+	newAction := func(patches string) *Action {
+		return newTestAction(t, http.NewServeMux(),
+			WithProcessorPath(filepath.Join(dir, "processors.yaml")),
+			WithPatchesPath(filepath.Join(dir, patches)),
+		)
+	}
+
+	a := newAction("prod.yaml")
+	require.NoError(t, a.LoadResources())
+	processor := a.resources[model.KindProcessor][0]
+	require.Equal(t, map[string]string{"env": "prod"}, processor.Metadata.Labels)
+	require.Equal(t, []any{
+		map[string]any{"name": "drop_ratio", "value": "90"},
+		map[string]any{"name": "sampling_method", "value": "hash"},
+	}, processor.Spec["parameters"])
+
+	a = newAction("missing.yaml")
+	require.EqualError(t, a.LoadResources(), "patches: patch "+filepath.Join(dir, "missing.yaml")+": Processor/missing is not a resource in the repository")
+
+	a = newAction("rename.yaml")
+	require.EqualError(t, a.LoadResources(), "patches: patch "+filepath.Join(dir, "rename.yaml")+": Processor/sampler: patches cannot change the kind or name of a resource")
+
+	a = newAction("invalid.yaml")
+	require.EqualError(t, a.LoadResources(), "patches: patch "+filepath.Join(dir, "invalid.yaml")+`: operation 0 has unsupported op "increment", must be one of add, remove, replace, move, copy, or test`)
+}
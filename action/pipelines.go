@@ -0,0 +1,252 @@
+package action
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/observiq/bindplane-op-action/internal/workflow"
+	"github.com/observiq/bindplane-op-action/pkg/client"
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"go.uber.org/zap"
+)
+
+// pipelineComponent is a source or destination of a configuration
+type pipelineComponent struct {
+	// description identifies the component in errors, such as
+	// source otlp or destination type logging
+	description string
+	typ         string
+}
+
+// pipelineChecker resolves the resources referenced by configurations,
+// from the loaded resources or the BindPlane server
+type pipelineChecker struct {
+	a *Action
+
+	// telemetry is the telemetry types of each source and destination type
+	telemetry map[model.Kind]map[string][]string
+
+	// server caches resources fetched from the BindPlane server,
+	// nil if the resource does not exist
+	server map[string]*model.AnyResource
+}
+
+// newPipelineChecker returns a pipelineChecker of the loaded resources
+func (a *Action) newPipelineChecker() *pipelineChecker {
+	return &pipelineChecker{a: a, server: map[string]*model.AnyResource{}}
+}
+
+// CheckReferences returns an error if a configuration references a
+// source, processor, or destination by name which is not loaded and does
+// not exist on the BindPlane server, so broken references fail before
+// apply instead of as invalid statuses. Each missing reference is
+// annotated on the configuration file. Raw configurations are not checked.
+func (a *Action) CheckReferences() error {
+	configurations, err := a.pipelineConfigurations()
+	if err != nil {
+		return err
+	}
+
+	p := a.newPipelineChecker()
+	errs := []error{}
+	for _, c := range configurations {
+		_, refErrs := p.components(c)
+		for _, err := range refErrs {
+			a.annotateResource(workflow.Error, c.Kind, c.Metadata.Name, "Missing reference", err.Error())
+			errs = append(errs, err)
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	a.Logger.Debug("All configuration references exist", zap.Int("configurations", len(configurations)))
+	return nil
+}
+
+// CheckPipelines returns an error if a configuration's source sends
+// telemetry which none of the configuration's destinations accept. Each
+// error is annotated on the configuration file. Configurations with
+// missing references are reported by CheckReferences, and are skipped.
+func (a *Action) CheckPipelines() error {
+	configurations, err := a.pipelineConfigurations()
+	if err != nil {
+		return err
+	}
+	if len(configurations) == 0 {
+		return nil
+	}
+
+	sourceTypes, err := a.client.SourceTypes(a.ctx)
+	if err != nil {
+		return fmt.Errorf("get source types: %w", err)
+	}
+	destinationTypes, err := a.client.DestinationTypes(a.ctx)
+	if err != nil {
+		return fmt.Errorf("get destination types: %w", err)
+	}
+
+	p := a.newPipelineChecker()
+	p.telemetry = map[model.Kind]map[string][]string{
+		model.KindSource:      telemetryTypes(sourceTypes),
+		model.KindDestination: telemetryTypes(destinationTypes),
+	}
+
+	errs := []error{}
+	for _, c := range configurations {
+		for _, err := range p.check(c) {
+			a.annotateResource(workflow.Error, c.Kind, c.Metadata.Name, "Invalid pipeline", err.Error())
+			errs = append(errs, err)
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	a.Logger.Debug("All configuration pipelines are valid", zap.Int("configurations", len(configurations)))
+	return nil
+}
+
+// pipelineConfigurations returns the loaded configurations which are
+// not raw, sorted by name
+func (a *Action) pipelineConfigurations() ([]*model.AnyResource, error) {
+	if a.resources == nil {
+		if err := a.LoadResources(); err != nil {
+			return nil, fmt.Errorf("load resources: %w", err)
+		}
+	}
+
+	configurations := []*model.AnyResource{}
+	for _, c := range a.resources[model.KindConfiguration] {
+		if raw, _ := c.Spec["raw"].(string); raw == "" {
+			configurations = append(configurations, c)
+		}
+	}
+	sort.SliceStable(configurations, func(i, j int) bool {
+		return configurations[i].Metadata.Name < configurations[j].Metadata.Name
+	})
+	return configurations, nil
+}
+
+// components resolves the sources and destinations of a configuration,
+// and returns an error for each missing source, processor, or destination
+func (p *pipelineChecker) components(c *model.AnyResource) (map[model.Kind][]pipelineComponent, []error) {
+	name := c.Metadata.Name
+	errs := []error{}
+
+	components := map[model.Kind][]pipelineComponent{}
+	for _, kind := range []model.Kind{model.KindSource, model.KindDestination} {
+		items, _ := c.Spec[strings.ToLower(string(kind))+"s"].([]any)
+		for _, item := range items {
+			m, _ := item.(map[string]any)
+			component, err := p.component(kind, m)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("configuration %s: %w", name, err))
+				continue
+			}
+			components[kind] = append(components[kind], component)
+
+			processors, _ := m["processors"].([]any)
+			for _, proc := range processors {
+				pm, _ := proc.(map[string]any)
+				if _, err := p.component(model.KindProcessor, pm); err != nil {
+					errs = append(errs, fmt.Errorf("configuration %s %s: %w", name, component.description, err))
+				}
+			}
+		}
+	}
+	return components, errs
+}
+
+// check returns the telemetry errors of a configuration's pipelines
+func (p *pipelineChecker) check(c *model.AnyResource) []error {
+	components, errs := p.components(c)
+	if len(errs) > 0 || len(components[model.KindDestination]) == 0 {
+		return nil
+	}
+
+	// Telemetry is only compared when the telemetry
+	// types of every destination are known
+	accepted := []string{}
+	for _, d := range components[model.KindDestination] {
+		types := p.telemetry[model.KindDestination][d.typ]
+		if len(types) == 0 {
+			return nil
+		}
+		accepted = append(accepted, types...)
+	}
+
+	for _, s := range components[model.KindSource] {
+		sends := p.telemetry[model.KindSource][s.typ]
+		if len(sends) == 0 {
+			continue
+		}
+		if !slices.ContainsFunc(sends, func(t string) bool { return slices.Contains(accepted, t) }) {
+			errs = append(errs, fmt.Errorf("configuration %s %s sends %s, which none of its destinations accept", c.Metadata.Name, s.description, strings.Join(sends, ", ")))
+		}
+	}
+	return errs
+}
+
+// component resolves a source, processor, or destination of a
+// configuration. Library resources are referenced by name, with an
+// optional version, and inline components by type.
+func (p *pipelineChecker) component(kind model.Kind, m map[string]any) (pipelineComponent, error) {
+	label := strings.ToLower(string(kind))
+
+	ref, _ := m["name"].(string)
+	if ref == "" {
+		typ, _ := m["type"].(string)
+		return pipelineComponent{description: fmt.Sprintf("%s type %s", label, typ), typ: typ}, nil
+	}
+
+	name, _, _ := strings.Cut(ref, ":")
+	r, err := p.resource(kind, name)
+	if err != nil {
+		return pipelineComponent{}, err
+	}
+	if r == nil {
+		return pipelineComponent{}, fmt.Errorf("%s %s is not in the repository or on the BindPlane server", label, ref)
+	}
+
+	typ, _ := r.Spec["type"].(string)
+	return pipelineComponent{description: fmt.Sprintf("%s %s", label, name), typ: typ}, nil
+}
+
+// resource returns a loaded resource, or a resource on the server if
+// it is not loaded. Nil is returned if the resource does not exist.
+func (p *pipelineChecker) resource(kind model.Kind, name string) (*model.AnyResource, error) {
+	for _, r := range p.a.resources[kind] {
+		if r.Metadata.Name == name {
+			return r, nil
+		}
+	}
+
+	key := resourceKey(string(kind), name)
+	if r, ok := p.server[key]; ok {
+		return r, nil
+	}
+
+	r, err := p.a.client.GetResource(p.a.ctx, kind, name)
+	if err != nil && !errors.Is(err, client.ErrNotFound) {
+		return nil, fmt.Errorf("get %s %s: %w", strings.ToLower(string(kind)), name, err)
+	}
+	p.server[key] = r
+	return r, nil
+}
+
+// telemetryTypes returns the lowercase telemetry types of each resource type
+func telemetryTypes(types []*model.ResourceType) map[string][]string {
+	telemetry := make(map[string][]string, len(types))
+	for _, t := range types {
+		for _, telemetryType := range t.Spec.TelemetryTypes {
+			telemetry[t.Metadata.Name] = append(telemetry[t.Metadata.Name], strings.ToLower(telemetryType))
+		}
+	}
+	return telemetry
+}
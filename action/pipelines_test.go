@@ -0,0 +1,170 @@
+package action
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/observiq/bindplane-op-action/internal/workflow"
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckReferencesAndPipelines(t *testing.T) {
+	typesHandler := func(field string, types map[string][]string) http.HandlerFunc {
+		return func(w http.ResponseWriter, _ *http.Request) {
+			list := []*model.ResourceType{}
+			for name, telemetry := range types {
+				rt := &model.ResourceType{}
+				rt.Metadata.Name = name
+				rt.Spec.TelemetryTypes = telemetry
+				list = append(list, rt)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]any{field: list})
+		}
+	}
+
+	dir := t.TempDir()
+	destinations := filepath.Join(dir, "destinations.yaml")
+	require.NoError(t, os.WriteFile(destinations, []byte(`apiVersion: bindplane.observiq.com/v1
+kind: Destination
+metadata:
+  name: prometheus
+spec:
+  type: prometheus_remote_write
+`), 0o600))
+
+	cases := []struct {
+		name          string
+		configuration string
+		expectErr     string
+		expectTitle   string
+	}{
+		{
+			"valid",
+			`
+  sources:
+    - type: otlp
+      processors:
+        - name: batch
+    - name: shared-filelog:2
+  destinations:
+    - name: prometheus
+    - type: logging
+`,
+			"",
+			"",
+		},
+		{
+			"missing references",
+			`
+  sources:
+    - name: missing-source
+    - type: otlp
+      processors:
+        - name: missing-processor
+  destinations:
+    - name: missing-destination:3
+`,
+			"configuration gateway: source missing-source is not in the repository or on the BindPlane server\n" +
+				"configuration gateway source type otlp: processor missing-processor is not in the repository or on the BindPlane server\n" +
+				"configuration gateway: destination missing-destination:3 is not in the repository or on the BindPlane server",
+			"Missing reference",
+		},
+		{
+			"incompatible telemetry",
+			`
+  sources:
+    - type: otlp
+    - name: shared-filelog
+  destinations:
+    - name: prometheus
+`,
+			"configuration gateway source shared-filelog sends logs, which none of its destinations accept",
+			"Invalid pipeline",
+		},
+		{
+			"unknown telemetry",
+			`
+  sources:
+    - name: shared-filelog
+  destinations:
+    - type: custom
+`,
+			"",
+			"",
+		},
+		{
+			"raw",
+			`
+  raw: "receivers: {}"
+  sources:
+    - name: missing-source
+`,
+			"",
+			"",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/v1/source-types", typesHandler("sourceTypes", map[string][]string{
+				"otlp":    {"Logs", "Metrics", "Traces"},
+				"filelog": {"Logs"},
+			}))
+			mux.HandleFunc("/v1/destination-types", typesHandler("destinationTypes", map[string][]string{
+				"prometheus_remote_write": {"Metrics"},
+				"logging":                 {"Logs", "Metrics", "Traces"},
+			}))
+			mux.HandleFunc("GET /v1/sources/{name}", func(w http.ResponseWriter, r *http.Request) {
+				if r.PathValue("name") != "shared-filelog" {
+					http.NotFound(w, r)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"source":{"kind":"Source","metadata":{"name":"shared-filelog"},"spec":{"type":"filelog"}}}`))
+			})
+			mux.HandleFunc("GET /v1/processors/{name}", func(w http.ResponseWriter, r *http.Request) {
+				if r.PathValue("name") != "batch" {
+					http.NotFound(w, r)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"processor":{"kind":"Processor","metadata":{"name":"batch"},"spec":{"type":"batch"}}}`))
+			})
+			mux.HandleFunc("GET /v1/destinations/{name}", http.NotFound)
+
+			configurations := filepath.Join(t.TempDir(), "configurations.yaml")
+			require.NoError(t, os.WriteFile(configurations, []byte(`apiVersion: bindplane.observiq.com/v1
+kind: Configuration
+metadata:
+  name: gateway
+spec:`+tc.configuration), 0o600))
+
+			buf := &bytes.Buffer{}
+			workflow.Output = buf
+			defer func() { workflow.Output = os.Stdout }()
+
+			a := newTestAction(t, mux,
+				WithDestinationPath(destinations),
+				WithConfigurationPath(configurations),
+			)
+			err := a.CheckReferences()
+			if err == nil {
+				err = a.CheckPipelines()
+			}
+			if tc.expectErr == "" {
+				require.NoError(t, err)
+				require.Empty(t, buf.String())
+				return
+			}
+			require.EqualError(t, err, tc.expectErr)
+			require.Contains(t, buf.String(), "title="+tc.expectTitle+"::")
+		})
+	}
+}
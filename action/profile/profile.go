@@ -0,0 +1,171 @@
+// Package profile implements named deployment targets, such as dev,
+// stage, and prod, which are selected by git ref or by name.
+package profile
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/observiq/bindplane-op-action/action/catalog"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	refPrefixBranch = "refs/heads/"
+	refPrefixTag    = "refs/tags/"
+)
+
+// Profile is a named BindPlane target. Fields which are set override
+// the corresponding action inputs.
+type Profile struct {
+	// Name identifies the profile when selected with the profile input
+	Name string `yaml:"name"`
+
+	// Branches and Tags are glob patterns, matched against the
+	// workflow ref, which select the profile.
+	Branches []string `yaml:"branches"`
+	Tags     []string `yaml:"tags"`
+
+	// Environment is used when resolving variables
+	Environment string `yaml:"environment"`
+
+	// Dir is the directory of the repository the profile's resources
+	// are read from, such as teams/payments. Resource, variables,
+	// overlays, and patches paths are relative to it.
+	Dir string `yaml:"dir"`
+
+	RemoteURL string `yaml:"bindplane_remote_url"`
+	APIKey    string `yaml:"bindplane_api_key"`
+	Username  string `yaml:"bindplane_username"`
+	Password  string `yaml:"bindplane_password"`
+	AccountID string `yaml:"bindplane_account_id"`
+	ProjectID string `yaml:"bindplane_project_id"`
+	TLSCACert string `yaml:"tls_ca_cert"`
+}
+
+// Profiles is a list of profiles
+type Profiles []*Profile
+
+// Load reads and validates a profiles file. Credentials should not be
+// committed to the repository, so ${secret.NAME} references are resolved
+// from the process environment before the file is decoded.
+//
+//	profiles:
+//	  - name: prod
+//	    tags: ["v*"]
+//	    bindplane_remote_url: https://bindplane.corp.net
+//	    bindplane_api_key: ${secret.PROD_API_KEY}
+func Load(path string) (Profiles, error) {
+	data, err := os.ReadFile(path) // #nosec G304 user defined filepath
+	if err != nil {
+		return nil, fmt.Errorf("read profiles file %s: %w", path, err)
+	}
+
+	data, err = catalog.New("", nil).Resolve(data)
+	if err != nil {
+		return nil, fmt.Errorf("profiles file %s: %w", path, err)
+	}
+
+	file := struct {
+		Profiles Profiles `yaml:"profiles"`
+	}{}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("profiles file %s is malformed, failed to unmarshal yaml: %w", path, err)
+	}
+
+	if err := file.Profiles.validate(); err != nil {
+		return nil, fmt.Errorf("profiles file %s: %w", path, err)
+	}
+
+	return file.Profiles, nil
+}
+
+func (p Profiles) validate() error {
+	if len(p) == 0 {
+		return fmt.Errorf("no profiles defined")
+	}
+
+	names := map[string]struct{}{}
+	for i, profile := range p {
+		if profile.Name == "" {
+			return fmt.Errorf("profile %d: name is required", i)
+		}
+		if _, ok := names[profile.Name]; ok {
+			return fmt.Errorf("profile %s: name is not unique", profile.Name)
+		}
+		names[profile.Name] = struct{}{}
+
+		if profile.Dir != "" {
+			dir := path.Clean(filepath.ToSlash(profile.Dir))
+			if path.IsAbs(dir) || dir == ".." || strings.HasPrefix(dir, "../") {
+				return fmt.Errorf("profile %s: dir %s must be a directory in the repository", profile.Name, profile.Dir)
+			}
+		}
+
+		for _, pattern := range append(profile.Branches, profile.Tags...) {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("profile %s: invalid pattern %s: %w", profile.Name, pattern, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Select returns the profiles with the given names. If names is empty,
+// every profile with a branch or tag pattern matching ref is returned.
+// Selecting more than one profile applies the same resources to each.
+func (p Profiles) Select(names []string, ref string) (Profiles, error) {
+	selected := Profiles{}
+
+	if len(names) > 0 {
+		for _, name := range names {
+			profile := p.get(name)
+			if profile == nil {
+				return nil, fmt.Errorf("profile %s is not defined", name)
+			}
+			selected = append(selected, profile)
+		}
+		return selected, nil
+	}
+
+	for _, profile := range p {
+		if profile.matches(ref) {
+			selected = append(selected, profile)
+		}
+	}
+	return selected, nil
+}
+
+func (p Profiles) get(name string) *Profile {
+	for _, profile := range p {
+		if profile.Name == name {
+			return profile
+		}
+	}
+	return nil
+}
+
+// matches returns true if the profile's branch or tag patterns
+// match the ref, such as refs/heads/main or refs/tags/v1.0.0
+func (p *Profile) matches(ref string) bool {
+	patterns := []string{}
+	switch {
+	case strings.HasPrefix(ref, refPrefixBranch):
+		ref = strings.TrimPrefix(ref, refPrefixBranch)
+		patterns = p.Branches
+	case strings.HasPrefix(ref, refPrefixTag):
+		ref = strings.TrimPrefix(ref, refPrefixTag)
+		patterns = p.Tags
+	}
+
+	for _, pattern := range patterns {
+		// Patterns are validated by Load
+		if ok, _ := path.Match(pattern, ref); ok {
+			return true
+		}
+	}
+	return false
+}
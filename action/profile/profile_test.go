@@ -0,0 +1,148 @@
+package profile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func setSecrets(t *testing.T) {
+	t.Setenv("BINDPLANE_SECRET_DEV_API_KEY", "dev-key")
+	t.Setenv("BINDPLANE_SECRET_STAGE_API_KEY", "stage-key")
+	t.Setenv("BINDPLANE_SECRET_PROD_PASSWORD", "prod-password")
+}
+
+func TestLoad(t *testing.T) {
+	setSecrets(t)
+
+	profiles, err := Load("testdata/profiles.yaml")
+	require.NoError(t, err)
+	require.Len(t, profiles, 4)
+	require.Equal(t, "dev-key", profiles[0].APIKey)
+	require.Equal(t, "prod-password", profiles[2].Password)
+}
+
+func TestLoadErrors(t *testing.T) {
+	cases := []struct {
+		name   string
+		path   string
+		errStr string
+	}{
+		{
+			"Missing file",
+			"testdata/missing.yaml",
+			"read profiles file testdata/missing.yaml",
+		},
+		{
+			"Undefined secret",
+			"testdata/profiles.yaml",
+			"undefined references for environment '': secret.DEV_API_KEY, secret.PROD_PASSWORD, secret.STAGE_API_KEY",
+		},
+		{
+			"Duplicate name",
+			"testdata/duplicate.yaml",
+			"profile dev: name is not unique",
+		},
+		{
+			"Dir outside repository",
+			"testdata/dir.yaml",
+			"profile payments: dir ../payments must be a directory in the repository",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Load(tc.path)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.errStr)
+		})
+	}
+}
+
+func TestSelect(t *testing.T) {
+	setSecrets(t)
+
+	profiles, err := Load("testdata/profiles.yaml")
+	require.NoError(t, err)
+
+	cases := []struct {
+		name     string
+		profiles []string
+		ref      string
+		expect   []string
+		errStr   string
+	}{
+		{
+			"Branch",
+			nil,
+			"refs/heads/main",
+			[]string{"stage"},
+			"",
+		},
+		{
+			"Branch glob",
+			nil,
+			"refs/heads/feature/otlp",
+			[]string{"dev"},
+			"",
+		},
+		{
+			"Tag",
+			nil,
+			"refs/tags/v1.2.0",
+			[]string{"prod"},
+			"",
+		},
+		{
+			"Multiple matches",
+			nil,
+			"refs/tags/v2.0.0",
+			[]string{"prod", "prod-eu"},
+			"",
+		},
+		{
+			"Tag does not match branch pattern",
+			nil,
+			"refs/tags/main",
+			[]string{},
+			"",
+		},
+		{
+			"No match",
+			nil,
+			"refs/heads/release",
+			[]string{},
+			"",
+		},
+		{
+			"Names override ref",
+			[]string{"prod", "dev"},
+			"refs/heads/main",
+			[]string{"prod", "dev"},
+			"",
+		},
+		{
+			"Undefined name",
+			[]string{"prod", "qa"},
+			"refs/heads/main",
+			nil,
+			"profile qa is not defined",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			selected, err := profiles.Select(tc.profiles, tc.ref)
+			if tc.errStr != "" {
+				require.EqualError(t, err, tc.errStr)
+				return
+			}
+			require.NoError(t, err)
+			names := []string{}
+			for _, p := range selected {
+				names = append(names, p.Name)
+			}
+			require.Equal(t, tc.expect, names)
+		})
+	}
+}
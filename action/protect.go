@@ -0,0 +1,133 @@
+package action
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/observiq/bindplane-op-action/internal/workflow"
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// protection identifies resources the action must not modify or delete
+type protection struct {
+	// names contains resource names and kind/name pairs, such
+	// as prod-otlp and Destination/prod-otlp
+	names    map[string]struct{}
+	selector labels.Selector
+}
+
+// newProtection parses the protected resource names and label
+// selector. Nil is returned when no resources are protected.
+func newProtection(names []string, selector string) (*protection, error) {
+	if len(names) == 0 && selector == "" {
+		return nil, nil
+	}
+
+	p := &protection{names: map[string]struct{}{}}
+	for _, n := range names {
+		p.names[n] = struct{}{}
+	}
+
+	if selector != "" {
+		s, err := labels.Parse(selector)
+		if err != nil {
+			return nil, fmt.Errorf("parse protected selector: %w", err)
+		}
+		p.selector = s
+	}
+
+	return p, nil
+}
+
+// enabled returns true if any resources are protected
+func (p *protection) enabled() bool {
+	return p != nil && (len(p.names) > 0 || p.selector != nil)
+}
+
+// reason returns why a resource is protected. An empty
+// reason means the resource is not protected.
+func (p *protection) reason(r *model.AnyResource) string {
+	if !p.enabled() || r == nil {
+		return ""
+	}
+
+	if _, ok := p.names[r.Metadata.Name]; ok {
+		return "it is listed in protected_resources"
+	}
+	if _, ok := p.names[r.Kind+"/"+r.Metadata.Name]; ok {
+		return "it is listed in protected_resources"
+	}
+	if p.selector != nil && p.selector.Matches(labels.Set(r.Metadata.Labels)) {
+		return fmt.Sprintf("its labels match protected_selector %s", p.selector)
+	}
+	return ""
+}
+
+// CheckProtected returns an error if applying the repository would create or
+// modify a protected resource. A resource is protected when its name is
+// protected, or when its labels in the repository or on the server match the
+// protected selector. Protected resources which are unchanged can remain in
+// the repository. Each violation is annotated on the resource file.
+func (a *Action) CheckProtected() error {
+	if !a.protection.enabled() {
+		return nil
+	}
+
+	if a.resources == nil {
+		if err := a.LoadResources(); err != nil {
+			return fmt.Errorf("load resources: %w", err)
+		}
+	}
+
+	errs := []error{}
+	for _, f := range a.resourceFiles() {
+		if f.path == "" || len(a.resources[f.kind]) == 0 {
+			continue
+		}
+
+		// Agent versions cannot be listed, so they are protected by name
+		// and by their labels in the repository
+		remote := []*model.AnyResource{}
+		if f.kind != model.KindAgentVersion {
+			r, err := a.client.Resources(a.ctx, f.kind)
+			if err != nil {
+				return fmt.Errorf("get %s: %w", f.label, err)
+			}
+			remote = r
+		}
+
+		remoteByName := map[string]*model.AnyResource{}
+		for _, r := range remote {
+			remoteByName[r.Metadata.Name] = r
+		}
+
+		for _, local := range a.resources[f.kind] {
+			server := remoteByName[local.Metadata.Name]
+
+			reason := a.protection.reason(local)
+			if reason == "" {
+				reason = a.protection.reason(server)
+			}
+			if reason == "" {
+				continue
+			}
+
+			change := "created"
+			if server != nil {
+				fields := diffFields(local, server)
+				if len(fields) == 0 && f.kind != model.KindAgentVersion {
+					continue
+				}
+				change = "modified: " + strings.Join(fields, ", ")
+			}
+
+			message := fmt.Sprintf("%s %s is protected because %s, it cannot be %s", local.Kind, local.Metadata.Name, reason, change)
+			a.annotateResource(workflow.Error, local.Kind, local.Metadata.Name, "Protected resource", message)
+			errs = append(errs, errors.New(message))
+		}
+	}
+
+	return errors.Join(errs...)
+}
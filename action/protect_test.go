@@ -0,0 +1,132 @@
+package action
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/observiq/bindplane-op-action/internal/workflow"
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProtectionReason(t *testing.T) {
+	p, err := newProtection([]string{"shared", "Destination/prod-otlp"}, "tier=production")
+	require.NoError(t, err)
+
+	prodOTLP := testResource(model.KindDestination, "prod-otlp", nil)
+	prodOTLPSource := testResource(model.KindSource, "prod-otlp", nil)
+	labeled := testResource(model.KindConfiguration, "gateway", nil)
+	labeled.Metadata.Labels = map[string]string{"tier": "production"}
+
+	require.Equal(t, "it is listed in protected_resources", p.reason(testResource(model.KindProcessor, "shared", nil)))
+	require.Equal(t, "it is listed in protected_resources", p.reason(prodOTLP))
+	require.Equal(t, "", p.reason(prodOTLPSource))
+	require.Equal(t, "its labels match protected_selector tier=production", p.reason(labeled))
+	require.Equal(t, "", p.reason(nil))
+
+	_, err = newProtection(nil, "tier in (")
+	require.ErrorContains(t, err, "parse protected selector")
+
+	p, err = newProtection(nil, "")
+	require.NoError(t, err)
+	require.Nil(t, p)
+	require.False(t, p.enabled())
+	require.Equal(t, "", p.reason(prodOTLP))
+}
+
+func TestCheckProtected(t *testing.T) {
+	a := &Action{configurationPath: "testdata/configuration.yaml"}
+	require.NoError(t, a.LoadResources())
+	configurations := a.resources[model.KindConfiguration]
+
+	// k8s-cluster is unchanged, k8s-gateway is protected by its server
+	// labels and changed, k8s-node does not exist on the server
+	gateway := *configurations[1]
+	gateway.Metadata.Labels = map[string]string{"tier": "production"}
+	remote := []*model.AnyResource{configurations[0], &gateway}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/configurations", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"configurations": remote})
+	})
+
+	cases := []struct {
+		name      string
+		protected []string
+		selector  string
+		expectErr string
+	}{
+		{
+			"Nothing protected",
+			nil,
+			"",
+			"",
+		},
+		{
+			"Unchanged protected resource",
+			[]string{"k8s-cluster"},
+			"",
+			"",
+		},
+		{
+			"Created protected resource",
+			[]string{"Configuration/k8s-node"},
+			"",
+			"Configuration k8s-node is protected because it is listed in protected_resources, it cannot be created",
+		},
+		{
+			"Modified resource protected by server labels",
+			nil,
+			"tier=production",
+			"Configuration k8s-gateway is protected because its labels match protected_selector tier=production, it cannot be modified: metadata.labels",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			workflow.Output = buf
+			defer func() { workflow.Output = os.Stdout }()
+
+			a := newTestAction(t, mux,
+				WithConfigurationPath("testdata/configuration.yaml"),
+				WithProtectedResources(tc.protected),
+				WithProtectedSelector(tc.selector),
+			)
+			err := a.CheckProtected()
+			if tc.expectErr == "" {
+				require.NoError(t, err)
+				require.Empty(t, buf.String())
+				return
+			}
+			require.EqualError(t, err, tc.expectErr)
+			require.Contains(t, buf.String(), "title=Protected resource::")
+		})
+	}
+}
+
+func TestPruneProtected(t *testing.T) {
+	old := testResource(model.KindConfiguration, "old-config", nil)
+	old.Metadata.Labels = map[string]string{"managed-by": "gitops", "tier": "production"}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/configurations", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"configurations": []*model.AnyResource{old}})
+	})
+	mux.HandleFunc("POST /v1/delete", func(_ http.ResponseWriter, _ *http.Request) {
+		t.Fatal("protected resource deleted")
+	})
+
+	a := newTestAction(t, mux,
+		WithConfigurationPath("testdata/configuration.yaml"),
+		WithPruneSelector("managed-by=gitops"),
+		WithPruneConfirm(true),
+		WithProtectedSelector("tier=production"),
+	)
+	require.EqualError(t, a.Prune(), "refusing to prune protected resources: Configuration old-config is protected because its labels match protected_selector tier=production, it cannot be pruned")
+}
@@ -0,0 +1,118 @@
+package action
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/observiq/bindplane-op-action/internal/workflow"
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Prune deletes resources from the server which are managed by the
+// repository, but no longer exist in it. A resource is managed by the
+// repository when its labels match the prune selector. Only kinds with a
+// configured path are pruned. Unless prune is confirmed, the resources which
+// would be deleted are logged and nothing is deleted.
+func (a *Action) Prune() error {
+	candidates, err := a.pruneCandidates()
+	if err != nil {
+		return err
+	}
+
+	if len(candidates) == 0 {
+		a.Logger.Info("No resources to prune")
+		return nil
+	}
+
+	protected := []error{}
+	for _, r := range candidates {
+		if reason := a.protection.reason(r); reason != "" {
+			protected = append(protected, fmt.Errorf("%s %s is protected because %s, it cannot be pruned", r.Kind, r.Metadata.Name, reason))
+			continue
+		}
+		a.Logger.Info("Resource is not in the repository and will be pruned", zap.String("kind", r.Kind), zap.String("name", r.Metadata.Name))
+	}
+
+	if err := errors.Join(protected...); err != nil {
+		return fmt.Errorf("refusing to prune protected resources: %w", err)
+	}
+
+	if !a.pruneConfirm {
+		message := fmt.Sprintf("%d resources would be deleted, set prune_confirm to delete them", len(candidates))
+		a.Logger.Warn("Prune dry run, no resources were deleted", zap.Int("count", len(candidates)))
+		workflow.Warning("", 0, "Prune dry run", message)
+		return nil
+	}
+
+	statuses, err := a.client.Delete(a.ctx, candidates)
+	if err != nil {
+		return fmt.Errorf("client error: %w", err)
+	}
+
+	errs := []error{}
+	for _, s := range statuses {
+		a.state.AddResourceStatus(*s)
+
+		name := s.Resource.Metadata.Name
+		switch s.Status {
+		case model.StatusDeleted, model.StatusNotFound:
+			a.Logger.Info("Pruned resource", zap.String("kind", s.Resource.Kind), zap.String("name", name), zap.String("status", string(s.Status)))
+		default:
+			errs = append(errs, fmt.Errorf("prune %s %s: %s: %s", s.Resource.Kind, name, s.Status, s.Reason))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// pruneCandidates returns the server resources which match the prune
+// selector but are not in the repository. Resources are ordered so
+// configurations are deleted before the resources they reference.
+func (a *Action) pruneCandidates() ([]*model.AnyResource, error) {
+	if a.pruneSelector == "" {
+		return nil, errors.New("prune requires a label selector")
+	}
+
+	selector, err := labels.Parse(a.pruneSelector)
+	if err != nil {
+		return nil, fmt.Errorf("parse prune selector: %w", err)
+	}
+
+	if a.resources == nil {
+		if err := a.LoadResources(); err != nil {
+			return nil, fmt.Errorf("load resources: %w", err)
+		}
+	}
+
+	files := a.resourceFiles()
+	candidates := []*model.AnyResource{}
+	for i := len(files) - 1; i >= 0; i-- {
+		f := files[i]
+		if f.path == "" || f.kind == model.KindAgentVersion {
+			continue
+		}
+
+		local := map[string]struct{}{}
+		for _, r := range a.resources[f.kind] {
+			local[r.Metadata.Name] = struct{}{}
+		}
+
+		remote, err := a.client.Resources(a.ctx, f.kind)
+		if err != nil {
+			return nil, fmt.Errorf("get %s: %w", f.label, err)
+		}
+
+		for _, r := range remote {
+			if _, ok := local[r.Metadata.Name]; ok {
+				continue
+			}
+			if selector.Matches(labels.Set(r.Metadata.Labels)) {
+				candidates = append(candidates, r)
+			}
+		}
+	}
+
+	return candidates, nil
+}
@@ -0,0 +1,97 @@
+package action
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/observiq/bindplane-op-action/internal/workflow"
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrune(t *testing.T) {
+	managed := func(kind model.Kind, name string) *model.AnyResource {
+		r := testResource(kind, name, nil)
+		r.Metadata.Labels = map[string]string{"managed-by": "gitops"}
+		return r
+	}
+
+	remote := map[string][]*model.AnyResource{
+		"configurations": {
+			managed(model.KindConfiguration, "k8s-node"),
+			managed(model.KindConfiguration, "old-config"),
+			testResource(model.KindConfiguration, "ui-config", nil),
+		},
+		"destinations": {
+			managed(model.KindDestination, "old-destination"),
+		},
+	}
+
+	cases := []struct {
+		name          string
+		confirm       bool
+		expectDeleted []string
+		expectOutput  string
+	}{
+		{
+			"Dry run",
+			false,
+			nil,
+			"::warning title=Prune dry run::1 resources would be deleted, set prune_confirm to delete them\n",
+		},
+		{
+			"Confirmed",
+			true,
+			[]string{"old-config"},
+			"",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var deleted []string
+			mux := http.NewServeMux()
+			mux.HandleFunc("/v1/{kind}", func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_ = json.NewEncoder(w).Encode(map[string]any{r.PathValue("kind"): remote[r.PathValue("kind")]})
+			})
+			mux.HandleFunc("POST /v1/delete", func(w http.ResponseWriter, r *http.Request) {
+				payload := model.ApplyPayload{}
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+				updates := []*model.AnyResourceStatus{}
+				for _, resource := range payload.Resources {
+					deleted = append(deleted, resource.Metadata.Name)
+					updates = append(updates, &model.AnyResourceStatus{Resource: *resource, Status: model.StatusDeleted})
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_ = json.NewEncoder(w).Encode(model.ApplyResponseClientSide{Updates: updates})
+			})
+
+			buf := &bytes.Buffer{}
+			workflow.Output = buf
+			defer func() { workflow.Output = os.Stdout }()
+
+			// Destinations are not pruned because destination_path is not set
+			a := newTestAction(t, mux,
+				WithConfigurationPath("testdata/configuration.yaml"),
+				WithPruneSelector("managed-by=gitops"),
+				WithPruneConfirm(tc.confirm),
+			)
+			require.NoError(t, a.Prune())
+			require.Equal(t, tc.expectDeleted, deleted)
+			require.Equal(t, tc.expectOutput, buf.String())
+			require.Len(t, a.state.ResourceStatuses(), len(tc.expectDeleted))
+		})
+	}
+}
+
+func TestPruneSelector(t *testing.T) {
+	a := &Action{}
+	require.EqualError(t, a.Prune(), "prune requires a label selector")
+
+	WithPruneSelector("managed-by in (")(a)
+	require.ErrorContains(t, a.Prune(), "parse prune selector")
+}
@@ -0,0 +1,26 @@
+package action
+
+import (
+	"go.uber.org/zap"
+)
+
+// SaveRawConfigurations writes the raw OpenTelemetry configuration of each
+// configuration applied or rolled out during the run to <dir>/<name>.yaml,
+// so later steps of the workflow can archive or scan them. Raw
+// configurations are streamed to disk. The file of each configuration is
+// set as the raw_configurations output.
+func (a *Action) SaveRawConfigurations() error {
+	a.rawConfigFiles = map[string]string{}
+	for _, name := range a.outputConfigurationNames() {
+		path, err := exportPath(a.rawConfigDir, name)
+		if err != nil {
+			return err
+		}
+		if err := a.writeRawConfiguration(name, path); err != nil {
+			return err
+		}
+		a.rawConfigFiles[name] = path
+		a.Logger.Info("Raw configuration saved", zap.String("name", name), zap.String("path", path))
+	}
+	return nil
+}
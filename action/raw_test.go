@@ -0,0 +1,55 @@
+package action
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveRawConfigurations(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/configurations/{name}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(model.ConfigurationResponse{Raw: "# " + r.PathValue("name") + "\n"})
+	})
+	mux.HandleFunc("GET /v1/rollouts/{name}/status", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(model.ConfigurationResponse{})
+	})
+
+	dir := filepath.Join(t.TempDir(), "rendered")
+	a := newTestAction(t, mux, WithRawConfigDir(dir))
+
+	outputs, err := a.Outputs()
+	require.NoError(t, err)
+	require.Equal(t, "{}", outputs[OutputRawConfigurations], "the output is set before any configuration is saved")
+
+	for _, name := range []string{"k8s-node", "k8s-gateway"} {
+		a.state.SetConfiguration(name, model.AnyResource{})
+	}
+	require.NoError(t, a.SaveRawConfigurations())
+
+	for _, name := range []string{"k8s-node", "k8s-gateway"} {
+		data, err := os.ReadFile(filepath.Join(dir, name+".yaml"))
+		require.NoError(t, err)
+		require.Equal(t, "# "+name+"\n", string(data))
+	}
+
+	outputs, err = a.Outputs()
+	require.NoError(t, err)
+	files := map[string]string{}
+	require.NoError(t, json.Unmarshal([]byte(outputs[OutputRawConfigurations]), &files))
+	require.Equal(t, map[string]string{
+		"k8s-gateway": filepath.Join(dir, "k8s-gateway.yaml"),
+		"k8s-node":    filepath.Join(dir, "k8s-node.yaml"),
+	}, files)
+
+	outputs, err = newTestAction(t, mux).Outputs()
+	require.NoError(t, err)
+	require.NotContains(t, outputs, OutputRawConfigurations)
+}
@@ -0,0 +1,216 @@
+package action
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/observiq/bindplane-op-action/action/notify"
+	"github.com/observiq/bindplane-op-action/internal/ci"
+	"github.com/observiq/bindplane-op-action/pkg/client"
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+)
+
+// AuditRecord is a machine readable record of every operation performed
+// by a run, written to the audit record file as evidence of the change
+type AuditRecord struct {
+	RemoteURL string         `json:"remote_url"`
+	Started   time.Time      `json:"started"`
+	Finished  time.Time      `json:"finished"`
+	Result    string         `json:"result"`
+	Error     string         `json:"error,omitempty"`
+	CI        ci.Environment `json:"ci"`
+
+	Requests  []client.Request      `json:"requests"`
+	Resources []AuditRecordResource `json:"resources"`
+	Rollouts  []AuditRecordRollout  `json:"rollouts"`
+}
+
+// AuditRecordResource is a resource applied or deleted by the run, with
+// its version on the server before and after the run. Versions are 0 when
+// the resource did not exist, or is not versioned.
+type AuditRecordResource struct {
+	Kind            string `json:"kind"`
+	Name            string `json:"name"`
+	ID              string `json:"id,omitempty"`
+	Status          string `json:"status"`
+	Reason          string `json:"reason,omitempty"`
+	PreviousVersion int    `json:"previous_version"`
+	Version         int    `json:"version"`
+}
+
+// AuditRecordRollout is the outcome of a rollout started or
+// progressed by the run
+type AuditRecordRollout struct {
+	Configuration string                `json:"configuration"`
+	Version       int                   `json:"version"`
+	Status        string                `json:"status"`
+	Progress      model.RolloutProgress `json:"progress"`
+}
+
+// auditRecorder collects the requests and resource versions
+// of a run while the audit record is enabled
+type auditRecorder struct {
+	mu       sync.Mutex
+	requests []client.Request
+
+	// previousVersions are the versions of resources
+	// on the server before they were changed, by kind
+	previousVersions map[model.Kind]map[string]int
+}
+
+// record is the client request recorder
+func (r *auditRecorder) record(req client.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, req)
+}
+
+// setPreviousVersion records the version of a resource before it was
+// changed, unless a previous version was already recorded
+func (r *auditRecorder) setPreviousVersion(kind model.Kind, name string, version int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.previousVersions[kind] == nil {
+		r.previousVersions[kind] = map[string]int{}
+	}
+	if _, ok := r.previousVersions[kind][name]; !ok {
+		r.previousVersions[kind][name] = version
+	}
+}
+
+// snapshotVersions records the server version of every resource of the
+// kinds in the repository, before they are applied or pruned
+func (a *Action) snapshotVersions() error {
+	if a.recorder == nil {
+		return nil
+	}
+
+	for _, f := range a.resourceFiles() {
+		if f.kind == model.KindAgentVersion || (f.path == "" && len(a.resources[f.kind]) == 0) {
+			continue
+		}
+		versions, err := a.resourceVersions(f.kind)
+		if err != nil {
+			return fmt.Errorf("audit record: %w", err)
+		}
+		for name, v := range versions {
+			a.recorder.setPreviousVersion(f.kind, name, v)
+		}
+	}
+	return nil
+}
+
+// resourceVersions returns the server version of every resource of kind, by name
+func (a *Action) resourceVersions(kind model.Kind) (map[string]int, error) {
+	resources, err := a.client.Resources(a.ctx, kind)
+	if err != nil {
+		return nil, fmt.Errorf("get %s versions: %w", kind, err)
+	}
+	versions := map[string]int{}
+	for _, r := range resources {
+		versions[r.Metadata.Name] = r.Metadata.Version
+	}
+	return versions, nil
+}
+
+// AuditRecord returns the audit record of the run. runErr is the error
+// the run failed with, if any. The current version of each changed
+// resource and the outcome of each rollout are read from the server.
+func (a *Action) AuditRecord(runErr error) (*AuditRecord, error) {
+	if a.recorder == nil {
+		return nil, fmt.Errorf("audit record is not enabled: %s", BugError)
+	}
+
+	record := &AuditRecord{
+		RemoteURL: a.config.Network.RemoteURL,
+		Started:   a.startTime.UTC(),
+		Result:    notify.ResultSucceeded,
+		CI:        ci.Detect(),
+		Resources: []AuditRecordResource{},
+		Rollouts:  []AuditRecordRollout{},
+	}
+	if runErr != nil {
+		record.Result = notify.ResultFailed
+		record.Error = runErr.Error()
+	}
+
+	versions := map[model.Kind]map[string]int{}
+	for _, s := range a.state.ResourceStatuses() {
+		kind := model.Kind(s.Resource.Kind)
+		if _, ok := versions[kind]; !ok && kind != model.KindAgentVersion {
+			v, err := a.resourceVersions(kind)
+			if err != nil {
+				return nil, err
+			}
+			versions[kind] = v
+		}
+
+		a.recorder.mu.Lock()
+		previous := a.recorder.previousVersions[kind][s.Resource.Metadata.Name]
+		a.recorder.mu.Unlock()
+
+		record.Resources = append(record.Resources, AuditRecordResource{
+			Kind:            s.Resource.Kind,
+			Name:            s.Resource.Metadata.Name,
+			ID:              s.Resource.Metadata.ID,
+			Status:          string(s.Status),
+			Reason:          s.Reason,
+			PreviousVersion: previous,
+			Version:         versions[kind][s.Resource.Metadata.Name],
+		})
+	}
+
+	names := map[string]struct{}{}
+	for _, name := range append(a.outputConfigurationNames(), a.startedRollouts...) {
+		names[name] = struct{}{}
+	}
+	for name := range names {
+		c, err := a.client.RolloutStatus(a.ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("rollout status %s: %w", name, err)
+		}
+		if c == nil {
+			continue
+		}
+		record.Rollouts = append(record.Rollouts, AuditRecordRollout{
+			Configuration: name,
+			Version:       c.Metadata.Version,
+			Status:        c.Status.Rollout.Status.String(),
+			Progress:      c.Status.Rollout.Progress,
+		})
+	}
+	sort.Slice(record.Rollouts, func(i, j int) bool {
+		return record.Rollouts[i].Configuration < record.Rollouts[j].Configuration
+	})
+
+	// Requests are copied last, so the requests made
+	// to build the record are included
+	a.recorder.mu.Lock()
+	record.Requests = append([]client.Request{}, a.recorder.requests...)
+	a.recorder.mu.Unlock()
+	record.Finished = time.Now().UTC()
+
+	return record, nil
+}
+
+// WriteAuditRecord writes the audit record of the run to the audit record
+// path as indented JSON. runErr is the error the run failed with, if any.
+func (a *Action) WriteAuditRecord(runErr error) error {
+	record, err := a.AuditRecord(runErr)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal audit record: %w", err)
+	}
+	if err := os.WriteFile(a.auditRecordPath, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("write audit record %s: %w", a.auditRecordPath, err)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package action
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/observiq/bindplane-op-action/action/notify"
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteAuditRecord(t *testing.T) {
+	dir := t.TempDir()
+	configurations := filepath.Join(dir, "configurations.yaml")
+	require.NoError(t, os.WriteFile(configurations, []byte(`apiVersion: bindplane.observiq.com/v1
+kind: Configuration
+metadata:
+  name: gateway
+`), 0o600))
+
+	version := 2
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/configurations", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"configurations":[{"kind":"Configuration","metadata":{"name":"gateway","version":%d}}]}`, version)
+	})
+	mux.HandleFunc("/v1/rollouts/{name}/status", func(w http.ResponseWriter, r *http.Request) {
+		c := model.Configuration{}
+		c.Metadata.Name = r.PathValue("name")
+		c.Metadata.Version = version
+		c.Status.Rollout.Status = model.RolloutStatusStable
+		c.Status.Rollout.Progress = model.RolloutProgress{Completed: 4}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(model.ConfigurationResponse{Configuration: &c})
+	})
+
+	path := filepath.Join(dir, "record.json")
+	a := newTestAction(t, mux, WithConfigurationPath(configurations), WithAuditRecordPath(path))
+	require.NoError(t, a.LoadResources())
+	require.NoError(t, a.snapshotVersions())
+
+	// The run applies a new version and rolls it out
+	version = 3
+	s := model.AnyResourceStatus{Status: model.StatusConfigured}
+	s.Resource.Kind = string(model.KindConfiguration)
+	s.Resource.Metadata.Name = "gateway"
+	s.Resource.Metadata.ID = "01H"
+	a.state.AddResourceStatus(s)
+	a.state.SetConfiguration("gateway", s.Resource)
+	a.startedRollouts = []string{"gateway"}
+
+	require.NoError(t, a.WriteAuditRecord(errors.New("rollout failed")))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	record := AuditRecord{}
+	require.NoError(t, json.Unmarshal(data, &record))
+
+	require.Equal(t, a.config.Network.RemoteURL, record.RemoteURL)
+	require.Equal(t, notify.ResultFailed, record.Result)
+	require.Equal(t, "rollout failed", record.Error)
+	require.False(t, record.Finished.Before(record.Started))
+	require.Equal(t, []AuditRecordResource{{
+		Kind:            "Configuration",
+		Name:            "gateway",
+		ID:              "01H",
+		Status:          string(model.StatusConfigured),
+		PreviousVersion: 2,
+		Version:         3,
+	}}, record.Resources)
+	require.Equal(t, []AuditRecordRollout{{
+		Configuration: "gateway",
+		Version:       3,
+		Status:        "stable",
+		Progress:      model.RolloutProgress{Completed: 4},
+	}}, record.Rollouts)
+
+	urls := []string{}
+	for _, r := range record.Requests {
+		require.Equal(t, http.StatusOK, r.StatusCode)
+		urls = append(urls, r.Method+" "+r.URL[len(a.config.Network.RemoteURL):])
+	}
+	require.Equal(t, []string{
+		"GET /v1/configurations",
+		"GET /v1/configurations",
+		"GET /v1/rollouts/gateway/status",
+	}, urls)
+}
+
+func TestAuditRecordDisabled(t *testing.T) {
+	a := newTestAction(t, http.NewServeMux())
+	require.Nil(t, a.recorder)
+	require.NoError(t, a.snapshotVersions())
+}
@@ -0,0 +1,67 @@
+package action
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+)
+
+// remoteTimeout is the timeout for downloading a remote resource file
+const remoteTimeout = 30 * time.Second
+
+// maxRemoteFileSize is the maximum size of a remote resource file
+const maxRemoteFileSize = 10 << 20
+
+// IsRemotePath returns true if a resource path is an http or https URL,
+// which is downloaded rather than read from the workspace
+func IsRemotePath(path string) bool {
+	return strings.HasPrefix(path, "https://") || strings.HasPrefix(path, "http://")
+}
+
+// decodeRemoteResourceFile downloads a resource file from an https URL
+// and decodes it. The remote headers, such as an authorization header,
+// are sent with the request.
+func (a *Action) decodeRemoteResourceFile(url string) ([]*model.AnyResource, []resourceOrigin, error) {
+	if !strings.HasPrefix(url, "https://") {
+		return nil, nil, fmt.Errorf("remote resource file %s must be an https URL", url)
+	}
+
+	req, err := http.NewRequestWithContext(a.ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("create request for %s: %w", url, err)
+	}
+	for k, v := range a.remoteHeaders {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := a.remoteClient.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("download %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode > 299 {
+		return nil, nil, fmt.Errorf("download %s: server returned status %d", url, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxRemoteFileSize+1))
+	if err != nil {
+		return nil, nil, fmt.Errorf("download %s: %w", url, err)
+	}
+	if len(data) > maxRemoteFileSize {
+		return nil, nil, fmt.Errorf("download %s: file is larger than %d bytes", url, maxRemoteFileSize)
+	}
+
+	resources, origins, err := decodeResources(url, url, data, a.catalog)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(resources) == 0 {
+		return nil, nil, fmt.Errorf("no resources found in file: %s", url)
+	}
+	return resources, origins, nil
+}
@@ -0,0 +1,66 @@
+package action
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/observiq/bindplane-op-action/internal/workflow"
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsRemotePath(t *testing.T) {
+	require.True(t, IsRemotePath("https://raw.githubusercontent.com/org/golden/main/configuration.yaml"))
+	require.True(t, IsRemotePath("http://example.com/configuration.yaml"))
+	require.False(t, IsRemotePath("configurations/*.yaml"))
+	require.False(t, IsRemotePath("/workspace/https.yaml"))
+}
+
+func TestLoadRemoteResources(t *testing.T) {
+	configuration, err := os.ReadFile("testdata/configuration.yaml")
+	require.NoError(t, err)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/golden/configuration.yaml", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write(configuration)
+	})
+	mux.HandleFunc("/golden/malformed.yaml", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("kind: [Configuration"))
+	})
+	server := httptest.NewTLSServer(mux)
+	defer server.Close()
+
+	newAction := func(path string, headers map[string]string) *Action {
+		a := newTestAction(t, http.NewServeMux(), WithConfigurationPath(path), WithRemoteResourceHeaders(headers))
+		a.remoteClient = server.Client()
+		return a
+	}
+
+	a := newAction(server.URL+"/golden/configuration.yaml", map[string]string{"Authorization": "Bearer token"})
+	require.NoError(t, a.LoadResources())
+	require.Len(t, a.resources[model.KindConfiguration], 3)
+	require.Equal(t, resourceOrigin{file: server.URL + "/golden/configuration.yaml", line: 2}, a.origins["Configuration/k8s-cluster"])
+
+	a = newAction(server.URL+"/golden/configuration.yaml", nil)
+	require.EqualError(t, a.LoadResources(), "configuration: download "+server.URL+"/golden/configuration.yaml: server returned status 401")
+
+	a = newAction("http://example.com/configuration.yaml", nil)
+	require.EqualError(t, a.LoadResources(), "configuration: remote resource file http://example.com/configuration.yaml must be an https URL")
+
+	// Remote files are not in the repository, so errors are
+	// not annotated on a file
+	buf := &bytes.Buffer{}
+	workflow.Output = buf
+	defer func() { workflow.Output = os.Stdout }()
+
+	a = newAction(server.URL+"/golden/malformed.yaml", nil)
+	require.ErrorContains(t, a.LoadResources(), "resource file "+server.URL+"/golden/malformed.yaml is malformed")
+	require.Regexp(t, "^::error title=Resource file error::", buf.String())
+}
@@ -0,0 +1,122 @@
+package action
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+)
+
+// Resource name environment positions, see WithResourceNameEnvironment
+const (
+	EnvironmentNamePrefix = "prefix"
+	EnvironmentNameSuffix = "suffix"
+)
+
+// renamedKinds are the kinds whose names include the environment.
+// Agent versions are named after the version, so they are not renamed.
+var renamedKinds = []model.Kind{
+	model.KindDestination,
+	model.KindSource,
+	model.KindProcessor,
+	model.KindConfiguration,
+}
+
+// ValidateResourceNameEnvironment returns an error if s is not
+// a resource name environment position
+func ValidateResourceNameEnvironment(s string) error {
+	switch s {
+	case "", EnvironmentNamePrefix, EnvironmentNameSuffix:
+		return nil
+	}
+	return fmt.Errorf("invalid resource name environment %s, must be %s or %s", s, EnvironmentNamePrefix, EnvironmentNameSuffix)
+}
+
+// environmentName returns name with the environment added
+func (a *Action) environmentName(name string) string {
+	if a.resourceNameEnvironment == EnvironmentNameSuffix {
+		return name + "-" + a.environment
+	}
+	return a.environment + "-" + name
+}
+
+// renameResources adds the environment to the names of the loaded
+// resources, and to references between them, so the resources of each
+// environment can be applied to the same project. References to
+// resources which are not in the repository are not changed. Renamed
+// resources do not keep their ID, which identifies a single resource.
+func (a *Action) renameResources(resources map[model.Kind][]*model.AnyResource, origins map[string]resourceOrigin) error {
+	if a.resourceNameEnvironment == "" {
+		return nil
+	}
+	if a.environment == "" {
+		return errors.New("an environment is required when the resource name environment is set")
+	}
+
+	names := map[model.Kind]map[string]string{}
+	for _, kind := range renamedKinds {
+		names[kind] = map[string]string{}
+		for _, r := range resources[kind] {
+			name := r.Metadata.Name
+			names[kind][name] = a.environmentName(name)
+
+			if o, ok := origins[resourceKey(r.Kind, name)]; ok {
+				delete(origins, resourceKey(r.Kind, name))
+				origins[resourceKey(r.Kind, names[kind][name])] = o
+			}
+			r.Metadata.Name = names[kind][name]
+			r.Metadata.ID = ""
+		}
+	}
+
+	for _, r := range resources[model.KindConfiguration] {
+		for field, kind := range map[string]model.Kind{"sources": model.KindSource, "destinations": model.KindDestination} {
+			items, _ := r.Spec[field].([]any)
+			for _, item := range items {
+				m, ok := item.(map[string]any)
+				if !ok {
+					continue
+				}
+				renameReference(m, names[kind])
+
+				processors, _ := m["processors"].([]any)
+				for _, p := range processors {
+					if pm, ok := p.(map[string]any); ok {
+						renameReference(pm, names[model.KindProcessor])
+					}
+				}
+			}
+		}
+
+		// Agents are selected by the configuration label, which
+		// defaults to the name of the configuration
+		selector, _ := r.Spec["selector"].(map[string]any)
+		labels, _ := selector["matchLabels"].(map[string]any)
+		if name, ok := labels["configuration"].(string); ok {
+			if renamed, ok := names[model.KindConfiguration][name]; ok {
+				labels["configuration"] = renamed
+			}
+		}
+	}
+
+	return nil
+}
+
+// renameReference renames the library resource referenced by the name
+// field of m. Versioned references, such as otlp:2, keep their version.
+func renameReference(m map[string]any, names map[string]string) {
+	ref, ok := m["name"].(string)
+	if !ok {
+		return
+	}
+	name, version, versioned := strings.Cut(ref, ":")
+	renamed, ok := names[name]
+	if !ok {
+		return
+	}
+	if versioned {
+		renamed += ":" + version
+	}
+	m["name"] = renamed
+}
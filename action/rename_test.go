@@ -0,0 +1,108 @@
+package action
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadResourcesRename(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	destinations := writeFile("destinations.yaml", `apiVersion: bindplane.observiq.com/v1
+kind: Destination
+metadata:
+  id: otlp
+  name: otlp
+spec:
+  type: otlp_grpc
+`)
+	processors := writeFile("processors.yaml", `apiVersion: bindplane.observiq.com/v1
+kind: Processor
+metadata:
+  name: sampler
+spec:
+  type: probabilistic_sampler
+`)
+	configurations := writeFile("configurations.yaml", `apiVersion: bindplane.observiq.com/v1
+kind: Configuration
+metadata:
+  id: gateway
+  name: gateway
+spec:
+  sources:
+    - type: otlp
+      processors:
+        - name: sampler
+        - name: shared-batch
+  destinations:
+    - name: otlp:2
+    - name: logging
+  selector:
+    matchLabels:
+      configuration: gateway
+`)
+
+	cases := []struct {
+		name     string
+		position string
+		expect   func(string) string
+	}{
+		{"prefix", EnvironmentNamePrefix, func(name string) string { return "dev-" + name }},
+		{"suffix", EnvironmentNameSuffix, func(name string) string { return name + "-dev" }},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			a := newTestAction(t, http.NewServeMux(),
+				WithDestinationPath(destinations),
+				WithProcessorPath(processors),
+				WithConfigurationPath(configurations),
+				WithEnvironment("dev"),
+				WithResourceNameEnvironment(tc.position),
+			)
+			require.NoError(t, a.LoadResources())
+
+			dest := a.resources[model.KindDestination][0]
+			require.Equal(t, tc.expect("otlp"), dest.Metadata.Name)
+			require.Empty(t, dest.Metadata.ID)
+			require.Equal(t, tc.expect("sampler"), a.resources[model.KindProcessor][0].Metadata.Name)
+
+			conf := a.resources[model.KindConfiguration][0]
+			require.Equal(t, tc.expect("gateway"), conf.Metadata.Name)
+			require.Equal(t, map[string]any{
+				"sources": []any{
+					map[string]any{"type": "otlp", "processors": []any{
+						map[string]any{"name": tc.expect("sampler")},
+						map[string]any{"name": "shared-batch"},
+					}},
+				},
+				"destinations": []any{
+					map[string]any{"name": tc.expect("otlp") + ":2"},
+					map[string]any{"name": "logging"},
+				},
+				"selector": map[string]any{"matchLabels": map[string]any{"configuration": tc.expect("gateway")}},
+			}, conf.Spec)
+
+			require.Equal(t, configurations, a.origins[resourceKey("Configuration", tc.expect("gateway"))].file)
+		})
+	}
+
+	a := newTestAction(t, http.NewServeMux(),
+		WithDestinationPath(destinations),
+		WithResourceNameEnvironment(EnvironmentNamePrefix),
+	)
+	require.EqualError(t, a.LoadResources(), "rename resources: an environment is required when the resource name environment is set")
+
+	_, err := New(nil, WithResourceNameEnvironment("infix"))
+	require.EqualError(t, err, "invalid resource name environment infix, must be prefix or suffix")
+}
@@ -0,0 +1,126 @@
+package action
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/observiq/bindplane-op-action/internal/workflow"
+	"github.com/observiq/bindplane-op-action/pkg/client"
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"github.com/pmezard/go-difflib/difflib"
+	"go.uber.org/zap"
+)
+
+// RenderedDiff is the change to the rendered OpenTelemetry configuration
+// of a configuration made by applying it
+type RenderedDiff struct {
+	Name string `json:"name"`
+	Diff string `json:"diff"`
+}
+
+// snapshotRendered retrieves the rendered configuration of every
+// configuration in the repository before it is applied, so it can be
+// compared after apply. Configurations which do not exist yet are empty.
+func (a *Action) snapshotRendered() error {
+	if !a.renderedDiff {
+		return nil
+	}
+
+	a.renderedBefore = map[string]string{}
+	for _, c := range a.resources[model.KindConfiguration] {
+		name := c.Metadata.Name
+		raw, err := a.client.RawConfiguration(a.ctx, name)
+		if err != nil && !errors.Is(err, client.ErrNotFound) {
+			return fmt.Errorf("get rendered configuration %s: %w", name, err)
+		}
+		a.renderedBefore[name] = raw
+	}
+	return nil
+}
+
+// RenderedDiffs retrieves the rendered configuration of every configuration
+// which was retrieved before apply, and returns a unified diff of each
+// which changed, sorted by name. Resource diffs show the parameters which
+// changed, the rendered diff shows how the configuration agents receive
+// changed.
+func (a *Action) RenderedDiffs() ([]RenderedDiff, error) {
+	names := make([]string, 0, len(a.renderedBefore))
+	for name := range a.renderedBefore {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	diffs := []RenderedDiff{}
+	for _, name := range names {
+		after, err := a.client.RawConfiguration(a.ctx, name)
+		if err != nil && !errors.Is(err, client.ErrNotFound) {
+			return nil, fmt.Errorf("get rendered configuration %s: %w", name, err)
+		}
+
+		before := a.renderedBefore[name]
+		if before == after {
+			continue
+		}
+
+		diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        splitLines(before),
+			B:        splitLines(after),
+			FromFile: name + " (before)",
+			ToFile:   name + " (after)",
+			Context:  3,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("diff rendered configuration %s: %w", name, err)
+		}
+		diffs = append(diffs, RenderedDiff{Name: name, Diff: diff})
+	}
+	return diffs, nil
+}
+
+// reportRenderedDiffs logs the rendered diff of each changed configuration
+// and appends them to the job summary. Resources are already applied, so
+// failing to report the diffs is logged instead of failing the run.
+func (a *Action) reportRenderedDiffs() {
+	if !a.renderedDiff {
+		return
+	}
+
+	diffs, err := a.RenderedDiffs()
+	if err != nil {
+		a.Logger.Warn("Failed to diff rendered configurations", zap.Error(err))
+		return
+	}
+
+	for _, d := range diffs {
+		a.Logger.Info("Rendered configuration changed", zap.String("name", d.Name), zap.String("diff", d.Diff))
+	}
+	if len(diffs) == 0 {
+		a.Logger.Info("Rendered configurations did not change")
+	}
+
+	if err := workflow.AppendSummary(renderedDiffSummary(a.config.Network.RemoteURL, diffs)); err != nil {
+		a.Logger.Warn("Failed to write rendered configuration job summary", zap.Error(err))
+	}
+}
+
+// renderedDiffSummary formats the rendered diffs of the server at remoteURL
+// as markdown. The server is named, because each profile writes a summary.
+func renderedDiffSummary(remoteURL string, diffs []RenderedDiff) string {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "### Rendered configuration changes for %s\n\n", remoteURL)
+	if len(diffs) == 0 {
+		b.WriteString("The rendered configurations did not change.\n")
+		return b.String()
+	}
+
+	for _, d := range diffs {
+		diff := d.Diff
+		if !strings.HasSuffix(diff, "\n") {
+			diff += "\n"
+		}
+		fmt.Fprintf(b, "<details><summary>%s</summary>\n\n```diff\n%s```\n\n</details>\n\n", d.Name, diff)
+	}
+	return b.String()
+}
@@ -0,0 +1,75 @@
+package action
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderedDiffs(t *testing.T) {
+	rendered := map[string]string{
+		"gateway":   "receivers:\n  otlp: {}\nexporters:\n  otlp:\n    endpoint: a:4317\n",
+		"unchanged": "receivers:\n  otlp: {}\n",
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/configurations/{name}", func(w http.ResponseWriter, r *http.Request) {
+		raw, ok := rendered[r.PathValue("name")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(model.ConfigurationResponse{Raw: raw})
+	})
+
+	a := newTestAction(t, mux, WithRenderedDiff(true))
+	a.resources = map[model.Kind][]*model.AnyResource{}
+	for _, name := range []string{"gateway", "unchanged", "new"} {
+		r := &model.AnyResource{}
+		r.Metadata.Name = name
+		a.resources[model.KindConfiguration] = append(a.resources[model.KindConfiguration], r)
+	}
+	require.NoError(t, a.snapshotRendered())
+
+	rendered["gateway"] = "receivers:\n  otlp: {}\nexporters:\n  otlp:\n    endpoint: b:4317\n"
+	rendered["new"] = "receivers:\n  otlp: {}\n"
+
+	diffs, err := a.RenderedDiffs()
+	require.NoError(t, err)
+	require.Equal(t, []RenderedDiff{
+		{Name: "gateway", Diff: `--- gateway (before)
++++ gateway (after)
+@@ -2,4 +2,4 @@
+   otlp: {}
+ exporters:
+   otlp:
+-    endpoint: a:4317
++    endpoint: b:4317
+`},
+		{Name: "new", Diff: `--- new (before)
++++ new (after)
+@@ -0,0 +1,2 @@
++receivers:
++  otlp: {}
+`},
+	}, diffs)
+
+	summary := filepath.Join(t.TempDir(), "summary.md")
+	t.Setenv("GITHUB_STEP_SUMMARY", summary)
+	a.reportRenderedDiffs()
+	data, err := os.ReadFile(summary)
+	require.NoError(t, err)
+	require.Contains(t, string(data), "<details><summary>gateway</summary>\n\n```diff\n--- gateway (before)")
+	require.NotContains(t, string(data), "unchanged")
+}
+
+func TestRenderedDiffSummaryEmpty(t *testing.T) {
+	require.Equal(t, "### Rendered configuration changes for https://bindplane.example.com\n\nThe rendered configurations did not change.\n",
+		renderedDiffSummary("https://bindplane.example.com", nil))
+}
@@ -0,0 +1,51 @@
+package action
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+)
+
+// StatusReport is the status of every resource applied during the run,
+// written to the status report file for downstream automation
+type StatusReport struct {
+	// Resources are in the order they were applied
+	Resources []AppliedResource `json:"resources"`
+
+	// Statuses is the number of resources with each status
+	Statuses map[string]int `json:"statuses"`
+
+	// Failed is the number of resources whose status
+	// does not indicate they were applied
+	Failed int `json:"failed"`
+}
+
+// StatusReport returns the status of every resource applied during the run
+func (a *Action) StatusReport() StatusReport {
+	report := StatusReport{
+		Resources: a.appliedResources(),
+		Statuses:  map[string]int{},
+	}
+	for _, r := range report.Resources {
+		report.Statuses[r.Status]++
+		if !model.UpdateStatus(r.Status).Succeeded() {
+			report.Failed++
+		}
+	}
+	return report
+}
+
+// WriteStatusReport writes the status report to the status report path.
+// It is written even when the run fails, to report on partial results.
+func (a *Action) WriteStatusReport() error {
+	data, err := json.MarshalIndent(a.StatusReport(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal status report: %w", err)
+	}
+	if err := os.WriteFile(a.statusReportPath, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("write status report %s: %w", a.statusReportPath, err)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package action
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteStatusReport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "status.json")
+	a := newTestAction(t, http.NotFoundHandler(), WithStatusReportPath(path))
+
+	for _, s := range []struct {
+		kind, name string
+		status     model.UpdateStatus
+		reason     string
+	}{
+		{string(model.KindDestination), "otlp", model.StatusUnchanged, ""},
+		{string(model.KindConfiguration), "gateway", model.StatusConfigured, ""},
+		{string(model.KindConfiguration), "edge", model.StatusInvalid, "missing destination"},
+	} {
+		status := model.AnyResourceStatus{Status: s.status, Reason: s.reason}
+		status.Resource.Kind = s.kind
+		status.Resource.Metadata.Name = s.name
+		a.state.AddResourceStatus(status)
+	}
+
+	require.NoError(t, a.WriteStatusReport())
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	report := StatusReport{}
+	require.NoError(t, json.Unmarshal(data, &report))
+	require.Equal(t, StatusReport{
+		Resources: []AppliedResource{
+			{Kind: "Destination", Name: "otlp", Status: "unchanged"},
+			{Kind: "Configuration", Name: "gateway", Status: "configured"},
+			{Kind: "Configuration", Name: "edge", Status: "invalid", Reason: "missing destination"},
+		},
+		Statuses: map[string]int{"unchanged": 1, "configured": 1, "invalid": 1},
+		Failed:   1,
+	}, report)
+}
+
+func TestStatusReportEmpty(t *testing.T) {
+	a := newTestAction(t, http.NotFoundHandler())
+	require.Equal(t, StatusReport{Resources: []AppliedResource{}, Statuses: map[string]int{}}, a.StatusReport())
+}
@@ -0,0 +1,131 @@
+package action
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/observiq/bindplane-op-action/internal/workflow"
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"go.uber.org/zap"
+)
+
+// snapshotPrevious reads the server version of each resource which will be
+// applied, so the resources changed by a failed apply can be restored when
+// the apply strategy is rollback. Agent versions are not restored.
+func (a *Action) snapshotPrevious() error {
+	if a.applyStrategy != ApplyStrategyRollback {
+		return nil
+	}
+
+	a.previousResources = map[model.Kind]map[string]*model.AnyResource{}
+	for _, f := range a.resourceFiles() {
+		if f.kind == model.KindAgentVersion || len(a.resources[f.kind]) == 0 {
+			continue
+		}
+
+		names := map[string]bool{}
+		for _, r := range a.resources[f.kind] {
+			names[r.Metadata.Name] = true
+		}
+
+		resources, err := a.client.Resources(a.ctx, f.kind)
+		if err != nil {
+			return fmt.Errorf("get %s resources before apply: %w", f.label, err)
+		}
+		previous := map[string]*model.AnyResource{}
+		for _, r := range resources {
+			if names[r.Metadata.Name] {
+				previous[r.Metadata.Name] = r
+			}
+		}
+		a.previousResources[f.kind] = previous
+	}
+	return nil
+}
+
+// trackApplied records the resources created or configured by an apply,
+// in the order they were applied, so they can be restored
+func (a *Action) trackApplied(statuses []*model.AnyResourceStatus) {
+	if a.previousResources == nil {
+		return
+	}
+	for _, s := range statuses {
+		if s.Status == model.StatusCreated || s.Status == model.StatusConfigured {
+			a.restorable = append(a.restorable, s)
+		}
+	}
+}
+
+// restoreApplied restores the resources changed by a failed apply, in the
+// reverse order they were applied, so configurations are restored before
+// the resources they reference. Configured resources are restored by
+// applying their previous version, and created resources are deleted.
+// Restored configurations have a new pending version, which is not rolled
+// out. Every resource is restored, and every error is returned.
+func (a *Action) restoreApplied() error {
+	if len(a.restorable) == 0 {
+		a.Logger.Info("Apply failed before any resources were changed, nothing to restore")
+		return nil
+	}
+	a.Logger.Warn("Apply failed, restoring the resources it changed", zap.Int("resources", len(a.restorable)))
+
+	errs := []error{}
+	for _, s := range slices.Backward(a.restorable) {
+		kind := s.Resource.Kind
+		name := s.Resource.Metadata.Name
+
+		if err := a.restoreResource(s); err != nil {
+			a.Logger.Error("Failed to restore resource", zap.String("kind", kind), zap.String("name", name), zap.Error(err))
+			a.annotateResource(workflow.Error, kind, name, "Resource not restored", fmt.Sprintf("%s %s was changed by a failed apply and could not be restored: %s", kind, name, err))
+			errs = append(errs, fmt.Errorf("restore %s %s: %w", kind, name, err))
+			continue
+		}
+
+		a.Logger.Info("Restored resource", zap.String("kind", kind), zap.String("name", name), zap.String("applied_status", string(s.Status)))
+		a.annotateResource(workflow.Warning, kind, name, "Resource restored", fmt.Sprintf("%s %s was %s by a failed apply, and has been restored", kind, name, s.Status))
+	}
+	a.restorable = nil
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("failed to restore resources changed by the failed apply: %w", err)
+	}
+	return nil
+}
+
+// restoreResource deletes a created resource, or applies the
+// previous version of a configured resource
+func (a *Action) restoreResource(s *model.AnyResourceStatus) error {
+	kind := model.Kind(s.Resource.Kind)
+	name := s.Resource.Metadata.Name
+
+	if s.Status == model.StatusCreated {
+		r := &model.AnyResource{ResourceMeta: model.ResourceMeta{Kind: s.Resource.Kind, Metadata: model.Metadata{Name: name}}}
+		statuses, err := a.client.Delete(a.ctx, []*model.AnyResource{r})
+		if err != nil {
+			return err
+		}
+		for _, d := range statuses {
+			if d.Status != model.StatusDeleted && d.Status != model.StatusNotFound {
+				return fmt.Errorf("delete: %s: %s", d.Status, d.Reason)
+			}
+		}
+		return nil
+	}
+
+	previous, ok := a.previousResources[kind][name]
+	if !ok {
+		return fmt.Errorf("previous version was not found: %s", BugError)
+	}
+
+	statuses, err := a.client.Apply(a.ctx, []*model.AnyResource{withoutServerFields(previous)})
+	if err != nil {
+		return err
+	}
+	for _, u := range statuses {
+		if !u.Status.Succeeded() {
+			return fmt.Errorf("apply previous version: %s: %s", u.Status, u.Reason)
+		}
+	}
+	return nil
+}
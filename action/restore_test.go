@@ -0,0 +1,128 @@
+package action
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyStrategyRollback(t *testing.T) {
+	dir := t.TempDir()
+	writeResources := func(name, kind string, names ...string) string {
+		path := filepath.Join(dir, name)
+		data := ""
+		for _, n := range names {
+			data += fmt.Sprintf("---\napiVersion: bindplane.observiq.com/v1\nkind: %s\nmetadata:\n  name: %s\nspec:\n  type: new\n", kind, n)
+		}
+		require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+		return path
+	}
+	destinations := writeResources("destinations.yaml", "Destination", "otlp", "logging")
+	sources := writeResources("sources.yaml", "Source", "host", "broken", "file")
+
+	previous := testResource(model.KindDestination, "otlp", map[string]any{"type": "old"})
+	previous.Metadata.ID = "1"
+	previous.Metadata.Version = 2
+
+	var applied, restored, deleted []string
+	restoring := false
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/{kind}", func(w http.ResponseWriter, r *http.Request) {
+		remote := map[string][]*model.AnyResource{
+			"destinations": {previous, testResource(model.KindDestination, "unmanaged", nil)},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{r.PathValue("kind"): remote[r.PathValue("kind")]})
+	})
+	mux.HandleFunc("POST /v1/apply", func(w http.ResponseWriter, r *http.Request) {
+		payload := model.ApplyPayload{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+
+		updates := []*model.AnyResourceStatus{}
+		for _, resource := range payload.Resources {
+			name := resource.Metadata.Name
+			switch {
+			case restoring:
+				require.Empty(t, resource.Metadata.ID)
+				require.Zero(t, resource.Metadata.Version)
+				require.Equal(t, "old", resource.Spec["type"])
+				restored = append(restored, name)
+				updates = append(updates, &model.AnyResourceStatus{Resource: *resource, Status: model.StatusConfigured})
+			case name == "broken":
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			case name == "otlp":
+				applied = append(applied, name)
+				updates = append(updates, &model.AnyResourceStatus{Resource: *resource, Status: model.StatusConfigured})
+			default:
+				applied = append(applied, name)
+				updates = append(updates, &model.AnyResourceStatus{Resource: *resource, Status: model.StatusCreated})
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(model.ApplyResponseClientSide{Updates: updates})
+	})
+	mux.HandleFunc("POST /v1/delete", func(w http.ResponseWriter, r *http.Request) {
+		payload := model.ApplyPayload{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		updates := []*model.AnyResourceStatus{}
+		for _, resource := range payload.Resources {
+			deleted = append(deleted, resource.Metadata.Name)
+			updates = append(updates, &model.AnyResourceStatus{Resource: *resource, Status: model.StatusDeleted})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(model.ApplyResponseClientSide{Updates: updates})
+	})
+
+	// Each resource is applied in its own request
+	a := newTestAction(t, mux,
+		WithDestinationPath(destinations),
+		WithSourcePath(sources),
+		WithApplyMaxPayloadSize(1),
+		WithApplyStrategy(ApplyStrategyRollback),
+	)
+	require.NoError(t, a.LoadResources())
+	require.NoError(t, a.snapshotPrevious())
+	require.Equal(t, map[string]*model.AnyResource{"otlp": previous}, a.previousResources[model.KindDestination])
+	require.Empty(t, a.previousResources[model.KindSource])
+
+	require.ErrorContains(t, a.Apply(), "sources: client error: BindPlane API returned status 400")
+	require.Equal(t, []string{"otlp", "logging", "host"}, applied)
+
+	restoring = true
+	require.NoError(t, a.restoreApplied())
+	require.Equal(t, []string{"host", "logging"}, deleted)
+	require.Equal(t, []string{"otlp"}, restored)
+	require.Empty(t, a.restorable)
+}
+
+func TestApplyStrategyRollbackNotRestored(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/delete", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	a := newTestAction(t, mux, WithApplyStrategy(ApplyStrategyRollback))
+	a.previousResources = map[model.Kind]map[string]*model.AnyResource{}
+
+	created := &model.AnyResourceStatus{Status: model.StatusCreated}
+	created.Resource.Kind = string(model.KindSource)
+	created.Resource.Metadata.Name = "host"
+	configured := &model.AnyResourceStatus{Status: model.StatusConfigured}
+	configured.Resource.Kind = string(model.KindDestination)
+	configured.Resource.Metadata.Name = "otlp"
+	unchanged := &model.AnyResourceStatus{Status: model.StatusUnchanged}
+	a.trackApplied([]*model.AnyResourceStatus{configured, unchanged, created})
+	require.Equal(t, []*model.AnyResourceStatus{configured, created}, a.restorable)
+
+	err := a.restoreApplied()
+	require.ErrorContains(t, err, "failed to restore resources changed by the failed apply")
+	require.ErrorContains(t, err, "restore Source host: ")
+	require.ErrorContains(t, err, "restore Destination otlp: previous version was not found")
+}
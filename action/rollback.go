@@ -0,0 +1,58 @@
+package action
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/observiq/bindplane-op-action/pkg/client"
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"go.uber.org/zap"
+)
+
+// Rollback restores a previous version of a configuration and starts its
+// rollout. When version is 0, the configuration is rolled back to the
+// version before its current version, which is the version agents are
+// running. The previous version is applied as a new version, so the
+// configuration history is preserved.
+func (a *Action) Rollback(name string, version int) error {
+	if version < 0 {
+		return fmt.Errorf("version %d is not a configuration version", version)
+	}
+	if err := a.checkFreeze(time.Now()); err != nil {
+		return err
+	}
+
+	c, err := a.client.Configuration(a.ctx, name)
+	if errors.Is(err, client.ErrNotFound) || (err == nil && c == nil) {
+		return fmt.Errorf("configuration %s does not exist", name)
+	}
+	if err != nil {
+		return fmt.Errorf("get configuration %s: %w", name, err)
+	}
+
+	if a.recorder != nil {
+		a.recorder.setPreviousVersion(model.KindConfiguration, name, c.Metadata.Version)
+	}
+
+	current := c.Status.CurrentVersion
+	if version == 0 {
+		if current < 2 {
+			return fmt.Errorf("configuration %s does not have a version before its current version %d", name, current)
+		}
+		version = current - 1
+	}
+
+	a.Logger.Info("Rolling back configuration", zap.String("name", name), zap.Int("current_version", current), zap.Int("version", version))
+
+	s, err := a.client.RollbackConfiguration(a.ctx, name, version)
+	if err != nil {
+		return fmt.Errorf("rollback configuration %s: %w", name, err)
+	}
+	if !s.Status.Succeeded() {
+		return fmt.Errorf("rollback configuration %s: version %d was %s: %s", name, version, s.Status, s.Reason)
+	}
+	a.state.AddResourceStatus(*s)
+
+	return a.RunRollout(name)
+}
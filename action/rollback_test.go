@@ -0,0 +1,76 @@
+package action
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRollback(t *testing.T) {
+	cases := []struct {
+		name          string
+		current       int
+		version       int
+		expectVersion string
+		expectErr     string
+	}{
+		{"previous version", 3, 0, "gateway:2", ""},
+		{"explicit version", 3, 1, "gateway:1", ""},
+		{"no previous version", 1, 0, "", "configuration gateway does not have a version before its current version 1"},
+		{"missing version", 3, 7, "", "rollback configuration gateway: get version 7 of configuration gateway: BindPlane API returned status 404: 404 page not found"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fetched := ""
+			started := []string{}
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /v1/configurations/{name}", func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				switch r.PathValue("name") {
+				case "gateway":
+					c := model.Configuration{}
+					c.Metadata.Name = "gateway"
+					c.Status.CurrentVersion = tc.current
+					_ = json.NewEncoder(w).Encode(model.ConfigurationResponse{Configuration: &c})
+				case "gateway:1", "gateway:2":
+					fetched = r.PathValue("name")
+					_, _ = w.Write([]byte(`{"configuration":{"kind":"Configuration","metadata":{"name":"gateway"},"spec":{}}}`))
+				default:
+					http.NotFound(w, r)
+				}
+			})
+			mux.HandleFunc("POST /v1/apply", func(w http.ResponseWriter, r *http.Request) {
+				payload := model.ApplyPayload{}
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+				w.Header().Set("Content-Type", "application/json")
+				_ = json.NewEncoder(w).Encode(model.ApplyResponseClientSide{
+					Updates: []*model.AnyResourceStatus{{Resource: *payload.Resources[0], Status: model.StatusConfigured}},
+				})
+			})
+			mux.HandleFunc("POST /v1/rollouts/{name}/start", func(w http.ResponseWriter, r *http.Request) {
+				started = append(started, r.PathValue("name"))
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{}`))
+			})
+
+			a := newTestAction(t, mux)
+			err := a.Rollback("gateway", tc.version)
+			if tc.expectErr != "" {
+				require.EqualError(t, err, tc.expectErr)
+				require.Empty(t, started)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectVersion, fetched)
+			require.Equal(t, []string{"gateway"}, started)
+			require.Equal(t, "gateway", a.rolloutConfiguration)
+		})
+	}
+
+	a := newTestAction(t, http.NewServeMux())
+	require.EqualError(t, a.Rollback("gateway", 0), "configuration gateway does not exist")
+}
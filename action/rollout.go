@@ -0,0 +1,304 @@
+package action
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/observiq/bindplane-op-action/action/notify"
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// DefaultRolloutTimeout is the default amount of time to wait for rollouts
+	DefaultRolloutTimeout = 30 * time.Minute
+
+	// DefaultRolloutPollInterval is the default interval rollout status is polled at
+	DefaultRolloutPollInterval = 15 * time.Second
+
+	// DefaultRolloutPollJitter is the default percentage of the poll
+	// interval randomly added to each poll
+	DefaultRolloutPollJitter = 20
+
+	// DefaultAgentWaitTimeout is the default amount of time to wait for
+	// agents to run a configuration once its rollout is complete
+	DefaultAgentWaitTimeout = 5 * time.Minute
+)
+
+// ErrorThreshold is the number of errored agents a rollout may have before
+// the action stops waiting on it. It is either a count, or a percentage of
+// the agents in the rollout.
+type ErrorThreshold struct {
+	count   int
+	percent float64
+}
+
+// ParseErrorThreshold parses a count such as 5, or a percentage such as 10%
+func ParseErrorThreshold(s string) (*ErrorThreshold, error) {
+	s = strings.TrimSpace(s)
+	if p, ok := strings.CutSuffix(s, "%"); ok {
+		percent, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil || percent < 0 || percent > 100 {
+			return nil, fmt.Errorf("%s is not a percentage between 0%% and 100%%", s)
+		}
+		return &ErrorThreshold{percent: percent}, nil
+	}
+
+	count, err := strconv.Atoi(s)
+	if err != nil || count < 0 {
+		return nil, fmt.Errorf("%s is not a non-negative integer or percentage", s)
+	}
+	return &ErrorThreshold{count: count}, nil
+}
+
+// Exceeded returns true if the rollout has more errored agents than allowed
+func (t *ErrorThreshold) Exceeded(p model.RolloutProgress) bool {
+	if t.percent == 0 {
+		return p.Errors > t.count
+	}
+
+	total := p.Completed + p.Errors + p.Pending + p.Waiting
+	if total == 0 {
+		return false
+	}
+	return float64(p.Errors)/float64(total)*100 > t.percent
+}
+
+// String returns the threshold as it was configured
+func (t *ErrorThreshold) String() string {
+	if t.percent == 0 {
+		return strconv.Itoa(t.count)
+	}
+	return strconv.FormatFloat(t.percent, 'f', -1, 64) + "%"
+}
+
+// rolloutCandidates returns the names of configurations which may have a
+// pending rollout. These are the configurations applied from the repository,
+// the configurations matching the rollout selector, and when rolloutAllPending
+// is set, every configuration in BindPlane with a pending version. Changes to
+// shared sources, processors, and destinations create pending versions of
+// configurations which are not in the repository.
+func (a *Action) rolloutCandidates() ([]string, error) {
+	names := a.state.ConfigurationNames()
+
+	if a.rolloutSelector != "" {
+		selected, err := a.selectedConfigurations()
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range selected {
+			if !slices.Contains(names, name) {
+				a.rolloutLogger().Debug("Configuration matches the rollout selector", zap.String("name", name))
+				names = append(names, name)
+			}
+		}
+	}
+
+	if !a.rolloutAllPending {
+		return names, nil
+	}
+
+	configurations, err := a.client.Configurations(a.ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list configurations: %w", err)
+	}
+
+	for _, c := range configurations {
+		if c == nil || slices.Contains(names, c.Metadata.Name) {
+			continue
+		}
+		if c.Status.Pending || c.Status.PendingVersion > 0 {
+			a.rolloutLogger().Debug("Configuration outside of the repository has a pending version", zap.String("name", c.Metadata.Name))
+			names = append(names, c.Metadata.Name)
+		}
+	}
+	return names, nil
+}
+
+// selectedConfigurations returns the names of the configurations whose
+// labels match the rollout selector. The selector is resolved by BindPlane,
+// and checked again so servers which ignore it do not select every
+// configuration.
+func (a *Action) selectedConfigurations() ([]string, error) {
+	selector, err := labels.Parse(a.rolloutSelector)
+	if err != nil {
+		return nil, fmt.Errorf("parse rollout selector: %w", err)
+	}
+
+	configurations, err := a.client.ConfigurationsBySelector(a.ctx, a.rolloutSelector)
+	if err != nil {
+		return nil, fmt.Errorf("list configurations matching %s: %w", a.rolloutSelector, err)
+	}
+
+	names := []string{}
+	for _, c := range configurations {
+		if c != nil && selector.Matches(labels.Set(c.Metadata.Labels)) {
+			names = append(names, c.Metadata.Name)
+		}
+	}
+	if len(names) == 0 {
+		a.rolloutLogger().Warn("No configurations match the rollout selector", zap.String("selector", a.rolloutSelector))
+	}
+	return names, nil
+}
+
+// WaitForRollouts polls the status of each rollout started by the action,
+// logging agent progress, until every rollout finishes. An error is returned
+// if a rollout fails, has more errored agents than the max rollout errors,
+// or the rollout timeout is reached. Paused and replaced rollouts are no
+// longer waited on.
+func (a *Action) WaitForRollouts() (err error) {
+	if len(a.startedRollouts) == 0 {
+		return nil
+	}
+
+	end := a.startSpan("rollout.wait", attribute.StringSlice("bindplane.rollouts", a.startedRollouts))
+	defer func() { end(err) }()
+
+	timeout := a.rolloutTimeout
+	if timeout <= 0 {
+		timeout = DefaultRolloutTimeout
+	}
+	interval := a.rolloutPollInterval
+	if interval <= 0 {
+		interval = DefaultRolloutPollInterval
+	}
+
+	deadline := time.Now().Add(timeout)
+	waiting := append([]string{}, a.startedRollouts...)
+	seen := map[string]*rolloutSeen{}
+	for poll := 1; ; poll++ {
+		remaining, err := a.pollRollouts(poll, waiting, seen)
+		if err != nil {
+			return err
+		}
+
+		if len(remaining) == 0 {
+			if a.agentWait {
+				if err := a.waitForAgents(a.startedRollouts); err != nil {
+					return err
+				}
+			}
+			return a.VerifyThroughput()
+		}
+		waiting = remaining
+
+		wait := a.pollJitter(interval)
+		if time.Now().Add(wait).After(deadline) {
+			err := fmt.Errorf("timed out after %s waiting for rollouts: %v", timeout, waiting)
+			for _, name := range waiting {
+				a.notifyRollout(name, seen[name], notify.ResultFailed, err)
+			}
+			return err
+		}
+		time.Sleep(wait)
+	}
+}
+
+// pollJitter returns interval plus a random amount of up to the rollout
+// poll jitter percentage of it, so workflows started at the same time do
+// not poll the BindPlane server in lockstep
+func (a *Action) pollJitter(interval time.Duration) time.Duration {
+	n := int64(interval) * int64(a.rolloutPollJitter) / 100
+	if n <= 0 {
+		return interval
+	}
+	return interval + time.Duration(rand.Int64N(n+1))
+}
+
+// pollRollouts checks the progress of each rollout being waited on, and
+// returns the rollouts which are not finished. Finished and failed
+// rollouts are notified.
+func (a *Action) pollRollouts(poll int, waiting []string, seen map[string]*rolloutSeen) (remaining []string, err error) {
+	end := a.startSpan("rollout.poll",
+		attribute.Int("bindplane.rollout.poll", poll),
+		attribute.StringSlice("bindplane.rollouts", waiting),
+	)
+	defer func() { end(err) }()
+
+	remaining = []string{}
+	for _, name := range waiting {
+		c, done, err := a.rolloutProgress(name)
+		if c != nil {
+			seen[name] = seen[name].update(c)
+		}
+		if err != nil {
+			a.notifyRollout(name, seen[name], notify.ResultFailed, err)
+			return nil, err
+		}
+		if !done {
+			remaining = append(remaining, name)
+			continue
+		}
+		if c.Status.Rollout.Status == model.RolloutStatusStable {
+			a.notifyRollout(name, seen[name], notify.ResultSucceeded, nil)
+		}
+	}
+	return remaining, nil
+}
+
+// rolloutProgress logs the progress of a rollout and returns its status,
+// and true if the rollout is finished. An error is returned if the rollout
+// failed.
+func (a *Action) rolloutProgress(name string) (*model.Configuration, bool, error) {
+	c, err := a.client.RolloutStatus(a.ctx, name)
+	if err != nil {
+		return nil, false, fmt.Errorf("rollout status %s: %w", name, err)
+	}
+	if c == nil {
+		return nil, false, fmt.Errorf("rollout status '%s' is nil: %s", name, BugError)
+	}
+
+	rollout := c.Status.Rollout
+	fields := []zap.Field{
+		zap.String("name", name),
+		zap.String("status", rollout.Status.String()),
+		zap.Int("completed", rollout.Progress.Completed),
+		zap.Int("errors", rollout.Progress.Errors),
+		zap.Int("pending", rollout.Progress.Pending),
+		zap.Int("waiting", rollout.Progress.Waiting),
+	}
+	if len(rollout.Stages) > 0 && rollout.Stage < len(rollout.Stages) {
+		fields = append(fields, zap.String("stage", rollout.Stages[rollout.Stage].Name))
+	}
+	a.rolloutLogger().Info("Rollout progress", fields...)
+	trace.SpanFromContext(a.ctx).AddEvent("rollout progress", trace.WithAttributes(
+		attribute.String("bindplane.name", name),
+		attribute.String("bindplane.rollout.status", rollout.Status.String()),
+		attribute.Int("bindplane.rollout.completed", rollout.Progress.Completed),
+		attribute.Int("bindplane.rollout.errors", rollout.Progress.Errors),
+		attribute.Int("bindplane.rollout.pending", rollout.Progress.Pending),
+		attribute.Int("bindplane.rollout.waiting", rollout.Progress.Waiting),
+	))
+
+	if a.maxRolloutErrors != nil && a.maxRolloutErrors.Exceeded(rollout.Progress) {
+		err := fmt.Errorf("rollout %s has %d errored agents, exceeding max_rollout_errors %s", name, rollout.Progress.Errors, a.maxRolloutErrors)
+		if a.rolloutPauseOnErrors {
+			if pauseErr := a.client.PauseRollout(a.ctx, name); pauseErr != nil {
+				return c, false, fmt.Errorf("%w: pause rollout: %w", err, pauseErr)
+			}
+			a.rolloutLogger().Warn("Paused rollout", zap.String("name", name))
+		}
+		return c, false, err
+	}
+
+	switch rollout.Status {
+	case model.RolloutStatusStable:
+		a.rolloutLogger().Info("Rollout complete", zap.String("name", name))
+		return c, true, nil
+	case model.RolloutStatusError:
+		return c, false, fmt.Errorf("rollout %s failed with %d agent errors", name, rollout.Progress.Errors)
+	case model.RolloutStatusPaused, model.RolloutStatusReplaced:
+		a.rolloutLogger().Warn("Rollout is no longer progressing, not waiting for it", zap.String("name", name), zap.String("status", rollout.Status.String()))
+		return c, true, nil
+	default:
+		return c, false, nil
+	}
+}
@@ -0,0 +1,490 @@
+package action
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWaitForRollouts(t *testing.T) {
+	cases := []struct {
+		name        string
+		statuses    []model.RolloutStatus
+		timeout     time.Duration
+		expectErr   string
+		expectPolls int
+	}{
+		{
+			"Stable",
+			[]model.RolloutStatus{model.RolloutStatusStarted, model.RolloutStatusStarted, model.RolloutStatusStable},
+			time.Minute,
+			"",
+			3,
+		},
+		{
+			"Error",
+			[]model.RolloutStatus{model.RolloutStatusStarted, model.RolloutStatusError},
+			time.Minute,
+			"rollout my-config failed with 2 agent errors",
+			2,
+		},
+		{
+			"Paused",
+			[]model.RolloutStatus{model.RolloutStatusPaused},
+			time.Minute,
+			"",
+			1,
+		},
+		{
+			"Timeout",
+			[]model.RolloutStatus{model.RolloutStatusStarted},
+			10 * time.Millisecond,
+			"timed out after 10ms waiting for rollouts: [my-config]",
+			0,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			polls := 0
+			mux := http.NewServeMux()
+			mux.HandleFunc("/v1/rollouts/{name}/status", func(w http.ResponseWriter, r *http.Request) {
+				status := tc.statuses[min(polls, len(tc.statuses)-1)]
+				polls++
+
+				c := model.Configuration{}
+				c.Metadata.Name = r.PathValue("name")
+				c.Status.Rollout.Status = status
+				c.Status.Rollout.Progress = model.RolloutProgress{Completed: polls, Errors: 2, Pending: 1}
+				w.Header().Set("Content-Type", "application/json")
+				_ = json.NewEncoder(w).Encode(model.ConfigurationResponse{Configuration: &c})
+			})
+
+			a := newTestAction(t, mux,
+				WithRolloutWait(true),
+				WithRolloutTimeout(tc.timeout),
+				WithRolloutPollInterval(5*time.Millisecond),
+			)
+			a.startedRollouts = []string{"my-config"}
+
+			err := a.WaitForRollouts()
+			if tc.expectErr != "" {
+				require.EqualError(t, err, tc.expectErr)
+			} else {
+				require.NoError(t, err)
+			}
+			if tc.expectPolls > 0 {
+				require.Equal(t, tc.expectPolls, polls)
+			}
+		})
+	}
+}
+
+func TestWaitForRolloutsSlack(t *testing.T) {
+	messages := []string{}
+	slack := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m := map[string]string{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&m))
+		messages = append(messages, m["text"])
+	}))
+	defer slack.Close()
+
+	polls := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/rollouts/{name}/status", func(w http.ResponseWriter, r *http.Request) {
+		polls++
+		c := model.Configuration{}
+		c.Metadata.Name = r.PathValue("name")
+		c.Metadata.Version = 3
+		c.Status.CurrentVersion = 2
+		c.Status.PendingVersion = 3
+		c.Status.Rollout.Status = model.RolloutStatusStarted
+		if polls > 1 {
+			c.Status.CurrentVersion = 3
+			c.Status.PendingVersion = 0
+			c.Status.Rollout.Status = model.RolloutStatusStable
+			c.Status.Rollout.Progress.Completed = 5
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(model.ConfigurationResponse{Configuration: &c})
+	})
+
+	a := newTestAction(t, mux,
+		WithRolloutWait(true),
+		WithRolloutPollInterval(time.Millisecond),
+		WithSlackWebhookURL(slack.URL),
+	)
+	a.startedRollouts = []string{"my-config"}
+
+	require.NoError(t, a.WaitForRollouts())
+	require.Len(t, messages, 1)
+	require.Contains(t, messages[0], ":white_check_mark: Rollout of configuration *my-config* version 3 (from 2) succeeded")
+	require.Contains(t, messages[0], "/configurations/my-config|BindPlane configuration>")
+}
+
+func TestErrorThreshold(t *testing.T) {
+	cases := []struct {
+		name     string
+		input    string
+		progress model.RolloutProgress
+		exceeded bool
+		errStr   string
+	}{
+		{
+			"Count not exceeded",
+			"2",
+			model.RolloutProgress{Completed: 10, Errors: 2},
+			false,
+			"",
+		},
+		{
+			"Count exceeded",
+			"2",
+			model.RolloutProgress{Completed: 10, Errors: 3},
+			true,
+			"",
+		},
+		{
+			"Zero count",
+			"0",
+			model.RolloutProgress{Completed: 10, Errors: 1},
+			true,
+			"",
+		},
+		{
+			"Percent not exceeded",
+			"10%",
+			model.RolloutProgress{Completed: 80, Errors: 10, Pending: 5, Waiting: 5},
+			false,
+			"",
+		},
+		{
+			"Percent exceeded",
+			" 10 % ",
+			model.RolloutProgress{Completed: 80, Errors: 11, Pending: 5, Waiting: 4},
+			true,
+			"",
+		},
+		{
+			"Percent without agents",
+			"10%",
+			model.RolloutProgress{},
+			false,
+			"",
+		},
+		{
+			"Invalid count",
+			"-1",
+			model.RolloutProgress{},
+			false,
+			"-1 is not a non-negative integer or percentage",
+		},
+		{
+			"Invalid percent",
+			"150%",
+			model.RolloutProgress{},
+			false,
+			"150% is not a percentage between 0% and 100%",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			threshold, err := ParseErrorThreshold(tc.input)
+			if tc.errStr != "" {
+				require.EqualError(t, err, tc.errStr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.exceeded, threshold.Exceeded(tc.progress))
+		})
+	}
+}
+
+func TestPollJitter(t *testing.T) {
+	a, err := New(nil)
+	require.NoError(t, err)
+	for range 100 {
+		d := a.pollJitter(10 * time.Second)
+		require.GreaterOrEqual(t, d, 10*time.Second)
+		require.LessOrEqual(t, d, 12*time.Second)
+	}
+
+	a, err = New(nil, WithRolloutPollJitter(0))
+	require.NoError(t, err)
+	require.Equal(t, 10*time.Second, a.pollJitter(10*time.Second))
+
+	_, err = New(nil, WithRolloutPollJitter(101))
+	require.EqualError(t, err, "rollout poll jitter 101% must be between 0 and 100")
+}
+
+func TestWaitForRolloutsMaxErrors(t *testing.T) {
+	cases := []struct {
+		name        string
+		pause       bool
+		expectPause bool
+	}{
+		{"Fail", false, false},
+		{"Fail and pause", true, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			paused := false
+			mux := http.NewServeMux()
+			mux.HandleFunc("/v1/rollouts/{name}/status", func(w http.ResponseWriter, r *http.Request) {
+				c := model.Configuration{}
+				c.Metadata.Name = r.PathValue("name")
+				c.Status.Rollout.Status = model.RolloutStatusStarted
+				c.Status.Rollout.Progress = model.RolloutProgress{Completed: 5, Errors: 3, Pending: 2}
+				w.Header().Set("Content-Type", "application/json")
+				_ = json.NewEncoder(w).Encode(model.ConfigurationResponse{Configuration: &c})
+			})
+			mux.HandleFunc("PUT /v1/rollouts/{name}/pause", func(w http.ResponseWriter, r *http.Request) {
+				paused = true
+			})
+
+			threshold, err := ParseErrorThreshold("20%")
+			require.NoError(t, err)
+
+			a := newTestAction(t, mux,
+				WithRolloutWait(true),
+				WithMaxRolloutErrors(threshold),
+				WithRolloutPauseOnErrors(tc.pause),
+			)
+			a.startedRollouts = []string{"my-config"}
+
+			err = a.WaitForRollouts()
+			require.EqualError(t, err, "rollout my-config has 3 errored agents, exceeding max_rollout_errors 20%")
+			require.Equal(t, tc.expectPause, paused)
+		})
+	}
+}
+
+func TestAutoRolloutAllPending(t *testing.T) {
+	configuration := func(name string, pending bool) *model.Configuration {
+		c := &model.Configuration{}
+		c.Metadata.Name = name
+		c.Status.Pending = pending
+		if pending {
+			c.Status.Rollout.Status = model.RolloutStatusPending
+		}
+		return c
+	}
+
+	remote := map[string]*model.Configuration{
+		"repo":    configuration("repo", true),
+		"shared":  configuration("shared", true),
+		"current": configuration("current", false),
+	}
+
+	cases := []struct {
+		name          string
+		allPending    bool
+		expectStarted []string
+	}{
+		{"Repository only", false, []string{"repo"}},
+		{"All pending", true, []string{"repo", "shared"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			started := []string{}
+			mux := http.NewServeMux()
+			mux.HandleFunc("/v1/configurations", func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_ = json.NewEncoder(w).Encode(model.ConfigurationsResponse{
+					Configurations: []*model.Configuration{remote["current"], remote["repo"], remote["shared"]},
+				})
+			})
+			mux.HandleFunc("/v1/configurations/{name}", func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_ = json.NewEncoder(w).Encode(model.ConfigurationResponse{Configuration: remote[r.PathValue("name")]})
+			})
+			mux.HandleFunc("/v1/rollouts/{name}/status", func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_ = json.NewEncoder(w).Encode(model.ConfigurationResponse{Configuration: remote[r.PathValue("name")]})
+			})
+			mux.HandleFunc("POST /v1/rollouts/{name}/start", func(w http.ResponseWriter, r *http.Request) {
+				started = append(started, r.PathValue("name"))
+			})
+
+			events := []string{}
+			webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				e := map[string]any{}
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&e))
+				events = append(events, e["event"].(string)+" "+e["rollout"].(map[string]any)["name"].(string))
+			}))
+			defer webhook.Close()
+
+			a := newTestAction(t, mux, WithAutoRollout(true), WithRolloutAllPending(tc.allPending), WithWebhookURL(webhook.URL))
+			a.state.SetConfiguration("repo", model.AnyResource{})
+
+			require.NoError(t, a.AutoRollout())
+			require.Equal(t, tc.expectStarted, started)
+			require.Equal(t, tc.expectStarted, a.startedRollouts)
+			require.Len(t, events, len(tc.expectStarted))
+			for i, name := range tc.expectStarted {
+				require.Equal(t, "rollout_started "+name, events[i])
+			}
+		})
+	}
+}
+
+func TestAutoRolloutSelector(t *testing.T) {
+	configuration := func(name, team string) *model.Configuration {
+		c := &model.Configuration{}
+		c.Metadata.Name = name
+		c.Metadata.Labels = map[string]string{"team": team}
+		c.Status.Rollout.Status = model.RolloutStatusPending
+		return c
+	}
+
+	remote := map[string]*model.Configuration{
+		"repo":         configuration("repo", "platform"),
+		"payments-a1f": configuration("payments-a1f", "payments"),
+		"payments-9c2": configuration("payments-9c2", "payments"),
+		"checkout-77d": configuration("checkout-77d", "checkout"),
+	}
+
+	cases := []struct {
+		name            string
+		ignoresSelector bool
+	}{
+		{"Resolved by the server", false},
+		{"Server ignores the selector", true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			started := []string{}
+			mux := http.NewServeMux()
+			mux.HandleFunc("/v1/configurations", func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, "team=payments", r.URL.Query().Get("selector"))
+				configurations := []*model.Configuration{remote["checkout-77d"], remote["payments-a1f"], remote["payments-9c2"]}
+				if !tc.ignoresSelector {
+					configurations = configurations[1:]
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_ = json.NewEncoder(w).Encode(model.ConfigurationsResponse{Configurations: configurations})
+			})
+			mux.HandleFunc("/v1/configurations/{name}", func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_ = json.NewEncoder(w).Encode(model.ConfigurationResponse{Configuration: remote[r.PathValue("name")]})
+			})
+			mux.HandleFunc("/v1/rollouts/{name}/status", func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_ = json.NewEncoder(w).Encode(model.ConfigurationResponse{Configuration: remote[r.PathValue("name")]})
+			})
+			mux.HandleFunc("POST /v1/rollouts/{name}/start", func(_ http.ResponseWriter, r *http.Request) {
+				started = append(started, r.PathValue("name"))
+			})
+
+			a := newTestAction(t, mux, WithAutoRollout(true), WithRolloutSelector("team=payments"))
+			a.state.SetConfiguration("repo", model.AnyResource{})
+
+			require.NoError(t, a.AutoRollout())
+			require.Equal(t, []string{"repo", "payments-a1f", "payments-9c2"}, started)
+		})
+	}
+}
+
+func TestRunRolloutVersion(t *testing.T) {
+	cases := []struct {
+		name          string
+		config        string
+		expectStarted []string
+		expectErr     string
+	}{
+		{"latest", "gateway", []string{"gateway"}, ""},
+		{"pinned", "gateway:3", []string{"gateway:3"}, ""},
+		{"missing version", "gateway:7", []string{}, "configuration gateway does not have version 7"},
+		{"invalid version", "gateway:latest", []string{}, "configuration gateway:latest must be a name, or a name and version such as gateway:3"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			started := []string{}
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /v1/configurations/{name}", func(w http.ResponseWriter, r *http.Request) {
+				if r.PathValue("name") != "gateway:3" {
+					http.NotFound(w, r)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"configuration":{"kind":"Configuration","metadata":{"name":"gateway","version":3},"spec":{}}}`))
+			})
+			mux.HandleFunc("POST /v1/rollouts/{name}/start", func(w http.ResponseWriter, r *http.Request) {
+				started = append(started, r.PathValue("name"))
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{}`))
+			})
+
+			a := newTestAction(t, mux)
+			err := a.RunRollout(tc.config)
+			if tc.expectErr != "" {
+				require.EqualError(t, err, tc.expectErr)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, []string{"gateway"}, a.startedRollouts)
+			}
+			require.Equal(t, tc.expectStarted, started)
+		})
+	}
+}
+
+func TestRunRolloutResume(t *testing.T) {
+	cases := []struct {
+		name      string
+		config    string
+		status    model.RolloutStatus
+		expectErr string
+	}{
+		{"in progress", "gateway", model.RolloutStatusStarted, ""},
+		{"in progress version", "gateway:3", model.RolloutStatusStarted, ""},
+		{"other version", "gateway:2", model.RolloutStatusStarted, "start rollout: BindPlane API returned status 409: rollout is already in progress"},
+		{"paused", "gateway", model.RolloutStatusPaused, "start rollout: BindPlane API returned status 409: rollout is already in progress"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			polls := 0
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /v1/configurations/{name}", func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"configuration":{"kind":"Configuration","metadata":{"name":"gateway","version":3},"spec":{}}}`))
+			})
+			mux.HandleFunc("POST /v1/rollouts/{name}/start", func(w http.ResponseWriter, _ *http.Request) {
+				http.Error(w, "rollout is already in progress", http.StatusConflict)
+			})
+			mux.HandleFunc("GET /v1/rollouts/{name}/status", func(w http.ResponseWriter, _ *http.Request) {
+				c := model.Configuration{}
+				c.Metadata.Name = "gateway"
+				c.Metadata.Version = 3
+				c.Status.Rollout.Status = tc.status
+				if polls > 1 {
+					c.Status.Rollout.Status = model.RolloutStatusStable
+				}
+				polls++
+				w.Header().Set("Content-Type", "application/json")
+				_ = json.NewEncoder(w).Encode(model.ConfigurationResponse{Configuration: &c})
+			})
+
+			a := newTestAction(t, mux, WithRolloutWait(true), WithRolloutPollInterval(time.Millisecond))
+			err := a.RunRollout(tc.config)
+			if tc.expectErr != "" {
+				require.EqualError(t, err, tc.expectErr)
+				require.Empty(t, a.startedRollouts)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, []string{"gateway"}, a.startedRollouts)
+			require.Equal(t, 3, polls, "the resumed rollout is waited on until it is stable")
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package action
+
+import (
+	"fmt"
+
+	"github.com/observiq/bindplane-op-action/internal/workflow"
+	"github.com/observiq/bindplane-op-action/pkg/client/version"
+	"go.uber.org/zap"
+)
+
+// The oldest and newest BindPlane server versions the action is tested
+// against, matching the bindplane_versions of the CI workflow. Update
+// them when the versions tested change.
+const (
+	OldestTestedBindPlaneVersion = "v1.45.0"
+	NewestTestedBindPlaneVersion = "v1.88.1"
+)
+
+// VersionSkewMinorVersions is the number of minor versions newer than
+// NewestTestedBindPlaneVersion, of the same major version, which are
+// expected to work with the action
+const VersionSkewMinorVersions = 5
+
+// WithFailOnVersionSkew sets the flag to fail, instead of warn, when the
+// BindPlane server version is outside of the versions supported
+func WithFailOnVersionSkew(b bool) Option {
+	return func(a *Action) {
+		a.failOnVersionSkew = b
+	}
+}
+
+// CheckVersionSkew warns when the BindPlane server version, found by
+// TestConnection, is older than the oldest version the action is tested
+// against, or newer than the newest by more than VersionSkewMinorVersions
+// minor versions. An error is returned instead when failOnVersionSkew is
+// set. Servers without a semantic version, such as development builds,
+// are not checked.
+func (a *Action) CheckVersionSkew() error {
+	skew, err := versionSkew(a.bindplaneVersion.Tag)
+	if err != nil {
+		a.Logger.Debug("Skipping BindPlane version skew check", zap.Error(err))
+		return nil
+	}
+	if skew == "" {
+		return nil
+	}
+
+	if a.failOnVersionSkew {
+		return fmt.Errorf("%s, upgrade the action or the server, or disable fail_on_version_skew", skew)
+	}
+	a.Logger.Warn("BindPlane version skew", zap.String("reason", skew))
+	workflow.Warning("", 0, "BindPlane version skew", skew)
+	return nil
+}
+
+// versionSkew returns why the server version is unsupported, or
+// an empty string if it is within the supported window
+func versionSkew(tag string) (string, error) {
+	server, err := version.Parse(tag)
+	if err != nil {
+		return "", err
+	}
+	newest, err := version.Parse(NewestTestedBindPlaneVersion)
+	if err != nil {
+		return "", err
+	}
+
+	ok, err := version.Version{Tag: tag}.AtLeast(OldestTestedBindPlaneVersion)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return fmt.Sprintf("BindPlane server version %s is older than %s, the oldest version supported by this version of the action", tag, OldestTestedBindPlaneVersion), nil
+	}
+	if server[0] > newest[0] || (server[0] == newest[0] && server[1] > newest[1]+VersionSkewMinorVersions) {
+		return fmt.Sprintf("BindPlane server version %s is more than %d minor versions newer than %s, the newest version this version of the action is tested against", tag, VersionSkewMinorVersions, NewestTestedBindPlaneVersion), nil
+	}
+	return "", nil
+}
@@ -0,0 +1,51 @@
+package action
+
+import (
+	"testing"
+
+	"github.com/observiq/bindplane-op-action/pkg/client/version"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestVersionSkew(t *testing.T) {
+	cases := []struct {
+		tag       string
+		expect    string
+		expectErr bool
+	}{
+		{OldestTestedBindPlaneVersion, "", false},
+		{NewestTestedBindPlaneVersion, "", false},
+		{"v1.93.0", "", false},
+		{"v1.44.9", "BindPlane server version v1.44.9 is older than v1.45.0, the oldest version supported by this version of the action", false},
+		{"v1.94.0", "BindPlane server version v1.94.0 is more than 5 minor versions newer than v1.88.1, the newest version this version of the action is tested against", false},
+		{"v2.0.0", "BindPlane server version v2.0.0 is more than 5 minor versions newer than v1.88.1, the newest version this version of the action is tested against", false},
+		{"latest", "", true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.tag, func(t *testing.T) {
+			skew, err := versionSkew(tc.tag)
+			if tc.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, skew)
+		})
+	}
+}
+
+func TestCheckVersionSkew(t *testing.T) {
+	a := &Action{Logger: zap.NewNop(), bindplaneVersion: version.Version{Tag: "v1.40.0"}}
+	require.NoError(t, a.CheckVersionSkew(), "warns by default")
+
+	WithFailOnVersionSkew(true)(a)
+	require.EqualError(t, a.CheckVersionSkew(), "BindPlane server version v1.40.0 is older than v1.45.0, the oldest version supported by this version of the action, upgrade the action or the server, or disable fail_on_version_skew")
+
+	a.bindplaneVersion.Tag = "v1.80.0"
+	require.NoError(t, a.CheckVersionSkew())
+
+	a.bindplaneVersion.Tag = "dev"
+	require.NoError(t, a.CheckVersionSkew(), "development servers are not checked")
+}
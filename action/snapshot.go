@@ -0,0 +1,171 @@
+package action
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// WithSnapshotPath sets the path of the snapshot file. Run writes the
+// server version of each resource to it before applying, and Restore
+// applies the resources it contains.
+func WithSnapshotPath(path string) Option {
+	return func(a *Action) {
+		a.snapshotPath = path
+	}
+}
+
+// WriteSnapshot writes the server version of every resource the run may
+// change to the snapshot path, before anything is applied, so a bad
+// deploy can be undone with Restore. The snapshot is a multi-document
+// YAML file, in the order resources are applied, without the fields
+// managed by the server. Resources which do not exist on the server yet
+// are not written. When prune is confirmed, the resources which would be
+// pruned are written as well. Agent versions are not written.
+func (a *Action) WriteSnapshot() error {
+	touched := map[model.Kind]map[string]bool{}
+	add := func(r *model.AnyResource) {
+		kind := model.Kind(r.Kind)
+		if touched[kind] == nil {
+			touched[kind] = map[string]bool{}
+		}
+		touched[kind][r.Metadata.Name] = true
+	}
+	for _, resources := range a.resources {
+		for _, r := range resources {
+			add(r)
+		}
+	}
+	if a.prune && a.pruneConfirm {
+		candidates, err := a.pruneCandidates()
+		if err != nil {
+			return err
+		}
+		for _, r := range candidates {
+			add(r)
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	enc := yaml.NewEncoder(buf)
+	enc.SetIndent(2)
+
+	count := 0
+	for _, f := range a.resourceFiles() {
+		if f.kind == model.KindAgentVersion || len(touched[f.kind]) == 0 {
+			continue
+		}
+
+		resources, err := a.client.Resources(a.ctx, f.kind)
+		if err != nil {
+			return fmt.Errorf("get %s: %w", f.label, err)
+		}
+		for _, r := range resources {
+			if !touched[f.kind][r.Metadata.Name] {
+				continue
+			}
+			if err := enc.Encode(withoutServerFields(r)); err != nil {
+				return fmt.Errorf("marshal %s %s: %w", r.Kind, r.Metadata.Name, err)
+			}
+			count++
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("marshal snapshot: %w", err)
+	}
+
+	if err := os.WriteFile(a.snapshotPath, buf.Bytes(), 0600); err != nil {
+		return fmt.Errorf("write snapshot %s: %w", a.snapshotPath, err)
+	}
+	a.Logger.Info("Wrote snapshot of resources before apply", zap.String("path", a.snapshotPath), zap.Int("resources", count))
+	return nil
+}
+
+// Restore applies the resources in the snapshot at the snapshot path,
+// returning them to the versions they had before the run which wrote it.
+// Kinds are applied in the same order as Run, and resources created by
+// that run are not deleted. Restored configurations have a new pending
+// version, which is rolled out when auto rollout is enabled.
+func (a *Action) Restore() error {
+	if err := a.checkFreeze(time.Now()); err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(a.snapshotPath)
+	if err != nil {
+		return fmt.Errorf("read snapshot: %w", err)
+	}
+	// Snapshots hold server values, so variables are not resolved
+	resources, _, err := decodeResources(a.snapshotPath, a.snapshotPath, data, nil)
+	if err != nil {
+		return fmt.Errorf("decode snapshot: %w", err)
+	}
+	if len(resources) == 0 {
+		a.Logger.Info("Snapshot has no resources to restore", zap.String("path", a.snapshotPath))
+		return nil
+	}
+
+	byKind := map[model.Kind][]*model.AnyResource{}
+	for _, r := range resources {
+		byKind[model.Kind(r.Kind)] = append(byKind[model.Kind(r.Kind)], r)
+	}
+
+	errs := []error{}
+	for _, f := range a.resourceFiles() {
+		if len(byKind[f.kind]) == 0 {
+			continue
+		}
+		a.Logger.Info("Restoring resources from snapshot", zap.String("kind", string(f.kind)), zap.Int("resources", len(byKind[f.kind])))
+		if err := a.apply(byKind[f.kind]); err != nil {
+			err = fmt.Errorf("%s: %w", f.label, err)
+			if !a.continueOnError() {
+				return fmt.Errorf("failed to restore resources: %w", err)
+			}
+			errs = append(errs, err)
+		}
+		delete(byKind, f.kind)
+	}
+	for kind := range byKind {
+		errs = append(errs, fmt.Errorf("snapshot resources of kind %s cannot be restored", kind))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("failed to restore resources: %w", err)
+	}
+
+	if a.autoRollout {
+		if err := a.AutoRollout(); err != nil {
+			return fmt.Errorf("failed to rollout configuration: %w", err)
+		}
+	}
+
+	if a.rolloutWait {
+		if err := a.WaitForRollouts(); err != nil {
+			return fmt.Errorf("failed waiting for rollout: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// withoutServerFields returns a copy of r without the fields managed by
+// the server, which identify its current version and are assigned again
+// when it is applied. Lock labels are removed as well, because snapshots
+// are written while the run holds its locks, and restoring them would lock
+// the configurations for a run which has ended.
+func withoutServerFields(r *model.AnyResource) *model.AnyResource {
+	c := *r
+	if c.Metadata.Labels != nil {
+		c.Metadata.Labels = withoutLockLabels(c.Metadata.Labels)
+	}
+	c.Metadata.ID = ""
+	c.Metadata.Hash = ""
+	c.Metadata.Version = 0
+	c.Metadata.DateModified = nil
+	return &c
+}
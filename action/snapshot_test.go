@@ -0,0 +1,99 @@
+package action
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshot(t *testing.T) {
+	remote := map[string][]*model.AnyResource{
+		"configurations": {
+			testResource(model.KindConfiguration, "k8s-node", map[string]any{"selector": "old"}),
+			testResource(model.KindConfiguration, "ui-config", nil),
+		},
+		"destinations": {
+			testResource(model.KindDestination, "otlp", map[string]any{"type": "otlp"}),
+		},
+	}
+	remote["configurations"][0].Metadata.ID = "1"
+	remote["configurations"][0].Metadata.Version = 4
+	remote["configurations"][0].Metadata.Hash = "abc"
+	// The snapshot is written while this run holds its lock
+	remote["configurations"][0].Metadata.Labels = map[string]string{
+		"env":            "prod",
+		LabelLockHolder:  "run-1",
+		LabelLockExpires: "1700000000",
+	}
+
+	var applied []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/{kind}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{r.PathValue("kind"): remote[r.PathValue("kind")]})
+	})
+	mux.HandleFunc("POST /v1/apply", func(w http.ResponseWriter, r *http.Request) {
+		payload := model.ApplyPayload{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		updates := []*model.AnyResourceStatus{}
+		for _, resource := range payload.Resources {
+			require.Empty(t, resource.Metadata.ID)
+			require.Zero(t, resource.Metadata.Version)
+			require.Empty(t, resource.Metadata.Hash)
+			require.NotContains(t, resource.Metadata.Labels, LabelLockHolder)
+			require.NotContains(t, resource.Metadata.Labels, LabelLockExpires)
+			applied = append(applied, resource.Kind+"/"+resource.Metadata.Name)
+			updates = append(updates, &model.AnyResourceStatus{Resource: *resource, Status: model.StatusConfigured})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(model.ApplyResponseClientSide{Updates: updates})
+	})
+
+	path := filepath.Join(t.TempDir(), "snapshot.yaml")
+
+	// Only resources in the repository which exist on the server are written
+	a := newTestAction(t, mux, WithConfigurationPath("testdata/configuration.yaml"), WithSnapshotPath(path))
+	require.NoError(t, a.LoadResources())
+	require.NoError(t, a.WriteSnapshot())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	resources, _, err := decodeResources(path, path, data, nil)
+	require.NoError(t, err)
+	require.Len(t, resources, 1)
+	require.Equal(t, "k8s-node", resources[0].Metadata.Name)
+	require.Equal(t, "old", resources[0].Spec["selector"])
+	require.Equal(t, map[string]string{"env": "prod"}, resources[0].Metadata.Labels)
+	require.Contains(t, remote["configurations"][0].Metadata.Labels, LabelLockHolder)
+
+	// The snapshot is restored without the resource paths
+	a = newTestAction(t, mux, WithSnapshotPath(path))
+	require.NoError(t, a.Restore())
+	require.Equal(t, []string{"Configuration/k8s-node"}, applied)
+	require.Len(t, a.state.ResourceStatuses(), 1)
+
+	// Snapshots are restored in the order kinds are applied
+	data = []byte(`---
+apiVersion: bindplane.observiq.com/v1
+kind: Configuration
+metadata:
+  name: k8s-node
+---
+apiVersion: bindplane.observiq.com/v1
+kind: Destination
+metadata:
+  name: otlp
+`)
+	require.NoError(t, os.WriteFile(path, data, 0600))
+	applied = nil
+	require.NoError(t, a.Restore())
+	require.Equal(t, []string{"Destination/otlp", "Configuration/k8s-node"}, applied)
+
+	a = newTestAction(t, mux, WithSnapshotPath(filepath.Join(t.TempDir(), "missing.yaml")))
+	require.ErrorContains(t, a.Restore(), "read snapshot")
+}
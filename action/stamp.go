@@ -0,0 +1,96 @@
+package action
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/observiq/bindplane-op-action/internal/ci"
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+)
+
+// Labels added to every resource when stamping is enabled, see WithStampLabels
+const (
+	// LabelManagedBy identifies the resources applied by the action.
+	// A managed-by label set in the repository is kept.
+	LabelManagedBy = "managed-by"
+
+	// LabelSourceRepo is the repository the resource was applied from
+	LabelSourceRepo = "source-repo"
+
+	// LabelContentHash is the hash of the resource's content, without
+	// the stamped labels, when it was applied
+	LabelContentHash = "content-hash"
+
+	// managedByValue is the value of LabelManagedBy
+	managedByValue = "bindplane-op-action"
+)
+
+// invalidLabelChars matches characters which are not allowed in label values
+var invalidLabelChars = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// stampResources adds the managed-by, source-repo, and content-hash labels
+// to every resource, so resources applied by the action can be selected,
+// and resources modified outside of the repository can be found by
+// comparing their content with the content hash.
+func (a *Action) stampResources(resources map[model.Kind][]*model.AnyResource) {
+	if !a.stampLabels {
+		return
+	}
+
+	repository := labelValue(ci.Detect().Repository)
+	for _, list := range resources {
+		for _, r := range list {
+			hash := ContentHash(r)
+			if r.Metadata.Labels == nil {
+				r.Metadata.Labels = map[string]string{}
+			}
+			if _, ok := r.Metadata.Labels[LabelManagedBy]; !ok {
+				r.Metadata.Labels[LabelManagedBy] = managedByValue
+			}
+			if repository != "" {
+				r.Metadata.Labels[LabelSourceRepo] = repository
+			}
+			r.Metadata.Labels[LabelContentHash] = hash
+		}
+	}
+}
+
+// ContentHash returns a hash of the display name, description, labels, and
+// spec of a resource. Stamped labels, IDs, and empty values are not
+// included, so a resource read from the server has the same hash as the
+// resource it was applied from.
+func ContentHash(r *model.AnyResource) string {
+	labels := map[string]string{}
+	for k, v := range r.Metadata.Labels {
+		switch {
+		case k == LabelSourceRepo, k == LabelContentHash, k == LabelLockHolder, k == LabelLockExpires:
+		case k == LabelManagedBy && v == managedByValue:
+		default:
+			labels[k] = v
+		}
+	}
+
+	content := map[string]any{
+		"displayName": r.Metadata.DisplayName,
+		"description": r.Metadata.Description,
+		"labels":      labels,
+		"spec":        r.Spec,
+	}
+
+	// Maps are marshaled with sorted keys, so the hash is stable
+	data, _ := json.Marshal(normalize(content))
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// labelValue converts s, such as owner/repo, to a valid label value
+func labelValue(s string) string {
+	v := invalidLabelChars.ReplaceAllString(s, "_")
+	if len(v) > 63 {
+		v = v[:63]
+	}
+	return strings.Trim(v, "_.-")
+}
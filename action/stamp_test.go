@@ -0,0 +1,61 @@
+package action
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadResourcesStamp(t *testing.T) {
+	t.Setenv("GITHUB_ACTIONS", "true")
+	t.Setenv("GITHUB_REPOSITORY", "observiq/otel-configs")
+
+	destinations := filepath.Join(t.TempDir(), "destinations.yaml")
+	require.NoError(t, os.WriteFile(destinations, []byte(`apiVersion: bindplane.observiq.com/v1
+kind: Destination
+metadata:
+  name: otlp
+spec:
+  type: otlp_grpc
+---
+apiVersion: bindplane.observiq.com/v1
+kind: Destination
+metadata:
+  name: logging
+  labels:
+    managed-by: platform-team
+spec:
+  type: logging
+`), 0o600))
+
+	a := newTestAction(t, http.NewServeMux(), WithDestinationPath(destinations), WithStampLabels(true))
+	require.NoError(t, a.LoadResources())
+
+	otlp := a.resources[model.KindDestination][0]
+	require.Equal(t, "bindplane-op-action", otlp.Metadata.Labels[LabelManagedBy])
+	require.Equal(t, "observiq_otel-configs", otlp.Metadata.Labels[LabelSourceRepo])
+	require.Len(t, otlp.Metadata.Labels[LabelContentHash], 16)
+	require.Equal(t, otlp.Metadata.Labels[LabelContentHash], ContentHash(otlp), "stamped labels are not part of the hash")
+
+	logging := a.resources[model.KindDestination][1]
+	require.Equal(t, "platform-team", logging.Metadata.Labels[LabelManagedBy])
+	require.NotEqual(t, otlp.Metadata.Labels[LabelContentHash], logging.Metadata.Labels[LabelContentHash])
+
+	modified := *otlp
+	modified.Spec = map[string]any{"type": "otlp_http"}
+	require.NotEqual(t, otlp.Metadata.Labels[LabelContentHash], ContentHash(&modified))
+
+	a = newTestAction(t, http.NewServeMux(), WithDestinationPath(destinations))
+	require.NoError(t, a.LoadResources())
+	require.Empty(t, a.resources[model.KindDestination][0].Metadata.Labels)
+}
+
+func TestLabelValue(t *testing.T) {
+	require.Equal(t, "observiq_bindplane-op-action", labelValue("observiq/bindplane-op-action"))
+	require.Equal(t, "group_sub_project", labelValue("group/sub project"))
+	require.Equal(t, "", labelValue("/"))
+}
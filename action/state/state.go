@@ -3,7 +3,7 @@ package state
 import (
 	"sync"
 
-	"github.com/observiq/bindplane-op-action/internal/client/model"
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
 )
 
 // state can be used to cache data during the
@@ -14,6 +14,13 @@ type State interface {
 
 	// SetConfiguration inserts a configuration into the state
 	SetConfiguration(name string, configuration model.AnyResource)
+
+	// AddResourceStatus records the status of an applied resource
+	AddResourceStatus(status model.AnyResourceStatus)
+
+	// ResourceStatuses returns the status of all applied resources
+	// in the order they were applied
+	ResourceStatuses() []model.AnyResourceStatus
 }
 
 // Memory is a state that stores data in memory
@@ -24,6 +31,9 @@ type Memory struct {
 	// The key is the name of the configuration
 	// and value is the AnyResource representation
 	configurations map[string]model.AnyResource
+
+	// statuses is the list of applied resource statuses
+	statuses []model.AnyResourceStatus
 }
 
 var _ State = &Memory{}
@@ -54,3 +64,20 @@ func (m *Memory) SetConfiguration(name string, configuration model.AnyResource)
 	defer m.mu.Unlock()
 	m.configurations[name] = configuration
 }
+
+// AddResourceStatus appends the status of an applied resource
+func (m *Memory) AddResourceStatus(status model.AnyResourceStatus) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.statuses = append(m.statuses, status)
+}
+
+// ResourceStatuses returns a copy of the applied resource statuses
+func (m *Memory) ResourceStatuses() []model.AnyResourceStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	statuses := make([]model.AnyResourceStatus, len(m.statuses))
+	copy(statuses, m.statuses)
+	return statuses
+}
@@ -3,7 +3,7 @@ package state
 import (
 	"testing"
 
-	"github.com/observiq/bindplane-op-action/internal/client/model"
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
 	"github.com/stretchr/testify/require"
 )
 
@@ -26,3 +26,20 @@ func TestNewMemory(t *testing.T) {
 	require.Len(t, out, 1)
 	require.Equal(t, "test", out[0])
 }
+
+func TestMemoryResourceStatuses(t *testing.T) {
+	memory := NewMemory()
+	require.Empty(t, memory.ResourceStatuses())
+
+	a := model.AnyResourceStatus{Status: model.StatusCreated}
+	b := model.AnyResourceStatus{Status: model.StatusUnchanged}
+	memory.AddResourceStatus(a)
+	memory.AddResourceStatus(b)
+
+	out := memory.ResourceStatuses()
+	require.Equal(t, []model.AnyResourceStatus{a, b}, out)
+
+	// Modifying the returned slice does not modify the state
+	out[0].Status = model.StatusError
+	require.Equal(t, model.StatusCreated, memory.ResourceStatuses()[0].Status)
+}
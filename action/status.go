@@ -0,0 +1,102 @@
+package action
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/observiq/bindplane-op-action/internal/workflow"
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"go.uber.org/zap"
+)
+
+// ActiveRollout is a rollout which is pending, in progress, or errored
+type ActiveRollout struct {
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	Version   int    `json:"version"`
+	Completed int    `json:"completed"`
+	Errors    int    `json:"errors"`
+	Pending   int    `json:"pending"`
+	Waiting   int    `json:"waiting"`
+}
+
+// RunStatus logs every pending, in progress, and errored rollout on the
+// BindPlane server, and writes them to the rollouts output. Errored rollouts
+// are annotated as warnings.
+func (a *Action) RunStatus() error {
+	rollouts, err := a.ActiveRollouts()
+	if err != nil {
+		return fmt.Errorf("list rollouts: %w", err)
+	}
+
+	for _, r := range rollouts {
+		fields := []zap.Field{
+			zap.String("name", r.Name),
+			zap.String("status", r.Status),
+			zap.Int("version", r.Version),
+			zap.Int("completed", r.Completed),
+			zap.Int("errors", r.Errors),
+			zap.Int("pending", r.Pending),
+			zap.Int("waiting", r.Waiting),
+		}
+		if r.Status == model.RolloutStatusError.String() {
+			a.Logger.Warn("Rollout errored", fields...)
+			workflow.Warning("", 0, "Rollout errored", fmt.Sprintf("Rollout for configuration %s errored with %d agent errors", r.Name, r.Errors))
+			continue
+		}
+		a.Logger.Info("Rollout", fields...)
+	}
+
+	if len(rollouts) == 0 {
+		a.Logger.Info("No pending, in progress, or errored rollouts")
+	}
+
+	data, err := json.Marshal(rollouts)
+	if err != nil {
+		return fmt.Errorf("marshal rollouts: %w", err)
+	}
+	if err := workflow.SetOutput(OutputRollouts, string(data)); err != nil {
+		return fmt.Errorf("set output %s: %w", OutputRollouts, err)
+	}
+
+	return nil
+}
+
+// ActiveRollouts returns the pending, in progress, and errored
+// rollouts on the BindPlane server, sorted by configuration name
+func (a *Action) ActiveRollouts() ([]ActiveRollout, error) {
+	configurations, err := a.client.Rollouts(a.ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rollouts := []ActiveRollout{}
+	for _, c := range configurations {
+		if c == nil {
+			continue
+		}
+
+		r := c.Status.Rollout
+		switch r.Status {
+		case model.RolloutStatusPending, model.RolloutStatusStarted, model.RolloutStatusError:
+		default:
+			continue
+		}
+
+		rollouts = append(rollouts, ActiveRollout{
+			Name:      c.Metadata.Name,
+			Status:    r.Status.String(),
+			Version:   c.Metadata.Version,
+			Completed: r.Progress.Completed,
+			Errors:    r.Progress.Errors,
+			Pending:   r.Progress.Pending,
+			Waiting:   r.Progress.Waiting,
+		})
+	}
+
+	sort.Slice(rollouts, func(i, j int) bool {
+		return rollouts[i].Name < rollouts[j].Name
+	})
+	return rollouts, nil
+}
@@ -0,0 +1,62 @@
+package action
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/observiq/bindplane-op-action/internal/workflow"
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunStatus(t *testing.T) {
+	configuration := func(name string, status model.RolloutStatus, errors int) *model.Configuration {
+		c := &model.Configuration{}
+		c.Metadata.Name = name
+		c.Metadata.Version = 2
+		c.Status.Rollout.Status = status
+		c.Status.Rollout.Progress = model.RolloutProgress{Completed: 4, Errors: errors, Waiting: 1}
+		return c
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/rollouts", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(model.RolloutsResponse{
+			Configurations: []*model.Configuration{
+				configuration("stable", model.RolloutStatusStable, 0),
+				configuration("started", model.RolloutStatusStarted, 0),
+				configuration("k8s-node", model.RolloutStatusError, 3),
+				configuration("pending", model.RolloutStatusPending, 0),
+				configuration("replaced", model.RolloutStatusReplaced, 0),
+			},
+		})
+	})
+
+	buf := &bytes.Buffer{}
+	workflow.Output = buf
+	defer func() { workflow.Output = os.Stdout }()
+
+	outputFile := t.TempDir() + "/output"
+	t.Setenv("GITHUB_OUTPUT", outputFile)
+
+	a := newTestAction(t, mux)
+	rollouts, err := a.ActiveRollouts()
+	require.NoError(t, err)
+	require.Equal(t, []ActiveRollout{
+		{Name: "k8s-node", Status: "error", Version: 2, Completed: 4, Errors: 3, Waiting: 1},
+		{Name: "pending", Status: "pending", Version: 2, Completed: 4, Waiting: 1},
+		{Name: "started", Status: "started", Version: 2, Completed: 4, Waiting: 1},
+	}, rollouts)
+
+	require.NoError(t, a.RunStatus())
+	require.Equal(t, "::warning title=Rollout errored::Rollout for configuration k8s-node errored with 3 agent errors\n", buf.String())
+
+	data, err := os.ReadFile(outputFile)
+	require.NoError(t, err)
+	require.Contains(t, string(data), "rollouts<<")
+	require.Contains(t, string(data), `"name":"started"`)
+}
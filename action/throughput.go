@@ -0,0 +1,110 @@
+package action
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// throughputPeriod is the period throughput is averaged over
+const throughputPeriod = "1m"
+
+// DefaultThroughputWait is the default amount of time to wait after
+// rollouts complete before throughput is measured
+const DefaultThroughputWait = 2 * time.Minute
+
+// configurationThroughput returns the bytes per second a configuration
+// sends to its destinations, by pipeline type, such as logs
+func (a *Action) configurationThroughput(name string) (map[string]float64, error) {
+	metrics, err := a.client.ConfigurationMetrics(a.ctx, name, throughputPeriod)
+	if err != nil {
+		return nil, fmt.Errorf("get metrics of configuration %s: %w", name, err)
+	}
+
+	throughput := map[string]float64{}
+	for _, m := range metrics {
+		if strings.HasPrefix(m.NodeID, "destination") {
+			throughput[m.PipelineType] += m.Value
+		}
+	}
+	return throughput, nil
+}
+
+// recordThroughput records the throughput of a configuration before its
+// rollout starts, which VerifyThroughput compares against
+func (a *Action) recordThroughput(name string) error {
+	if a.minThroughputPercent <= 0 {
+		return nil
+	}
+
+	throughput, err := a.configurationThroughput(name)
+	if err != nil {
+		return err
+	}
+	if a.throughputBaselines == nil {
+		a.throughputBaselines = map[string]map[string]float64{}
+	}
+	a.throughputBaselines[name] = throughput
+	a.rolloutLogger().Info("Recorded throughput before rollout", zap.String("name", name), zap.Any("throughput", throughput))
+	return nil
+}
+
+// VerifyThroughput compares the throughput of each rolled out configuration
+// with its throughput before the rollout, and returns an error if a
+// pipeline type dropped below the minimum throughput percent. A rollout can
+// succeed while the new configuration silently stops sending telemetry.
+// Pipeline types without throughput before the rollout are not compared.
+func (a *Action) VerifyThroughput() error {
+	if len(a.throughputBaselines) == 0 {
+		return nil
+	}
+
+	wait := a.throughputWait
+	if wait <= 0 {
+		wait = DefaultThroughputWait
+	}
+	a.rolloutLogger().Info("Waiting before measuring throughput", zap.Duration("throughput_wait", wait))
+	time.Sleep(wait)
+
+	names := []string{}
+	for name := range a.throughputBaselines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	errs := []error{}
+	for _, name := range names {
+		current, err := a.configurationThroughput(name)
+		if err != nil {
+			return err
+		}
+
+		baseline := a.throughputBaselines[name]
+		types := []string{}
+		for t := range baseline {
+			types = append(types, t)
+		}
+		sort.Strings(types)
+
+		for _, t := range types {
+			if baseline[t] <= 0 {
+				continue
+			}
+			percent := current[t] / baseline[t] * 100
+			a.rolloutLogger().Info("Throughput after rollout",
+				zap.String("name", name),
+				zap.String("pipeline", t),
+				zap.Float64("before", baseline[t]),
+				zap.Float64("after", current[t]),
+			)
+			if percent < float64(a.minThroughputPercent) {
+				errs = append(errs, fmt.Errorf("configuration %s %s throughput dropped to %.0f%% of its throughput before the rollout, below min_throughput_percent %d", name, t, percent, a.minThroughputPercent))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,91 @@
+package action
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyThroughput(t *testing.T) {
+	before := []*model.Metric{
+		{NodeID: "source/otlp", PipelineType: "logs", Value: 1000},
+		{NodeID: "destination/otlp", PipelineType: "logs", Value: 600},
+		{NodeID: "destination/backup", PipelineType: "logs", Value: 400},
+		{NodeID: "destination/otlp", PipelineType: "metrics", Value: 200},
+	}
+
+	cases := []struct {
+		name      string
+		after     []*model.Metric
+		expectErr string
+	}{
+		{
+			"throughput kept",
+			[]*model.Metric{
+				{NodeID: "destination/otlp", PipelineType: "logs", Value: 950},
+				{NodeID: "destination/otlp", PipelineType: "metrics", Value: 180},
+				{NodeID: "destination/otlp", PipelineType: "traces", Value: 50},
+			},
+			"",
+		},
+		{
+			"logs dropped",
+			[]*model.Metric{
+				{NodeID: "source/otlp", PipelineType: "logs", Value: 1000},
+				{NodeID: "destination/otlp", PipelineType: "logs", Value: 300},
+				{NodeID: "destination/otlp", PipelineType: "metrics", Value: 200},
+			},
+			"configuration gateway logs throughput dropped to 30% of its throughput before the rollout, below min_throughput_percent 80",
+		},
+		{
+			"no throughput",
+			[]*model.Metric{},
+			"configuration gateway logs throughput dropped to 0% of its throughput before the rollout, below min_throughput_percent 80\nconfiguration gateway metrics throughput dropped to 0% of its throughput before the rollout, below min_throughput_percent 80",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			started := false
+			mux := http.NewServeMux()
+			mux.HandleFunc("POST /v1/graphql", func(w http.ResponseWriter, _ *http.Request) {
+				resp := model.ConfigurationMetricsResponse{}
+				resp.Data.ConfigurationMetrics.Metrics = before
+				if started {
+					resp.Data.ConfigurationMetrics.Metrics = tc.after
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_ = json.NewEncoder(w).Encode(resp)
+			})
+			mux.HandleFunc("POST /v1/rollouts/{name}/start", func(w http.ResponseWriter, _ *http.Request) {
+				started = true
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{}`))
+			})
+			mux.HandleFunc("GET /v1/rollouts/{name}/status", func(w http.ResponseWriter, r *http.Request) {
+				c := model.Configuration{}
+				c.Metadata.Name = r.PathValue("name")
+				c.Status.Rollout.Status = model.RolloutStatusStable
+				w.Header().Set("Content-Type", "application/json")
+				_ = json.NewEncoder(w).Encode(model.ConfigurationResponse{Configuration: &c})
+			})
+
+			a := newTestAction(t, mux,
+				WithRolloutWait(true),
+				WithRolloutPollInterval(time.Millisecond),
+				WithMinThroughputPercent(80),
+				WithThroughputWait(time.Millisecond),
+			)
+			err := a.RunRollout("gateway")
+			if tc.expectErr != "" {
+				require.EqualError(t, err, "failed waiting for rollout: "+tc.expectErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
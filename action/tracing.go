@@ -0,0 +1,51 @@
+package action
+
+import (
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer creates action spans. Spans are only exported when a tracer
+// provider is registered with otel.SetTracerProvider.
+var tracer = otel.Tracer("github.com/observiq/bindplane-op-action/action")
+
+// startSpan starts a child span of the action context and makes it the
+// action context, so requests and spans started before the returned func
+// is called are its children. The func ends the span, recording err, and
+// restores the parent context. Spans must not be started concurrently.
+func (a *Action) startSpan(name string, attrs ...attribute.KeyValue) func(err error) {
+	parent := a.ctx
+	ctx, span := tracer.Start(parent, name, trace.WithAttributes(attrs...))
+	a.ctx = ctx
+
+	return func(err error) {
+		endSpan(span, err)
+		a.ctx = parent
+	}
+}
+
+// endSpan ends the span, marking it as failed when err is not nil
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// resourceSpan records the result of applying a resource as a span.
+// Resources which were not applied are marked as failed.
+func (a *Action) resourceSpan(s *model.AnyResourceStatus) {
+	_, span := tracer.Start(a.ctx, "apply.resource", trace.WithAttributes(
+		attribute.String("bindplane.kind", s.Resource.Kind),
+		attribute.String("bindplane.name", s.Resource.Metadata.Name),
+		attribute.String("bindplane.status", string(s.Status)),
+	))
+	if !s.Status.Succeeded() {
+		span.SetStatus(codes.Error, s.Reason)
+	}
+	span.End()
+}
@@ -0,0 +1,113 @@
+package action
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSpans(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+
+	polls := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/apply", func(w http.ResponseWriter, r *http.Request) {
+		payload := model.ApplyPayload{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+
+		updates := []*model.AnyResourceStatus{}
+		for _, resource := range payload.Resources {
+			status := model.StatusCreated
+			if resource.Metadata.Name == "invalid" {
+				status = model.StatusInvalid
+			}
+			updates = append(updates, &model.AnyResourceStatus{Resource: *resource, Status: status, Reason: "bad"})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(model.ApplyResponseClientSide{Updates: updates})
+	})
+	mux.HandleFunc("/v1/rollouts/{name}/status", func(w http.ResponseWriter, r *http.Request) {
+		polls++
+		c := model.Configuration{}
+		c.Metadata.Name = r.PathValue("name")
+		c.Status.Rollout.Status = model.RolloutStatusStarted
+		if polls > 1 {
+			c.Status.Rollout.Status = model.RolloutStatusStable
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(model.ConfigurationResponse{Configuration: &c})
+	})
+
+	spanNames := func(spans tracetest.SpanStubs) []string {
+		names := []string{}
+		for _, s := range spans {
+			names = append(names, s.Name)
+		}
+		return names
+	}
+
+	t.Run("apply", func(t *testing.T) {
+		exporter.Reset()
+
+		a := newTestAction(t, mux, WithSourcePath("sources.yaml"))
+		a.resources = map[model.Kind][]*model.AnyResource{
+			model.KindSource: {
+				testResource(model.KindSource, "valid", nil),
+				testResource(model.KindSource, "invalid", nil),
+			},
+		}
+		require.ErrorContains(t, a.Apply(), "invalid resource: invalid: bad")
+
+		spans := exporter.GetSpans()
+		require.Equal(t, []string{"POST /apply", "apply.resource", "apply.resource", "apply.kind", "apply"}, spanNames(spans))
+
+		apply, kind := spans[4], spans[3]
+		require.Equal(t, codes.Error, apply.Status.Code)
+		require.Equal(t, apply.SpanContext.SpanID(), kind.Parent.SpanID())
+		require.Contains(t, kind.Attributes, attribute.String("bindplane.kind", "Source"))
+		for _, s := range spans[:3] {
+			require.Equal(t, kind.SpanContext.SpanID(), s.Parent.SpanID())
+		}
+
+		valid, invalid := spans[1], spans[2]
+		require.Contains(t, valid.Attributes, attribute.String("bindplane.name", "valid"))
+		require.Equal(t, codes.Unset, valid.Status.Code)
+		require.Contains(t, invalid.Attributes, attribute.String("bindplane.status", "invalid"))
+		require.Equal(t, codes.Error, invalid.Status.Code)
+	})
+
+	t.Run("rollout wait", func(t *testing.T) {
+		exporter.Reset()
+
+		a := newTestAction(t, mux, WithRolloutWait(true), WithRolloutPollInterval(time.Millisecond))
+		a.startedRollouts = []string{"my-config"}
+		require.NoError(t, a.WaitForRollouts())
+
+		spans := exporter.GetSpans()
+		require.Equal(t, []string{
+			"GET /rollouts/my-config/status", "rollout.poll",
+			"GET /rollouts/my-config/status", "rollout.poll",
+			"rollout.wait",
+		}, spanNames(spans))
+
+		// Status requests are children of their poll
+		wait := spans[4]
+		for i, poll := range []tracetest.SpanStub{spans[1], spans[3]} {
+			require.Equal(t, poll.SpanContext.SpanID(), spans[2*i].Parent.SpanID())
+			require.Equal(t, wait.SpanContext.SpanID(), poll.Parent.SpanID())
+			require.Contains(t, poll.Attributes, attribute.Int("bindplane.rollout.poll", i+1))
+			require.Len(t, poll.Events, 1)
+			require.Equal(t, "rollout progress", poll.Events[0].Name)
+		}
+	})
+}
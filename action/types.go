@@ -0,0 +1,120 @@
+package action
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/observiq/bindplane-op-action/internal/workflow"
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"go.uber.org/zap"
+)
+
+// typeReference is a source or destination type referenced by a resource
+type typeReference struct {
+	// kind and name identify the resource which references the type
+	kind string
+	name string
+
+	// typeKind is either KindSource or KindDestination
+	typeKind model.Kind
+	typ      string
+}
+
+// CheckResourceTypes returns an error if any loaded resource references a
+// source or destination type which does not exist on the BindPlane server.
+// Each missing type is annotated on the file of the resource referencing it.
+func (a *Action) CheckResourceTypes() error {
+	if a.resources == nil {
+		if err := a.LoadResources(); err != nil {
+			return fmt.Errorf("load resources: %w", err)
+		}
+	}
+
+	refs := typeReferences(a.resources)
+	if len(refs) == 0 {
+		return nil
+	}
+
+	sourceTypes, err := a.client.SourceTypes(a.ctx)
+	if err != nil {
+		return fmt.Errorf("get source types: %w", err)
+	}
+
+	destinationTypes, err := a.client.DestinationTypes(a.ctx)
+	if err != nil {
+		return fmt.Errorf("get destination types: %w", err)
+	}
+
+	known := map[model.Kind]map[string]struct{}{
+		model.KindSource:      typeNames(sourceTypes),
+		model.KindDestination: typeNames(destinationTypes),
+	}
+
+	errs := []error{}
+	for _, ref := range refs {
+		if _, ok := known[ref.typeKind][ref.typ]; ok {
+			continue
+		}
+
+		message := fmt.Sprintf("%s %s references %s type %s which does not exist on the BindPlane server", ref.kind, ref.name, strings.ToLower(string(ref.typeKind)), ref.typ)
+		a.annotateResource(workflow.Error, ref.kind, ref.name, "Unknown resource type", message)
+		errs = append(errs, errors.New(message))
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	a.Logger.Debug("All referenced resource types exist", zap.Int("references", len(refs)))
+	return nil
+}
+
+// typeReferences returns the source and destination types referenced by
+// resources, sorted by resource kind and name. Source and Destination
+// resources reference their own type. Configurations reference the types
+// of inline sources and destinations, references to library resources by
+// name do not have a type.
+func typeReferences(resources map[model.Kind][]*model.AnyResource) []typeReference {
+	refs := []typeReference{}
+	for _, kind := range []model.Kind{model.KindSource, model.KindDestination} {
+		for _, r := range resources[kind] {
+			if typ, ok := r.Spec["type"].(string); ok && typ != "" {
+				refs = append(refs, typeReference{r.Kind, r.Metadata.Name, kind, typ})
+			}
+		}
+	}
+
+	for _, r := range resources[model.KindConfiguration] {
+		for field, kind := range map[string]model.Kind{"sources": model.KindSource, "destinations": model.KindDestination} {
+			items, _ := r.Spec[field].([]any)
+			for _, item := range items {
+				m, _ := item.(map[string]any)
+				if typ, ok := m["type"].(string); ok && typ != "" {
+					refs = append(refs, typeReference{r.Kind, r.Metadata.Name, kind, typ})
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(refs, func(i, j int) bool {
+		if refs[i].kind != refs[j].kind {
+			return refs[i].kind < refs[j].kind
+		}
+		if refs[i].name != refs[j].name {
+			return refs[i].name < refs[j].name
+		}
+		return refs[i].typ < refs[j].typ
+	})
+	return refs
+}
+
+// typeNames returns the set of names of the resource types
+func typeNames(types []*model.ResourceType) map[string]struct{} {
+	names := make(map[string]struct{}, len(types))
+	for _, t := range types {
+		names[t.Metadata.Name] = struct{}{}
+	}
+	return names
+}
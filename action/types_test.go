@@ -0,0 +1,81 @@
+package action
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/observiq/bindplane-op-action/internal/workflow"
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTypeReferences(t *testing.T) {
+	a := &Action{configurationPath: "testdata/configuration.yaml"}
+	require.NoError(t, a.LoadResources())
+
+	refs := typeReferences(a.resources)
+	require.Equal(t, []typeReference{
+		{"Configuration", "k8s-cluster", model.KindSource, "k8s_cluster"},
+		{"Configuration", "k8s-cluster", model.KindSource, "k8s_events"},
+		{"Configuration", "k8s-gateway", model.KindSource, "otlp"},
+		{"Configuration", "k8s-node", model.KindSource, "k8s_container"},
+		{"Configuration", "k8s-node", model.KindSource, "k8s_kubelet"},
+	}, refs)
+}
+
+func TestCheckResourceTypes(t *testing.T) {
+	typesHandler := func(field string, names ...string) http.HandlerFunc {
+		return func(w http.ResponseWriter, _ *http.Request) {
+			types := []*model.ResourceType{}
+			for _, name := range names {
+				rt := &model.ResourceType{}
+				rt.Metadata.Name = name
+				types = append(types, rt)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]any{field: types})
+		}
+	}
+
+	cases := []struct {
+		name        string
+		sourceTypes []string
+		expectErr   string
+	}{
+		{
+			"All types exist",
+			[]string{"k8s_cluster", "k8s_events", "otlp", "k8s_container", "k8s_kubelet"},
+			"",
+		},
+		{
+			"Missing types",
+			[]string{"k8s_cluster", "otlp", "k8s_container"},
+			"Configuration k8s-cluster references source type k8s_events which does not exist on the BindPlane server\nConfiguration k8s-node references source type k8s_kubelet which does not exist on the BindPlane server",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/v1/source-types", typesHandler("sourceTypes", tc.sourceTypes...))
+			mux.HandleFunc("/v1/destination-types", typesHandler("destinationTypes"))
+
+			buf := &bytes.Buffer{}
+			workflow.Output = buf
+			defer func() { workflow.Output = os.Stdout }()
+
+			a := newTestAction(t, mux, WithConfigurationPath("testdata/configuration.yaml"))
+			err := a.CheckResourceTypes()
+			if tc.expectErr == "" {
+				require.NoError(t, err)
+				require.Empty(t, buf.String())
+				return
+			}
+			require.EqualError(t, err, tc.expectErr)
+			require.Contains(t, buf.String(), "::error file=testdata/configuration.yaml,line=106,title=Unknown resource type::Configuration k8s-node references source type k8s_kubelet")
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package action
+
+import (
+	"fmt"
+
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"go.uber.org/zap"
+)
+
+// ReasonSkippedUnchanged is the reason of resources which were not applied
+// because they are the same as the server's current version
+const ReasonSkippedUnchanged = "skipped, unchanged on the server"
+
+// unchangedResources compares resources with the server's current version
+// of each, and returns the resources which must be applied. Resources which
+// are the same as the server are recorded as unchanged without being
+// applied. Agent versions are always applied.
+func (a *Action) unchangedResources(kind model.Kind, resources []*model.AnyResource) ([]*model.AnyResource, error) {
+	if !a.skipUnchanged || kind == model.KindAgentVersion || len(resources) == 0 {
+		return resources, nil
+	}
+
+	remote, err := a.client.Resources(a.ctx, kind)
+	if err != nil {
+		return nil, fmt.Errorf("get %s: %w", kind, err)
+	}
+	remoteByName := map[string]*model.AnyResource{}
+	for _, r := range remote {
+		remoteByName[r.Metadata.Name] = r
+	}
+
+	out := []*model.AnyResource{}
+	for _, r := range resources {
+		server, ok := remoteByName[r.Metadata.Name]
+		if !ok || len(diffFields(r, server)) > 0 {
+			out = append(out, r)
+			continue
+		}
+
+		a.Logger.Info("Resource unchanged, skipped", zap.String("name", r.Metadata.Name), zap.String("kind", r.Kind))
+		a.state.AddResourceStatus(model.AnyResourceStatus{Resource: *server, Status: model.StatusUnchanged, Reason: ReasonSkippedUnchanged})
+		if r.Kind == string(model.KindConfiguration) {
+			a.state.SetConfiguration(server.Metadata.Name, *server)
+		}
+	}
+	return out, nil
+}
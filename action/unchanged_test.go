@@ -0,0 +1,95 @@
+package action
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplySkipUnchanged(t *testing.T) {
+	dir := t.TempDir()
+	destinations := filepath.Join(dir, "destinations.yaml")
+	require.NoError(t, os.WriteFile(destinations, []byte(`apiVersion: bindplane.observiq.com/v1
+kind: Destination
+metadata:
+  name: otlp
+spec:
+  type: otlp_grpc
+  parameters:
+    - name: hostname
+      value: otlp.example.com
+---
+apiVersion: bindplane.observiq.com/v1
+kind: Destination
+metadata:
+  name: logging
+spec:
+  type: logging
+---
+apiVersion: bindplane.observiq.com/v1
+kind: Destination
+metadata:
+  name: new
+spec:
+  type: logging
+`), 0o600))
+
+	cases := []struct {
+		name          string
+		skip          bool
+		expectApplied []string
+		expectSkipped []string
+	}{
+		{"skip unchanged", true, []string{"logging", "new"}, []string{"otlp"}},
+		{"disabled", false, []string{"otlp", "logging", "new"}, nil},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			applied := []string{}
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /v1/destinations", func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"destinations":[
+					{"kind":"Destination","metadata":{"id":"01","name":"otlp","version":4},"spec":{"type":"otlp_grpc","parameters":[{"name":"hostname","value":"otlp.example.com"}]}},
+					{"kind":"Destination","metadata":{"id":"02","name":"logging","version":1},"spec":{"type":"logging","parameters":[{"name":"verbosity","value":"detailed"}]}}
+				]}`))
+			})
+			mux.HandleFunc("POST /v1/apply", func(w http.ResponseWriter, r *http.Request) {
+				payload := model.ApplyPayload{}
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+				resp := model.ApplyResponseClientSide{}
+				for _, res := range payload.Resources {
+					applied = append(applied, res.Metadata.Name)
+					resp.Updates = append(resp.Updates, &model.AnyResourceStatus{Resource: *res, Status: model.StatusConfigured})
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_ = json.NewEncoder(w).Encode(resp)
+			})
+
+			a := newTestAction(t, mux,
+				WithDestinationPath(destinations),
+				WithSkipUnchanged(tc.skip),
+			)
+			require.NoError(t, a.Apply())
+			require.Equal(t, tc.expectApplied, applied)
+
+			skipped := []string{}
+			for _, r := range a.appliedResources() {
+				if r.Reason == ReasonSkippedUnchanged {
+					skipped = append(skipped, r.Name)
+				}
+			}
+			if tc.expectSkipped == nil {
+				require.Empty(t, skipped)
+			} else {
+				require.Equal(t, tc.expectSkipped, skipped)
+			}
+		})
+	}
+}
@@ -1,26 +1,41 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/observiq/bindplane-op-action/action"
+	"github.com/observiq/bindplane-op-action/internal/telemetry"
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
 )
 
 // parseArgs parses the arguments passed to the action. The action will always
 // pass the correct number of arguments regardless of the user input. If the
 // number of args passed is not expected, an error will be returned indicating
-// a bug with the action.
+// a bug with the action. Otherwise, every invalid input is returned.
 func parseArgs() error {
 	args := os.Args
 
 	// Add one to account for arg 0 being the binary name
 	count := argCount + 1
 	if len(args) != count {
-		return fmt.Errorf("Not enough arguments, expected 17, got %d. %s.", len(args), action.BugError)
+		return fmt.Errorf("Not enough arguments, expected %d, got %d. %s.", count, len(args), action.BugError)
 	}
 
+	// Inputs the workflow did not set are read from the environment,
+	// then the config file
+	args, err := applyConfigFile(args)
+	if err != nil {
+		return err
+	}
+
+	// Every invalid input is reported, not only the first
+	errs := []error{}
+
 	// First arg is always the binary name, so we skip it. We could
 	// also use args[1:] to get all args after the binary name but
 	// that could introduce confusion as the first arg would be at index 0.
@@ -38,7 +53,7 @@ func parseArgs() error {
 
 	b, err := strconv.ParseBool(args[8])
 	if err != nil {
-		return fmt.Errorf("enable_otel_config_write_back must be a boolean value")
+		errs = append(errs, fix("Set enable_otel_config_write_back to true or false.", "enable_otel_config_write_back must be a boolean value"))
 	}
 	enable_otel_config_write_back = b
 
@@ -47,7 +62,7 @@ func parseArgs() error {
 
 	b, err = strconv.ParseBool(args[11])
 	if err != nil {
-		return fmt.Errorf("enable_auto_rollout must be a boolean value")
+		errs = append(errs, fix("Set enable_auto_rollout to true or false.", "enable_auto_rollout must be a boolean value"))
 	}
 	enable_auto_rollout = b
 
@@ -64,8 +79,499 @@ func parseArgs() error {
 	source_path = args[14]
 	processor_path = args[15]
 	github_url = args[16]
+	environment = args[17]
+	variables_path = args[18]
 
-	return nil
+	if args[19] != "" {
+		n, err := strconv.Atoi(args[19])
+		if err != nil {
+			errs = append(errs, fix("Use a whole number, such as 5.", "retry_max_attempts must be an integer"))
+		}
+		retry_max_attempts = n
+	}
+
+	if args[20] != "" {
+		d, err := time.ParseDuration(args[20])
+		if err != nil {
+			errs = append(errs, fix("Use a number followed by a unit of s, m, or h.", "retry_max_elapsed_time must be a duration such as 30s or 5m"))
+		}
+		retry_max_elapsed_time = d
+	}
+
+	codes, err := parseStatusCodes(args[21])
+	if err != nil {
+		errs = append(errs, fix("Use a comma separated list of HTTP status codes, such as 429,503.", "retry_status_codes: %w", err))
+	}
+	retry_status_codes = codes
+
+	freeze_windows_path = args[22]
+
+	b, err = strconv.ParseBool(args[23])
+	if err != nil {
+		errs = append(errs, fix("Set freeze_override to true or false.", "freeze_override must be a boolean value"))
+	}
+	freeze_override = b
+
+	tls_cert = args[24]
+	tls_key = args[25]
+
+	b, err = strconv.ParseBool(args[26])
+	if err != nil {
+		errs = append(errs, fix("Set insecure_skip_verify to true or false.", "insecure_skip_verify must be a boolean value"))
+	}
+	insecure_skip_verify = b
+
+	tls_min_version = args[27]
+	tls_cipher_suites = splitList(args[28])
+	log_level = args[29]
+	log_format = args[30]
+
+	b, err = strconv.ParseBool(args[31])
+	if err != nil {
+		errs = append(errs, fix("Set http_trace to true or false.", "http_trace must be a boolean value"))
+	}
+	http_trace = b
+
+	statuses, err := parseUpdateStatuses(args[32])
+	if err != nil {
+		errs = append(errs, fix("Use a comma separated list of unsuccessful statuses, such as invalid,error.", "fail_on_statuses: %w", err))
+	}
+	fail_on_statuses = statuses
+
+	profiles_path = args[33]
+	profile_names = splitList(args[34])
+	bindplane_account_id = args[35]
+	bindplane_project_id = args[36]
+	min_bindplane_version = args[37]
+	agent_version_path = args[38]
+
+	b, err = strconv.ParseBool(args[39])
+	if err != nil {
+		errs = append(errs, fix("Set validate_rendered_config to true or false.", "validate_rendered_config must be a boolean value"))
+	}
+	validate_rendered_config = b
+
+	mode = args[40]
+	if mode == "" {
+		mode = modeApply
+	}
+
+	export_dir = args[41]
+
+	b, err = strconv.ParseBool(args[42])
+	if err != nil {
+		errs = append(errs, fix("Set fail_on_drift to true or false.", "fail_on_drift must be a boolean value"))
+	}
+	fail_on_drift = b
+
+	b, err = strconv.ParseBool(args[43])
+	if err != nil {
+		errs = append(errs, fix("Set prune to true or false.", "prune must be a boolean value"))
+	}
+	prune = b
+
+	prune_selector = args[44]
+
+	b, err = strconv.ParseBool(args[45])
+	if err != nil {
+		errs = append(errs, fix("Set prune_confirm to true or false.", "prune_confirm must be a boolean value"))
+	}
+	prune_confirm = b
+
+	protected_resources = splitList(args[46])
+	protected_selector = args[47]
+
+	b, err = strconv.ParseBool(args[48])
+	if err != nil {
+		errs = append(errs, fix("Set rollout_wait to true or false.", "rollout_wait must be a boolean value"))
+	}
+	rollout_wait = b
+
+	if args[49] != "" {
+		d, err := time.ParseDuration(args[49])
+		if err != nil {
+			errs = append(errs, fix("Use a number followed by a unit of s, m, or h.", "rollout_timeout must be a duration such as 30s or 5m"))
+		}
+		rollout_timeout = d
+	}
+
+	if args[50] != "" {
+		d, err := time.ParseDuration(args[50])
+		if err != nil {
+			errs = append(errs, fix("Use a number followed by a unit of s, m, or h.", "rollout_poll_interval must be a duration such as 30s or 5m"))
+		}
+		rollout_poll_interval = d
+	}
+
+	if args[51] != "" {
+		t, err := action.ParseErrorThreshold(args[51])
+		if err != nil {
+			errs = append(errs, fix("Use a number of agents, such as 5, or a percentage, such as 10%.", "max_rollout_errors: %w", err))
+		}
+		max_rollout_errors = t
+	}
+
+	b, err = strconv.ParseBool(args[52])
+	if err != nil {
+		errs = append(errs, fix("Set rollout_pause_on_errors to true or false.", "rollout_pause_on_errors must be a boolean value"))
+	}
+	rollout_pause_on_errors = b
+
+	if args[53] != "" {
+		d, err := time.ParseDuration(args[53])
+		if err != nil {
+			errs = append(errs, fix("Use a number followed by a unit of s, m, or h.", "apply_timeout must be a duration such as 30s or 5m"))
+		}
+		apply_timeout = d
+	}
+
+	if args[54] != "" {
+		d, err := time.ParseDuration(args[54])
+		if err != nil {
+			errs = append(errs, fix("Use a number followed by a unit of s, m, or h.", "fetch_timeout must be a duration such as 30s or 5m"))
+		}
+		fetch_timeout = d
+	}
+
+	if args[55] != "" {
+		f, err := strconv.ParseFloat(args[55], 64)
+		if err != nil {
+			errs = append(errs, fix("Use a number of requests per second, such as 5 or 0.5.", "rate_limit must be a number"))
+		}
+		rate_limit = f
+	}
+
+	if args[56] != "" {
+		n, err := strconv.Atoi(args[56])
+		if err != nil {
+			errs = append(errs, fix("Use a whole number, such as 5.", "apply_concurrency must be an integer"))
+		}
+		apply_concurrency = n
+	}
+
+	size, err := parseByteSize(args[57])
+	if err != nil {
+		errs = append(errs, fix("Use a size such as 5MB or 512KiB.", "apply_max_payload_size: %w", err))
+	}
+	apply_max_payload_size = size
+
+	b, err = strconv.ParseBool(args[58])
+	if err != nil {
+		errs = append(errs, fix("Set rollout_all_pending to true or false.", "rollout_all_pending must be a boolean value"))
+	}
+	rollout_all_pending = b
+
+	slack_webhook_url = args[59]
+	webhook_url = args[60]
+	webhook_template = args[61]
+	webhook_events = splitList(args[62])
+
+	b, err = strconv.ParseBool(args[63])
+	if err != nil {
+		errs = append(errs, fix("Set github_deployment to true or false.", "github_deployment must be a boolean value"))
+	}
+	github_deployment = b
+
+	github_deployment_environment = args[64]
+
+	b, err = strconv.ParseBool(args[65])
+	if err != nil {
+		errs = append(errs, fix("Set commit_status to true or false.", "commit_status must be a boolean value"))
+	}
+	commit_status = b
+
+	commit_status_prefix = args[66]
+	otel_exporter_endpoint = args[67]
+
+	headers, err := telemetry.ParseHeaders(args[68])
+	if err != nil {
+		errs = append(errs, fix("Use a comma separated list of key=value pairs, such as api-key=secret.", "otel_exporter_headers: %w", err))
+	}
+	otel_exporter_headers = headers
+
+	oidc_broker_url = args[70]
+	oidc_audience = args[71]
+	oidc_role = args[72]
+	oidc_credential_field = args[73]
+	secret_store = args[74]
+	secret_store_url = args[75]
+	secret_path = args[76]
+	secret_role = args[77]
+
+	httpHeaders, err := telemetry.ParseHeaders(args[78])
+	if err != nil {
+		errs = append(errs, fix("Use a comma separated list of key=value pairs, such as x-tenant=payments.", "http_headers: %w", err))
+	}
+	http_headers = httpHeaders
+
+	api_version = args[79]
+	rollout_selector = args[80]
+
+	resourceURLHeaders, err := telemetry.ParseHeaders(args[81])
+	if err != nil {
+		errs = append(errs, fix("Use a comma separated list of key=value pairs, such as authorization=Bearer token.", "resource_url_headers: %w", err))
+	}
+	resource_url_headers = resourceURLHeaders
+
+	oci_artifact = args[82]
+	oci_username = args[83]
+	oci_password = args[84]
+	overlays_dir = args[85]
+	patches_path = args[86]
+	golden_dir = args[87]
+
+	b, err = strconv.ParseBool(args[88])
+	if err != nil {
+		errs = append(errs, fix("Set golden_update to true or false.", "golden_update must be a boolean value"))
+	}
+	golden_update = b
+
+	rollback_configuration = args[89]
+
+	if args[90] != "" {
+		n, err := strconv.Atoi(args[90])
+		if err != nil {
+			errs = append(errs, fix("Use a configuration version, such as 3.", "rollback_version must be an integer"))
+		}
+		rollback_version = n
+	}
+
+	b, err = strconv.ParseBool(args[91])
+	if err != nil {
+		errs = append(errs, fix("Set audit_summary to true or false.", "audit_summary must be a boolean value"))
+	}
+	audit_summary = b
+
+	audit_record_path = args[92]
+	apply_strategy = args[93]
+	status_report_path = args[94]
+
+	b, err = strconv.ParseBool(args[95])
+	if err != nil {
+		errs = append(errs, fix("Set changed_files_only to true or false.", "changed_files_only must be a boolean value"))
+	}
+	changed_files_only = b
+
+	changed_files_base = args[96]
+	resource_name_environment = args[97]
+	canary_selector = args[98]
+
+	if args[99] != "" {
+		d, err := time.ParseDuration(args[99])
+		if err != nil {
+			errs = append(errs, fix("Use a number followed by a unit of s, m, or h.", "canary_wait must be a duration such as 30s or 5m"))
+		}
+		canary_wait = d
+	}
+
+	agent_check = args[100]
+
+	if args[101] != "" {
+		r, err := action.ParseAgentRange(args[101])
+		if err != nil {
+			errs = append(errs, fix("Use a minimum number of agents, such as 10, or a range, such as 10-50.", "expected_agents: %w", err))
+		}
+		expected_agents = r
+	}
+
+	b, err = strconv.ParseBool(args[102])
+	if err != nil {
+		errs = append(errs, fix("Set agent_wait to true or false.", "agent_wait must be a boolean value"))
+	}
+	agent_wait = b
+
+	if args[103] != "" {
+		d, err := time.ParseDuration(args[103])
+		if err != nil {
+			errs = append(errs, fix("Use a number followed by a unit of s, m, or h.", "agent_wait_timeout must be a duration such as 30s or 5m"))
+		}
+		agent_wait_timeout = d
+	}
+
+	if args[104] != "" {
+		n, err := strconv.Atoi(args[104])
+		if err != nil {
+			errs = append(errs, fix("Use a whole number of days, such as 7.", "agent_cleanup_days must be an integer"))
+		}
+		agent_cleanup_days = n
+	}
+
+	if args[105] != "" {
+		n, err := strconv.Atoi(args[105])
+		if err != nil {
+			errs = append(errs, fix("Use a whole number percentage, such as 80.", "min_throughput_percent must be an integer"))
+		}
+		min_throughput_percent = n
+	}
+
+	if args[106] != "" {
+		d, err := time.ParseDuration(args[106])
+		if err != nil {
+			errs = append(errs, fix("Use a number followed by a unit of s, m, or h.", "throughput_wait must be a duration such as 30s or 5m"))
+		}
+		throughput_wait = d
+	}
+
+	b, err = strconv.ParseBool(args[107])
+	if err != nil {
+		errs = append(errs, fix("Set validate_pipelines to true or false.", "validate_pipelines must be a boolean value"))
+	}
+	validate_pipelines = b
+
+	b, err = strconv.ParseBool(args[108])
+	if err != nil {
+		errs = append(errs, fix("Set skip_unchanged to true or false.", "skip_unchanged must be a boolean value"))
+	}
+	skip_unchanged = b
+
+	b, err = strconv.ParseBool(args[109])
+	if err != nil {
+		errs = append(errs, fix("Set stamp_labels to true or false.", "stamp_labels must be a boolean value"))
+	}
+	stamp_labels = b
+
+	if args[110] != "" {
+		n, err := strconv.Atoi(args[110])
+		if err != nil {
+			errs = append(errs, fix("Use a whole number percentage, such as 20.", "rollout_poll_jitter must be an integer"))
+		}
+		rollout_poll_jitter = n
+	}
+
+	b, err = strconv.ParseBool(args[111])
+	if err != nil {
+		errs = append(errs, fix("Set lock to true or false.", "lock must be a boolean value"))
+	}
+	lock = b
+
+	if args[112] != "" {
+		d, err := time.ParseDuration(args[112])
+		if err != nil {
+			errs = append(errs, fix("Use a number followed by a unit of s, m, or h.", "lock_timeout must be a duration such as 30s or 5m"))
+		}
+		lock_timeout = d
+	}
+
+	log_levels = args[113]
+
+	b, err = strconv.ParseBool(args[114])
+	if err != nil {
+		errs = append(errs, fix("Set export_raw to true or false.", "export_raw must be a boolean value"))
+	}
+	export_raw = b
+
+	raw_config_dir = args[115]
+
+	b, err = strconv.ParseBool(args[116])
+	if err != nil {
+		errs = append(errs, fix("Set rendered_diff to true or false.", "rendered_diff must be a boolean value"))
+	}
+	rendered_diff = b
+
+	if args[117] != "" {
+		n, err := strconv.Atoi(args[117])
+		if err != nil {
+			errs = append(errs, fix("Use a whole number of connections, such as 10.", "max_idle_conns must be an integer"))
+		}
+		max_idle_conns = n
+	}
+
+	if args[118] != "" {
+		d, err := time.ParseDuration(args[118])
+		if err != nil {
+			errs = append(errs, fix("Use a number followed by a unit of s, m, or h.", "idle_conn_timeout must be a duration such as 30s or 5m"))
+		}
+		idle_conn_timeout = d
+	}
+
+	b, err = strconv.ParseBool(args[119])
+	if err != nil {
+		errs = append(errs, fix("Set disable_keep_alives to true or false.", "disable_keep_alives must be a boolean value"))
+	}
+	disable_keep_alives = b
+
+	dns_server = args[120]
+	ip_family = args[121]
+	proxy_url = args[122]
+
+	b, err = strconv.ParseBool(args[123])
+	if err != nil {
+		errs = append(errs, fix("Set disable_adaptive_throttling to true or false.", "disable_adaptive_throttling must be a boolean value"))
+	}
+	disable_adaptive_throttling = b
+
+	b, err = strconv.ParseBool(args[124])
+	if err != nil {
+		errs = append(errs, fix("Set fail_on_version_skew to true or false.", "fail_on_version_skew must be a boolean value"))
+	}
+	fail_on_version_skew = b
+
+	snapshot_path = args[125]
+
+	return errors.Join(errs...)
+}
+
+// splitList splits a comma separated list, trimming
+// whitespace and omitting empty values
+func splitList(s string) []string {
+	values := []string{}
+	for _, v := range strings.Split(s, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		values = append(values, v)
+	}
+	return values
+}
+
+// parseStatusCodes parses a comma separated list of HTTP status codes
+func parseStatusCodes(s string) ([]int, error) {
+	codes := []int{}
+	for _, c := range splitList(s) {
+		code, err := strconv.Atoi(c)
+		if err != nil {
+			return nil, fmt.Errorf("status code %s is not an integer", c)
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+// byteUnits are the size suffixes accepted by parseByteSize,
+// longest first so KiB is not matched as B
+var byteUnits = []struct {
+	suffix string
+	size   int
+}{
+	{"KiB", 1 << 10},
+	{"MiB", 1 << 20},
+	{"KB", 1000},
+	{"MB", 1000 * 1000},
+	{"B", 1},
+}
+
+// parseByteSize parses a size such as 5MB, 512KiB, or 1048576.
+// An empty string returns zero.
+func parseByteSize(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+
+	unit := 1
+	for _, u := range byteUnits {
+		if v, ok := strings.CutSuffix(s, u.suffix); ok {
+			s, unit = strings.TrimSpace(v), u.size
+			break
+		}
+	}
+
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("size must be a non-negative number of bytes, optionally with a KB, MB, KiB, or MiB suffix")
+	}
+	return n * unit, nil
 }
 
 // writeTLSFile takes a file path and writes the given contents to it
@@ -83,3 +589,19 @@ func writeTLSFile(path string, contents string) error {
 
 	return nil
 }
+
+// parseUpdateStatuses parses a comma separated list of resource statuses
+func parseUpdateStatuses(s string) ([]model.UpdateStatus, error) {
+	statuses := []model.UpdateStatus{}
+	for _, v := range splitList(s) {
+		status := model.UpdateStatus(v)
+		if !status.Valid() {
+			return nil, fmt.Errorf("unknown status %s", v)
+		}
+		if status.Succeeded() {
+			return nil, fmt.Errorf("status %s indicates success and cannot fail the action", v)
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
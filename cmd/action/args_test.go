@@ -0,0 +1,162 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStatusCodes(t *testing.T) {
+	cases := []struct {
+		name   string
+		input  string
+		expect []int
+		errStr string
+	}{
+		{
+			"Empty",
+			"",
+			[]int{},
+			"",
+		},
+		{
+			"Single",
+			"503",
+			[]int{503},
+			"",
+		},
+		{
+			"Multiple with spaces",
+			"429, 502,503 ,504,",
+			[]int{429, 502, 503, 504},
+			"",
+		},
+		{
+			"Invalid",
+			"429,abc",
+			nil,
+			"status code abc is not an integer",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			codes, err := parseStatusCodes(tc.input)
+			if tc.errStr != "" {
+				require.Error(t, err)
+				require.Equal(t, tc.errStr, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, codes)
+		})
+	}
+}
+
+func TestParseUpdateStatuses(t *testing.T) {
+	cases := []struct {
+		name   string
+		input  string
+		expect []model.UpdateStatus
+		errStr string
+	}{
+		{
+			"Empty",
+			"",
+			[]model.UpdateStatus{},
+			"",
+		},
+		{
+			"Multiple with spaces",
+			"invalid, error,in-use",
+			[]model.UpdateStatus{model.StatusInvalid, model.StatusError, model.StatusInUse},
+			"",
+		},
+		{
+			"Unknown",
+			"invalid,broken",
+			nil,
+			"unknown status broken",
+		},
+		{
+			"Success",
+			"error,unchanged",
+			nil,
+			"status unchanged indicates success and cannot fail the action",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			statuses, err := parseUpdateStatuses(tc.input)
+			if tc.errStr != "" {
+				require.Error(t, err)
+				require.Equal(t, tc.errStr, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, statuses)
+		})
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	cases := []struct {
+		name   string
+		input  string
+		expect int
+		errStr string
+	}{
+		{"Empty", "", 0, ""},
+		{"Bytes", "1048576", 1048576, ""},
+		{"Bytes suffix", "512B", 512, ""},
+		{"Kilobytes", "500KB", 500000, ""},
+		{"Megabytes", "5MB", 5000000, ""},
+		{"Kibibytes", "512KiB", 524288, ""},
+		{"Mebibytes", " 4 MiB ", 4194304, ""},
+		{"Invalid", "5GB", 0, "size must be a non-negative number of bytes, optionally with a KB, MB, KiB, or MiB suffix"},
+		{"Negative", "-1MB", 0, "size must be a non-negative number of bytes, optionally with a KB, MB, KiB, or MiB suffix"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			size, err := parseByteSize(tc.input)
+			if tc.errStr != "" {
+				require.EqualError(t, err, tc.errStr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, size)
+		})
+	}
+}
+
+func TestParseArgsReportsEveryProblem(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	args := make([]string, argCount+1)
+	args[indexOf(t, "prune")] = "yes"
+	args[indexOf(t, "rollout_timeout")] = "10"
+	args[indexOf(t, "rate_limit")] = "fast"
+
+	osArgs := os.Args
+	os.Args = args
+	defer func() {
+		// Reset the inputs parseArgs sets to non-zero defaults
+		os.Args = osArgs
+		log_level, log_format, mode, export_dir = "", "", "", ""
+		fail_on_drift = false
+	}()
+
+	msgs := []string{}
+	for _, err := range problems(parseArgs()) {
+		msgs = append(msgs, err.Error())
+	}
+	require.Equal(t, []string{
+		"prune must be a boolean value",
+		"rollout_timeout must be a duration such as 30s or 5m",
+		"rate_limit must be a number",
+	}, msgs)
+}
@@ -0,0 +1,556 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/observiq/bindplane-op-action/action/catalog"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultConfigPath is the configuration file used when config_path is
+// not set. It is optional, the action runs with inputs alone when it
+// does not exist.
+const defaultConfigPath = "bindplane-action.yaml"
+
+// configPathIndex is the argument index of the config_path input
+const configPathIndex = 69
+
+// envPrefix prefixes the environment variable of each input. The
+// bindplane_ prefix of inputs is not repeated, so bindplane_api_key
+// is read from BINDPLANE_API_KEY, the same as the command line.
+const envPrefix = "BINDPLANE_"
+
+// Sources of input values, from the highest precedence to the lowest
+const (
+	sourceInput   = "input"
+	sourceEnv     = "environment"
+	sourceFile    = "config file"
+	sourceDefault = "default"
+
+	// Credentials exchanged or fetched after inputs are read
+	// replace the credential inputs
+	sourceOIDC        = "oidc"
+	sourceSecretStore = "secret store"
+)
+
+// inputSources is the source of each input which has a value,
+// by input name. It is set by applyConfigFile.
+var inputSources = map[string]string{}
+
+// inputNames are the action inputs, in the order they are passed
+// as arguments. See action.yml.
+var inputNames = []string{
+	"bindplane_remote_url", "bindplane_api_key", "bindplane_username", "bindplane_password",
+	"target_branch", "destination_path", "configuration_path", "enable_otel_config_write_back",
+	"configuration_output_dir", "token", "enable_auto_rollout", "configuration_output_branch",
+	"tls_ca_cert", "source_path", "processor_path", "github_url", "environment", "variables_path",
+	"retry_max_attempts", "retry_max_elapsed_time", "retry_status_codes", "freeze_windows_path",
+	"freeze_override", "tls_cert", "tls_key", "insecure_skip_verify", "tls_min_version",
+	"tls_cipher_suites", "log_level", "log_format", "http_trace", "fail_on_statuses",
+	"profiles_path", "profile", "bindplane_account_id", "bindplane_project_id",
+	"min_bindplane_version", "agent_version_path", "validate_rendered_config", "mode",
+	"export_dir", "fail_on_drift", "prune", "prune_selector", "prune_confirm",
+	"protected_resources", "protected_selector", "rollout_wait", "rollout_timeout",
+	"rollout_poll_interval", "max_rollout_errors", "rollout_pause_on_errors", "apply_timeout",
+	"fetch_timeout", "rate_limit", "apply_concurrency", "apply_max_payload_size",
+	"rollout_all_pending", "slack_webhook_url", "webhook_url", "webhook_template",
+	"webhook_events", "github_deployment", "github_deployment_environment", "commit_status",
+	"commit_status_prefix", "otel_exporter_endpoint", "otel_exporter_headers", "config_path",
+	"oidc_broker_url", "oidc_audience", "oidc_role", "oidc_credential_field",
+	"secret_store", "secret_store_url", "secret_path", "secret_role", "http_headers",
+	"api_version", "rollout_selector", "resource_url_headers", "oci_artifact",
+	"oci_username", "oci_password", "overlays_dir", "patches_path",
+	"golden_dir", "golden_update", "rollback_configuration", "rollback_version",
+	"audit_summary", "audit_record_path", "apply_strategy", "status_report_path",
+	"changed_files_only", "changed_files_base", "resource_name_environment",
+	"canary_selector", "canary_wait", "agent_check", "expected_agents",
+	"agent_wait", "agent_wait_timeout", "agent_cleanup_days",
+	"min_throughput_percent", "throughput_wait", "validate_pipelines",
+	"skip_unchanged", "stamp_labels", "rollout_poll_jitter",
+	"lock", "lock_timeout", "log_levels", "export_raw", "raw_config_dir",
+	"rendered_diff", "max_idle_conns", "idle_conn_timeout", "disable_keep_alives",
+	"dns_server", "ip_family", "proxy_url", "disable_adaptive_throttling",
+	"fail_on_version_skew", "snapshot_path",
+}
+
+// inputDefaults are used for inputs set by neither the workflow nor the
+// configuration file. They are not defaults in action.yml, because the
+// action cannot tell a default apart from a value set by the workflow.
+var inputDefaults = map[string]string{
+	"enable_otel_config_write_back": "false",
+	"enable_auto_rollout":           "false",
+	"freeze_override":               "false",
+	"insecure_skip_verify":          "false",
+	"log_level":                     "info",
+	"log_format":                    "json",
+	"http_trace":                    "false",
+	"disable_keep_alives":           "false",
+	"disable_adaptive_throttling":   "false",
+	"fail_on_version_skew":          "false",
+	"validate_rendered_config":      "false",
+	"validate_pipelines":            "false",
+	"skip_unchanged":                "false",
+	"stamp_labels":                  "false",
+	"rendered_diff":                 "false",
+	"lock":                          "false",
+	"mode":                          modeApply,
+	"export_dir":                    "bindplane",
+	"export_raw":                    "false",
+	"fail_on_drift":                 "true",
+	"golden_dir":                    "golden",
+	"apply_strategy":                "fail_fast",
+	"changed_files_only":            "false",
+	"golden_update":                 "false",
+	"prune":                         "false",
+	"prune_confirm":                 "false",
+	"rollout_wait":                  "false",
+	"rollout_pause_on_errors":       "false",
+	"rollout_all_pending":           "false",
+	"github_deployment":             "false",
+	"commit_status":                 "false",
+	"audit_summary":                 "false",
+	"agent_wait":                    "false",
+	"rollout_poll_jitter":           "20",
+}
+
+// configFile is the action configuration file. Every value is optional,
+// and is used when the workflow does not set the matching input. Scalars
+// are decoded as strings, so they are parsed and reported on the same
+// way as inputs.
+type configFile struct {
+	TargetBranch string `yaml:"target_branch"`
+	Mode         string `yaml:"mode"`
+	ExportDir    string `yaml:"export_dir"`
+	ExportRaw    string `yaml:"export_raw"`
+	FailOnDrift  string `yaml:"fail_on_drift"`
+	GoldenDir    string `yaml:"golden_dir"`
+	GoldenUpdate string `yaml:"golden_update"`
+	RawConfigDir string `yaml:"raw_config_dir"`
+	SnapshotPath string `yaml:"snapshot_path"`
+
+	Rollback struct {
+		Configuration string `yaml:"configuration"`
+		Version       string `yaml:"version"`
+	} `yaml:"rollback"`
+
+	BindPlane struct {
+		RemoteURL         string `yaml:"remote_url"`
+		APIKey            string `yaml:"api_key"`
+		Username          string `yaml:"username"`
+		Password          string `yaml:"password"`
+		AccountID         string `yaml:"account_id"`
+		ProjectID         string `yaml:"project_id"`
+		MinVersion        string `yaml:"min_version"`
+		FailOnVersionSkew string `yaml:"fail_on_version_skew"`
+	} `yaml:"bindplane"`
+
+	TLS struct {
+		CACert             string   `yaml:"ca_cert"`
+		Cert               string   `yaml:"cert"`
+		Key                string   `yaml:"key"`
+		InsecureSkipVerify string   `yaml:"insecure_skip_verify"`
+		MinVersion         string   `yaml:"min_version"`
+		CipherSuites       []string `yaml:"cipher_suites"`
+	} `yaml:"tls"`
+
+	Client struct {
+		RetryMaxAttempts    string            `yaml:"retry_max_attempts"`
+		RetryMaxElapsedTime string            `yaml:"retry_max_elapsed_time"`
+		RetryStatusCodes    []string          `yaml:"retry_status_codes"`
+		ApplyTimeout        string            `yaml:"apply_timeout"`
+		FetchTimeout        string            `yaml:"fetch_timeout"`
+		RateLimit           string            `yaml:"rate_limit"`
+		MaxIdleConns        string            `yaml:"max_idle_conns"`
+		IdleConnTimeout     string            `yaml:"idle_conn_timeout"`
+		DisableKeepAlives   string            `yaml:"disable_keep_alives"`
+		DNSServer           string            `yaml:"dns_server"`
+		IPFamily            string            `yaml:"ip_family"`
+		ProxyURL            string            `yaml:"proxy_url"`
+		DisableThrottling   string            `yaml:"disable_adaptive_throttling"`
+		HTTPTrace           string            `yaml:"http_trace"`
+		Headers             map[string]string `yaml:"headers"`
+		APIVersion          string            `yaml:"api_version"`
+	} `yaml:"client"`
+
+	Log struct {
+		Level  string            `yaml:"level"`
+		Format string            `yaml:"format"`
+		Levels map[string]string `yaml:"levels"`
+	} `yaml:"log"`
+
+	// Profiles are the targets. They are either read from ProfilesPath,
+	// or defined inline with the same fields as a profiles file.
+	ProfilesPath string      `yaml:"profiles_path"`
+	Profiles     []yaml.Node `yaml:"profiles"`
+	Profile      []string    `yaml:"profile"`
+
+	Resources struct {
+		DestinationPath        string            `yaml:"destination_path"`
+		SourcePath             string            `yaml:"source_path"`
+		ProcessorPath          string            `yaml:"processor_path"`
+		AgentVersionPath       string            `yaml:"agent_version_path"`
+		ConfigurationPath      string            `yaml:"configuration_path"`
+		VariablesPath          string            `yaml:"variables_path"`
+		Environment            string            `yaml:"environment"`
+		ValidateRenderedConfig string            `yaml:"validate_rendered_config"`
+		ValidatePipelines      string            `yaml:"validate_pipelines"`
+		FailOnStatuses         []string          `yaml:"fail_on_statuses"`
+		ApplyConcurrency       string            `yaml:"apply_concurrency"`
+		ApplyMaxPayloadSize    string            `yaml:"apply_max_payload_size"`
+		ApplyStrategy          string            `yaml:"apply_strategy"`
+		StatusReportPath       string            `yaml:"status_report_path"`
+		ChangedFilesOnly       string            `yaml:"changed_files_only"`
+		ChangedFilesBase       string            `yaml:"changed_files_base"`
+		SkipUnchanged          string            `yaml:"skip_unchanged"`
+		StampLabels            string            `yaml:"stamp_labels"`
+		RenderedDiff           string            `yaml:"rendered_diff"`
+		Lock                   string            `yaml:"lock"`
+		LockTimeout            string            `yaml:"lock_timeout"`
+		NameEnvironment        string            `yaml:"resource_name_environment"`
+		URLHeaders             map[string]string `yaml:"url_headers"`
+		OverlaysDir            string            `yaml:"overlays_dir"`
+		PatchesPath            string            `yaml:"patches_path"`
+
+		OCI struct {
+			Artifact string `yaml:"artifact"`
+			Username string `yaml:"username"`
+			Password string `yaml:"password"`
+		} `yaml:"oci"`
+	} `yaml:"resources"`
+
+	Prune struct {
+		Enabled            string   `yaml:"enabled"`
+		Selector           string   `yaml:"selector"`
+		Confirm            string   `yaml:"confirm"`
+		ProtectedResources []string `yaml:"protected_resources"`
+		ProtectedSelector  string   `yaml:"protected_selector"`
+		AgentCleanupDays   string   `yaml:"agent_cleanup_days"`
+	} `yaml:"prune"`
+
+	Rollout struct {
+		Auto           string `yaml:"auto"`
+		AllPending     string `yaml:"all_pending"`
+		Selector       string `yaml:"selector"`
+		Wait           string `yaml:"wait"`
+		Timeout        string `yaml:"timeout"`
+		PollInterval   string `yaml:"poll_interval"`
+		PollJitter     string `yaml:"poll_jitter"`
+		MaxErrors      string `yaml:"max_errors"`
+		PauseOnErrors  string `yaml:"pause_on_errors"`
+		CanarySelector string `yaml:"canary_selector"`
+		CanaryWait     string `yaml:"canary_wait"`
+		AgentCheck     string `yaml:"agent_check"`
+		ExpectedAgents string `yaml:"expected_agents"`
+		AgentWait      string `yaml:"agent_wait"`
+		AgentTimeout   string `yaml:"agent_wait_timeout"`
+		MinThroughput  string `yaml:"min_throughput_percent"`
+		ThroughputWait string `yaml:"throughput_wait"`
+	} `yaml:"rollout"`
+
+	WriteBack struct {
+		Enabled   string `yaml:"enabled"`
+		OutputDir string `yaml:"output_dir"`
+		Branch    string `yaml:"branch"`
+	} `yaml:"write_back"`
+
+	Freeze struct {
+		WindowsPath string `yaml:"windows_path"`
+		Override    string `yaml:"override"`
+	} `yaml:"freeze"`
+
+	Notifications struct {
+		SlackWebhookURL string   `yaml:"slack_webhook_url"`
+		WebhookURL      string   `yaml:"webhook_url"`
+		WebhookTemplate string   `yaml:"webhook_template"`
+		WebhookEvents   []string `yaml:"webhook_events"`
+	} `yaml:"notifications"`
+
+	GitHub struct {
+		URL                   string `yaml:"url"`
+		Deployment            string `yaml:"deployment"`
+		DeploymentEnvironment string `yaml:"deployment_environment"`
+		CommitStatus          string `yaml:"commit_status"`
+		CommitStatusPrefix    string `yaml:"commit_status_prefix"`
+		AuditSummary          string `yaml:"audit_summary"`
+		AuditRecordPath       string `yaml:"audit_record_path"`
+	} `yaml:"github"`
+
+	Telemetry struct {
+		Endpoint string            `yaml:"endpoint"`
+		Headers  map[string]string `yaml:"headers"`
+	} `yaml:"telemetry"`
+
+	OIDC struct {
+		BrokerURL       string `yaml:"broker_url"`
+		Audience        string `yaml:"audience"`
+		Role            string `yaml:"role"`
+		CredentialField string `yaml:"credential_field"`
+	} `yaml:"oidc"`
+
+	SecretStore struct {
+		Type string `yaml:"type"`
+		URL  string `yaml:"url"`
+		Path string `yaml:"path"`
+		Role string `yaml:"role"`
+	} `yaml:"secret_store"`
+}
+
+// loadConfigFile reads the configuration file at path. Secret references
+// are resolved from the process environment, so credentials do not need
+// to be committed. When required is false, a missing file returns nil.
+func loadConfigFile(path string, required bool) (*configFile, error) {
+	data, err := os.ReadFile(path) // #nosec G304 user defined filepath
+	if err != nil {
+		if !required && errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read config file %s: %w", path, err)
+	}
+
+	data, err = catalog.New("", nil).Resolve(data)
+	if err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+
+	c := &configFile{}
+	if err := yaml.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("config file %s is malformed, failed to unmarshal yaml: %w", path, err)
+	}
+
+	if c.ProfilesPath != "" && len(c.Profiles) > 0 {
+		return nil, fmt.Errorf("config file %s: profiles_path and profiles cannot both be set", path)
+	}
+
+	return c, nil
+}
+
+// inputs returns the values set by the configuration file, by input name.
+// Inline profiles are read by the profiles loader from path.
+func (c *configFile) inputs(path string) map[string]string {
+	profilesPath := c.ProfilesPath
+	if len(c.Profiles) > 0 {
+		profilesPath = path
+	}
+
+	return map[string]string{
+		"target_branch":                 c.TargetBranch,
+		"mode":                          c.Mode,
+		"export_dir":                    c.ExportDir,
+		"export_raw":                    c.ExportRaw,
+		"fail_on_drift":                 c.FailOnDrift,
+		"golden_dir":                    c.GoldenDir,
+		"golden_update":                 c.GoldenUpdate,
+		"raw_config_dir":                c.RawConfigDir,
+		"snapshot_path":                 c.SnapshotPath,
+		"rollback_configuration":        c.Rollback.Configuration,
+		"rollback_version":              c.Rollback.Version,
+		"bindplane_remote_url":          c.BindPlane.RemoteURL,
+		"bindplane_api_key":             c.BindPlane.APIKey,
+		"bindplane_username":            c.BindPlane.Username,
+		"bindplane_password":            c.BindPlane.Password,
+		"bindplane_account_id":          c.BindPlane.AccountID,
+		"bindplane_project_id":          c.BindPlane.ProjectID,
+		"min_bindplane_version":         c.BindPlane.MinVersion,
+		"fail_on_version_skew":          c.BindPlane.FailOnVersionSkew,
+		"tls_ca_cert":                   c.TLS.CACert,
+		"tls_cert":                      c.TLS.Cert,
+		"tls_key":                       c.TLS.Key,
+		"insecure_skip_verify":          c.TLS.InsecureSkipVerify,
+		"tls_min_version":               c.TLS.MinVersion,
+		"tls_cipher_suites":             strings.Join(c.TLS.CipherSuites, ","),
+		"retry_max_attempts":            c.Client.RetryMaxAttempts,
+		"retry_max_elapsed_time":        c.Client.RetryMaxElapsedTime,
+		"retry_status_codes":            strings.Join(c.Client.RetryStatusCodes, ","),
+		"apply_timeout":                 c.Client.ApplyTimeout,
+		"fetch_timeout":                 c.Client.FetchTimeout,
+		"rate_limit":                    c.Client.RateLimit,
+		"http_trace":                    c.Client.HTTPTrace,
+		"max_idle_conns":                c.Client.MaxIdleConns,
+		"idle_conn_timeout":             c.Client.IdleConnTimeout,
+		"disable_keep_alives":           c.Client.DisableKeepAlives,
+		"dns_server":                    c.Client.DNSServer,
+		"ip_family":                     c.Client.IPFamily,
+		"proxy_url":                     c.Client.ProxyURL,
+		"disable_adaptive_throttling":   c.Client.DisableThrottling,
+		"http_headers":                  joinHeaders(c.Client.Headers),
+		"api_version":                   c.Client.APIVersion,
+		"log_level":                     c.Log.Level,
+		"log_levels":                    joinHeaders(c.Log.Levels),
+		"log_format":                    c.Log.Format,
+		"profiles_path":                 profilesPath,
+		"profile":                       strings.Join(c.Profile, ","),
+		"destination_path":              c.Resources.DestinationPath,
+		"source_path":                   c.Resources.SourcePath,
+		"processor_path":                c.Resources.ProcessorPath,
+		"agent_version_path":            c.Resources.AgentVersionPath,
+		"configuration_path":            c.Resources.ConfigurationPath,
+		"variables_path":                c.Resources.VariablesPath,
+		"environment":                   c.Resources.Environment,
+		"validate_rendered_config":      c.Resources.ValidateRenderedConfig,
+		"validate_pipelines":            c.Resources.ValidatePipelines,
+		"fail_on_statuses":              strings.Join(c.Resources.FailOnStatuses, ","),
+		"apply_concurrency":             c.Resources.ApplyConcurrency,
+		"apply_max_payload_size":        c.Resources.ApplyMaxPayloadSize,
+		"apply_strategy":                c.Resources.ApplyStrategy,
+		"status_report_path":            c.Resources.StatusReportPath,
+		"changed_files_only":            c.Resources.ChangedFilesOnly,
+		"changed_files_base":            c.Resources.ChangedFilesBase,
+		"skip_unchanged":                c.Resources.SkipUnchanged,
+		"stamp_labels":                  c.Resources.StampLabels,
+		"rendered_diff":                 c.Resources.RenderedDiff,
+		"lock":                          c.Resources.Lock,
+		"lock_timeout":                  c.Resources.LockTimeout,
+		"resource_name_environment":     c.Resources.NameEnvironment,
+		"resource_url_headers":          joinHeaders(c.Resources.URLHeaders),
+		"oci_artifact":                  c.Resources.OCI.Artifact,
+		"oci_username":                  c.Resources.OCI.Username,
+		"oci_password":                  c.Resources.OCI.Password,
+		"overlays_dir":                  c.Resources.OverlaysDir,
+		"patches_path":                  c.Resources.PatchesPath,
+		"prune":                         c.Prune.Enabled,
+		"prune_selector":                c.Prune.Selector,
+		"prune_confirm":                 c.Prune.Confirm,
+		"protected_resources":           strings.Join(c.Prune.ProtectedResources, ","),
+		"protected_selector":            c.Prune.ProtectedSelector,
+		"agent_cleanup_days":            c.Prune.AgentCleanupDays,
+		"enable_auto_rollout":           c.Rollout.Auto,
+		"rollout_all_pending":           c.Rollout.AllPending,
+		"rollout_selector":              c.Rollout.Selector,
+		"rollout_wait":                  c.Rollout.Wait,
+		"rollout_timeout":               c.Rollout.Timeout,
+		"rollout_poll_interval":         c.Rollout.PollInterval,
+		"rollout_poll_jitter":           c.Rollout.PollJitter,
+		"max_rollout_errors":            c.Rollout.MaxErrors,
+		"rollout_pause_on_errors":       c.Rollout.PauseOnErrors,
+		"canary_selector":               c.Rollout.CanarySelector,
+		"canary_wait":                   c.Rollout.CanaryWait,
+		"agent_check":                   c.Rollout.AgentCheck,
+		"expected_agents":               c.Rollout.ExpectedAgents,
+		"agent_wait":                    c.Rollout.AgentWait,
+		"agent_wait_timeout":            c.Rollout.AgentTimeout,
+		"min_throughput_percent":        c.Rollout.MinThroughput,
+		"throughput_wait":               c.Rollout.ThroughputWait,
+		"enable_otel_config_write_back": c.WriteBack.Enabled,
+		"configuration_output_dir":      c.WriteBack.OutputDir,
+		"configuration_output_branch":   c.WriteBack.Branch,
+		"freeze_windows_path":           c.Freeze.WindowsPath,
+		"freeze_override":               c.Freeze.Override,
+		"slack_webhook_url":             c.Notifications.SlackWebhookURL,
+		"webhook_url":                   c.Notifications.WebhookURL,
+		"webhook_template":              c.Notifications.WebhookTemplate,
+		"webhook_events":                strings.Join(c.Notifications.WebhookEvents, ","),
+		"github_url":                    c.GitHub.URL,
+		"github_deployment":             c.GitHub.Deployment,
+		"github_deployment_environment": c.GitHub.DeploymentEnvironment,
+		"commit_status":                 c.GitHub.CommitStatus,
+		"audit_summary":                 c.GitHub.AuditSummary,
+		"audit_record_path":             c.GitHub.AuditRecordPath,
+		"commit_status_prefix":          c.GitHub.CommitStatusPrefix,
+		"otel_exporter_endpoint":        c.Telemetry.Endpoint,
+		"otel_exporter_headers":         joinHeaders(c.Telemetry.Headers),
+		"oidc_broker_url":               c.OIDC.BrokerURL,
+		"oidc_audience":                 c.OIDC.Audience,
+		"oidc_role":                     c.OIDC.Role,
+		"oidc_credential_field":         c.OIDC.CredentialField,
+		"secret_store":                  c.SecretStore.Type,
+		"secret_store_url":              c.SecretStore.URL,
+		"secret_path":                   c.SecretStore.Path,
+		"secret_role":                   c.SecretStore.Role,
+	}
+}
+
+// applyConfigFile returns a copy of args, with inputs which the workflow
+// did not set read from their environment variable, then the configuration
+// file, then inputDefaults. Inputs set by the workflow always take
+// precedence, and the environment takes precedence over the file.
+func applyConfigFile(args []string) ([]string, error) {
+	args = append([]string{}, args...)
+
+	path, required := args[configPathIndex], true
+	if path == "" {
+		path = os.Getenv(inputEnv("config_path"))
+	}
+	if path == "" {
+		path, required = defaultConfigPath, false
+	}
+
+	c, err := loadConfigFile(path, required)
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]string{}
+	if c != nil {
+		values = c.inputs(path)
+	}
+
+	// Arg 0 is the binary name
+	inputSources = map[string]string{}
+	for i, name := range inputNames {
+		v, source := args[i+1], sourceInput
+		// Secret variables are never read as inputs, see inputEnvNames
+		if env := inputEnv(name); v == "" && !strings.HasPrefix(env, catalog.SecretEnvPrefix) {
+			v, source = os.Getenv(env), sourceEnv
+		}
+		if v == "" {
+			v, source = values[name], sourceFile
+		}
+		if v == "" {
+			v, source = inputDefaults[name], sourceDefault
+		}
+
+		args[i+1] = v
+		if v != "" {
+			inputSources[name] = source
+		}
+	}
+	return args, nil
+}
+
+// inputEnvNames are the environment variables of inputs which would
+// otherwise start with catalog.SecretEnvPrefix. Those variables are
+// secrets, such as BINDPLANE_SECRET_PATH for ${secret.PATH}, so the
+// secret store inputs are read from BINDPLANE_STORE_* instead.
+var inputEnvNames = map[string]string{
+	"secret_store":     envPrefix + "STORE",
+	"secret_store_url": envPrefix + "STORE_URL",
+	"secret_path":      envPrefix + "STORE_PATH",
+	"secret_role":      envPrefix + "STORE_ROLE",
+}
+
+// inputEnv returns the environment variable of an input
+func inputEnv(name string) string {
+	if env, ok := inputEnvNames[name]; ok {
+		return env
+	}
+	return envPrefix + strings.ToUpper(strings.TrimPrefix(name, "bindplane_"))
+}
+
+// logInputSources logs the source of every input which has a value.
+// Values are not logged, because many inputs are credentials.
+func logInputSources(logger *zap.Logger) {
+	fields := make([]zap.Field, 0, len(inputSources))
+	for _, name := range inputNames {
+		if source, ok := inputSources[name]; ok {
+			fields = append(fields, zap.String(name, source))
+		}
+	}
+	logger.Debug("Input sources", fields...)
+}
+
+// joinHeaders formats headers as a comma separated list of key=value
+// pairs, sorted by key
+func joinHeaders(headers map[string]string) string {
+	pairs := make([]string, 0, len(headers))
+	for k, v := range headers {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", k, v))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/observiq/bindplane-op-action/action/catalog"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"gopkg.in/yaml.v3"
+)
+
+func TestInputNames(t *testing.T) {
+	require.Len(t, inputNames, argCount)
+	require.Equal(t, "config_path", inputNames[configPathIndex-1])
+
+	data, err := os.ReadFile("../../action.yml")
+	require.NoError(t, err)
+
+	file := struct {
+		Runs struct {
+			Args []string `yaml:"args"`
+		} `yaml:"runs"`
+	}{}
+	require.NoError(t, yaml.Unmarshal(data, &file))
+
+	expect := []string{}
+	for _, name := range inputNames {
+		expect = append(expect, "${{ inputs."+name+" }}")
+	}
+	require.Equal(t, expect, file.Runs.Args)
+}
+
+func TestApplyConfigFile(t *testing.T) {
+	t.Setenv("BINDPLANE_SECRET_API_KEY", "secret-key")
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "bindplane.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+target_branch: main
+bindplane:
+  remote_url: https://bindplane.example.com
+  api_key: ${secret.API_KEY}
+resources:
+  configuration_path: configurations/*.yaml
+  fail_on_statuses: [invalid, error]
+rollout:
+  auto: true
+  wait: true
+  timeout: 10m
+write_back:
+  enabled: true
+  output_dir: otel
+client:
+  headers:
+    x-tenant: payments
+telemetry:
+  headers:
+    x-tenant: ci
+    api-key: abc
+`), 0600))
+
+	args := make([]string, argCount+1)
+	args[configPathIndex] = path
+
+	// Inputs set by the workflow take precedence
+	args[indexOf(t, "rollout_timeout")] = "5m"
+	args[indexOf(t, "enable_otel_config_write_back")] = "false"
+
+	out, err := applyConfigFile(args)
+	require.NoError(t, err)
+	require.Empty(t, args[indexOf(t, "target_branch")], "args are copied")
+
+	expect := map[string]string{
+		"target_branch":                 "main",
+		"bindplane_remote_url":          "https://bindplane.example.com",
+		"bindplane_api_key":             "secret-key",
+		"configuration_path":            "configurations/*.yaml",
+		"fail_on_statuses":              "invalid,error",
+		"enable_auto_rollout":           "true",
+		"rollout_wait":                  "true",
+		"rollout_timeout":               "5m",
+		"enable_otel_config_write_back": "false",
+		"configuration_output_dir":      "otel",
+		"otel_exporter_headers":         "api-key=abc,x-tenant=ci",
+		"http_headers":                  "x-tenant=payments",
+		"mode":                          modeApply,
+		"fail_on_drift":                 "true",
+		"prune":                         "false",
+		"source_path":                   "",
+	}
+	for name, v := range expect {
+		require.Equal(t, v, out[indexOf(t, name)], name)
+	}
+}
+
+func TestApplyConfigFilePrecedence(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	path := filepath.Join(dir, "bindplane.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+bindplane:
+  remote_url: https://file.example.com
+  api_key: file-key
+rollout:
+  timeout: 10m
+  poll_interval: 30s
+`), 0600))
+
+	t.Setenv("BINDPLANE_CONFIG_PATH", path)
+	t.Setenv("BINDPLANE_REMOTE_URL", "https://env.example.com")
+	t.Setenv("BINDPLANE_ROLLOUT_TIMEOUT", "20m")
+	t.Setenv("BINDPLANE_ROLLOUT_WAIT", "true")
+
+	args := make([]string, argCount+1)
+	args[indexOf(t, "rollout_timeout")] = "5m"
+
+	out, err := applyConfigFile(args)
+	require.NoError(t, err)
+
+	cases := []struct {
+		input  string
+		value  string
+		source string
+	}{
+		{"rollout_timeout", "5m", sourceInput},
+		{"bindplane_remote_url", "https://env.example.com", sourceEnv},
+		{"rollout_wait", "true", sourceEnv},
+		{"bindplane_api_key", "file-key", sourceFile},
+		{"rollout_poll_interval", "30s", sourceFile},
+		{"log_level", "info", sourceDefault},
+		{"config_path", path, sourceEnv},
+	}
+	for _, tc := range cases {
+		require.Equal(t, tc.value, out[indexOf(t, tc.input)], tc.input)
+		require.Equal(t, tc.source, inputSources[tc.input], tc.input)
+	}
+
+	_, ok := inputSources["bindplane_username"]
+	require.False(t, ok, "inputs without a value have no source")
+
+	core, logs := observer.New(zap.DebugLevel)
+	logInputSources(zap.New(core))
+	require.Equal(t, 1, logs.Len())
+	fields := logs.All()[0].ContextMap()
+	require.Equal(t, sourceEnv, fields["bindplane_remote_url"])
+	require.NotContains(t, fields, "bindplane_username")
+	for _, v := range fields {
+		require.NotEqual(t, "file-key", v, "values are not logged")
+	}
+}
+
+func TestInputEnv(t *testing.T) {
+	// No input is read from a secret variable, and no
+	// two inputs are read from the same variable
+	seen := map[string]string{}
+	for _, name := range inputNames {
+		env := inputEnv(name)
+		require.False(t, strings.HasPrefix(env, catalog.SecretEnvPrefix), "%s is read from the secret variable %s", name, env)
+		require.NotContains(t, seen, env, "%s and %s are both read from %s", name, seen[env], env)
+		seen[env] = name
+	}
+	require.Equal(t, "BINDPLANE_API_KEY", inputEnv("bindplane_api_key"))
+	require.Equal(t, "BINDPLANE_STORE_PATH", inputEnv("secret_path"))
+
+	// Secrets named like the secret store inputs do not configure it
+	t.Chdir(t.TempDir())
+	t.Setenv("BINDPLANE_SECRET_PATH", "secret-value")
+	t.Setenv("BINDPLANE_SECRET_ROLE", "secret-value")
+	t.Setenv("BINDPLANE_STORE_ROLE", "deploy")
+
+	out, err := applyConfigFile(make([]string, argCount+1))
+	require.NoError(t, err)
+	require.Empty(t, out[indexOf(t, "secret_path")])
+	require.Equal(t, "deploy", out[indexOf(t, "secret_role")])
+}
+
+func TestApplyConfigFileProfiles(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	// Without a config file, only defaults are set
+	out, err := applyConfigFile(make([]string, argCount+1))
+	require.NoError(t, err)
+	require.Equal(t, "info", out[indexOf(t, "log_level")])
+	require.Empty(t, out[indexOf(t, "profiles_path")])
+
+	// Inline profiles are read from the config file
+	require.NoError(t, os.WriteFile(defaultConfigPath, []byte(`
+profiles:
+  - name: prod
+    tags: ["v*"]
+profile: [prod]
+`), 0600))
+
+	out, err = applyConfigFile(make([]string, argCount+1))
+	require.NoError(t, err)
+	require.Equal(t, defaultConfigPath, out[indexOf(t, "profiles_path")])
+	require.Equal(t, "prod", out[indexOf(t, "profile")])
+
+	require.NoError(t, os.WriteFile(defaultConfigPath, []byte(`
+profiles_path: profiles.yaml
+profiles:
+  - name: prod
+`), 0600))
+
+	_, err = applyConfigFile(make([]string, argCount+1))
+	require.EqualError(t, err, "config file bindplane-action.yaml: profiles_path and profiles cannot both be set")
+}
+
+func TestApplyConfigFileErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	cases := []struct {
+		name   string
+		data   string
+		errStr string
+	}{
+		{
+			"Missing",
+			"",
+			"read config file",
+		},
+		{
+			"Malformed",
+			"rollout: [wait]",
+			"is malformed, failed to unmarshal yaml",
+		},
+		{
+			"Undefined secret",
+			"bindplane:\n  api_key: ${secret.UNDEFINED_KEY}",
+			"undefined references for environment '': secret.UNDEFINED_KEY",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(dir, "missing.yaml")
+			if tc.data != "" {
+				path = filepath.Join(dir, tc.name+".yaml")
+				require.NoError(t, os.WriteFile(path, []byte(tc.data), 0600))
+			}
+
+			args := make([]string, argCount+1)
+			args[configPathIndex] = path
+			_, err := applyConfigFile(args)
+			require.ErrorContains(t, err, tc.errStr)
+		})
+	}
+}
+
+// indexOf returns the argument index of an input
+func indexOf(t *testing.T, name string) int {
+	t.Helper()
+	for i, n := range inputNames {
+		if n == name {
+			return i + 1
+		}
+	}
+	t.Fatalf("unknown input %s", name)
+	return 0
+}
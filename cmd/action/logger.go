@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/observiq/bindplane-op-action/internal/logging"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	logFormatJSON    = "json"
+	logFormatConsole = "console"
+)
+
+// newLogger creates a logger with the given level and format. The
+// format can be json or console. Components, such as client=debug,
+// override the level of each component. Logs are written to stdout.
+func newLogger(level, format, components string) (*zap.Logger, error) {
+	lvl, err := parseLogLevel(level)
+	if err != nil {
+		return nil, err
+	}
+	levels, err := logging.ParseLevels(components, parseLogLevel)
+	if err != nil {
+		return nil, err
+	}
+
+	zapConf := zap.NewProductionConfig()
+	zapConf.Level.SetLevel(logging.MinLevel(lvl, levels))
+	zapConf.OutputPaths = []string{"stdout"}
+	zapConf.DisableStacktrace = true
+	zapConf.DisableCaller = true
+	zapConf.EncoderConfig.TimeKey = "time"
+	zapConf.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+
+	switch format {
+	case "", logFormatJSON:
+		zapConf.Encoding = logFormatJSON
+	case logFormatConsole:
+		zapConf.Encoding = logFormatConsole
+		zapConf.EncoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+	default:
+		return nil, fmt.Errorf("unsupported log format '%s', must be json or console", format)
+	}
+
+	return zapConf.Build(logging.WithLevels(lvl, levels))
+}
+
+// parseLogLevel parses a log level. Supported levels are debug,
+// info, warn, and error. An empty string returns info.
+func parseLogLevel(level string) (zapcore.Level, error) {
+	switch level {
+	case "":
+		return zapcore.InfoLevel, nil
+	case "debug", "info", "warn", "error":
+		return zapcore.ParseLevel(level)
+	default:
+		return zapcore.InfoLevel, fmt.Errorf("unsupported log level '%s', must be debug, info, warn, or error", level)
+	}
+}
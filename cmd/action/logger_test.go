@@ -0,0 +1,74 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestNewLogger(t *testing.T) {
+	cases := []struct {
+		name        string
+		level       string
+		format      string
+		expectLevel zapcore.Level
+		errStr      string
+	}{
+		{
+			"Defaults",
+			"",
+			"",
+			zapcore.InfoLevel,
+			"",
+		},
+		{
+			"Debug json",
+			"debug",
+			"json",
+			zapcore.DebugLevel,
+			"",
+		},
+		{
+			"Error console",
+			"error",
+			"console",
+			zapcore.ErrorLevel,
+			"",
+		},
+		{
+			"Invalid level",
+			"trace",
+			"",
+			zapcore.InfoLevel,
+			"unsupported log level 'trace', must be debug, info, warn, or error",
+		},
+		{
+			"Invalid format",
+			"info",
+			"text",
+			zapcore.InfoLevel,
+			"unsupported log format 'text', must be json or console",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			logger, err := newLogger(tc.level, tc.format, "")
+			if tc.errStr != "" {
+				require.EqualError(t, err, tc.errStr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectLevel, logger.Level())
+		})
+	}
+
+	// The logger is enabled at the lowest component level
+	logger, err := newLogger("warn", "json", "client=debug")
+	require.NoError(t, err)
+	require.Equal(t, zapcore.DebugLevel, logger.Level())
+
+	_, err = newLogger("info", "json", "http=debug")
+	require.EqualError(t, err, "unknown component 'http', must be one of client, rollout, loader")
+}
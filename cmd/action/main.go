@@ -1,22 +1,37 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
+	_ "time/tzdata" // Freeze window timezones, the action image does not include tzdata
 
 	"github.com/observiq/bindplane-op-action/action"
+	"github.com/observiq/bindplane-op-action/action/catalog"
+	"github.com/observiq/bindplane-op-action/internal/buildinfo"
+	"github.com/observiq/bindplane-op-action/internal/ci"
 	"github.com/observiq/bindplane-op-action/internal/repo"
+	"github.com/observiq/bindplane-op-action/internal/telemetry"
+	"github.com/observiq/bindplane-op-action/internal/workflow"
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
-	"go.uber.org/zap/zapcore"
 )
 
 // argCount is the number of arguments passed to the action, and does not
 // include the binary name itself (which is returned by os.Args[0]).
 // When adding new arguments to the action, this number should be updated
 // and new global variables should be declared and handled in parseArgs().
-const argCount = 16
+const argCount = 125
 
 // Global variables will be used when creating the action configuration. These
 // are the options set by the user. Their order in parseArgs() is important.
@@ -37,6 +52,114 @@ var (
 	source_path                   string
 	processor_path                string
 	github_url                    string
+	environment                   string
+	variables_path                string
+	retry_max_attempts            int
+	retry_max_elapsed_time        time.Duration
+	retry_status_codes            []int
+	freeze_windows_path           string
+	freeze_override               bool
+	tls_cert                      string
+	tls_key                       string
+	insecure_skip_verify          bool
+	tls_min_version               string
+	tls_cipher_suites             []string
+	log_level                     string
+	log_format                    string
+	http_trace                    bool
+	fail_on_statuses              []model.UpdateStatus
+	profiles_path                 string
+	profile_names                 []string
+	bindplane_account_id          string
+	bindplane_project_id          string
+	min_bindplane_version         string
+	agent_version_path            string
+	validate_rendered_config      bool
+	mode                          string
+	export_dir                    string
+	fail_on_drift                 bool
+	prune                         bool
+	prune_selector                string
+	prune_confirm                 bool
+	protected_resources           []string
+	protected_selector            string
+	rollout_wait                  bool
+	rollout_timeout               time.Duration
+	rollout_poll_interval         time.Duration
+	max_rollout_errors            *action.ErrorThreshold
+	rollout_pause_on_errors       bool
+	apply_timeout                 time.Duration
+	fetch_timeout                 time.Duration
+	rate_limit                    float64
+	apply_concurrency             int
+	apply_max_payload_size        int
+	rollout_all_pending           bool
+	slack_webhook_url             string
+	webhook_url                   string
+	webhook_template              string
+	webhook_events                []string
+	github_deployment             bool
+	github_deployment_environment string
+	commit_status                 bool
+	commit_status_prefix          string
+	otel_exporter_endpoint        string
+	otel_exporter_headers         map[string]string
+	oidc_broker_url               string
+	oidc_audience                 string
+	oidc_role                     string
+	oidc_credential_field         string
+	secret_store                  string
+	secret_store_url              string
+	secret_path                   string
+	secret_role                   string
+	http_headers                  map[string]string
+	api_version                   string
+	rollout_selector              string
+	resource_url_headers          map[string]string
+	oci_artifact                  string
+	oci_username                  string
+	oci_password                  string
+	overlays_dir                  string
+	patches_path                  string
+	golden_dir                    string
+	golden_update                 bool
+	rollback_configuration        string
+	rollback_version              int
+	audit_summary                 bool
+	audit_record_path             string
+	apply_strategy                string
+	status_report_path            string
+	changed_files_only            bool
+	changed_files_base            string
+	resource_name_environment     string
+	canary_selector               string
+	canary_wait                   time.Duration
+	agent_check                   string
+	expected_agents               *action.AgentRange
+	agent_wait                    bool
+	agent_wait_timeout            time.Duration
+	agent_cleanup_days            int
+	min_throughput_percent        int
+	throughput_wait               time.Duration
+	validate_pipelines            bool
+	skip_unchanged                bool
+	stamp_labels                  bool
+	rollout_poll_jitter           int
+	lock                          bool
+	lock_timeout                  time.Duration
+	log_levels                    string
+	export_raw                    bool
+	raw_config_dir                string
+	rendered_diff                 bool
+	max_idle_conns                int
+	idle_conn_timeout             time.Duration
+	disable_keep_alives           bool
+	dns_server                    string
+	ip_family                     string
+	proxy_url                     string
+	disable_adaptive_throttling   bool
+	fail_on_version_skew          bool
+	snapshot_path                 string
 )
 
 const (
@@ -45,35 +168,146 @@ const (
 	exitClientInitError           = 102
 	exitClientTestConnectionError = 103
 	exitLoggerInitError           = 104
+	exitVersionError              = 105
+	exitCredentialError           = 106
 	exitClientError               = 1
 )
 
+// telemetryShutdownTimeout is the amount of time telemetry is
+// flushed for before the action exits
+const telemetryShutdownTimeout = 10 * time.Second
+
+// tracer creates the run span
+var tracer = otel.Tracer("github.com/observiq/bindplane-op-action/cmd/action")
+
+// defaultDeploymentEnvironment is the GitHub deployment environment
+// when no environment or profile is set
+const defaultDeploymentEnvironment = "bindplane"
+
+// defaultCommitStatusPrefix is the commit status context prefix
+// when commit_status_prefix is not set
+const defaultCommitStatusPrefix = "bindplane"
+
+const (
+	// modeApply applies resources from the repository to BindPlane
+	modeApply = "apply"
+
+	// modeExport writes resources from BindPlane to the workspace
+	modeExport = "export"
+
+	// modeDrift compares resources in the repository with BindPlane
+	modeDrift = "drift"
+
+	// modeStatus reports active rollouts on the BindPlane server
+	modeStatus = "status"
+
+	// modeGolden compares rendered configurations with golden files
+	modeGolden = "golden"
+
+	// modeRollback restores a previous version of a configuration
+	// and starts its rollout
+	modeRollback = "rollback"
+
+	// modeRestore applies the resources of a snapshot written
+	// by an earlier apply
+	modeRestore = "restore"
+)
+
 func main() {
 	if err := parseArgs(); err != nil {
-		fmt.Printf("Error parsing arguments: %s\n", err)
+		fmt.Printf("Error parsing arguments:\n%s\n", strings.Join(formatProblems("", err), "\n"))
 		os.Exit(exitParseArgsError)
 	}
 
-	if err := validate(); err != nil {
-		fmt.Printf("Error validating arguments: %s\n", err)
+	// Exchanged and fetched credentials are used as the credential
+	// inputs, so they must be set before targets are selected
+	if err := errors.Join(validateOIDC(), validateSecretStore()); err != nil {
+		fmt.Printf("Error validating arguments:\n%s\n", strings.Join(formatProblems("", err), "\n"))
+		os.Exit(exitValidationError)
+	}
+	if err := exchangeOIDC(context.Background()); err != nil {
+		fmt.Printf("Error exchanging OIDC token:\n%s\n", strings.Join(formatProblems("", err), "\n"))
+		os.Exit(exitCredentialError)
+	}
+	if err := fetchSecrets(context.Background()); err != nil {
+		fmt.Printf("Error reading credentials from the secret store:\n%s\n", strings.Join(formatProblems("", err), "\n"))
+		os.Exit(exitCredentialError)
+	}
+
+	// Profiles override connection inputs, so targets must be
+	// selected before credentials are masked and validated.
+	ref := os.Getenv("GITHUB_REF")
+	targets, err := selectTargets(ref)
+	if err != nil {
+		fmt.Printf("Error loading profiles: %s\n", err)
+		os.Exit(exitValidationError)
+	}
+	if len(targets) == 0 {
+		fmt.Printf("Skipping action, no profile matches ref %s\n", ref)
+		os.Exit(0)
+	}
+
+	// Mask credentials before anything else is logged, so they cannot
+	// leak through debug logs or error bodies echoed by the client.
+	workflow.Mask(token)
+	for _, t := range targets {
+		workflow.Mask(t.conn.apiKey, t.conn.password)
+	}
+	workflow.Mask(catalog.Secrets()...)
+	workflow.Mask(slack_webhook_url, webhook_url, oci_password)
+	if u, err := url.Parse(proxy_url); err == nil {
+		password, _ := u.User.Password()
+		workflow.Mask(password)
+	}
+	for _, v := range otel_exporter_headers {
+		workflow.Mask(v)
+	}
+	for _, v := range http_headers {
+		workflow.Mask(v)
+	}
+	for _, v := range resource_url_headers {
+		workflow.Mask(v)
+	}
+	if strings.Contains(tls_key, "-----BEGIN") {
+		workflow.Mask(tls_key)
+	}
+	if u, err := url.Parse(github_url); err == nil && u.User != nil {
+		p, _ := u.User.Password()
+		workflow.Mask(u.User.Username(), p)
+	}
+
+	// Validate every target before applying to any of them, and
+	// report every problem so they can be fixed in a single run
+	invalid := []string{}
+	for _, t := range targets {
+		t.use()
+
+		prefix := ""
+		if t.name != "" {
+			prefix = fmt.Sprintf("profile %s: ", t.name)
+		}
+		invalid = append(invalid, formatProblems(prefix, validate())...)
+	}
+	if len(invalid) > 0 {
+		fmt.Printf("Error validating arguments, found %d problems:\n%s\n", len(invalid), strings.Join(invalid, "\n"))
 		os.Exit(exitValidationError)
 	}
 
-	zapConf := zap.NewProductionConfig()
-	zapConf.Level.SetLevel(zap.DebugLevel) // TODO(jsirianni): Expose this as an option
-	zapConf.OutputPaths = []string{"stdout"}
-	zapConf.DisableStacktrace = true
-	zapConf.DisableCaller = true
-	zapConf.EncoderConfig.TimeKey = "time"
-	zapConf.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
-	logger, err := zapConf.Build()
+	logger, err := newLogger(log_level, log_format, log_levels)
 	if err != nil {
 		fmt.Printf("failed to create logger: %s\n", err)
 		os.Exit(exitLoggerInitError)
 	}
+	logInputSources(logger)
+	logger.Info("BindPlane action", zap.String("version", buildinfo.Version()), zap.String("commit", buildinfo.Commit()))
 
-	branch := strings.Split(os.Getenv("GITHUB_REF"), "/")[2]
-	if branch != target_branch {
+	if insecure_skip_verify {
+		workflow.Warning("", 0, "Insecure TLS", "insecure_skip_verify is enabled, the BindPlane server certificate will not be verified. Do not use this option in production.")
+	}
+
+	branch := strings.SplitN(ref, "/", 3)[2]
+	// Read only modes do not apply resources, so they can run from any branch
+	if !readOnlyMode() && profiles_path == "" && branch != target_branch {
 		logger.Info(
 			"Skipping action, branch does not match target branch",
 			zap.String("branch", branch),
@@ -82,25 +316,191 @@ func main() {
 		os.Exit(0)
 	}
 
+	// Telemetry is best effort, a broken exporter must not fail the action
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		logger.Warn("error exporting telemetry", zap.Error(err))
+	}))
+	shutdownTelemetry, err := telemetry.Setup(context.Background(), otel_exporter_endpoint, otel_exporter_headers)
+	if err != nil {
+		logger.Warn("Telemetry disabled, failed to create exporters", zap.Error(err))
+		shutdownTelemetry = func(context.Context) error { return nil }
+	}
+
+	// Apply to each target, continuing when a target fails so
+	// every target is attempted and reported on.
+	exitCode := 0
+	results := map[string]targetResult{}
+	for _, t := range targets {
+		t.use()
+
+		l := logger
+		if t.name != "" {
+			l = logger.With(zap.String("profile", t.name))
+			l.Info("Using profile", zap.String("bindplane_remote_url", bindplane_remote_url))
+		}
+
+		code, err := run(l, branch, t.name, len(targets) == 1)
+		result := targetResult{RemoteURL: bindplane_remote_url, Status: targetStatusSucceeded}
+		if err != nil {
+			fields := []zap.Field{zap.Error(err)}
+			if hint := connectionHint(err); hint != "" {
+				fields = append(fields, zap.String("fix", hint))
+			}
+			l.Error("error running action", fields...)
+			result.Status = targetStatusFailed
+			result.Error = err.Error()
+			if exitCode == 0 {
+				exitCode = code
+			}
+		}
+		results[t.name] = result
+	}
+
+	if len(targets) > 1 {
+		if err := writeResults(results); err != nil {
+			logger.Error("error writing action outputs", zap.Error(err))
+		}
+	}
+
+	// Flush telemetry before exiting, os.Exit does not run deferred funcs
+	ctx, cancel := context.WithTimeout(context.Background(), telemetryShutdownTimeout)
+	if err := shutdownTelemetry(ctx); err != nil {
+		logger.Warn("error flushing telemetry", zap.Error(err))
+	}
+	cancel()
+
+	os.Exit(exitCode)
+}
+
+// run applies resources to, exports resources from, detects drift with, or
+// reports rollouts on the BindPlane server configured by the connection inputs. The returned exit code is only
+// meaningful when an error is returned. When outputs is true, the action
+// outputs are written, even if the run fails. When name is set, it is the
+// profile name and is used as the export subdirectory.
+func run(logger *zap.Logger, branch, name string, outputs bool) (code int, err error) {
+	// The run span is the parent of every action and request span
+	ctx, span := tracer.Start(context.Background(), mode, trace.WithAttributes(
+		attribute.String("bindplane.remote_url", bindplane_remote_url),
+		attribute.String("bindplane.profile", name),
+		attribute.String("github.branch", branch),
+	))
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
 	action, err := action.New(
 		logger,
+		action.WithContext(ctx),
 
 		// Client options
 		action.WithBindPlaneRemoteURL(bindplane_remote_url),
 		action.WithBindPlaneAPIKey(bindplane_api_key),
 		action.WithBindPlaneUsername(bindplane_username),
 		action.WithBindPlanePassword(bindplane_password),
+		action.WithBindPlaneAccountID(bindplane_account_id),
+		action.WithBindPlaneProjectID(bindplane_project_id),
 		action.WithTLSCACert(tls_ca_cert),
+		action.WithTLSCert(tls_cert),
+		action.WithTLSKey(tls_key),
+		action.WithInsecureSkipVerify(insecure_skip_verify),
+		action.WithTLSMinVersion(tls_min_version),
+		action.WithTLSCipherSuites(tls_cipher_suites),
+		action.WithRetryMaxAttempts(retry_max_attempts),
+		action.WithRetryMaxElapsedTime(retry_max_elapsed_time),
+		action.WithRetryStatusCodes(retry_status_codes),
+		action.WithApplyTimeout(apply_timeout),
+		action.WithFetchTimeout(fetch_timeout),
+		action.WithRateLimit(rate_limit),
+		action.WithDisableAdaptiveThrottling(disable_adaptive_throttling),
+		action.WithMaxIdleConns(max_idle_conns),
+		action.WithIdleConnTimeout(idle_conn_timeout),
+		action.WithDisableKeepAlives(disable_keep_alives),
+		action.WithDNSServer(dns_server),
+		action.WithIPFamily(ip_family),
+		action.WithProxyURL(proxy_url),
+		action.WithHTTPTrace(http_trace),
+		action.WithHTTPHeaders(http_headers),
+		action.WithAPIVersion(api_version),
+		action.WithMinBindPlaneVersion(min_bindplane_version),
+		action.WithFailOnVersionSkew(fail_on_version_skew),
 
 		// Base action options for reading resources
 		// from the repo, to apply to bindplane
 		action.WithDestinationPath(destination_path),
 		action.WithSourcePath(source_path),
 		action.WithProcessorPath(processor_path),
+		action.WithAgentVersionPath(agent_version_path),
 		action.WithConfigurationPath(configuration_path),
+		action.WithRemoteResourceHeaders(resource_url_headers),
+		action.WithOCIArtifact(oci_artifact),
+		action.WithOCICredentials(oci_username, oci_password),
+		action.WithOverlaysDir(overlays_dir),
+		action.WithPatchesPath(patches_path),
+		action.WithChangedFiles(changedFiles(logger)),
+		action.WithFailOnStatuses(fail_on_statuses),
+		action.WithApplyConcurrency(apply_concurrency),
+		action.WithApplyStrategy(apply_strategy),
+		action.WithApplyMaxPayloadSize(apply_max_payload_size),
+		action.WithValidateRenderedConfig(validate_rendered_config),
+		action.WithValidatePipelines(validate_pipelines),
+		action.WithSkipUnchanged(skip_unchanged),
+		action.WithStampLabels(stamp_labels),
+		action.WithExportRaw(export_raw),
+		action.WithRenderedDiff(rendered_diff),
+		action.WithLock(lock),
+		action.WithLockTimeout(lock_timeout),
+
+		// Prune option(s)
+		action.WithPrune(prune),
+		action.WithPruneSelector(prune_selector),
+		action.WithPruneConfirm(prune_confirm),
+
+		// Protected resource option(s)
+		action.WithProtectedResources(protected_resources),
+		action.WithProtectedSelector(protected_selector),
+
+		// Environment variable resolution option(s)
+		action.WithEnvironment(environment),
+		action.WithVariablesPath(variables_path),
+		action.WithResourceNameEnvironment(resource_name_environment),
 
 		// Auto rollout option(s)
 		action.WithAutoRollout(enable_auto_rollout),
+		action.WithRolloutAllPending(rollout_all_pending),
+		action.WithRolloutSelector(rollout_selector),
+		action.WithRolloutWait(rollout_wait),
+		action.WithRolloutTimeout(rollout_timeout),
+		action.WithRolloutPollInterval(rollout_poll_interval),
+		action.WithRolloutPollJitter(rollout_poll_jitter),
+		action.WithMaxRolloutErrors(max_rollout_errors),
+		action.WithRolloutPauseOnErrors(rollout_pause_on_errors),
+		action.WithCanarySelector(canary_selector),
+		action.WithCanaryWait(canary_wait),
+		action.WithAgentCheck(agent_check),
+		action.WithExpectedAgents(expected_agents),
+		action.WithAgentWait(agent_wait),
+		action.WithAgentWaitTimeout(agent_wait_timeout),
+		action.WithAgentCleanupAge(time.Duration(agent_cleanup_days)*24*time.Hour),
+		action.WithMinThroughputPercent(min_throughput_percent),
+		action.WithThroughputWait(throughput_wait),
+
+		// Notification option(s)
+		action.WithSlackWebhookURL(slack_webhook_url),
+		action.WithWebhookURL(webhook_url),
+		action.WithWebhookTemplate(webhook_template),
+		action.WithWebhookEvents(webhook_events),
+
+		// GitHub deployment option(s)
+		action.WithGithubDeploymentEnvironment(deploymentEnvironment(name)),
+		action.WithCommitStatusPrefix(commitStatusPrefix(name)),
+
+		// Freeze window option(s)
+		action.WithFreezeWindowsPath(freeze_windows_path),
+		action.WithFreezeOverride(freeze_override),
 
 		// Write back option(s)
 		action.WithOTELConfigWriteBack(enable_otel_config_write_back),
@@ -108,40 +508,135 @@ func main() {
 		action.WithConfigurationOutputBranch(configuration_output_branch),
 		action.WithGithubToken(token),
 		action.WithGithubURL(github_url),
+
+		// Raw configuration option(s)
+		action.WithRawConfigDir(profileDir(raw_config_dir, name)),
+
+		// Audit record option(s)
+		action.WithAuditRecordPath(profilePath(audit_record_path, name)),
+
+		// Status report option(s)
+		action.WithStatusReportPath(profilePath(status_report_path, name)),
+
+		// Snapshot option(s)
+		action.WithSnapshotPath(profilePath(snapshot_path, name)),
 	)
 	if err != nil {
-		fmt.Printf("Error creating action: %s\n", err)
-		os.Exit(exitClientInitError)
+		return exitClientInitError, fmt.Errorf("create action: %w", err)
+	}
+
+	// The audit record is evidence of the change, so failing
+	// to write it fails an otherwise successful run
+	if audit_record_path != "" {
+		defer func() {
+			if recordErr := action.WriteAuditRecord(err); recordErr != nil {
+				logger.Error("error writing audit record", zap.Error(recordErr))
+				if err == nil {
+					code, err = exitClientError, recordErr
+				}
+			}
+		}()
+	}
+
+	// Resolve and decode all resources before making any API
+	// calls so undefined variables are caught early.
+	if mode != modeExport && mode != modeStatus && mode != modeRollback && mode != modeRestore {
+		if err := action.LoadResources(); err != nil {
+			return exitValidationError, fmt.Errorf("load resources: %w", err)
+		}
+	}
+
+	if err := action.CheckHealth(); err != nil {
+		return exitClientTestConnectionError, err
 	}
 
 	logger.Info("Testing connection to BindPlane API")
 	version, err := action.TestConnection()
 	if err != nil {
-		fmt.Printf("Error testing connection: %s\n", err)
-		os.Exit(exitClientTestConnectionError)
+		return exitClientTestConnectionError, fmt.Errorf("test connection: %w", err)
 	}
 	logger.Info(
 		"Connection to BindPlane API successful",
 		zap.Any("bindplane_version", version.Tag),
 	)
 
+	if err := action.CheckVersion(); err != nil {
+		return exitVersionError, err
+	}
+	if err := action.CheckVersionSkew(); err != nil {
+		return exitVersionError, err
+	}
+
+	if mode == modeExport {
+		dir := filepath.Join(export_dir, name)
+		if err := action.Export(dir); err != nil {
+			return exitClientError, fmt.Errorf("export resources: %w", err)
+		}
+		return 0, nil
+	}
+
+	if mode == modeDrift {
+		if err := action.RunDrift(fail_on_drift); err != nil {
+			return exitClientError, err
+		}
+		return 0, nil
+	}
+
+	if mode == modeStatus {
+		if err := action.RunStatus(); err != nil {
+			return exitClientError, err
+		}
+		return 0, nil
+	}
+
+	if mode == modeGolden {
+		dir := filepath.Join(golden_dir, name)
+		if err := action.RunGolden(dir, golden_update); err != nil {
+			return exitClientError, err
+		}
+		return 0, nil
+	}
+
+	if outputs {
+		defer writeOutputs(action)
+	}
+
+	if audit_summary {
+		defer writeAuditSummary(action)
+	}
+
+	if status_report_path != "" {
+		defer writeStatusReport(action)
+	}
+
+	if mode == modeRollback {
+		if err := action.Rollback(rollback_configuration, rollback_version); err != nil {
+			return exitClientError, err
+		}
+		return 0, nil
+	}
+
+	if mode == modeRestore {
+		if err := action.Restore(); err != nil {
+			return exitClientError, err
+		}
+		return 0, nil
+	}
+
 	if token != "" || github_url != "" {
 		// Retrieve the commit message from the head commit on the branch
 		message, err := commitMessage(github_url, branch, token)
 		if err != nil {
-			logger.Error("error getting commit message", zap.Error(err))
-			os.Exit(exitClientError)
+			return exitClientError, fmt.Errorf("get commit message: %w", err)
 		}
 
 		// If the commit message contains `progress rollout <name>`, progress the rollout
 		// for the configuration instead of running the full workflow.
 		if name, ok := extractConfigName(message); ok {
-			err := action.RunRollout(name)
-			if err != nil {
-				logger.Error("error progressing rollout", zap.Error(err))
-				os.Exit(exitClientError)
+			if err := action.RunRollout(name); err != nil {
+				return exitClientError, fmt.Errorf("progress rollout: %w", err)
 			}
-			return
+			return 0, nil
 		}
 	} else {
 		logger.Info("Skipping commit message check, Github token not provided")
@@ -149,11 +644,120 @@ func main() {
 
 	// Run the full workflow
 	if err := action.Run(); err != nil {
-		action.Logger.Error("error running action", zap.Error(err))
-		os.Exit(exitClientError)
+		return exitClientError, err
+	}
+	return 0, nil
+}
+
+// deploymentEnvironment returns the GitHub deployment environment for the
+// target, or an empty string when deployments are disabled. It defaults to
+// the profile name, then the variables environment.
+func deploymentEnvironment(profile string) string {
+	if !github_deployment {
+		return ""
+	}
+
+	for _, env := range []string{github_deployment_environment, profile, environment} {
+		if env != "" {
+			return env
+		}
+	}
+	return defaultDeploymentEnvironment
+}
+
+// commitStatusPrefix returns the commit status context prefix for the
+// target, or an empty string when commit statuses are disabled. The
+// profile name is appended, so each server has its own statuses.
+func commitStatusPrefix(profile string) string {
+	if !commit_status {
+		return ""
+	}
+
+	prefix := commit_status_prefix
+	if prefix == "" {
+		prefix = defaultCommitStatusPrefix
 	}
+	if profile != "" {
+		prefix = fmt.Sprintf("%s/%s", prefix, profile)
+	}
+	return prefix
+}
+
+// profilePath returns the path of a file written for the target. The
+// profile name is added before the extension, so each server has its own file.
+func profilePath(path, profile string) string {
+	if path == "" || profile == "" {
+		return path
+	}
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s-%s%s", strings.TrimSuffix(path, ext), profile, ext)
+}
 
-	os.Exit(0)
+// profileDir returns the directory files are written to for the target.
+// The profile name is added as a subdirectory, so each server has its own.
+func profileDir(dir, profile string) string {
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, profile)
+}
+
+// changedFiles returns the files changed between the diff base and HEAD,
+// so only resources in changed files are applied. Nil is returned when
+// every resource should be applied, such as when changed_files_only is
+// not set or the base commit cannot be found.
+func changedFiles(logger *zap.Logger) []string {
+	if !changed_files_only || mode != modeApply {
+		return nil
+	}
+
+	base := changed_files_base
+	if base == "" {
+		base = ci.Detect().DiffBase()
+	}
+	if base == "" {
+		logger.Warn("No base commit to compare with, applying every resource. Set changed_files_base to compare with a specific commit.")
+		return nil
+	}
+
+	files, err := repo.ChangedFiles(".", base, "HEAD")
+	if err != nil {
+		logger.Warn("Failed to find changed files, applying every resource. Check out the repository with a fetch depth which includes the base commit, such as fetch-depth: 0.", zap.String("base", base), zap.Error(err))
+		return nil
+	}
+	return files
+}
+
+// readOnlyMode returns true if the mode does not modify the BindPlane server
+func readOnlyMode() bool {
+	return mode == modeExport || mode == modeDrift || mode == modeStatus || mode == modeGolden
+}
+
+// writeOutputs writes the action outputs. Outputs are written even when
+// the action fails, so later steps can report on partial results. Failing
+// to write outputs is logged but does not fail the action.
+func writeOutputs(a *action.Action) {
+	if err := a.WriteOutputs(); err != nil {
+		a.Logger.Error("error writing action outputs", zap.Error(err))
+	}
+}
+
+// writeStatusReport writes the status of every applied resource. Like
+// outputs, it is written even when the action fails, and failing to
+// write it is logged but does not fail the action.
+func writeStatusReport(a *action.Action) {
+	if err := a.WriteStatusReport(); err != nil {
+		a.Logger.Error("error writing status report", zap.Error(err))
+	}
+}
+
+// writeAuditSummary appends the audit log entries of the run to the job
+// summary. The audit log is a confirmation for reviewers, so failing to
+// read it is logged but does not fail the action.
+func writeAuditSummary(a *action.Action) {
+	if err := a.WriteAuditSummary(); err != nil {
+		a.Logger.Warn("error writing audit log job summary", zap.Error(err))
+	}
 }
 
 // commitMessage clones the repository and returns the commit message of the
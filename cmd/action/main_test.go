@@ -90,3 +90,46 @@ func Test_extractName(t *testing.T) {
 	}
 
 }
+
+func TestDeploymentEnvironment(t *testing.T) {
+	defer func() {
+		github_deployment = false
+		github_deployment_environment = ""
+		environment = ""
+	}()
+
+	require.Equal(t, "", deploymentEnvironment("prod"))
+
+	github_deployment = true
+	require.Equal(t, defaultDeploymentEnvironment, deploymentEnvironment(""))
+
+	environment = "staging"
+	require.Equal(t, "staging", deploymentEnvironment(""))
+	require.Equal(t, "prod", deploymentEnvironment("prod"))
+
+	github_deployment_environment = "production"
+	require.Equal(t, "production", deploymentEnvironment("prod"))
+}
+
+func TestCommitStatusPrefix(t *testing.T) {
+	defer func() {
+		commit_status = false
+		commit_status_prefix = ""
+	}()
+
+	require.Equal(t, "", commitStatusPrefix("prod"))
+
+	commit_status = true
+	require.Equal(t, "bindplane", commitStatusPrefix(""))
+	require.Equal(t, "bindplane/prod", commitStatusPrefix("prod"))
+
+	commit_status_prefix = "observability"
+	require.Equal(t, "observability", commitStatusPrefix(""))
+}
+
+func TestProfilePath(t *testing.T) {
+	require.Equal(t, "", profilePath("", "prod"))
+	require.Equal(t, "audit/record.json", profilePath("audit/record.json", ""))
+	require.Equal(t, "audit/record-prod.json", profilePath("audit/record.json", "prod"))
+	require.Equal(t, "record-prod", profilePath("record", "prod"))
+}
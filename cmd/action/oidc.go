@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+
+	"github.com/observiq/bindplane-op-action/internal/oidc"
+	"github.com/observiq/bindplane-op-action/internal/workflow"
+)
+
+// validateOIDC checks the OIDC inputs. It is called before the exchange,
+// because the exchanged key is set as bindplane_api_key.
+func validateOIDC() error {
+	if oidc_broker_url == "" {
+		// The audience is also used when secret_role is assumed
+		if oidc_role != "" || oidc_credential_field != "" || (oidc_audience != "" && secret_role == "") {
+			return fix("Set oidc_broker_url, or remove the other oidc inputs.", "oidc_broker_url is required when oidc_audience, oidc_role, or oidc_credential_field is set")
+		}
+		return nil
+	}
+
+	errs := []error{}
+
+	u, err := url.Parse(oidc_broker_url)
+	if err != nil || (u.Scheme != "https" && !(u.Scheme == "http" && isLoopback(u.Hostname()))) {
+		errs = append(errs, fix("Use the https URL of the token broker, such as https://vault.example.com/v1/auth/jwt/login.", "oidc_broker_url must be an https URL, the OIDC token and API key are not sent in cleartext"))
+	}
+
+	if bindplane_api_key != "" || bindplane_username != "" {
+		errs = append(errs, fix("Remove bindplane_api_key, bindplane_username, and bindplane_password, the API key is exchanged for the OIDC token.", "bindplane_api_key and bindplane_username cannot be set with oidc_broker_url"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// exchangeOIDC exchanges the workflow's OIDC token for an API key with
+// the token broker, and sets it as bindplane_api_key. Both the token and
+// the key are masked.
+func exchangeOIDC(ctx context.Context) error {
+	if oidc_broker_url == "" {
+		return nil
+	}
+
+	token, err := oidc.GitHubToken(ctx, oidc_audience)
+	if errors.Is(err, oidc.ErrUnavailable) {
+		return fix("Grant the workflow the id-token write permission, with permissions: id-token: write.", "%w", err)
+	}
+	if err != nil {
+		return err
+	}
+	workflow.Mask(token)
+
+	key, err := oidc.NewBroker(oidc_broker_url, oidc_role, oidc_credential_field).Exchange(ctx, token)
+	if err != nil {
+		return fix("Check that oidc_role allows this repository and ref, and that oidc_credential_field matches the broker response.", "%w", err)
+	}
+	workflow.Mask(key)
+
+	bindplane_api_key = key
+	inputSources["bindplane_api_key"] = sourceOIDC
+	return nil
+}
+
+// isLoopback returns true if host is localhost or a loopback address,
+// such as a token broker started by the workflow for testing
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateOIDC(t *testing.T) {
+	defer func() {
+		oidc_broker_url = ""
+		oidc_role = ""
+		bindplane_api_key = ""
+	}()
+
+	require.NoError(t, validateOIDC())
+
+	oidc_role = "deploy"
+	require.EqualError(t, validateOIDC(), "oidc_broker_url is required when oidc_audience, oidc_role, or oidc_credential_field is set")
+
+	oidc_broker_url = "https://vault.example.com/v1/auth/jwt/login"
+	require.NoError(t, validateOIDC())
+
+	oidc_broker_url = "http://localhost:8200/v1/auth/jwt/login"
+	require.NoError(t, validateOIDC())
+
+	oidc_broker_url = "http://127.0.0.1:8200/v1/auth/jwt/login"
+	require.NoError(t, validateOIDC())
+
+	oidc_broker_url = "http://vault.example.com/v1/auth/jwt/login"
+	require.EqualError(t, validateOIDC(), "oidc_broker_url must be an https URL, the OIDC token and API key are not sent in cleartext")
+
+	oidc_broker_url = "vault.example.com"
+	bindplane_api_key = "key"
+	require.EqualError(t, validateOIDC(), "oidc_broker_url must be an https URL, the OIDC token and API key are not sent in cleartext\nbindplane_api_key and bindplane_username cannot be set with oidc_broker_url")
+}
+
+func TestExchangeOIDC(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /token", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"value": "jwt"}`))
+	})
+	mux.HandleFunc("POST /v1/auth/jwt/login", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"auth": {"client_token": "exchanged-key"}}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	defer func() {
+		oidc_broker_url = ""
+		oidc_credential_field = ""
+		bindplane_api_key = ""
+	}()
+
+	// Not enabled
+	require.NoError(t, exchangeOIDC(context.Background()))
+	require.Empty(t, bindplane_api_key)
+
+	oidc_broker_url = server.URL + "/v1/auth/jwt/login"
+	oidc_credential_field = "auth.client_token"
+
+	t.Setenv("ACTIONS_ID_TOKEN_REQUEST_URL", "")
+	t.Setenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN", "")
+	err := exchangeOIDC(context.Background())
+	require.Equal(t, []string{
+		"  - OIDC token is not available, ACTIONS_ID_TOKEN_REQUEST_URL and ACTIONS_ID_TOKEN_REQUEST_TOKEN are not set\n    Fix: Grant the workflow the id-token write permission, with permissions: id-token: write.",
+	}, formatProblems("", err))
+
+	t.Setenv("ACTIONS_ID_TOKEN_REQUEST_URL", server.URL+"/token")
+	t.Setenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN", "request-token")
+	require.NoError(t, exchangeOIDC(context.Background()))
+	require.Equal(t, "exchanged-key", bindplane_api_key)
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/observiq/bindplane-op-action/action"
+	"github.com/observiq/bindplane-op-action/action/profile"
+)
+
+// target is a BindPlane server the action applies resources to. When
+// profiles are not used, a single target is created from the inputs.
+type target struct {
+	// name is the profile name, empty when profiles are not used
+	name string
+	conn connection
+}
+
+// connection holds the inputs which a profile can override
+type connection struct {
+	environment string
+	remoteURL   string
+	apiKey      string
+	username    string
+	password    string
+	accountID   string
+	projectID   string
+	tlsCACert   string
+
+	// Repository paths, which are relative to the profile's dir
+	destinationPath   string
+	sourcePath        string
+	processorPath     string
+	agentVersionPath  string
+	configurationPath string
+	variablesPath     string
+	overlaysDir       string
+	patchesPath       string
+}
+
+// currentConnection returns the connection inputs
+func currentConnection() connection {
+	return connection{
+		environment: environment,
+		remoteURL:   bindplane_remote_url,
+		apiKey:      bindplane_api_key,
+		username:    bindplane_username,
+		password:    bindplane_password,
+		accountID:   bindplane_account_id,
+		projectID:   bindplane_project_id,
+		tlsCACert:   tls_ca_cert,
+
+		destinationPath:   destination_path,
+		sourcePath:        source_path,
+		processorPath:     processor_path,
+		agentVersionPath:  agent_version_path,
+		configurationPath: configuration_path,
+		variablesPath:     variables_path,
+		overlaysDir:       overlays_dir,
+		patchesPath:       patches_path,
+	}
+}
+
+// use sets the connection inputs to the target's connection
+func (t target) use() {
+	environment = t.conn.environment
+	bindplane_remote_url = t.conn.remoteURL
+	bindplane_api_key = t.conn.apiKey
+	bindplane_username = t.conn.username
+	bindplane_password = t.conn.password
+	bindplane_account_id = t.conn.accountID
+	bindplane_project_id = t.conn.projectID
+	tls_ca_cert = t.conn.tlsCACert
+	destination_path = t.conn.destinationPath
+	source_path = t.conn.sourcePath
+	processor_path = t.conn.processorPath
+	agent_version_path = t.conn.agentVersionPath
+	configuration_path = t.conn.configurationPath
+	variables_path = t.conn.variablesPath
+	overlays_dir = t.conn.overlaysDir
+	patches_path = t.conn.patchesPath
+}
+
+// selectTargets loads profiles_path and returns a target for each profile
+// selected by profile_names, or by the workflow ref when profile_names is
+// not set. Fields set by a profile override the corresponding inputs. When
+// profiles are not used, a single target using the inputs is returned. When
+// no profile matches the ref, no targets are returned.
+func selectTargets(ref string) ([]target, error) {
+	base := currentConnection()
+	if profiles_path == "" {
+		return []target{{conn: base}}, nil
+	}
+
+	profiles, err := profile.Load(profiles_path)
+	if err != nil {
+		return nil, err
+	}
+
+	selected, err := profiles.Select(profile_names, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	// Write back defaults to target_branch, which is optional
+	// when using profiles. Use the branch that selected the profile.
+	// Profiles selected by a tag have no branch to write back to, so
+	// configuration_output_branch is required, see validateWriteBack.
+	if configuration_output_branch == "" {
+		if branch, ok := strings.CutPrefix(ref, "refs/heads/"); ok {
+			configuration_output_branch = branch
+		}
+	}
+
+	targets := []target{}
+	for _, p := range selected {
+		c := base
+		override(&c.environment, p.Environment)
+		override(&c.remoteURL, p.RemoteURL)
+		override(&c.apiKey, p.APIKey)
+		override(&c.username, p.Username)
+		override(&c.password, p.Password)
+		override(&c.accountID, p.AccountID)
+		override(&c.projectID, p.ProjectID)
+		override(&c.tlsCACert, p.TLSCACert)
+		if p.Dir != "" {
+			for _, path := range []*string{
+				&c.destinationPath, &c.sourcePath, &c.processorPath, &c.agentVersionPath,
+				&c.configurationPath, &c.variablesPath, &c.overlaysDir, &c.patchesPath,
+			} {
+				*path = inDir(p.Dir, *path)
+			}
+		}
+		targets = append(targets, target{name: p.Name, conn: c})
+	}
+	return targets, nil
+}
+
+// inDir returns path relative to dir. Empty paths and
+// remote resource URLs are returned unchanged.
+func inDir(dir, path string) string {
+	if path == "" || action.IsRemotePath(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// override sets dst to value when value is not empty
+func override(dst *string, value string) {
+	if value != "" {
+		*dst = value
+	}
+}
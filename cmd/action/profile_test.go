@@ -0,0 +1,117 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectTargets(t *testing.T) {
+	t.Setenv("BINDPLANE_SECRET_DEV_API_KEY", "dev-key")
+	t.Setenv("BINDPLANE_SECRET_STAGE_API_KEY", "stage-key")
+	t.Setenv("BINDPLANE_SECRET_PROD_PASSWORD", "prod-password")
+
+	defer target{conn: currentConnection()}.use()
+	defer func() {
+		profiles_path = ""
+		profile_names = nil
+		configuration_output_branch = ""
+	}()
+
+	bindplane_remote_url = "https://input.corp.net"
+	bindplane_username = "input-user"
+
+	targets, err := selectTargets("refs/heads/main")
+	require.NoError(t, err)
+	require.Equal(t, []target{{conn: currentConnection()}}, targets, "profiles are not used")
+
+	profiles_path = "../../action/profile/testdata/profiles.yaml"
+
+	targets, err = selectTargets("refs/heads/release")
+	require.NoError(t, err)
+	require.Empty(t, targets, "no profile matches")
+
+	configuration_output_branch = ""
+	targets, err = selectTargets("refs/heads/main")
+	require.NoError(t, err)
+	require.Equal(t, []target{
+		{
+			name: "stage",
+			conn: connection{
+				environment: "stage",
+				remoteURL:   "https://stage.bindplane.corp.net",
+				apiKey:      "stage-key",
+				username:    "input-user",
+			},
+		},
+	}, targets)
+
+	require.Equal(t, "main", configuration_output_branch, "write back defaults to the branch")
+
+	configuration_output_branch = ""
+	targets, err = selectTargets("refs/tags/v2.1.0")
+	require.NoError(t, err)
+	require.Len(t, targets, 2)
+	require.Empty(t, configuration_output_branch, "tags are not branches")
+
+	enable_otel_config_write_back, configuration_output_dir, token = true, "./output", "token"
+	require.EqualError(t, validateWriteBack(), "configuration_output_branch is required when enable_otel_config_write_back is true")
+	enable_otel_config_write_back, configuration_output_dir, token = false, "", ""
+	require.Equal(t, "https://bindplane.corp.net", targets[0].conn.remoteURL)
+	require.Equal(t, "https://eu.bindplane.corp.net", targets[1].conn.remoteURL)
+
+	targets[1].use()
+	require.Equal(t, "https://eu.bindplane.corp.net", bindplane_remote_url)
+	require.Equal(t, "admin", bindplane_username)
+
+	profile_names = []string{"qa"}
+	_, err = selectTargets("refs/heads/main")
+	require.EqualError(t, err, "profile qa is not defined")
+}
+
+func TestSelectTargetsDir(t *testing.T) {
+	defer target{conn: currentConnection()}.use()
+	defer func() {
+		profiles_path = ""
+		configuration_output_branch = ""
+	}()
+
+	profiles_path = filepath.Join(t.TempDir(), "profiles.yaml")
+	require.NoError(t, os.WriteFile(profiles_path, []byte(`profiles:
+  - name: payments
+    branches: ["main"]
+    dir: teams/payments
+    bindplane_project_id: payments
+  - name: search
+    branches: ["main"]
+    dir: teams/search
+    bindplane_project_id: search
+  - name: shared
+    branches: ["main"]
+`), 0o600))
+
+	configuration_path = "configurations/*.yaml"
+	variables_path = "variables.yaml"
+	destination_path = "https://example.com/destinations.yaml"
+	source_path = ""
+
+	targets, err := selectTargets("refs/heads/main")
+	require.NoError(t, err)
+	require.Len(t, targets, 3)
+
+	targets[0].use()
+	require.Equal(t, "payments", bindplane_project_id)
+	require.Equal(t, filepath.Join("teams", "payments", "configurations", "*.yaml"), configuration_path)
+	require.Equal(t, filepath.Join("teams", "payments", "variables.yaml"), variables_path)
+	require.Equal(t, "https://example.com/destinations.yaml", destination_path, "remote paths are not in the repository")
+	require.Equal(t, "", source_path)
+
+	targets[1].use()
+	require.Equal(t, "search", bindplane_project_id)
+	require.Equal(t, filepath.Join("teams", "search", "configurations", "*.yaml"), configuration_path)
+
+	targets[2].use()
+	require.Equal(t, "configurations/*.yaml", configuration_path, "profiles without a dir use the inputs")
+}
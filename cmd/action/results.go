@@ -0,0 +1,34 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/observiq/bindplane-op-action/internal/workflow"
+)
+
+// outputResults is the name of the output written when applying
+// to more than one target
+const outputResults = "results"
+
+const (
+	targetStatusSucceeded = "succeeded"
+	targetStatusFailed    = "failed"
+)
+
+// targetResult is the result of running the action against a target
+type targetResult struct {
+	RemoteURL string `json:"bindplane_remote_url"`
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+}
+
+// writeResults writes the result of each target, keyed
+// by profile name, as a JSON object
+func writeResults(results map[string]targetResult) error {
+	data, err := json.Marshal(results)
+	if err != nil {
+		return fmt.Errorf("marshal results: %w", err)
+	}
+	return workflow.SetOutput(outputResults, string(data))
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteResults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "output")
+	t.Setenv("GITHUB_OUTPUT", path)
+
+	require.NoError(t, writeResults(map[string]targetResult{
+		"us-east": {RemoteURL: "https://us-east.corp.net", Status: targetStatusSucceeded},
+		"eu-west": {RemoteURL: "https://eu-west.corp.net", Status: targetStatusFailed, Error: "test connection: timeout"},
+	}))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(data), `{"eu-west":{"bindplane_remote_url":"https://eu-west.corp.net","status":"failed","error":"test connection: timeout"},"us-east":{"bindplane_remote_url":"https://us-east.corp.net","status":"succeeded"}}`)
+}
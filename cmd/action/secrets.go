@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/url"
+
+	"github.com/observiq/bindplane-op-action/internal/secrets"
+	"github.com/observiq/bindplane-op-action/internal/workflow"
+)
+
+// validateSecretStore checks the secret store inputs. It is called before
+// the credentials are fetched, because they are set as the credential inputs.
+func validateSecretStore() error {
+	if secret_store == "" {
+		if secret_store_url != "" || secret_path != "" || secret_role != "" {
+			return fix("Set secret_store to vault or aws-secrets-manager, or remove the other secret inputs.", "secret_store is required when secret_store_url, secret_path, or secret_role is set")
+		}
+		return nil
+	}
+
+	errs := []error{}
+
+	if secret_store != secrets.StoreVault && secret_store != secrets.StoreAWS {
+		errs = append(errs, fix("Set secret_store to vault or aws-secrets-manager.", "secret_store must be vault or aws-secrets-manager"))
+	}
+
+	if secret_path == "" {
+		errs = append(errs, fix("Set secret_path to the Vault secret path, such as secret/data/bindplane, or the AWS secret name or ARN.", "secret_path is required when secret_store is set"))
+	}
+
+	if secret_store == secrets.StoreVault && secret_store_url == "" {
+		errs = append(errs, fix("Set secret_store_url to the Vault address, such as https://vault.example.com:8200.", "secret_store_url is required when secret_store is vault"))
+	}
+
+	if secret_store_url != "" {
+		u, err := url.Parse(secret_store_url)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			errs = append(errs, fix("Include the scheme, such as https://vault.example.com:8200.", "secret_store_url must be an http or https URL"))
+		}
+	}
+
+	if bindplane_api_key != "" || bindplane_username != "" || bindplane_password != "" {
+		errs = append(errs, fix("Remove bindplane_api_key, bindplane_username, and bindplane_password, the credentials are read from the secret.", "bindplane_api_key, bindplane_username, and bindplane_password cannot be set with secret_store"))
+	}
+
+	if oidc_broker_url != "" {
+		errs = append(errs, fix("Remove oidc_broker_url, or secret_store.", "oidc_broker_url and secret_store cannot both be set"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// fetchSecrets reads the credentials from the secret store and sets them
+// as the credential inputs. The credentials are masked.
+func fetchSecrets(ctx context.Context) error {
+	if secret_store == "" {
+		return nil
+	}
+
+	creds, err := secrets.Fetch(ctx, secrets.Config{
+		Store:    secret_store,
+		URL:      secret_store_url,
+		Path:     secret_path,
+		Role:     secret_role,
+		Audience: oidc_audience,
+	})
+	if err != nil {
+		return fix("Check that secret_role, or the workflow's credentials, can read secret_path, and that the secret has an api_key field, or username and password fields.", "%w", err)
+	}
+	workflow.Mask(creds.APIKey, creds.Password)
+
+	bindplane_api_key = creds.APIKey
+	bindplane_username = creds.Username
+	bindplane_password = creds.Password
+	for _, name := range []string{"bindplane_api_key", "bindplane_username", "bindplane_password"} {
+		inputSources[name] = sourceSecretStore
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateSecretStore(t *testing.T) {
+	defer func() {
+		secret_store = ""
+		secret_store_url = ""
+		secret_path = ""
+		secret_role = ""
+		bindplane_api_key = ""
+		oidc_broker_url = ""
+	}()
+
+	require.NoError(t, validateSecretStore())
+
+	secret_path = "secret/data/bindplane"
+	require.EqualError(t, validateSecretStore(), "secret_store is required when secret_store_url, secret_path, or secret_role is set")
+
+	secret_store = "vault"
+	require.EqualError(t, validateSecretStore(), "secret_store_url is required when secret_store is vault")
+
+	secret_store_url = "https://vault.example.com:8200"
+	require.NoError(t, validateSecretStore())
+
+	// The AWS endpoint is optional
+	secret_store = "aws-secrets-manager"
+	secret_store_url = ""
+	require.NoError(t, validateSecretStore())
+
+	secret_store = "gcp"
+	secret_path = ""
+	bindplane_api_key = "key"
+	oidc_broker_url = "https://broker.example.com"
+	require.Len(t, problems(validateSecretStore()), 4)
+}
+
+func TestFetchSecrets(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/secret/data/bindplane", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"data": {"data": {"username": "user", "password": "pass"}, "metadata": {}}}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	defer func() {
+		secret_store = ""
+		secret_store_url = ""
+		secret_path = ""
+		bindplane_username = ""
+		bindplane_password = ""
+	}()
+
+	// Not enabled
+	require.NoError(t, fetchSecrets(context.Background()))
+	require.Empty(t, bindplane_username)
+
+	t.Setenv("VAULT_TOKEN", "token")
+	secret_store = "vault"
+	secret_store_url = server.URL
+	secret_path = "secret/data/bindplane"
+	require.NoError(t, fetchSecrets(context.Background()))
+	require.Equal(t, "user", bindplane_username)
+	require.Equal(t, "pass", bindplane_password)
+
+	secret_path = "secret/data/missing"
+	require.ErrorContains(t, fetchSecrets(context.Background()), "read secret secret/data/missing from vault: vault returned status 404")
+}
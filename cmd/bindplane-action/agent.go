@@ -0,0 +1,28 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func newAgentConfigCommand(g *globalFlags) *cobra.Command {
+	return &cobra.Command{
+		Use:   "agent-config <agent-id>",
+		Short: "Write the configuration served to an agent, rendered for the agent",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			a, err := g.newAction(false)
+			if err != nil {
+				return err
+			}
+
+			raw, err := a.AgentConfiguration(args[0])
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprint(cmd.OutOrStdout(), raw)
+			return err
+		},
+	}
+}
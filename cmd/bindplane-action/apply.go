@@ -0,0 +1,115 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/observiq/bindplane-op-action/action"
+	"github.com/observiq/bindplane-op-action/internal/repo"
+	"github.com/spf13/cobra"
+)
+
+func newApplyCommand(g *globalFlags) *cobra.Command {
+	resources := &resourceFlags{}
+	wait := &waitFlags{}
+	var (
+		autoRollout            bool
+		rolloutAllPending      bool
+		rolloutSelector        string
+		prune                  bool
+		pruneSelector          string
+		pruneConfirm           bool
+		validateRenderedConfig bool
+		validatePipelines      bool
+		skipUnchanged          bool
+		stampLabels            bool
+		lock                   bool
+		lockTimeout            time.Duration
+		applyStrategy          string
+		statusReportPath       string
+		snapshotPath           string
+		rawConfigDir           string
+		renderedDiff           bool
+		changedSince           string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Apply resources to BindPlane and optionally roll them out",
+		Args:  cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			waitOpts, err := wait.options()
+			if err != nil {
+				return err
+			}
+
+			opts := append(resources.options(), waitOpts...)
+			if changedSince != "" {
+				files, err := repo.ChangedFiles(".", changedSince, "HEAD")
+				if err != nil {
+					return fmt.Errorf("changed files: %w", err)
+				}
+				opts = append(opts, action.WithChangedFiles(files))
+			}
+			opts = append(opts,
+				action.WithAutoRollout(autoRollout),
+				action.WithRolloutAllPending(rolloutAllPending),
+				action.WithRolloutSelector(rolloutSelector),
+				action.WithPrune(prune),
+				action.WithPruneSelector(pruneSelector),
+				action.WithPruneConfirm(pruneConfirm),
+				action.WithValidateRenderedConfig(validateRenderedConfig),
+				action.WithValidatePipelines(validatePipelines),
+				action.WithSkipUnchanged(skipUnchanged),
+				action.WithStampLabels(stampLabels),
+				action.WithLock(lock),
+				action.WithLockTimeout(lockTimeout),
+				action.WithApplyStrategy(applyStrategy),
+				action.WithStatusReportPath(statusReportPath),
+				action.WithSnapshotPath(snapshotPath),
+				action.WithRawConfigDir(rawConfigDir),
+				action.WithRenderedDiff(renderedDiff),
+			)
+
+			a, err := g.newAction(true, opts...)
+			if err != nil {
+				return err
+			}
+
+			// Outputs are set even when the run fails,
+			// to report on partial results
+			err = a.Run()
+			g.setOutputs(a)
+			if statusReportPath != "" {
+				if reportErr := a.WriteStatusReport(); reportErr != nil {
+					return errors.Join(err, reportErr)
+				}
+			}
+			return err
+		},
+	}
+
+	f := cmd.Flags()
+	resources.register(f)
+	wait.register(f)
+	f.BoolVar(&autoRollout, "auto-rollout", false, "Start rollouts for configurations with a pending version")
+	f.BoolVar(&rolloutAllPending, "rollout-all-pending", false, "Include configurations outside of the resource files in auto rollout")
+	f.StringVar(&rolloutSelector, "rollout-selector", "", "Label selector of configurations outside of the resource files to include in auto rollout")
+	f.BoolVar(&prune, "prune", false, "Delete resources matching --prune-selector which are not in the resource files")
+	f.StringVar(&pruneSelector, "prune-selector", "", "Label selector of resources managed by the resource files")
+	f.BoolVar(&pruneConfirm, "prune-confirm", false, "Delete pruned resources, otherwise they are only reported")
+	f.BoolVar(&validateRenderedConfig, "validate-rendered-config", false, "Validate the rendered OpenTelemetry configuration of applied configurations")
+	f.BoolVar(&validatePipelines, "validate-pipelines", false, "Check the telemetry types of configuration sources and destinations before applying")
+	f.BoolVar(&skipUnchanged, "skip-unchanged", false, "Skip applying resources which are the same as the server")
+	f.BoolVar(&lock, "lock", false, "Lock configurations while they are applied and rolled out")
+	f.DurationVar(&lockTimeout, "lock-timeout", action.DefaultLockTimeout, "How long to wait for configurations locked by another run")
+	f.BoolVar(&stampLabels, "stamp-labels", false, "Add the managed-by, source-repo, and content-hash labels to every resource")
+	f.StringVar(&applyStrategy, "apply-strategy", action.ApplyStrategyFailFast, "How apply failures are handled, fail_fast, continue, or rollback")
+	f.StringVar(&changedSince, "changed-since", "", "Apply only resources in files changed since this commit, such as origin/main")
+	f.BoolVar(&renderedDiff, "rendered-diff", false, "Log a diff of the rendered OpenTelemetry configuration of each configuration before and after apply")
+	f.StringVar(&rawConfigDir, "raw-config-dir", "", "Directory the raw OpenTelemetry configuration of each applied configuration is written to")
+	f.StringVar(&statusReportPath, "status-report", "", "Path of a JSON file the status of every applied resource is written to")
+	f.StringVar(&snapshotPath, "snapshot", "", "Path of a YAML file the server version of every resource is written to before applying")
+	return cmd
+}
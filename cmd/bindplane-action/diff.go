@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/observiq/bindplane-op-action/action"
+	"github.com/spf13/cobra"
+)
+
+// driftSymbols prefix each difference written by diff
+var driftSymbols = map[action.DriftChange]string{
+	action.DriftAdded:   "+",
+	action.DriftChanged: "~",
+	action.DriftRemoved: "-",
+}
+
+func newDiffCommand(g *globalFlags) *cobra.Command {
+	resources := &resourceFlags{}
+	var (
+		exitCode   bool
+		jsonOutput bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "diff",
+		Short: "Compare resource files with BindPlane without applying them",
+		Long: "Compare resource files with BindPlane without applying them. Resources only in the\n" +
+			"files are prefixed with +, resources only on the server with -, and changed resources\n" +
+			"with ~ followed by the differing fields.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			a, err := g.newAction(true, resources.options()...)
+			if err != nil {
+				return err
+			}
+
+			drift, err := a.DetectDrift()
+			if err != nil {
+				return fmt.Errorf("detect drift: %w", err)
+			}
+			g.outputs = map[string]any{action.OutputDrift: drift}
+
+			out := cmd.OutOrStdout()
+			if jsonOutput {
+				if err := json.NewEncoder(out).Encode(drift); err != nil {
+					return err
+				}
+			} else {
+				for _, d := range drift {
+					line := fmt.Sprintf("%s %s/%s", driftSymbols[d.Change], d.Kind, d.Name)
+					if len(d.Fields) > 0 {
+						line += " (" + strings.Join(d.Fields, ", ") + ")"
+					}
+					fmt.Fprintln(out, line)
+				}
+			}
+
+			if exitCode && len(drift) > 0 {
+				return fmt.Errorf("drift detected for %d resources", len(drift))
+			}
+			return nil
+		},
+	}
+
+	resources.register(cmd.Flags())
+	cmd.Flags().BoolVar(&exitCode, "exit-code", false, "Exit with a non-zero status when drift is detected")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Write differences as JSON")
+	return cmd
+}
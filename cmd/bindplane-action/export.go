@@ -0,0 +1,30 @@
+package main
+
+import (
+	"github.com/observiq/bindplane-op-action/action"
+	"github.com/spf13/cobra"
+)
+
+func newExportCommand(g *globalFlags) *cobra.Command {
+	var (
+		dir string
+		raw bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export resources from BindPlane to resource files",
+		Args:  cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			a, err := g.newAction(false, action.WithExportRaw(raw))
+			if err != nil {
+				return err
+			}
+			return a.Export(dir)
+		},
+	}
+
+	cmd.Flags().StringVar(&dir, "dir", ".", "Directory resources are written to, one subdirectory per kind")
+	cmd.Flags().BoolVar(&raw, "raw", false, "Also write the raw OpenTelemetry configuration of each configuration to the raw subdirectory")
+	return cmd
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/observiq/bindplane-op-action/action"
+	"github.com/spf13/cobra"
+)
+
+// goldenSymbols prefix each difference written by golden
+var goldenSymbols = map[action.GoldenChange]string{
+	action.GoldenAdded:   "+",
+	action.GoldenChanged: "~",
+	action.GoldenRemoved: "-",
+}
+
+func newGoldenCommand(g *globalFlags) *cobra.Command {
+	resources := &resourceFlags{}
+	var (
+		dir        string
+		update     bool
+		jsonOutput bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "golden",
+		Short: "Compare rendered configurations with golden files",
+		Long: "Compare the rendered OpenTelemetry configuration of each configuration with its golden\n" +
+			"file, <dir>/<configuration>.yaml. Configurations without a golden file are prefixed with +,\n" +
+			"golden files of configurations which are not in the resource files with -, and changed\n" +
+			"configurations with ~ followed by a unified diff. Use --update to write the golden files.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			a, err := g.newAction(true, resources.options()...)
+			if err != nil {
+				return err
+			}
+
+			diffs, err := a.CompareGolden(dir)
+			if err != nil {
+				return fmt.Errorf("compare golden files: %w", err)
+			}
+			g.outputs = map[string]any{action.OutputGolden: diffs}
+
+			out := cmd.OutOrStdout()
+			if jsonOutput {
+				if err := json.NewEncoder(out).Encode(diffs); err != nil {
+					return err
+				}
+			} else {
+				for _, d := range diffs {
+					fmt.Fprintf(out, "%s %s (%s)\n", goldenSymbols[d.Change], d.Name, d.Path)
+					fmt.Fprint(out, d.Diff)
+				}
+			}
+
+			if update {
+				for _, d := range diffs {
+					if err := d.Update(); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+			if len(diffs) > 0 {
+				return fmt.Errorf("%d rendered configurations differ from golden files", len(diffs))
+			}
+			return nil
+		},
+	}
+
+	resources.register(cmd.Flags())
+	cmd.Flags().StringVar(&dir, "dir", "golden", "Directory of golden files")
+	cmd.Flags().BoolVar(&update, "update", false, "Write the rendered configurations to the golden files")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Write differences as JSON")
+	return cmd
+}
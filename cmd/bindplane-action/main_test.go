@@ -0,0 +1,256 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"github.com/observiq/bindplane-op-action/pkg/client/version"
+	"github.com/stretchr/testify/require"
+)
+
+// execute runs the command with args against the server,
+// returning its stdout
+func execute(t *testing.T, server *httptest.Server, args ...string) (string, error) {
+	t.Helper()
+
+	out := &bytes.Buffer{}
+	cmd := newRootCommand()
+	cmd.SetOut(out)
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs(append(args, "--remote-url", server.URL, "--api-key", "key", "--log-level", "error"))
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func newTestServer(t *testing.T, mux *http.ServeMux) *httptest.Server {
+	mux.HandleFunc("/v1/version", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(version.Version{Tag: "v1.80.0"})
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestConnectionFlags(t *testing.T) {
+	t.Setenv("BINDPLANE_REMOTE_URL", "")
+	t.Setenv("BINDPLANE_API_KEY", "")
+	t.Setenv("BINDPLANE_USERNAME", "")
+
+	cmd := newRootCommand()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+
+	cmd.SetArgs([]string{"status"})
+	require.EqualError(t, cmd.Execute(), "--remote-url or BINDPLANE_REMOTE_URL is required")
+
+	cmd.SetArgs([]string{"status", "--remote-url", "http://localhost:3001"})
+	require.EqualError(t, cmd.Execute(), "either --api-key or --username is required")
+}
+
+func TestClientEnv(t *testing.T) {
+	requests := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/rollouts", func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	server := newTestServer(t, mux)
+
+	t.Setenv("BINDPLANE_RETRY_MAX_ATTEMPTS", "2")
+	t.Setenv("BINDPLANE_RETRY_STATUS_CODES", "429,503")
+	t.Setenv("BINDPLANE_RATE_LIMIT", "100")
+	_, err := execute(t, server, "status")
+	require.Error(t, err)
+	require.Equal(t, 2, requests)
+
+	t.Setenv("BINDPLANE_RETRY_MAX_ATTEMPTS", "many")
+	_, err = execute(t, server, "status")
+	require.ErrorContains(t, err, "BINDPLANE_RETRY_MAX_ATTEMPTS")
+
+	t.Setenv("BINDPLANE_RETRY_MAX_ATTEMPTS", "1")
+	t.Setenv("BINDPLANE_TLS_MIN_VERSION", "1.0")
+	_, err = execute(t, server, "status")
+	require.ErrorContains(t, err, "1.0")
+}
+
+func TestHeaderFlag(t *testing.T) {
+	var header http.Header
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/rollouts", func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(model.RolloutsResponse{})
+	})
+	server := newTestServer(t, mux)
+
+	_, err := execute(t, server, "status", "--header", "x-tenant=payments", "--header", "x-gateway-auth=gateway-token")
+	require.NoError(t, err)
+	require.Equal(t, "payments", header.Get("X-Tenant"))
+	require.Equal(t, "gateway-token", header.Get("X-Gateway-Auth"))
+
+	t.Setenv("BINDPLANE_HEADER", "x-tenant=checkout")
+	_, err = execute(t, server, "status")
+	require.NoError(t, err)
+	require.Equal(t, "checkout", header.Get("X-Tenant"))
+
+	// The CI system is included in the User-Agent
+	t.Setenv("GITHUB_ACTIONS", "")
+	t.Setenv("GITLAB_CI", "true")
+	_, err = execute(t, server, "status")
+	require.NoError(t, err)
+	require.Regexp(t, `^bindplane-op-action/\S+ \(gitlab\)$`, header.Get("User-Agent"))
+}
+
+func TestStatus(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/rollouts", func(w http.ResponseWriter, _ *http.Request) {
+		c := &model.Configuration{}
+		c.Metadata.Name = "gateway"
+		c.Metadata.Version = 3
+		c.Status.Rollout.Status = model.RolloutStatusStarted
+		c.Status.Rollout.Progress = model.RolloutProgress{Completed: 2, Errors: 1, Pending: 4}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(model.RolloutsResponse{Configurations: []*model.Configuration{c}})
+	})
+	server := newTestServer(t, mux)
+
+	out, err := execute(t, server, "status")
+	require.NoError(t, err)
+	require.Equal(t, "NAME     STATUS   VERSION  COMPLETED  ERRORS  PENDING  WAITING\ngateway  started  3        2          1       4        0\n", out)
+
+	out, err = execute(t, server, "status", "--json")
+	require.NoError(t, err)
+	require.JSONEq(t, `[{"name":"gateway","status":"started","version":3,"completed":2,"errors":1,"pending":4,"waiting":0}]`, out)
+}
+
+func TestDiff(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "destinations.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`apiVersion: bindplane.observiq.com/v1
+kind: Destination
+metadata:
+  name: otlp
+  labels:
+    env: prod
+spec:
+  type: otlp
+---
+apiVersion: bindplane.observiq.com/v1
+kind: Destination
+metadata:
+  name: added
+spec:
+  type: otlp
+`), 0600))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/destinations", func(w http.ResponseWriter, _ *http.Request) {
+		otlp := &model.AnyResource{Spec: map[string]any{"type": "otlp"}}
+		otlp.Kind = string(model.KindDestination)
+		otlp.Metadata.Name = "otlp"
+		removed := &model.AnyResource{Spec: map[string]any{"type": "logging"}}
+		removed.Kind = string(model.KindDestination)
+		removed.Metadata.Name = "removed"
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"destinations": []*model.AnyResource{otlp, removed}})
+	})
+	server := newTestServer(t, mux)
+
+	out, err := execute(t, server, "diff", "--destination-path", path)
+	require.NoError(t, err)
+	require.Equal(t, "+ Destination/added\n~ Destination/otlp (metadata.labels)\n- Destination/removed\n", out)
+
+	_, err = execute(t, server, "diff", "--destination-path", path, "--exit-code")
+	require.EqualError(t, err, "drift detected for 3 resources")
+}
+
+func TestBindEnv(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/rollouts", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "env-key", r.Header.Get("X-Bindplane-Api-Key"))
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(model.RolloutsResponse{})
+	})
+	server := newTestServer(t, mux)
+
+	t.Setenv("BINDPLANE_REMOTE_URL", server.URL)
+	t.Setenv("BINDPLANE_API_KEY", "env-key")
+	t.Setenv("BINDPLANE_LOG_LEVEL", "error")
+	t.Setenv("BINDPLANE_JSON", "true")
+
+	out := &bytes.Buffer{}
+	cmd := newRootCommand()
+	cmd.SetOut(out)
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"status"})
+	require.NoError(t, cmd.Execute())
+	require.Equal(t, "[]\n", out.String())
+
+	// Flags take precedence over the environment
+	t.Setenv("BINDPLANE_API_KEY", "")
+	cmd = newRootCommand()
+	cmd.SetOut(out)
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"status", "--api-key", "env-key"})
+	require.NoError(t, cmd.Execute())
+
+	t.Setenv("BINDPLANE_JSON", "maybe")
+	cmd = newRootCommand()
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"status"})
+	require.ErrorContains(t, cmd.Execute(), "BINDPLANE_JSON: ")
+}
+
+func TestResultsFile(t *testing.T) {
+	t.Setenv("GITHUB_ACTIONS", "")
+	t.Setenv("GITLAB_CI", "true")
+	t.Setenv("CI_COMMIT_SHA", "0a1b2c3d")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/rollouts", func(w http.ResponseWriter, _ *http.Request) {
+		c := &model.Configuration{}
+		c.Metadata.Name = "gateway"
+		c.Status.Rollout.Status = model.RolloutStatusError
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(model.RolloutsResponse{Configurations: []*model.Configuration{c}})
+	})
+	mux.HandleFunc("/v1/rollouts/{name}/start", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+	})
+	server := newTestServer(t, mux)
+
+	path := filepath.Join(t.TempDir(), "results.json")
+	_, err := execute(t, server, "status", "--results-file", path)
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"command": "status",
+		"result": "succeeded",
+		"ci": {"provider": "gitlab", "commit": "0a1b2c3d"},
+		"outputs": {
+			"rollouts": [{"name":"gateway","status":"error","version":0,"completed":0,"errors":0,"pending":0,"waiting":0}]
+		}
+	}`, string(data))
+
+	// Failed commands write their results
+	_, cmdErr := execute(t, server, "rollout", "gateway", "--results-file", path)
+	require.Error(t, cmdErr)
+
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+
+	r := results{}
+	require.NoError(t, json.Unmarshal(data, &r))
+	require.Equal(t, "rollout", r.Command)
+	require.Equal(t, "failed", r.Result)
+	require.Equal(t, cmdErr.Error(), r.Error)
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/observiq/bindplane-op-action/action"
+	"github.com/spf13/pflag"
+)
+
+// resourceFlags are the resource file flags of commands which read
+// resources from the repository
+type resourceFlags struct {
+	destinationPath   string
+	sourcePath        string
+	processorPath     string
+	agentVersionPath  string
+	configurationPath string
+	environment       string
+	variablesPath     string
+	urlHeaders        map[string]string
+	ociArtifact       string
+	ociUsername       string
+	ociPassword       string
+	overlaysDir       string
+	patchesPath       string
+}
+
+func (r *resourceFlags) register(f *pflag.FlagSet) {
+	f.StringVar(&r.destinationPath, "destination-path", "", "Path or glob of destination resource files")
+	f.StringVar(&r.sourcePath, "source-path", "", "Path or glob of source resource files")
+	f.StringVar(&r.processorPath, "processor-path", "", "Path or glob of processor resource files")
+	f.StringVar(&r.agentVersionPath, "agent-version-path", "", "Path or glob of agent version resource files")
+	f.StringVar(&r.configurationPath, "configuration-path", "", "Path or glob of configuration resource files")
+	f.StringVar(&r.environment, "environment", "", "Environment used to resolve variables")
+	f.StringVar(&r.variablesPath, "variables-path", "", "Path of the variables file, requires --environment")
+	f.StringVar(&r.patchesPath, "patches-path", "", "Path or glob of patch files applied to resources before they are applied")
+	f.StringToStringVar(&r.urlHeaders, "resource-url-header", nil, "Header sent when downloading resource files from https URLs as key=value. Can be repeated")
+	f.StringVar(&r.ociArtifact, "oci-artifact", "", "OCI artifact of resources to apply, pinned to a digest such as ghcr.io/my-org/bindplane@sha256:<digest>")
+	f.StringVar(&r.ociUsername, "oci-username", "", "Username used to pull the OCI artifact")
+	f.StringVar(&r.ociPassword, "oci-password", "", "Password or token used to pull the OCI artifact")
+	f.StringVar(&r.overlaysDir, "overlays-dir", "", "Directory of overlays for each environment, requires --environment")
+}
+
+func (r *resourceFlags) options() []action.Option {
+	return []action.Option{
+		action.WithDestinationPath(r.destinationPath),
+		action.WithSourcePath(r.sourcePath),
+		action.WithProcessorPath(r.processorPath),
+		action.WithAgentVersionPath(r.agentVersionPath),
+		action.WithConfigurationPath(r.configurationPath),
+		action.WithEnvironment(r.environment),
+		action.WithVariablesPath(r.variablesPath),
+		action.WithRemoteResourceHeaders(r.urlHeaders),
+		action.WithOCIArtifact(r.ociArtifact),
+		action.WithOCICredentials(r.ociUsername, r.ociPassword),
+		action.WithOverlaysDir(r.overlaysDir),
+		action.WithPatchesPath(r.patchesPath),
+	}
+}
+
+// waitFlags are the rollout wait flags of commands which start rollouts
+type waitFlags struct {
+	wait             bool
+	timeout          time.Duration
+	pollInterval     time.Duration
+	pollJitter       int
+	maxRolloutErrors string
+	canarySelector   string
+	canaryWait       time.Duration
+	agentCheck       string
+	expectedAgents   string
+	agentWait        bool
+	agentWaitTimeout time.Duration
+	minThroughput    int
+	throughputWait   time.Duration
+}
+
+func (w *waitFlags) register(f *pflag.FlagSet) {
+	f.BoolVar(&w.wait, "wait", false, "Wait for started rollouts to finish")
+	f.DurationVar(&w.timeout, "rollout-timeout", action.DefaultRolloutTimeout, "Maximum amount of time to wait for rollouts")
+	f.DurationVar(&w.pollInterval, "rollout-poll-interval", action.DefaultRolloutPollInterval, "Interval rollout status is polled at")
+	f.IntVar(&w.pollJitter, "rollout-poll-jitter", action.DefaultRolloutPollJitter, "Percentage of the poll interval randomly added to each poll")
+	f.StringVar(&w.maxRolloutErrors, "max-rollout-errors", "", "Errored agents, such as 5 or 10%, which fail a rollout")
+	f.StringVar(&w.canarySelector, "canary-selector", "", "Labels of canary agents, such as canary=true, which are rolled out to first")
+	f.DurationVar(&w.canaryWait, "canary-wait", 0, "How long canary agents must stay healthy before the rollout continues")
+	f.StringVar(&w.agentCheck, "agent-check", "", "Count the agents matching a configuration before its rollout starts, warn or fail")
+	f.BoolVar(&w.agentWait, "agent-wait", false, "Wait for agents to run the rolled out configuration versions")
+	f.DurationVar(&w.agentWaitTimeout, "agent-wait-timeout", action.DefaultAgentWaitTimeout, "Maximum amount of time to wait for agents")
+	f.IntVar(&w.minThroughput, "min-throughput-percent", 0, "Fail if throughput drops below this percent of its throughput before the rollout")
+	f.DurationVar(&w.throughputWait, "throughput-wait", action.DefaultThroughputWait, "How long to wait after rollouts complete before throughput is measured")
+	f.StringVar(&w.expectedAgents, "expected-agents", "", "Agents each configuration is expected to match, such as 10 or 10-50")
+}
+
+func (w *waitFlags) options() ([]action.Option, error) {
+	opts := []action.Option{
+		action.WithRolloutWait(w.wait),
+		action.WithRolloutTimeout(w.timeout),
+		action.WithRolloutPollInterval(w.pollInterval),
+		action.WithRolloutPollJitter(w.pollJitter),
+		action.WithCanarySelector(w.canarySelector),
+		action.WithCanaryWait(w.canaryWait),
+		action.WithAgentCheck(w.agentCheck),
+		action.WithAgentWait(w.agentWait),
+		action.WithAgentWaitTimeout(w.agentWaitTimeout),
+		action.WithMinThroughputPercent(w.minThroughput),
+		action.WithThroughputWait(w.throughputWait),
+	}
+
+	if w.maxRolloutErrors != "" {
+		t, err := action.ParseErrorThreshold(w.maxRolloutErrors)
+		if err != nil {
+			return nil, fmt.Errorf("--max-rollout-errors: %w", err)
+		}
+		opts = append(opts, action.WithMaxRolloutErrors(t))
+	}
+
+	if w.expectedAgents != "" {
+		r, err := action.ParseAgentRange(w.expectedAgents)
+		if err != nil {
+			return nil, fmt.Errorf("--expected-agents: %w", err)
+		}
+		opts = append(opts, action.WithExpectedAgents(r))
+	}
+
+	return opts, nil
+}
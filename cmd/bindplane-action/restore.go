@@ -0,0 +1,46 @@
+package main
+
+import (
+	"github.com/observiq/bindplane-op-action/action"
+	"github.com/spf13/cobra"
+)
+
+func newRestoreCommand(g *globalFlags) *cobra.Command {
+	wait := &waitFlags{}
+	var (
+		autoRollout   bool
+		applyStrategy string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "restore <snapshot>",
+		Short: "Apply the resources of a snapshot written by apply --snapshot",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			opts, err := wait.options()
+			if err != nil {
+				return err
+			}
+			opts = append(opts,
+				action.WithSnapshotPath(args[0]),
+				action.WithAutoRollout(autoRollout),
+				action.WithApplyStrategy(applyStrategy),
+			)
+
+			a, err := g.newAction(false, opts...)
+			if err != nil {
+				return err
+			}
+
+			err = a.Restore()
+			g.setOutputs(a)
+			return err
+		},
+	}
+
+	f := cmd.Flags()
+	wait.register(f)
+	f.BoolVar(&autoRollout, "auto-rollout", false, "Start rollouts for restored configurations")
+	f.StringVar(&applyStrategy, "apply-strategy", action.ApplyStrategyFailFast, "How apply failures are handled, fail_fast or continue")
+	return cmd
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/observiq/bindplane-op-action/action"
+	"github.com/observiq/bindplane-op-action/action/notify"
+	"github.com/observiq/bindplane-op-action/internal/ci"
+	"go.uber.org/zap"
+)
+
+// results is the machine readable result of a command, written to
+// the results file so any CI system can consume it
+type results struct {
+	Command string         `json:"command"`
+	Result  string         `json:"result"`
+	Error   string         `json:"error,omitempty"`
+	CI      ci.Environment `json:"ci"`
+
+	// Outputs match the action outputs of the same name
+	Outputs map[string]any `json:"outputs,omitempty"`
+}
+
+// setOutputs sets the action outputs as the command outputs. JSON
+// encoded outputs are decoded, so they are not encoded twice. Failing
+// to get the outputs is logged and does not fail the command.
+func (g *globalFlags) setOutputs(a *action.Action) {
+	if g.resultsFile == "" {
+		return
+	}
+
+	outputs, err := a.Outputs()
+	if err != nil {
+		a.Logger.Error("error getting outputs", zap.Error(err))
+		return
+	}
+
+	g.outputs = map[string]any{}
+	for name, v := range outputs {
+		if json.Valid([]byte(v)) {
+			g.outputs[name] = json.RawMessage(v)
+			continue
+		}
+		g.outputs[name] = v
+	}
+}
+
+// writeResults writes the results of the command to the results file,
+// if set, and returns the command error. Failing to write the results
+// fails the command.
+func (g *globalFlags) writeResults(command string, err error) error {
+	if g.resultsFile == "" {
+		return err
+	}
+
+	r := results{
+		Command: command,
+		Result:  notify.ResultSucceeded,
+		CI:      ci.Detect(),
+		Outputs: g.outputs,
+	}
+	if err != nil {
+		r.Result = notify.ResultFailed
+		r.Error = err.Error()
+	}
+
+	if writeErr := writeJSON(g.resultsFile, r); writeErr != nil && err == nil {
+		return fmt.Errorf("write results file: %w", writeErr)
+	}
+	return err
+}
+
+// writeJSON writes v to path as indented JSON
+func writeJSON(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0600)
+}
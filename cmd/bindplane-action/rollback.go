@@ -0,0 +1,35 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func newRollbackCommand(g *globalFlags) *cobra.Command {
+	wait := &waitFlags{}
+	var version int
+
+	cmd := &cobra.Command{
+		Use:   "rollback <configuration>",
+		Short: "Restore a previous version of a configuration and start its rollout",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			opts, err := wait.options()
+			if err != nil {
+				return err
+			}
+
+			a, err := g.newAction(false, opts...)
+			if err != nil {
+				return err
+			}
+
+			err = a.Rollback(args[0], version)
+			g.setOutputs(a)
+			return err
+		},
+	}
+
+	cmd.Flags().IntVar(&version, "version", 0, "Configuration version to roll back to, defaults to the version before the current version")
+	wait.register(cmd.Flags())
+	return cmd
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func newRolloutCommand(g *globalFlags) *cobra.Command {
+	wait := &waitFlags{}
+	var version int
+
+	cmd := &cobra.Command{
+		Use:   "rollout <configuration>",
+		Short: "Start or progress the rollout of a configuration",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			opts, err := wait.options()
+			if err != nil {
+				return err
+			}
+
+			a, err := g.newAction(false, opts...)
+			if err != nil {
+				return err
+			}
+
+			config := args[0]
+			if version > 0 {
+				config = fmt.Sprintf("%s:%d", config, version)
+			}
+
+			err = a.RunRollout(config)
+			g.setOutputs(a)
+			return err
+		},
+	}
+
+	cmd.Flags().IntVar(&version, "version", 0, "Configuration version to roll out, defaults to the latest pending version")
+	wait.register(cmd.Flags())
+	return cmd
+}
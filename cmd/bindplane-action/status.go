@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/observiq/bindplane-op-action/action"
+	"github.com/spf13/cobra"
+)
+
+func newStatusCommand(g *globalFlags) *cobra.Command {
+	var jsonOutput bool
+
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "List pending, in progress, and errored rollouts",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			a, err := g.newAction(false)
+			if err != nil {
+				return err
+			}
+
+			rollouts, err := a.ActiveRollouts()
+			if err != nil {
+				return fmt.Errorf("list rollouts: %w", err)
+			}
+			g.outputs = map[string]any{action.OutputRollouts: rollouts}
+
+			if jsonOutput {
+				return json.NewEncoder(cmd.OutOrStdout()).Encode(rollouts)
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "NAME\tSTATUS\tVERSION\tCOMPLETED\tERRORS\tPENDING\tWAITING")
+			for _, r := range rollouts {
+				fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%d\t%d\n", r.Name, r.Status, r.Version, r.Completed, r.Errors, r.Pending, r.Waiting)
+			}
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Write rollouts as JSON")
+	return cmd
+}
@@ -0,0 +1,63 @@
+// Package buildinfo reports the version of the action, which
+// identifies the action in BindPlane API requests.
+package buildinfo
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// Name identifies the action in the User-Agent header
+const Name = "bindplane-op-action"
+
+// version and commit are set at build time with
+// -ldflags "-X github.com/observiq/bindplane-op-action/internal/buildinfo.version=v1.2.3"
+var (
+	version = ""
+	commit  = ""
+)
+
+// readBuildInfo is replaced by tests
+var readBuildInfo = debug.ReadBuildInfo
+
+// Version returns the version set at build time. When not set, the module
+// version is used, such as when installed with go install, otherwise Release.
+func Version() string {
+	if version != "" {
+		return version
+	}
+	if info, ok := readBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return Release
+}
+
+// Commit returns the commit set at build time. When not set, the VCS
+// revision stamped by go build is used, otherwise it is empty, such as
+// when GitHub builds the action.
+func Commit() string {
+	if commit != "" {
+		return commit
+	}
+	if info, ok := readBuildInfo(); ok {
+		for _, s := range info.Settings {
+			if s.Key == "vcs.revision" {
+				return s.Value
+			}
+		}
+	}
+	return ""
+}
+
+// String returns the version and commit, such as v1.2.3 (abc123)
+func String() string {
+	if c := Commit(); c != "" {
+		return fmt.Sprintf("%s (%s)", Version(), c)
+	}
+	return Version()
+}
+
+// UserAgent returns the User-Agent header value, such as bindplane-op-action/v1.2.3
+func UserAgent() string {
+	return Name + "/" + Version()
+}
@@ -0,0 +1,79 @@
+package buildinfo
+
+import (
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersion(t *testing.T) {
+	defer func() {
+		version = ""
+		readBuildInfo = debug.ReadBuildInfo
+	}()
+
+	cases := []struct {
+		name    string
+		version string
+		module  string
+		expect  string
+	}{
+		{"build time", "v1.2.3", "v1.0.0", "v1.2.3"},
+		{"go install", "", "v1.0.0", "v1.0.0"},
+		{"source build", "", "(devel)", Release},
+		{"no module version", "", "", Release},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			version = tc.version
+			readBuildInfo = func() (*debug.BuildInfo, bool) {
+				return &debug.BuildInfo{Main: debug.Module{Version: tc.module}}, true
+			}
+			require.Equal(t, tc.expect, Version())
+			require.Equal(t, "bindplane-op-action/"+tc.expect, UserAgent())
+		})
+	}
+}
+
+func TestCommit(t *testing.T) {
+	defer func() {
+		commit = ""
+		readBuildInfo = debug.ReadBuildInfo
+	}()
+
+	cases := []struct {
+		name     string
+		commit   string
+		revision string
+		expect   string
+	}{
+		{"build time", "abc123", "def456", "abc123"},
+		{"vcs revision", "", "def456", "def456"},
+		{"unknown", "", "", ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			commit = tc.commit
+			readBuildInfo = func() (*debug.BuildInfo, bool) {
+				info := &debug.BuildInfo{}
+				if tc.revision != "" {
+					info.Settings = []debug.BuildSetting{{Key: "vcs.revision", Value: tc.revision}}
+				}
+				return info, true
+			}
+			require.Equal(t, tc.expect, Commit())
+			if tc.expect != "" {
+				require.Equal(t, Release+" ("+tc.expect+")", String())
+			} else {
+				require.Equal(t, Release, String())
+			}
+		})
+	}
+}
+
+func TestRelease(t *testing.T) {
+	require.Regexp(t, `^v\d+\.\d+\.\d+$`, Release, "Release must be a semantic version, such as v1.2.3")
+}
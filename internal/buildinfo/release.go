@@ -0,0 +1,7 @@
+package buildinfo
+
+// Release is the version of the action, updated in the commit which is
+// tagged for each release. GitHub builds the action from the Dockerfile
+// without build arguments, and without the repository's .git directory,
+// so the version the action reports comes from the source.
+const Release = "v1.0.0"
@@ -0,0 +1,152 @@
+// Package ci detects the CI system the action or CLI is running in,
+// and reads the run details each system sets in the environment.
+package ci
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// CI providers
+const (
+	ProviderGitHub  = "github"
+	ProviderGitLab  = "gitlab"
+	ProviderJenkins = "jenkins"
+
+	// ProviderShell is any environment which is not a known CI system,
+	// such as a developer's shell
+	ProviderShell = "shell"
+)
+
+// Environment describes the CI run. Fields the provider
+// does not set are empty.
+type Environment struct {
+	Provider   string `json:"provider"`
+	Repository string `json:"repository,omitempty"`
+	Branch     string `json:"branch,omitempty"`
+	Commit     string `json:"commit,omitempty"`
+	RunURL     string `json:"run_url,omitempty"`
+}
+
+// Detect returns the CI environment, based on the
+// variables each CI system sets
+func Detect() Environment {
+	switch {
+	case os.Getenv("GITHUB_ACTIONS") == "true":
+		return github()
+	case os.Getenv("GITLAB_CI") == "true":
+		return gitlab()
+	case os.Getenv("JENKINS_URL") != "":
+		return jenkins()
+	default:
+		return Environment{Provider: ProviderShell}
+	}
+}
+
+// GitHub returns true if running in a GitHub Actions runner, where
+// workflow commands and step outputs are supported
+func (e Environment) GitHub() bool {
+	return e.Provider == ProviderGitHub
+}
+
+// DiffBase returns the commit the changes of the run are compared to.
+// For pull and merge requests it is the base of the request, and for
+// pushes it is the commit before the push. An empty string is returned
+// when there is no base, such as the first push of a branch.
+func (e Environment) DiffBase() string {
+	var base string
+	switch e.Provider {
+	case ProviderGitHub:
+		base = githubDiffBase()
+	case ProviderGitLab:
+		base = os.Getenv("CI_MERGE_REQUEST_DIFF_BASE_SHA")
+		if base == "" {
+			base = os.Getenv("CI_COMMIT_BEFORE_SHA")
+		}
+	case ProviderJenkins:
+		base = os.Getenv("GIT_PREVIOUS_SUCCESSFUL_COMMIT")
+	}
+
+	// New branches are pushed with a zero before commit
+	if strings.Trim(base, "0") == "" {
+		return ""
+	}
+	return base
+}
+
+// githubDiffBase reads the diff base from the event which triggered
+// the workflow.
+// https://docs.github.com/en/webhooks/webhook-events-and-payloads
+func githubDiffBase() string {
+	data, err := os.ReadFile(os.Getenv("GITHUB_EVENT_PATH"))
+	if err != nil {
+		return ""
+	}
+
+	event := struct {
+		Before      string `json:"before"`
+		PullRequest struct {
+			Base struct {
+				SHA string `json:"sha"`
+			} `json:"base"`
+		} `json:"pull_request"`
+	}{}
+	if err := json.Unmarshal(data, &event); err != nil {
+		return ""
+	}
+
+	if event.PullRequest.Base.SHA != "" {
+		return event.PullRequest.Base.SHA
+	}
+	return event.Before
+}
+
+// https://docs.github.com/en/actions/learn-github-actions/variables#default-environment-variables
+func github() Environment {
+	e := Environment{
+		Provider:   ProviderGitHub,
+		Repository: os.Getenv("GITHUB_REPOSITORY"),
+		Branch:     os.Getenv("GITHUB_REF_NAME"),
+		Commit:     os.Getenv("GITHUB_SHA"),
+	}
+
+	server, id := os.Getenv("GITHUB_SERVER_URL"), os.Getenv("GITHUB_RUN_ID")
+	if server != "" && e.Repository != "" && id != "" {
+		e.RunURL = fmt.Sprintf("%s/%s/actions/runs/%s", strings.TrimSuffix(server, "/"), e.Repository, id)
+	}
+	return e
+}
+
+// https://docs.gitlab.com/ee/ci/variables/predefined_variables.html
+func gitlab() Environment {
+	return Environment{
+		Provider:   ProviderGitLab,
+		Repository: os.Getenv("CI_PROJECT_PATH"),
+		Branch:     os.Getenv("CI_COMMIT_REF_NAME"),
+		Commit:     os.Getenv("CI_COMMIT_SHA"),
+		RunURL:     os.Getenv("CI_PIPELINE_URL"),
+	}
+}
+
+// https://www.jenkins.io/doc/book/pipeline/jenkinsfile/#using-environment-variables
+func jenkins() Environment {
+	// Multibranch pipelines set BRANCH_NAME, the git
+	// plugin sets GIT_BRANCH prefixed with the remote
+	branch := os.Getenv("BRANCH_NAME")
+	if branch == "" {
+		branch = os.Getenv("GIT_BRANCH")
+		if _, b, ok := strings.Cut(branch, "/"); ok {
+			branch = b
+		}
+	}
+
+	return Environment{
+		Provider:   ProviderJenkins,
+		Repository: os.Getenv("JOB_NAME"),
+		Branch:     branch,
+		Commit:     os.Getenv("GIT_COMMIT"),
+		RunURL:     os.Getenv("BUILD_URL"),
+	}
+}
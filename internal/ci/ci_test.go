@@ -0,0 +1,199 @@
+package ci
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// ciVariables are unset before each test case, so the
+// environment running the tests is not detected
+var ciVariables = []string{
+	"GITHUB_ACTIONS", "GITHUB_REPOSITORY", "GITHUB_REF_NAME", "GITHUB_SHA", "GITHUB_SERVER_URL", "GITHUB_RUN_ID",
+	"GITLAB_CI", "CI_PROJECT_PATH", "CI_COMMIT_REF_NAME", "CI_COMMIT_SHA", "CI_PIPELINE_URL",
+	"JENKINS_URL", "JOB_NAME", "BRANCH_NAME", "GIT_BRANCH", "GIT_COMMIT", "BUILD_URL",
+}
+
+// diffBaseVariables are unset before each diff base test case
+var diffBaseVariables = []string{
+	"GITHUB_EVENT_PATH", "CI_MERGE_REQUEST_DIFF_BASE_SHA", "CI_COMMIT_BEFORE_SHA", "GIT_PREVIOUS_SUCCESSFUL_COMMIT",
+}
+
+func TestDetect(t *testing.T) {
+	cases := []struct {
+		name   string
+		env    map[string]string
+		expect Environment
+	}{
+		{
+			"Shell",
+			nil,
+			Environment{Provider: ProviderShell},
+		},
+		{
+			"GitHub",
+			map[string]string{
+				"GITHUB_ACTIONS":    "true",
+				"GITHUB_REPOSITORY": "org/repo",
+				"GITHUB_REF_NAME":   "main",
+				"GITHUB_SHA":        "0a1b2c3d",
+				"GITHUB_SERVER_URL": "https://github.com/",
+				"GITHUB_RUN_ID":     "42",
+			},
+			Environment{
+				Provider:   ProviderGitHub,
+				Repository: "org/repo",
+				Branch:     "main",
+				Commit:     "0a1b2c3d",
+				RunURL:     "https://github.com/org/repo/actions/runs/42",
+			},
+		},
+		{
+			"GitHub without run",
+			map[string]string{"GITHUB_ACTIONS": "true", "GITHUB_REPOSITORY": "org/repo"},
+			Environment{Provider: ProviderGitHub, Repository: "org/repo"},
+		},
+		{
+			"GitLab",
+			map[string]string{
+				"GITLAB_CI":          "true",
+				"CI_PROJECT_PATH":    "group/project",
+				"CI_COMMIT_REF_NAME": "main",
+				"CI_COMMIT_SHA":      "0a1b2c3d",
+				"CI_PIPELINE_URL":    "https://gitlab.com/group/project/-/pipelines/7",
+			},
+			Environment{
+				Provider:   ProviderGitLab,
+				Repository: "group/project",
+				Branch:     "main",
+				Commit:     "0a1b2c3d",
+				RunURL:     "https://gitlab.com/group/project/-/pipelines/7",
+			},
+		},
+		{
+			"Jenkins multibranch",
+			map[string]string{
+				"JENKINS_URL": "https://jenkins.example.com/",
+				"JOB_NAME":    "bindplane/main",
+				"BRANCH_NAME": "main",
+				"GIT_BRANCH":  "origin/ignored",
+				"GIT_COMMIT":  "0a1b2c3d",
+				"BUILD_URL":   "https://jenkins.example.com/job/bindplane/job/main/3/",
+			},
+			Environment{
+				Provider:   ProviderJenkins,
+				Repository: "bindplane/main",
+				Branch:     "main",
+				Commit:     "0a1b2c3d",
+				RunURL:     "https://jenkins.example.com/job/bindplane/job/main/3/",
+			},
+		},
+		{
+			"Jenkins git plugin",
+			map[string]string{
+				"JENKINS_URL": "https://jenkins.example.com/",
+				"GIT_BRANCH":  "origin/feature/logs",
+			},
+			Environment{Provider: ProviderJenkins, Branch: "feature/logs"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, name := range ciVariables {
+				t.Setenv(name, "")
+			}
+			for name, value := range tc.env {
+				t.Setenv(name, value)
+			}
+
+			e := Detect()
+			require.Equal(t, tc.expect, e)
+			require.Equal(t, tc.expect.Provider == ProviderGitHub, e.GitHub())
+		})
+	}
+}
+
+func TestDiffBase(t *testing.T) {
+	dir := t.TempDir()
+	writeEvent := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	cases := []struct {
+		name   string
+		env    map[string]string
+		expect string
+	}{
+		{
+			"GitHub pull request",
+			map[string]string{
+				"GITHUB_ACTIONS":    "true",
+				"GITHUB_EVENT_PATH": writeEvent("pull_request.json", `{"pull_request":{"base":{"sha":"1a2b"},"head":{"sha":"3c4d"}}}`),
+			},
+			"1a2b",
+		},
+		{
+			"GitHub push",
+			map[string]string{
+				"GITHUB_ACTIONS":    "true",
+				"GITHUB_EVENT_PATH": writeEvent("push.json", `{"before":"5e6f","after":"7a8b"}`),
+			},
+			"5e6f",
+		},
+		{
+			"GitHub new branch",
+			map[string]string{
+				"GITHUB_ACTIONS":    "true",
+				"GITHUB_EVENT_PATH": writeEvent("branch.json", `{"before":"0000000000000000000000000000000000000000"}`),
+			},
+			"",
+		},
+		{
+			"GitHub without event",
+			map[string]string{"GITHUB_ACTIONS": "true"},
+			"",
+		},
+		{
+			"GitLab merge request",
+			map[string]string{
+				"GITLAB_CI":                      "true",
+				"CI_MERGE_REQUEST_DIFF_BASE_SHA": "1a2b",
+				"CI_COMMIT_BEFORE_SHA":           "5e6f",
+			},
+			"1a2b",
+		},
+		{
+			"GitLab push",
+			map[string]string{"GITLAB_CI": "true", "CI_COMMIT_BEFORE_SHA": "5e6f"},
+			"5e6f",
+		},
+		{
+			"Jenkins",
+			map[string]string{"JENKINS_URL": "https://jenkins.example.com/", "GIT_PREVIOUS_SUCCESSFUL_COMMIT": "5e6f"},
+			"5e6f",
+		},
+		{
+			"Shell",
+			nil,
+			"",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, name := range append(ciVariables, diffBaseVariables...) {
+				t.Setenv(name, "")
+			}
+			for name, value := range tc.env {
+				t.Setenv(name, value)
+			}
+
+			require.Equal(t, tc.expect, Detect().DiffBase())
+		})
+	}
+}
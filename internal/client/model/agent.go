@@ -1,5 +0,0 @@
-package model
-
-type AgentSelector struct {
-	MatchLabels `json:"matchLabels" yaml:"matchLabels" mapstructure:"matchLabels"`
-}
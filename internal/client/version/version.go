@@ -1,6 +0,0 @@
-package version
-
-type Version struct {
-	Commit string `json:"commit"`
-	Tag    string `json:"tag"`
-}
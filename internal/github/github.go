@@ -0,0 +1,183 @@
+// Package github is a minimal GitHub REST API client for deployments
+// and commit statuses.
+// https://docs.github.com/en/rest/deployments
+// https://docs.github.com/en/rest/commits/statuses
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultAPIURL is used when GITHUB_API_URL is not set
+const DefaultAPIURL = "https://api.github.com"
+
+// requestTimeout is the timeout for a GitHub API request
+const requestTimeout = 30 * time.Second
+
+// Deployment status states
+const (
+	StateInProgress = "in_progress"
+	StateSuccess    = "success"
+	StateFailure    = "failure"
+)
+
+// Commit status states
+const (
+	StatusPending = "pending"
+	StatusSuccess = "success"
+	StatusFailure = "failure"
+)
+
+// Client creates deployments, deployment statuses, and
+// commit statuses for a repository
+type Client struct {
+	apiURL     string
+	repository string
+	token      string
+	client     *http.Client
+}
+
+// New returns a client for the repository, in owner/name form
+func New(apiURL, repository, token string) *Client {
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+	return &Client{
+		apiURL:     strings.TrimSuffix(apiURL, "/"),
+		repository: repository,
+		token:      token,
+		client:     &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// NewFromEnv returns a client for the repository the workflow is running in
+func NewFromEnv(token string) *Client {
+	return New(os.Getenv("GITHUB_API_URL"), os.Getenv("GITHUB_REPOSITORY"), token)
+}
+
+// Deployment is a request to create a deployment
+type Deployment struct {
+	Ref         string `json:"ref"`
+	Environment string `json:"environment"`
+	Description string `json:"description,omitempty"`
+
+	// AutoMerge and RequiredContexts are set so the deployment is created
+	// for the ref as is, without merging the default branch or waiting on
+	// commit statuses.
+	AutoMerge        bool     `json:"auto_merge"`
+	RequiredContexts []string `json:"required_contexts"`
+}
+
+// DeploymentStatus is a request to create a deployment status
+type DeploymentStatus struct {
+	State          string `json:"state"`
+	LogURL         string `json:"log_url,omitempty"`
+	EnvironmentURL string `json:"environment_url,omitempty"`
+	Description    string `json:"description,omitempty"`
+}
+
+// CreateDeployment creates a deployment and returns its ID
+func (c *Client) CreateDeployment(ctx context.Context, d Deployment) (int64, error) {
+	if d.RequiredContexts == nil {
+		d.RequiredContexts = []string{}
+	}
+
+	resp := struct {
+		ID int64 `json:"id"`
+	}{}
+	if err := c.post(ctx, fmt.Sprintf("/repos/%s/deployments", c.repository), d, &resp); err != nil {
+		return 0, fmt.Errorf("create deployment: %w", err)
+	}
+	if resp.ID == 0 {
+		return 0, fmt.Errorf("create deployment: response did not include a deployment id")
+	}
+	return resp.ID, nil
+}
+
+// CreateDeploymentStatus sets the status of a deployment. Long
+// descriptions are truncated.
+func (c *Client) CreateDeploymentStatus(ctx context.Context, id int64, s DeploymentStatus) error {
+	s.Description = truncate(s.Description)
+	if err := c.post(ctx, fmt.Sprintf("/repos/%s/deployments/%d/statuses", c.repository, id), s, nil); err != nil {
+		return fmt.Errorf("create deployment status: %w", err)
+	}
+	return nil
+}
+
+// CommitStatus is a request to create a commit status
+type CommitStatus struct {
+	State       string `json:"state"`
+	TargetURL   string `json:"target_url,omitempty"`
+	Description string `json:"description,omitempty"`
+
+	// Context identifies the status, such as bindplane/prod-gateway.
+	// Branch protection rules require statuses by context.
+	Context string `json:"context"`
+}
+
+// CreateCommitStatus sets a status on a commit. Long descriptions
+// are truncated.
+func (c *Client) CreateCommitStatus(ctx context.Context, sha string, s CommitStatus) error {
+	s.Description = truncate(s.Description)
+	if err := c.post(ctx, fmt.Sprintf("/repos/%s/statuses/%s", c.repository, sha), s, nil); err != nil {
+		return fmt.Errorf("create commit status: %w", err)
+	}
+	return nil
+}
+
+// maxDescription is the maximum length of a status description
+const maxDescription = 140
+
+// truncate shortens a status description to the maximum length
+func truncate(description string) string {
+	if len(description) <= maxDescription {
+		return description
+	}
+	return description[:maxDescription-3] + "..."
+}
+
+// post sends body as JSON and decodes the response into result, if set
+func (c *Client) post(ctx context.Context, endpoint string, body, result any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.apiURL+endpoint, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("GitHub API returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	if result == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
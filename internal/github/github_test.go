@@ -0,0 +1,71 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeployments(t *testing.T) {
+	var deployment map[string]any
+	statuses := []DeploymentStatus{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /repos/org/repo/deployments", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&deployment))
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id": 42}`))
+	})
+	mux.HandleFunc("POST /repos/org/repo/deployments/{id}/statuses", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "42", r.PathValue("id"))
+		s := DeploymentStatus{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&s))
+		statuses = append(statuses, s)
+		w.WriteHeader(http.StatusCreated)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c := New(server.URL+"/", "org/repo", "token")
+
+	id, err := c.CreateDeployment(context.Background(), Deployment{Ref: "abc123", Environment: "production"})
+	require.NoError(t, err)
+	require.Equal(t, int64(42), id)
+	require.Equal(t, map[string]any{
+		"ref":               "abc123",
+		"environment":       "production",
+		"auto_merge":        false,
+		"required_contexts": []any{},
+	}, deployment)
+
+	require.NoError(t, c.CreateDeploymentStatus(context.Background(), id, DeploymentStatus{State: StateSuccess, LogURL: "https://github.com/org/repo/actions/runs/1"}))
+	require.Equal(t, []DeploymentStatus{{State: StateSuccess, LogURL: "https://github.com/org/repo/actions/runs/1"}}, statuses)
+
+	c = New(server.URL, "org/missing", "token")
+	_, err = c.CreateDeployment(context.Background(), Deployment{Ref: "abc123", Environment: "production"})
+	require.EqualError(t, err, "create deployment: GitHub API returned status 404: 404 page not found")
+}
+
+func TestCreateCommitStatus(t *testing.T) {
+	var status CommitStatus
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /repos/org/repo/statuses/abc123", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&status))
+		w.WriteHeader(http.StatusCreated)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c := New(server.URL, "org/repo", "token")
+	expect := CommitStatus{State: StatusPending, Context: "bindplane/k8s-node", Description: "Rollout started"}
+	require.NoError(t, c.CreateCommitStatus(context.Background(), "abc123", expect))
+	require.Equal(t, expect, status)
+
+	err := c.CreateCommitStatus(context.Background(), "missing", expect)
+	require.EqualError(t, err, "create commit status: GitHub API returned status 404: 404 page not found")
+}
@@ -0,0 +1,103 @@
+// Package logging sets the log level of each component of the action, so
+// one component, such as the BindPlane client, can log at debug without
+// the others.
+package logging
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Components are the names of the loggers of the action
+const (
+	// ComponentClient logs BindPlane API requests
+	ComponentClient = "client"
+
+	// ComponentRollout logs rollout, canary, and agent progress
+	ComponentRollout = "rollout"
+
+	// ComponentLoader logs loading resource files
+	ComponentLoader = "loader"
+)
+
+// Components are the components whose level can be set
+var Components = []string{ComponentClient, ComponentRollout, ComponentLoader}
+
+// ParseLevels parses a comma separated list of component=level pairs,
+// such as client=debug,rollout=warn. Levels are parsed with parseLevel.
+func ParseLevels(s string, parseLevel func(string) (zapcore.Level, error)) (map[string]zapcore.Level, error) {
+	levels := map[string]zapcore.Level{}
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		component, level, ok := strings.Cut(pair, "=")
+		component, level = strings.TrimSpace(component), strings.TrimSpace(level)
+		if !ok || level == "" {
+			return nil, fmt.Errorf("%s must be a component=level pair, such as client=debug", pair)
+		}
+		if !slices.Contains(Components, component) {
+			return nil, fmt.Errorf("unknown component '%s', must be one of %s", component, strings.Join(Components, ", "))
+		}
+
+		lvl, err := parseLevel(level)
+		if err != nil {
+			return nil, fmt.Errorf("component %s: %w", component, err)
+		}
+		levels[component] = lvl
+	}
+	return levels, nil
+}
+
+// MinLevel returns the lowest of level and levels, the level
+// the logger must be enabled at for every component to log
+func MinLevel(level zapcore.Level, levels map[string]zapcore.Level) zapcore.Level {
+	for _, l := range levels {
+		level = min(level, l)
+	}
+	return level
+}
+
+// WithLevels returns an option which filters the entries of each
+// component by its level in levels, and other entries by level. The
+// logger must be enabled at MinLevel.
+func WithLevels(level zapcore.Level, levels map[string]zapcore.Level) zap.Option {
+	return zap.WrapCore(func(c zapcore.Core) zapcore.Core {
+		if len(levels) == 0 {
+			return c
+		}
+		return &core{Core: c, level: level, levels: levels}
+	})
+}
+
+// core filters entries by the level of the component which logged them.
+// The component is the first segment of the logger name.
+type core struct {
+	zapcore.Core
+	level  zapcore.Level
+	levels map[string]zapcore.Level
+}
+
+// With adds fields to the core, keeping the component levels
+func (c *core) With(fields []zapcore.Field) zapcore.Core {
+	return &core{Core: c.Core.With(fields), level: c.level, levels: c.levels}
+}
+
+// Check drops entries below the level of their component
+func (c *core) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	component, _, _ := strings.Cut(ent.LoggerName, ".")
+	level, ok := c.levels[component]
+	if !ok {
+		level = c.level
+	}
+	if ent.Level < level {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
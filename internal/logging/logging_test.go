@@ -0,0 +1,58 @@
+package logging
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestParseLevels(t *testing.T) {
+	cases := []struct {
+		name   string
+		input  string
+		expect map[string]zapcore.Level
+		errStr string
+	}{
+		{"empty", "", map[string]zapcore.Level{}, ""},
+		{"levels", " client=debug, rollout = warn ,", map[string]zapcore.Level{ComponentClient: zapcore.DebugLevel, ComponentRollout: zapcore.WarnLevel}, ""},
+		{"missing level", "client", nil, "client must be a component=level pair, such as client=debug"},
+		{"unknown component", "http=debug", nil, "unknown component 'http', must be one of client, rollout, loader"},
+		{"invalid level", "loader=verbose", nil, `component loader: unrecognized level: "verbose"`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			levels, err := ParseLevels(tc.input, zapcore.ParseLevel)
+			if tc.errStr != "" {
+				require.EqualError(t, err, tc.errStr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, levels)
+		})
+	}
+}
+
+func TestWithLevels(t *testing.T) {
+	levels := map[string]zapcore.Level{ComponentClient: zapcore.DebugLevel, ComponentRollout: zapcore.WarnLevel}
+	require.Equal(t, zapcore.DebugLevel, MinLevel(zapcore.InfoLevel, levels))
+
+	core, logs := observer.New(MinLevel(zapcore.InfoLevel, levels))
+	logger := zap.New(core, WithLevels(zapcore.InfoLevel, levels))
+
+	logger.Debug("action debug")
+	logger.Info("action info")
+	logger.Named(ComponentClient).Debug("client debug")
+	logger.Named(ComponentClient).Named("trace").With(zap.String("k", "v")).Debug("client trace")
+	logger.Named(ComponentRollout).Info("rollout info")
+	logger.Named(ComponentRollout).Warn("rollout warn")
+
+	messages := []string{}
+	for _, e := range logs.All() {
+		messages = append(messages, e.Message)
+	}
+	require.Equal(t, []string{"action info", "client debug", "client trace", "rollout warn"}, messages)
+}
@@ -0,0 +1,159 @@
+// Package oidc exchanges the GitHub Actions OIDC token for BindPlane
+// credentials with a token broker, such as Vault JWT auth, so workflows
+// do not need long lived API keys stored as secrets.
+// https://docs.github.com/en/actions/security-for-github-actions/security-hardening-your-deployments/about-security-hardening-with-openid-connect
+package oidc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultCredentialField is the field of the broker
+// response which contains the credential
+const DefaultCredentialField = "api_key"
+
+// requestTimeout is the timeout for a token request
+const requestTimeout = 30 * time.Second
+
+// ErrUnavailable is returned when the runner does not provide an
+// OIDC token, usually because the workflow does not have the
+// id-token write permission
+var ErrUnavailable = errors.New("OIDC token is not available, ACTIONS_ID_TOKEN_REQUEST_URL and ACTIONS_ID_TOKEN_REQUEST_TOKEN are not set")
+
+// GitHubToken requests an OIDC token for the workflow run from the
+// GitHub Actions runner. When audience is empty, the GitHub default
+// audience, the repository owner's URL, is used.
+func GitHubToken(ctx context.Context, audience string) (string, error) {
+	requestURL, requestToken := os.Getenv("ACTIONS_ID_TOKEN_REQUEST_URL"), os.Getenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN")
+	if requestURL == "" || requestToken == "" {
+		return "", ErrUnavailable
+	}
+
+	u, err := url.Parse(requestURL)
+	if err != nil {
+		return "", fmt.Errorf("parse ACTIONS_ID_TOKEN_REQUEST_URL: %w", err)
+	}
+	if audience != "" {
+		q := u.Query()
+		q.Set("audience", audience)
+		u.RawQuery = q.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+requestToken)
+
+	resp := struct {
+		Value string `json:"value"`
+	}{}
+	if err := do(req, &resp); err != nil {
+		return "", fmt.Errorf("request OIDC token: %w", err)
+	}
+	if resp.Value == "" {
+		return "", fmt.Errorf("request OIDC token: response did not include a token")
+	}
+	return resp.Value, nil
+}
+
+// Broker exchanges an OIDC token for a credential. The token and role
+// are sent as a JSON object with jwt and role fields, which is the
+// request format of Vault JWT auth.
+type Broker struct {
+	url   string
+	role  string
+	field string
+}
+
+// NewBroker returns a broker which posts to url, requesting role. field
+// is the dot separated path of the credential in the response, such as
+// auth.client_token. When empty, DefaultCredentialField is used.
+func NewBroker(url, role, field string) *Broker {
+	if field == "" {
+		field = DefaultCredentialField
+	}
+	return &Broker{url: url, role: role, field: field}
+}
+
+// Exchange sends the OIDC token to the broker and returns the credential
+func (b *Broker) Exchange(ctx context.Context, token string) (string, error) {
+	body := struct {
+		JWT  string `json:"jwt"`
+		Role string `json:"role,omitempty"`
+	}{JWT: token, Role: b.role}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return "", fmt.Errorf("marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.url, bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp := map[string]any{}
+	if err := do(req, &resp); err != nil {
+		return "", fmt.Errorf("exchange OIDC token: %w", err)
+	}
+
+	credential, err := lookup(resp, b.field)
+	if err != nil {
+		return "", fmt.Errorf("exchange OIDC token: %w", err)
+	}
+	return credential, nil
+}
+
+// lookup returns the string at the dot separated path in v
+func lookup(v map[string]any, path string) (string, error) {
+	var value any = v
+	for _, key := range strings.Split(path, ".") {
+		m, ok := value.(map[string]any)
+		if !ok {
+			return "", fmt.Errorf("response field %s not found", path)
+		}
+		if value, ok = m[key]; !ok {
+			return "", fmt.Errorf("response field %s not found", path)
+		}
+	}
+
+	s, ok := value.(string)
+	if !ok || s == "" {
+		return "", fmt.Errorf("response field %s is not a string", path)
+	}
+	return s, nil
+}
+
+// do sends req and decodes the JSON response into result
+func do(req *http.Request, result any) error {
+	req.Header.Set("Accept", "application/json")
+
+	client := &http.Client{Timeout: requestTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitHubToken(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /token", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer request-token", r.Header.Get("Authorization"))
+		require.Equal(t, "1", r.URL.Query().Get("api-version"))
+		_, _ = w.Write([]byte(`{"value": "jwt-` + r.URL.Query().Get("audience") + `"}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	t.Setenv("ACTIONS_ID_TOKEN_REQUEST_URL", "")
+	t.Setenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN", "")
+	_, err := GitHubToken(context.Background(), "")
+	require.ErrorIs(t, err, ErrUnavailable)
+
+	t.Setenv("ACTIONS_ID_TOKEN_REQUEST_URL", server.URL+"/token?api-version=1")
+	t.Setenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN", "request-token")
+
+	token, err := GitHubToken(context.Background(), "bindplane")
+	require.NoError(t, err)
+	require.Equal(t, "jwt-bindplane", token)
+
+	// The GitHub default audience is used
+	token, err = GitHubToken(context.Background(), "")
+	require.NoError(t, err)
+	require.Equal(t, "jwt-", token)
+}
+
+func TestExchange(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/auth/jwt/login", func(w http.ResponseWriter, r *http.Request) {
+		body := map[string]string{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		if body["jwt"] != "jwt" || body["role"] != "bindplane-deploy" {
+			http.Error(w, `{"errors":["permission denied"]}`, http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"auth": {"client_token": "key", "lease_duration": 300}}`))
+	})
+	mux.HandleFunc("POST /exchange", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"api_key": "key"}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	cases := []struct {
+		name   string
+		url    string
+		role   string
+		field  string
+		errStr string
+	}{
+		{
+			"Default field",
+			server.URL + "/exchange",
+			"",
+			"",
+			"",
+		},
+		{
+			"Vault",
+			server.URL + "/v1/auth/jwt/login",
+			"bindplane-deploy",
+			"auth.client_token",
+			"",
+		},
+		{
+			"Denied",
+			server.URL + "/v1/auth/jwt/login",
+			"other",
+			"auth.client_token",
+			`exchange OIDC token: returned status 400: {"errors":["permission denied"]}`,
+		},
+		{
+			"Missing field",
+			server.URL + "/v1/auth/jwt/login",
+			"bindplane-deploy",
+			"auth.token",
+			"exchange OIDC token: response field auth.token not found",
+		},
+		{
+			"Not a string",
+			server.URL + "/v1/auth/jwt/login",
+			"bindplane-deploy",
+			"auth.lease_duration",
+			"exchange OIDC token: response field auth.lease_duration is not a string",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			key, err := NewBroker(tc.url, tc.role, tc.field).Exchange(context.Background(), "jwt")
+			if tc.errStr != "" {
+				require.EqualError(t, err, tc.errStr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "key", key)
+		})
+	}
+}
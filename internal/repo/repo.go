@@ -4,10 +4,12 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
 )
 
 // CloneRepo clones a repository from the provided URL and branch. If the cloneURL
@@ -48,3 +50,75 @@ func CloneRepo(cloneURL, branch, token string) (*git.Repository, error) {
 
 	return repo, nil
 }
+
+// ChangedFiles returns the files added, modified, renamed, or deleted
+// between the base and head revisions of the repository containing dir.
+// Paths are relative to dir, so they can be compared with resource paths.
+// The base commit must have been fetched, so shallow clones need a fetch
+// depth which includes it.
+func ChangedFiles(dir, base, head string) ([]string, error) {
+	r, err := git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, fmt.Errorf("open repository: %w", err)
+	}
+
+	baseTree, err := revisionTree(r, base)
+	if err != nil {
+		return nil, err
+	}
+	headTree, err := revisionTree(r, head)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := object.DiffTree(baseTree, headTree)
+	if err != nil {
+		return nil, fmt.Errorf("diff %s and %s: %w", base, head, err)
+	}
+
+	w, err := r.Worktree()
+	if err != nil {
+		return nil, fmt.Errorf("get worktree: %w", err)
+	}
+	root := w.Filesystem.Root()
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("resolve %s: %w", dir, err)
+	}
+
+	files := []string{}
+	seen := map[string]struct{}{}
+	for _, c := range changes {
+		for _, name := range []string{c.From.Name, c.To.Name} {
+			if name == "" {
+				continue
+			}
+			file, err := filepath.Rel(abs, filepath.Join(root, filepath.FromSlash(name)))
+			if err != nil {
+				return nil, fmt.Errorf("resolve %s: %w", name, err)
+			}
+			if _, ok := seen[file]; !ok {
+				seen[file] = struct{}{}
+				files = append(files, file)
+			}
+		}
+	}
+	return files, nil
+}
+
+// revisionTree returns the tree of the commit rev resolves to
+func revisionTree(r *git.Repository, rev string) (*object.Tree, error) {
+	hash, err := r.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return nil, fmt.Errorf("resolve revision %s: %w", rev, err)
+	}
+	commit, err := r.CommitObject(*hash)
+	if err != nil {
+		return nil, fmt.Errorf("get commit %s: %w", rev, err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("get tree of commit %s: %w", rev, err)
+	}
+	return tree, nil
+}
@@ -0,0 +1,72 @@
+package repo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	r, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+	w, err := r.Worktree()
+	require.NoError(t, err)
+
+	commit := func(files map[string]string, removed ...string) string {
+		for name, content := range files {
+			path := filepath.Join(dir, name)
+			require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o750))
+			require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+			_, err := w.Add(name)
+			require.NoError(t, err)
+		}
+		for _, name := range removed {
+			_, err := w.Remove(name)
+			require.NoError(t, err)
+		}
+		hash, err := w.Commit("update", &git.CommitOptions{
+			Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+		})
+		require.NoError(t, err)
+		return hash.String()
+	}
+
+	base := commit(map[string]string{
+		"bindplane/configurations/gateway.yaml": "gateway",
+		"bindplane/configurations/edge.yaml":    "edge",
+		"bindplane/destinations.yaml":           "otlp",
+		"README.md":                             "readme",
+	})
+	head := commit(map[string]string{
+		"bindplane/configurations/gateway.yaml": "gateway v2",
+		"bindplane/configurations/node.yaml":    "node",
+		"README.md":                             "readme v2",
+	}, "bindplane/destinations.yaml")
+
+	files, err := ChangedFiles(dir, base, head)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{
+		"README.md",
+		filepath.Join("bindplane", "configurations", "gateway.yaml"),
+		filepath.Join("bindplane", "configurations", "node.yaml"),
+		filepath.Join("bindplane", "destinations.yaml"),
+	}, files)
+
+	files, err = ChangedFiles(filepath.Join(dir, "bindplane"), base, "HEAD")
+	require.NoError(t, err)
+	require.Contains(t, files, filepath.Join("configurations", "gateway.yaml"))
+	require.Contains(t, files, filepath.Join("..", "README.md"))
+
+	files, err = ChangedFiles(dir, head, head)
+	require.NoError(t, err)
+	require.Empty(t, files)
+
+	_, err = ChangedFiles(dir, "0a1b2c3d4e5f", head)
+	require.ErrorContains(t, err, "resolve revision 0a1b2c3d4e5f")
+}
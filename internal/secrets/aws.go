@@ -0,0 +1,78 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/observiq/bindplane-op-action/internal/oidc"
+)
+
+// awsAudience is the OIDC token audience expected by AWS STS
+const awsAudience = "sts.amazonaws.com"
+
+// awsSessionName identifies the action in CloudTrail
+// when a role is assumed
+const awsSessionName = "bindplane-op-action"
+
+// fetchAWS reads a secret from AWS Secrets Manager. When a role is set, it
+// is assumed with the GitHub Actions OIDC token, otherwise the default
+// credential chain is used. The region is read from the secret ARN, when
+// the path is an ARN, or from the environment.
+// https://docs.aws.amazon.com/secretsmanager/latest/apireference/API_GetSecretValue.html
+func fetchAWS(ctx context.Context, c Config) (map[string]any, error) {
+	opts := []func(*config.LoadOptions) error{}
+	if a, err := arn.Parse(c.Path); err == nil && a.Region != "" {
+		opts = append(opts, config.WithRegion(a.Region))
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("load aws config: %w", err)
+	}
+
+	if c.Role != "" {
+		audience := c.Audience
+		if audience == "" {
+			audience = awsAudience
+		}
+
+		provider := stscreds.NewWebIdentityRoleProvider(
+			sts.NewFromConfig(cfg),
+			c.Role,
+			identityToken(func() (string, error) { return oidc.GitHubToken(ctx, audience) }),
+			func(o *stscreds.WebIdentityRoleOptions) { o.RoleSessionName = awsSessionName },
+		)
+		cfg.Credentials = aws.NewCredentialsCache(provider)
+	}
+
+	client := secretsmanager.NewFromConfig(cfg, func(o *secretsmanager.Options) {
+		if c.URL != "" {
+			o.BaseEndpoint = aws.String(c.URL)
+		}
+	})
+
+	out, err := client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{SecretId: aws.String(c.Path)})
+	if err != nil {
+		return nil, err
+	}
+	if out.SecretString == nil || strings.TrimSpace(*out.SecretString) == "" {
+		return nil, fmt.Errorf("secret does not have a string value")
+	}
+	return parseSecretString(*out.SecretString), nil
+}
+
+// identityToken adapts a token func to stscreds.IdentityTokenRetriever
+type identityToken func() (string, error)
+
+// GetIdentityToken returns the OIDC token
+func (f identityToken) GetIdentityToken() ([]byte, error) {
+	token, err := f()
+	return []byte(token), err
+}
@@ -0,0 +1,117 @@
+// Package secrets reads BindPlane credentials from an external secret
+// store, HashiCorp Vault or AWS Secrets Manager, so the credentials do
+// not need to be copied into the secrets of every repository.
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Secret stores
+const (
+	StoreVault = "vault"
+	StoreAWS   = "aws-secrets-manager"
+)
+
+// Fields of a secret which contain credentials
+const (
+	FieldAPIKey   = "api_key"
+	FieldUsername = "username"
+	FieldPassword = "password"
+)
+
+// Config configures the secret store
+type Config struct {
+	// Store is one of StoreVault or StoreAWS
+	Store string
+
+	// URL is the Vault address. For AWS Secrets Manager, it
+	// optionally overrides the endpoint, such as a VPC endpoint.
+	URL string
+
+	// Path is the Vault secret path, such as secret/data/bindplane,
+	// or the AWS secret name or ARN
+	Path string
+
+	// Role is the Vault JWT auth role or the AWS IAM role ARN, which
+	// is assumed with the GitHub Actions OIDC token. When empty, Vault
+	// uses VAULT_TOKEN and AWS uses the default credential chain.
+	Role string
+
+	// Audience is the audience of the OIDC token used to assume Role.
+	// When empty, the GitHub default is used for Vault, and
+	// sts.amazonaws.com for AWS.
+	Audience string
+}
+
+// Credentials are the BindPlane credentials read from a secret. Fields
+// the secret does not contain are empty.
+type Credentials struct {
+	APIKey   string
+	Username string
+	Password string
+}
+
+// Fetch reads the credentials from the secret store
+func Fetch(ctx context.Context, c Config) (*Credentials, error) {
+	var (
+		fields map[string]any
+		err    error
+	)
+
+	switch c.Store {
+	case StoreVault:
+		fields, err = fetchVault(ctx, c)
+	case StoreAWS:
+		fields, err = fetchAWS(ctx, c)
+	default:
+		return nil, fmt.Errorf("unknown secret store %s, must be %s or %s", c.Store, StoreVault, StoreAWS)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read secret %s from %s: %w", c.Path, c.Store, err)
+	}
+
+	creds, err := credentials(fields)
+	if err != nil {
+		return nil, fmt.Errorf("read secret %s from %s: %w", c.Path, c.Store, err)
+	}
+	return creds, nil
+}
+
+// credentials returns the credentials in the secret's fields
+func credentials(fields map[string]any) (*Credentials, error) {
+	creds := &Credentials{}
+	for name, dst := range map[string]*string{
+		FieldAPIKey:   &creds.APIKey,
+		FieldUsername: &creds.Username,
+		FieldPassword: &creds.Password,
+	} {
+		v, ok := fields[name]
+		if !ok {
+			continue
+		}
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("field %s is not a string", name)
+		}
+		*dst = s
+	}
+
+	if creds.APIKey == "" && creds.Username == "" {
+		return nil, fmt.Errorf("secret does not contain an %s or %s field", FieldAPIKey, FieldUsername)
+	}
+	return creds, nil
+}
+
+// parseSecretString parses a secret which is either a JSON object of
+// fields, or a plain string, which is the API key
+func parseSecretString(s string) map[string]any {
+	fields := map[string]any{}
+	if strings.HasPrefix(strings.TrimSpace(s), "{") && json.Unmarshal([]byte(s), &fields) == nil {
+		return fields
+	}
+	return map[string]any{FieldAPIKey: strings.TrimSpace(s)}
+}
@@ -0,0 +1,177 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchVault(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /token", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"value": "jwt"}`))
+	})
+	mux.HandleFunc("POST /v1/auth/jwt/login", func(w http.ResponseWriter, r *http.Request) {
+		body := map[string]string{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		require.Equal(t, map[string]string{"jwt": "jwt", "role": "bindplane"}, body)
+		_, _ = w.Write([]byte(`{"auth": {"client_token": "login-token"}}`))
+	})
+	mux.HandleFunc("GET /v1/secret/data/bindplane", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "login-token" {
+			http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data": {"data": {"api_key": "kv2-key"}, "metadata": {"version": 3}}}`))
+	})
+	mux.HandleFunc("GET /v1/kv/bindplane", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "static-token", r.Header.Get("X-Vault-Token"))
+		require.Equal(t, "ops", r.Header.Get("X-Vault-Namespace"))
+		_, _ = w.Write([]byte(`{"data": {"username": "user", "password": "pass"}}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	t.Setenv("ACTIONS_ID_TOKEN_REQUEST_URL", server.URL+"/token")
+	t.Setenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN", "request-token")
+
+	t.Run("jwt auth kv2", func(t *testing.T) {
+		t.Setenv("VAULT_TOKEN", "")
+		creds, err := Fetch(context.Background(), Config{Store: StoreVault, URL: server.URL + "/", Path: "secret/data/bindplane", Role: "bindplane"})
+		require.NoError(t, err)
+		require.Equal(t, &Credentials{APIKey: "kv2-key"}, creds)
+	})
+
+	t.Run("token kv1", func(t *testing.T) {
+		t.Setenv("VAULT_TOKEN", "static-token")
+		t.Setenv("VAULT_NAMESPACE", "ops")
+		creds, err := Fetch(context.Background(), Config{Store: StoreVault, URL: server.URL, Path: "/kv/bindplane"})
+		require.NoError(t, err)
+		require.Equal(t, &Credentials{Username: "user", Password: "pass"}, creds)
+	})
+
+	t.Run("no token", func(t *testing.T) {
+		t.Setenv("VAULT_TOKEN", "")
+		_, err := Fetch(context.Background(), Config{Store: StoreVault, URL: server.URL, Path: "kv/bindplane"})
+		require.EqualError(t, err, "read secret kv/bindplane from vault: a role is required when VAULT_TOKEN is not set")
+	})
+
+	t.Run("denied", func(t *testing.T) {
+		t.Setenv("VAULT_TOKEN", "other-token")
+		_, err := Fetch(context.Background(), Config{Store: StoreVault, URL: server.URL, Path: "secret/data/bindplane"})
+		require.EqualError(t, err, `read secret secret/data/bindplane from vault: vault returned status 403: {"errors":["permission denied"]}`)
+	})
+}
+
+func TestFetchAWS(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /token", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "sts.amazonaws.com", r.URL.Query().Get("audience"))
+		_, _ = w.Write([]byte(`{"value": "jwt"}`))
+	})
+	mux.HandleFunc("POST /sts/", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		require.Equal(t, "AssumeRoleWithWebIdentity", r.Form.Get("Action"))
+		require.Equal(t, "jwt", r.Form.Get("WebIdentityToken"))
+		require.Equal(t, "arn:aws:iam::123456789012:role/bindplane", r.Form.Get("RoleArn"))
+		w.Header().Set("Content-Type", "text/xml")
+		_, _ = w.Write([]byte(`<AssumeRoleWithWebIdentityResponse><AssumeRoleWithWebIdentityResult><Credentials>
+<AccessKeyId>ASIAROLE</AccessKeyId><SecretAccessKey>secret</SecretAccessKey><SessionToken>session</SessionToken>
+<Expiration>2099-01-01T00:00:00Z</Expiration></Credentials></AssumeRoleWithWebIdentityResult></AssumeRoleWithWebIdentityResponse>`))
+	})
+	mux.HandleFunc("POST /", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "secretsmanager.GetSecretValue", r.Header.Get("X-Amz-Target"))
+		body := map[string]string{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		switch body["SecretId"] {
+		case "bindplane/json":
+			require.Contains(t, r.Header.Get("Authorization"), "Credential=ASIAROLE/")
+			_ = json.NewEncoder(w).Encode(map[string]string{"SecretString": `{"api_key": "json-key"}`})
+		case "arn:aws:secretsmanager:eu-west-1:123456789012:secret:bindplane":
+			require.Contains(t, r.Header.Get("Authorization"), "/eu-west-1/secretsmanager/")
+			_ = json.NewEncoder(w).Encode(map[string]string{"SecretString": "plain-key\n"})
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"__type": "ResourceNotFoundException", "message": "Secrets Manager can't find the specified secret."}`))
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	t.Setenv("ACTIONS_ID_TOKEN_REQUEST_URL", server.URL+"/token")
+	t.Setenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN", "request-token")
+	t.Setenv("AWS_ENDPOINT_URL_STS", server.URL+"/sts/")
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIASTATIC")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_CONFIG_FILE", "missing")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", "missing")
+
+	creds, err := Fetch(context.Background(), Config{Store: StoreAWS, URL: server.URL, Path: "bindplane/json", Role: "arn:aws:iam::123456789012:role/bindplane"})
+	require.NoError(t, err)
+	require.Equal(t, &Credentials{APIKey: "json-key"}, creds)
+
+	// Plain string secrets are the API key, and the region is read from the ARN
+	creds, err = Fetch(context.Background(), Config{Store: StoreAWS, URL: server.URL, Path: "arn:aws:secretsmanager:eu-west-1:123456789012:secret:bindplane"})
+	require.NoError(t, err)
+	require.Equal(t, &Credentials{APIKey: "plain-key"}, creds)
+
+	_, err = Fetch(context.Background(), Config{Store: StoreAWS, URL: server.URL, Path: "missing"})
+	require.ErrorContains(t, err, "read secret missing from aws-secrets-manager: ")
+	require.ErrorContains(t, err, "ResourceNotFoundException")
+}
+
+func TestCredentials(t *testing.T) {
+	cases := []struct {
+		name   string
+		fields map[string]any
+		expect *Credentials
+		errStr string
+	}{
+		{
+			"API key",
+			map[string]any{"api_key": "key", "other": 1},
+			&Credentials{APIKey: "key"},
+			"",
+		},
+		{
+			"Username and password",
+			map[string]any{"username": "user", "password": "pass"},
+			&Credentials{Username: "user", Password: "pass"},
+			"",
+		},
+		{
+			"Missing",
+			map[string]any{"password": "pass"},
+			nil,
+			"secret does not contain an api_key or username field",
+		},
+		{
+			"Not a string",
+			map[string]any{"api_key": 1},
+			nil,
+			"field api_key is not a string",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			creds, err := credentials(tc.fields)
+			if tc.errStr != "" {
+				require.EqualError(t, err, tc.errStr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, creds)
+		})
+	}
+
+	_, err := Fetch(context.Background(), Config{Store: "gcp"})
+	require.EqualError(t, err, "unknown secret store gcp, must be vault or aws-secrets-manager")
+}
@@ -0,0 +1,82 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/observiq/bindplane-op-action/internal/oidc"
+)
+
+// requestTimeout is the timeout for a secret store request
+const requestTimeout = 30 * time.Second
+
+// vaultJWTLoginPath is the login endpoint of the JWT auth method,
+// mounted at its default path
+const vaultJWTLoginPath = "/v1/auth/jwt/login"
+
+// fetchVault reads a KV version 1 or 2 secret. When a role is set, the
+// GitHub Actions OIDC token is exchanged for a Vault token with JWT auth,
+// otherwise VAULT_TOKEN is used.
+// https://developer.hashicorp.com/vault/docs/auth/jwt
+// https://developer.hashicorp.com/vault/api-docs/secret/kv
+func fetchVault(ctx context.Context, c Config) (map[string]any, error) {
+	address := strings.TrimSuffix(c.URL, "/")
+
+	token := os.Getenv("VAULT_TOKEN")
+	if c.Role != "" {
+		jwt, err := oidc.GitHubToken(ctx, c.Audience)
+		if err != nil {
+			return nil, err
+		}
+
+		token, err = oidc.NewBroker(address+vaultJWTLoginPath, c.Role, "auth.client_token").Exchange(ctx, jwt)
+		if err != nil {
+			return nil, fmt.Errorf("vault login: %w", err)
+		}
+	}
+	if token == "" {
+		return nil, fmt.Errorf("a role is required when VAULT_TOKEN is not set")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/v1/%s", address, strings.TrimPrefix(c.Path, "/")), nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if ns := os.Getenv("VAULT_NAMESPACE"); ns != "" {
+		req.Header.Set("X-Vault-Namespace", ns)
+	}
+
+	client := &http.Client{Timeout: requestTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("vault returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	secret := struct {
+		Data map[string]any `json:"data"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&secret); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+
+	// KV version 2 nests the fields under data, alongside metadata
+	if data, ok := secret.Data["data"].(map[string]any); ok {
+		if _, ok := secret.Data["metadata"]; ok {
+			return data, nil
+		}
+	}
+	return secret.Data, nil
+}
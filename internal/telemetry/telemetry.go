@@ -0,0 +1,135 @@
+// Package telemetry exports action traces and metrics to an OTLP/HTTP endpoint
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// ServiceName is the service.name resource attribute of exported telemetry
+const ServiceName = "bindplane-op-action"
+
+// Signal paths are appended to the endpoint, following the
+// OTEL_EXPORTER_OTLP_ENDPOINT convention
+const (
+	tracesPath  = "/v1/traces"
+	metricsPath = "/v1/metrics"
+)
+
+// ShutdownFunc flushes buffered telemetry and stops the exporters
+type ShutdownFunc func(context.Context) error
+
+// Setup registers global tracer and meter providers which export to the
+// OTLP/HTTP endpoint, such as https://otel.example.com:4318. Spans and
+// metrics are sent to the /v1/traces and /v1/metrics paths of the endpoint.
+// Metrics are exported when the returned func is called, so a run reports
+// its totals once. When endpoint is empty, telemetry is disabled and the
+// returned func does nothing.
+func Setup(ctx context.Context, endpoint string, headers map[string]string) (ShutdownFunc, error) {
+	if endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("parse endpoint: %w", err)
+	}
+	base := strings.TrimSuffix(u.Path, "/")
+
+	u.Path = base + tracesPath
+	spanExporter, err := otlptracehttp.New(ctx,
+		otlptracehttp.WithEndpointURL(u.String()),
+		otlptracehttp.WithHeaders(headers),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create trace exporter: %w", err)
+	}
+
+	u.Path = base + metricsPath
+	metricExporter, err := otlpmetrichttp.New(ctx,
+		otlpmetrichttp.WithEndpointURL(u.String()),
+		otlpmetrichttp.WithHeaders(headers),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create metric exporter: %w", err)
+	}
+
+	res := resource.NewSchemaless(attributes()...)
+
+	tracerProvider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(tracerProvider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	// The action exits long before a periodic export interval
+	// elapses, metrics are collected when the reader shuts down
+	meterProvider := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExporter)),
+		sdkmetric.WithResource(res),
+	)
+	otel.SetMeterProvider(meterProvider)
+
+	return func(ctx context.Context) error {
+		return errors.Join(
+			tracerProvider.Shutdown(ctx),
+			meterProvider.Shutdown(ctx),
+		)
+	}, nil
+}
+
+// attributes returns the resource attributes of exported spans, which
+// identify the workflow run
+func attributes() []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		attribute.String("service.name", ServiceName),
+	}
+
+	env := []struct{ name, key string }{
+		{"GITHUB_REPOSITORY", "github.repository"},
+		{"GITHUB_WORKFLOW", "github.workflow"},
+		{"GITHUB_RUN_ID", "github.run_id"},
+		{"GITHUB_SHA", "github.sha"},
+		{"GITHUB_REF", "github.ref"},
+	}
+	for _, e := range env {
+		if v := os.Getenv(e.name); v != "" {
+			attrs = append(attrs, attribute.String(e.key, v))
+		}
+	}
+	return attrs
+}
+
+// ParseHeaders parses a comma separated list of key=value headers,
+// such as api-key=secret,x-tenant=ci
+func ParseHeaders(s string) (map[string]string, error) {
+	headers := map[string]string{}
+	for _, h := range strings.Split(s, ",") {
+		h = strings.TrimSpace(h)
+		if h == "" {
+			continue
+		}
+
+		k, v, ok := strings.Cut(h, "=")
+		k = strings.TrimSpace(k)
+		if !ok || k == "" {
+			return nil, fmt.Errorf("header %q must be in key=value form", h)
+		}
+		headers[k] = strings.TrimSpace(v)
+	}
+	return headers, nil
+}
@@ -0,0 +1,72 @@
+package telemetry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+)
+
+func TestParseHeaders(t *testing.T) {
+	cases := []struct {
+		name      string
+		input     string
+		expect    map[string]string
+		expectErr string
+	}{
+		{"empty", "", map[string]string{}, ""},
+		{"single", "api-key=secret", map[string]string{"api-key": "secret"}, ""},
+		{"multiple", " api-key = secret , x-tenant=ci,", map[string]string{"api-key": "secret", "x-tenant": "ci"}, ""},
+		{"value with equals", "authorization=Basic abc==", map[string]string{"authorization": "Basic abc=="}, ""},
+		{"missing value", "api-key", nil, `header "api-key" must be in key=value form`},
+		{"missing key", "=secret", nil, `header "=secret" must be in key=value form`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			headers, err := ParseHeaders(tc.input)
+			if tc.expectErr != "" {
+				require.EqualError(t, err, tc.expectErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, headers)
+		})
+	}
+}
+
+func TestSetup(t *testing.T) {
+	shutdown, err := Setup(context.Background(), "", nil)
+	require.NoError(t, err)
+	require.NoError(t, shutdown(context.Background()))
+
+	var mu sync.Mutex
+	paths, apiKeys := []string{}, []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		paths = append(paths, r.URL.Path)
+		apiKeys = append(apiKeys, r.Header.Get("api-key"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	shutdown, err = Setup(context.Background(), server.URL+"/otlp/", map[string]string{"api-key": "secret"})
+	require.NoError(t, err)
+
+	_, span := otel.Tracer("test").Start(context.Background(), "test")
+	span.End()
+
+	counter, err := otel.Meter("test").Int64Counter("test")
+	require.NoError(t, err)
+	counter.Add(context.Background(), 1)
+
+	// Shutdown flushes spans and metrics
+	require.NoError(t, shutdown(context.Background()))
+	require.ElementsMatch(t, []string{"/otlp/v1/traces", "/otlp/v1/metrics"}, paths)
+	require.Equal(t, []string{"secret", "secret"}, apiKeys)
+}
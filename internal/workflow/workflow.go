@@ -0,0 +1,135 @@
+// Package workflow writes GitHub Actions workflow commands.
+// https://docs.github.com/en/actions/using-workflows/workflow-commands-for-github-actions
+package workflow
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Output is where workflow commands are written. The runner
+// reads workflow commands from the action's stdout.
+var Output io.Writer = os.Stdout
+
+// Mask instructs the runner to mask each value in the workflow logs.
+// Multi-line values are masked line by line, because the runner
+// matches masks against individual log lines. Empty values are ignored.
+func Mask(values ...string) {
+	for _, value := range values {
+		for _, line := range strings.Split(value, "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" {
+				continue
+			}
+			fmt.Fprintf(Output, "::add-mask::%s\n", escapeData(line))
+		}
+	}
+}
+
+// Warning creates a warning annotation on the workflow run. When file is
+// set, the annotation is displayed inline on the file in pull requests.
+// Line is ignored when less than 1.
+func Warning(file string, line int, title, message string) {
+	annotate("warning", file, line, title, message)
+}
+
+// Error creates an error annotation on the workflow run. When file is set,
+// the annotation is displayed inline on the file in pull requests. Line is
+// ignored when less than 1.
+func Error(file string, line int, title, message string) {
+	annotate("error", file, line, title, message)
+}
+
+// annotate writes an annotation workflow command
+func annotate(command, file string, line int, title, message string) {
+	props := []string{}
+	if file != "" {
+		props = append(props, "file="+escapeProperty(file))
+		if line > 0 {
+			props = append(props, fmt.Sprintf("line=%d", line))
+		}
+	}
+	if title != "" {
+		props = append(props, "title="+escapeProperty(title))
+	}
+
+	cmd := "::" + command
+	if len(props) > 0 {
+		cmd += " " + strings.Join(props, ",")
+	}
+	fmt.Fprintf(Output, "%s::%s\n", cmd, escapeData(message))
+}
+
+// SetOutput sets a step output which can be consumed by later steps
+// in the job. Outputs are written to the file referenced by the
+// GITHUB_OUTPUT environment variable. If GITHUB_OUTPUT is not set,
+// the output is ignored.
+func SetOutput(name, value string) error {
+	path := os.Getenv("GITHUB_OUTPUT")
+	if path == "" {
+		return nil
+	}
+
+	// A random delimiter allows multi-line values and prevents
+	// a value from terminating the output early.
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Errorf("generate delimiter: %w", err)
+	}
+	delimiter := "ghadelimiter_" + hex.EncodeToString(b)
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600) // #nosec G304 path set by the runner
+	if err != nil {
+		return fmt.Errorf("open output file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := fmt.Fprintf(f, "%s<<%s\n%s\n%s\n", name, delimiter, value, delimiter); err != nil {
+		return fmt.Errorf("write output %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// AppendSummary appends markdown to the job summary, which is displayed
+// on the summary page of the workflow run. The summary is written to the
+// file referenced by the GITHUB_STEP_SUMMARY environment variable. If
+// GITHUB_STEP_SUMMARY is not set, the summary is ignored.
+func AppendSummary(markdown string) error {
+	path := os.Getenv("GITHUB_STEP_SUMMARY")
+	if path == "" {
+		return nil
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600) // #nosec G304 path set by the runner
+	if err != nil {
+		return fmt.Errorf("open summary file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := fmt.Fprintln(f, markdown); err != nil {
+		return fmt.Errorf("write summary: %w", err)
+	}
+
+	return nil
+}
+
+// escapeData escapes a workflow command's data
+func escapeData(s string) string {
+	s = strings.ReplaceAll(s, "%", "%25")
+	s = strings.ReplaceAll(s, "\r", "%0D")
+	s = strings.ReplaceAll(s, "\n", "%0A")
+	return s
+}
+
+// escapeProperty escapes a workflow command's property value
+func escapeProperty(s string) string {
+	s = escapeData(s)
+	s = strings.ReplaceAll(s, ":", "%3A")
+	s = strings.ReplaceAll(s, ",", "%2C")
+	return s
+}
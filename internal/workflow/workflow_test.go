@@ -0,0 +1,79 @@
+package workflow
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMask(t *testing.T) {
+	buf := &bytes.Buffer{}
+	Output = buf
+
+	Mask("", "secret", "multi\nline\n", "100%")
+	require.Equal(t, "::add-mask::secret\n::add-mask::multi\n::add-mask::line\n::add-mask::100%25\n", buf.String())
+}
+
+func TestWarning(t *testing.T) {
+	buf := &bytes.Buffer{}
+	Output = buf
+
+	Warning("", 0, "Title: a, b", "line one\nline two")
+	Warning("a.yaml", 3, "", "message")
+	require.Equal(t, "::warning title=Title%3A a%2C b::line one%0Aline two\n::warning file=a.yaml,line=3::message\n", buf.String())
+}
+
+func TestError(t *testing.T) {
+	buf := &bytes.Buffer{}
+	Output = buf
+
+	Error("resources/destination.yaml", 12, "Invalid resource", "otlp: missing hostname")
+	Error("resources/destination.yaml", 0, "", "malformed")
+	Error("", 5, "Title", "no file")
+	require.Equal(t,
+		"::error file=resources/destination.yaml,line=12,title=Invalid resource::otlp: missing hostname\n"+
+			"::error file=resources/destination.yaml::malformed\n"+
+			"::error title=Title::no file\n",
+		buf.String(),
+	)
+}
+
+func TestSetOutput(t *testing.T) {
+	t.Setenv("GITHUB_OUTPUT", "")
+	require.NoError(t, SetOutput("skipped", "value"))
+
+	path := filepath.Join(t.TempDir(), "output")
+	t.Setenv("GITHUB_OUTPUT", path)
+
+	require.NoError(t, SetOutput("single", "value"))
+	require.NoError(t, SetOutput("multi", "line one\nline two"))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	re := regexp.MustCompile(`^single<<(ghadelimiter_[0-9a-f]+)\nvalue\n(ghadelimiter_[0-9a-f]+)\nmulti<<(ghadelimiter_[0-9a-f]+)\nline one\nline two\n(ghadelimiter_[0-9a-f]+)\n$`)
+	matches := re.FindStringSubmatch(string(data))
+	require.Len(t, matches, 5, string(data))
+	require.Equal(t, matches[1], matches[2])
+	require.Equal(t, matches[3], matches[4])
+	require.NotEqual(t, matches[1], matches[3])
+}
+
+func TestAppendSummary(t *testing.T) {
+	t.Setenv("GITHUB_STEP_SUMMARY", "")
+	require.NoError(t, AppendSummary("skipped"))
+
+	path := filepath.Join(t.TempDir(), "summary")
+	t.Setenv("GITHUB_STEP_SUMMARY", path)
+
+	require.NoError(t, AppendSummary("### Title"))
+	require.NoError(t, AppendSummary("| a | b |"))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "### Title\n| a | b |\n", string(data))
+}
@@ -0,0 +1,110 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/observiq/bindplane-op-action/pkg/client/version"
+	"go.uber.org/zap"
+)
+
+// API versions
+const (
+	// APIVersionAuto uses the newest API version supported by both
+	// the client and the server, found with Negotiate
+	APIVersionAuto = "auto"
+
+	APIVersionV1 = "v1"
+	APIVersionV2 = "v2"
+)
+
+// apiVersions are the API versions supported by the client, newest first.
+// Each version serves the same routes and payloads under its prefix.
+var apiVersions = []string{APIVersionV2, APIVersionV1}
+
+// WithAPIVersion sets the API version, either auto, v1, or v2. Requests
+// use v1 until Negotiate is called when the version is auto. Defaults to auto.
+func WithAPIVersion(v string) Option {
+	return func(b *BindPlane) {
+		if v != "" {
+			b.apiVersion = v
+		}
+	}
+}
+
+// APIVersion returns the API version requests are sent to
+func (c *BindPlane) APIVersion() string {
+	return strings.TrimPrefix(c.client.BaseURL, c.remoteURL()+"/")
+}
+
+// Negotiate finds the newest API version served by BindPlane, by requesting
+// the version endpoint of each API version the client supports, and routes
+// every following request to it. Older servers, which only serve v1, are
+// detected by an error status. When the API version is not auto, it is
+// returned without making any requests.
+func (c *BindPlane) Negotiate(ctx context.Context) (string, error) {
+	if c.apiVersion != APIVersionAuto {
+		return c.apiVersion, nil
+	}
+
+	for _, v := range apiVersions[:len(apiVersions)-1] {
+		ok, err := c.servesAPIVersion(ctx, v)
+		if err != nil {
+			return "", fmt.Errorf("negotiate API version: %w", err)
+		}
+		if ok {
+			c.client.SetBaseURL(c.apiURL(v))
+			return v, nil
+		}
+	}
+
+	v := apiVersions[len(apiVersions)-1]
+	c.client.SetBaseURL(c.apiURL(v))
+	return v, nil
+}
+
+// servesAPIVersion returns true if the version endpoint of the API version
+// responds with a server version. Servers which serve their UI for unknown
+// routes respond 200 with HTML, which is not mistaken for the API.
+func (c *BindPlane) servesAPIVersion(ctx context.Context, apiVersion string) (bool, error) {
+	req, cancel := c.request(ctx, c.fetchTimeout)
+	defer cancel()
+
+	v := version.Version{}
+	resp, err := req.SetResult(&v).Get(c.apiURL(apiVersion) + "/version")
+	if err != nil {
+		return false, err
+	}
+
+	// Reverse proxies which only route the v1 API may respond
+	// with any status, so every error status falls back
+	if resp.StatusCode() != http.StatusOK {
+		c.logger.Debug("BindPlane does not serve API version", zap.String("api_version", apiVersion), zap.Int("status", resp.StatusCode()))
+		return false, nil
+	}
+	return v.Tag != "", nil
+}
+
+// ValidateAPIVersion returns an error if v is not empty, auto,
+// or a supported API version
+func ValidateAPIVersion(v string) error {
+	if v == "" || v == APIVersionAuto || slices.Contains(apiVersions, v) {
+		return nil
+	}
+	return fmt.Errorf("invalid API version %s, must be %s or one of %s", v, APIVersionAuto, strings.Join(apiVersions, ", "))
+}
+
+// apiURL returns the base URL of an API version
+func (c *BindPlane) apiURL(apiVersion string) string {
+	return fmt.Sprintf("%s/%s", c.remoteURL(), apiVersion)
+}
+
+// remoteURL returns the remote URL without a trailing slash. The remote
+// URL can include a route prefix, such as https://example.com/bindplane,
+// which is preserved.
+func (c *BindPlane) remoteURL() string {
+	return strings.TrimSuffix(c.config.Network.RemoteURL, "/")
+}
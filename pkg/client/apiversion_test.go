@@ -0,0 +1,115 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/observiq/bindplane-op-action/pkg/client/config"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestNegotiate(t *testing.T) {
+	cases := []struct {
+		name       string
+		apiVersion string
+		v2         http.HandlerFunc
+		expect     string
+	}{
+		{
+			"v2 server",
+			"",
+			func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"tag":"v2.0.0"}`))
+			},
+			APIVersionV2,
+		},
+		{
+			"v1 server",
+			"",
+			http.NotFound,
+			APIVersionV1,
+		},
+		{
+			"v1 server behind proxy",
+			APIVersionAuto,
+			func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusForbidden)
+			},
+			APIVersionV1,
+		},
+		{
+			"UI served for unknown routes",
+			"",
+			func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "text/html")
+				_, _ = w.Write([]byte(`<html></html>`))
+			},
+			APIVersionV1,
+		},
+		{
+			"pinned",
+			APIVersionV1,
+			func(_ http.ResponseWriter, _ *http.Request) {
+				t.Error("pinned API versions are not negotiated")
+			},
+			APIVersionV1,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var path string
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /bindplane/v2/version", tc.v2)
+			mux.HandleFunc("GET /bindplane/{api}/configurations", func(w http.ResponseWriter, r *http.Request) {
+				path = r.URL.Path
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"configurations":[]}`))
+			})
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			c, err := NewBindPlane(&config.Config{
+				Network: config.Network{
+					RemoteURL: server.URL + "/bindplane/",
+				},
+			}, zap.NewNop(), WithRetryMaxAttempts(1), WithAPIVersion(tc.apiVersion))
+			require.NoError(t, err)
+			require.Equal(t, APIVersionV1, c.APIVersion())
+
+			v, err := c.Negotiate(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, v)
+			require.Equal(t, tc.expect, c.APIVersion())
+
+			_, err = c.Configurations(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, "/bindplane/"+tc.expect+"/configurations", path)
+		})
+	}
+}
+
+func TestNegotiateUnreachable(t *testing.T) {
+	c, err := NewBindPlane(&config.Config{
+		Network: config.Network{
+			RemoteURL: "http://127.0.0.1:1",
+		},
+	}, zap.NewNop(), WithRetryMaxAttempts(1))
+	require.NoError(t, err)
+
+	_, err = c.Negotiate(context.Background())
+	require.ErrorContains(t, err, "negotiate API version: ")
+}
+
+func TestWithAPIVersion(t *testing.T) {
+	c, err := NewBindPlane(&config.Config{}, zap.NewNop(), WithAPIVersion(APIVersionV2))
+	require.NoError(t, err)
+	require.Equal(t, APIVersionV2, c.APIVersion())
+
+	_, err = NewBindPlane(&config.Config{}, zap.NewNop(), WithAPIVersion("v3"))
+	require.EqualError(t, err, "invalid API version v3, must be auto or one of v2, v1")
+}
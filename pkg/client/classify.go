@@ -0,0 +1,93 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+)
+
+// ErrorClass is the cause of a failed request, used to
+// report how a connection problem can be fixed
+type ErrorClass string
+
+const (
+	// ErrorClassUnknown is an error which could not be classified
+	ErrorClassUnknown ErrorClass = ""
+
+	// ErrorClassDNS is a failure to resolve the server's hostname
+	ErrorClassDNS ErrorClass = "dns"
+
+	// ErrorClassTLS is a failed TLS handshake, such as a server certificate
+	// signed by an unknown certificate authority, a hostname mismatch, or a
+	// rejected client certificate
+	ErrorClassTLS ErrorClass = "tls"
+
+	// ErrorClassAuth is a 401 or 403 response
+	ErrorClassAuth ErrorClass = "auth"
+
+	// ErrorClassTimeout is a request which did not complete in time
+	ErrorClassTimeout ErrorClass = "timeout"
+)
+
+// Classify returns the class of an error returned by the client
+func Classify(err error) ErrorClass {
+	if err == nil {
+		return ErrorClassUnknown
+	}
+
+	if errors.Is(err, ErrUnauthorized) {
+		return ErrorClassAuth
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return ErrorClassUnknown
+	}
+
+	// DNS errors can also be timeouts, they are
+	// classified as DNS errors so the hostname is checked
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return ErrorClassDNS
+	}
+
+	if isTLSError(err) {
+		return ErrorClassTLS
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return ErrorClassTimeout
+	}
+
+	return ErrorClassUnknown
+}
+
+// isTLSError returns true if err is a certificate verification
+// or TLS handshake error
+func isTLSError(err error) bool {
+	var (
+		verifyErr    *tls.CertificateVerificationError
+		unknownErr   x509.UnknownAuthorityError
+		hostnameErr  x509.HostnameError
+		invalidErr   x509.CertificateInvalidError
+		recordErr    tls.RecordHeaderError
+		opErr        *net.OpError
+		certAlertErr tls.AlertError
+	)
+	switch {
+	case errors.As(err, &verifyErr),
+		errors.As(err, &unknownErr),
+		errors.As(err, &hostnameErr),
+		errors.As(err, &invalidErr),
+		errors.As(err, &recordErr),
+		errors.As(err, &certAlertErr):
+		return true
+	case errors.As(err, &opErr):
+		// The server sent a TLS alert, such as when
+		// it rejects the client certificate
+		return opErr.Op == "remote error"
+	}
+	return false
+}
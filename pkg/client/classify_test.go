@@ -0,0 +1,76 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"syscall"
+	"testing"
+
+	"github.com/observiq/bindplane-op-action/pkg/client/config"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestClassify(t *testing.T) {
+	cases := []struct {
+		name   string
+		err    error
+		expect ErrorClass
+	}{
+		{"nil", nil, ErrorClassUnknown},
+		{"other", errors.New("boom"), ErrorClassUnknown},
+		{"DNS", &url.Error{Op: "Get", URL: "https://bindplane", Err: &net.OpError{Op: "dial", Err: &net.DNSError{Name: "bindplane", IsNotFound: true}}}, ErrorClassDNS},
+		{"DNS timeout", &net.DNSError{Name: "bindplane", IsTimeout: true}, ErrorClassDNS},
+		{"unknown authority", &url.Error{Err: &tls.CertificateVerificationError{Err: x509.UnknownAuthorityError{}}}, ErrorClassTLS},
+		{"hostname", x509.HostnameError{Host: "bindplane"}, ErrorClassTLS},
+		{"expired", x509.CertificateInvalidError{Reason: x509.Expired}, ErrorClassTLS},
+		{"not TLS", tls.RecordHeaderError{Msg: "first record does not look like a TLS handshake"}, ErrorClassTLS},
+		{"alert", &net.OpError{Op: "remote error", Err: errors.New("tls: certificate required")}, ErrorClassTLS},
+		{"refused", &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, ErrorClassUnknown},
+		{"401", fmt.Errorf("apply: %w", &StatusError{StatusCode: 401}), ErrorClassAuth},
+		{"403", &StatusError{StatusCode: 403}, ErrorClassAuth},
+		{"500", &StatusError{StatusCode: 500}, ErrorClassUnknown},
+		{"deadline", fmt.Errorf("apply: %w", context.DeadlineExceeded), ErrorClassTimeout},
+		{"net timeout", &url.Error{Err: timeoutError{}}, ErrorClassTimeout},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expect, Classify(tc.err))
+		})
+	}
+}
+
+func TestClassifyResponses(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	// The test server's certificate is not trusted
+	c, err := NewBindPlane(&config.Config{
+		Network: config.Network{
+			RemoteURL: server.URL,
+		},
+	}, zap.NewNop(), WithRetryMaxAttempts(1))
+	require.NoError(t, err)
+	_, err = c.Version(context.Background())
+	require.Equal(t, ErrorClassTLS, Classify(err))
+
+	c, err = NewBindPlane(&config.Config{
+		Network: config.Network{
+			RemoteURL: server.URL,
+			TLS:       config.TLS{InsecureSkipVerify: true},
+		},
+	}, zap.NewNop(), WithRetryMaxAttempts(1))
+	require.NoError(t, err)
+	_, err = c.Version(context.Background())
+	require.Equal(t, ErrorClassAuth, Classify(err))
+}
@@ -0,0 +1,1502 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/observiq/bindplane-op-action/internal/buildinfo"
+	"github.com/observiq/bindplane-op-action/pkg/client/config"
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"github.com/observiq/bindplane-op-action/pkg/client/version"
+
+	"github.com/go-resty/resty/v2"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+)
+
+const (
+	KeyHeader = "X-Bindplane-Api-Key"
+
+	// AccountHeader is the header used to select an account
+	AccountHeader = "X-Bindplane-Account"
+
+	// ProjectHeader is the header used to select a project
+	ProjectHeader = "X-Bindplane-Project"
+
+	// DefaultTimeout is the timeout for a single request attempt
+	DefaultTimeout = time.Second * 60
+
+	// DefaultRetryMaxAttempts is the maximum number of attempts made for
+	// a request that fails with a transient error, including the initial attempt.
+	DefaultRetryMaxAttempts = 6
+
+	// DefaultRetryMaxElapsedTime is the maximum amount of time spent retrying
+	// a request. Retries stop once this time has elapsed since the first attempt.
+	DefaultRetryMaxElapsedTime = time.Minute * 5
+
+	// DefaultRetryWaitTime is the initial wait time between retries. The
+	// wait time grows exponentially with jitter, up to DefaultRetryMaxWaitTime.
+	DefaultRetryWaitTime = time.Second
+
+	// DefaultRetryMaxWaitTime is the maximum wait time between retries,
+	// unless BindPlane responds with a longer Retry-After delay
+	DefaultRetryMaxWaitTime = time.Second * 30
+
+	// HealthTimeout is the timeout for a health check, including retries,
+	// so an unreachable server is reported quickly
+	HealthTimeout = time.Second * 15
+)
+
+// Errors matched by the errors returned when BindPlane responds with an
+// error status, using errors.Is, so callers can branch on the cause
+var (
+	// ErrNotFound is matched when a resource does not exist
+	ErrNotFound = errors.New("not found")
+
+	// ErrUnauthorized is matched by 401 and 403 responses, when the
+	// credentials are invalid or not permitted to make the request
+	ErrUnauthorized = errors.New("unauthorized")
+
+	// ErrConflict is matched by 409 responses, such as when a
+	// resource was modified by another request
+	ErrConflict = errors.New("conflict")
+
+	// ErrServer is matched by 5xx responses
+	ErrServer = errors.New("server error")
+)
+
+// requestIDHeaders are the response headers BindPlane, or a proxy in
+// front of it, identifies a request with, in order of preference
+var requestIDHeaders = []string{"X-Request-Id", "X-Correlation-Id", "Request-Id"}
+
+// StatusError is returned when the BindPlane API responds with an error status
+type StatusError struct {
+	StatusCode int
+	Body       string
+
+	// RequestID is the ID of the request in the response headers, if
+	// any, used to find the request in the BindPlane server logs
+	RequestID string
+}
+
+// Error returns the status code, request ID, and response body
+func (e *StatusError) Error() string {
+	if e.RequestID != "" {
+		return fmt.Sprintf("BindPlane API returned status %d (request ID %s): %s", e.StatusCode, e.RequestID, e.Body)
+	}
+	return fmt.Sprintf("BindPlane API returned status %d: %s", e.StatusCode, e.Body)
+}
+
+// requestID returns the ID of a request from its response headers
+func requestID(h http.Header) string {
+	for _, name := range requestIDHeaders {
+		if id := h.Get(name); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// Is returns true if target is the error matched by the status code,
+// such as ErrNotFound for 404
+func (e *StatusError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrServer:
+		return e.StatusCode >= http.StatusInternalServerError
+	}
+	return false
+}
+
+// statusError returns the error of a response with an error status
+func (c *BindPlane) statusError(resp *resty.Response) *StatusError {
+	return &StatusError{
+		StatusCode: resp.StatusCode(),
+		Body:       c.redactor.redact(resp.String()),
+		RequestID:  requestID(resp.Header()),
+	}
+}
+
+// idempotentKey is the context key which marks a request, other than a
+// GET, as safe to send more than once, see idempotentRequest
+type idempotentKey struct{}
+
+// requestStartKey is the context key used to track the
+// time of the first attempt of a request
+type requestStartKey struct{}
+
+// Option is a function that configures a BindPlane client option
+type Option func(*BindPlane)
+
+// WithRetryMaxAttempts sets the maximum number of attempts for a request,
+// including the initial attempt. A value of 1 disables retries. Values
+// less than 1 are ignored.
+func WithRetryMaxAttempts(n int) Option {
+	return func(b *BindPlane) {
+		if n < 1 {
+			return
+		}
+		b.retryMaxAttempts = n
+	}
+}
+
+// WithRetryMaxElapsedTime sets the maximum amount of time spent retrying
+// a request. Values less than or equal to 0 are ignored.
+func WithRetryMaxElapsedTime(d time.Duration) Option {
+	return func(b *BindPlane) {
+		if d <= 0 {
+			return
+		}
+		b.retryMaxElapsedTime = d
+	}
+}
+
+// WithRetryStatusCodes sets the HTTP status codes that will be retried.
+// When empty, all 5xx status codes are retried.
+func WithRetryStatusCodes(codes []int) Option {
+	return func(b *BindPlane) {
+		b.retryStatusCodes = codes
+	}
+}
+
+// WithApplyTimeout sets the maximum amount of time an apply or delete
+// request may take, including retries. Values less than or equal to 0
+// are ignored.
+func WithApplyTimeout(d time.Duration) Option {
+	return func(b *BindPlane) {
+		if d <= 0 {
+			return
+		}
+		b.applyTimeout = d
+	}
+}
+
+// WithFetchTimeout sets the maximum amount of time a request which reads
+// configurations or other resources may take, including retries. Values
+// less than or equal to 0 are ignored.
+func WithFetchTimeout(d time.Duration) Option {
+	return func(b *BindPlane) {
+		if d <= 0 {
+			return
+		}
+		b.fetchTimeout = d
+	}
+}
+
+// WithRateLimit limits the number of requests per second sent to BindPlane,
+// including retries. Values less than or equal to 0 are ignored.
+func WithRateLimit(rps float64) Option {
+	return func(b *BindPlane) {
+		if rps <= 0 {
+			return
+		}
+		b.rateLimiter = rate.NewLimiter(rate.Limit(rps), 1)
+	}
+}
+
+// WithMaxIdleConns sets the maximum number of idle connections kept open
+// to BindPlane for reuse. Values less than or equal to 0 are ignored.
+func WithMaxIdleConns(n int) Option {
+	return func(b *BindPlane) {
+		if n <= 0 {
+			return
+		}
+		b.maxIdleConns = n
+	}
+}
+
+// WithIdleConnTimeout sets how long an idle connection is kept open
+// before it is closed. Values less than or equal to 0 are ignored.
+func WithIdleConnTimeout(d time.Duration) Option {
+	return func(b *BindPlane) {
+		if d <= 0 {
+			return
+		}
+		b.idleConnTimeout = d
+	}
+}
+
+// WithDisableKeepAlives disables HTTP keep-alives, so every
+// request opens a new connection which is closed after it
+func WithDisableKeepAlives(disable bool) Option {
+	return func(b *BindPlane) {
+		b.disableKeepAlives = disable
+	}
+}
+
+// WithHTTPTrace enables logging of request and response headers and
+// bodies. Credentials are redacted from headers.
+func WithHTTPTrace(b bool) Option {
+	return func(bp *BindPlane) {
+		bp.httpTrace = b
+	}
+}
+
+// WithHeaders sets headers which are sent with every request, such as
+// the headers required by a gateway in front of BindPlane. Headers set
+// by the client, such as the API key header, take precedence.
+func WithHeaders(headers map[string]string) Option {
+	return func(b *BindPlane) {
+		b.headers = headers
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request.
+// Defaults to bindplane-op-action/<version>. Empty values are ignored.
+func WithUserAgent(ua string) Option {
+	return func(b *BindPlane) {
+		if ua != "" {
+			b.userAgent = ua
+		}
+	}
+}
+
+type BindPlane struct {
+	logger *zap.Logger
+	config *config.Config
+	client *resty.Client
+
+	// httpTrace enables request and response trace logging
+	httpTrace bool
+
+	// headers are sent with every request
+	headers map[string]string
+
+	// redactor removes credentials from errors and trace logs
+	redactor *redactor
+
+	// userAgent is the User-Agent header of every request
+	userAgent string
+
+	// apiVersion is the configured API version, which may be auto
+	apiVersion string
+
+	// Retry policy
+	retryMaxAttempts    int
+	retryMaxElapsedTime time.Duration
+	retryStatusCodes    []int
+
+	// Operation timeouts, unset when zero
+	applyTimeout time.Duration
+	fetchTimeout time.Duration
+
+	// rateLimiter limits request attempts, nil when not rate limited
+	rateLimiter *rate.Limiter
+
+	// throttle slows requests when the rate limit headers of responses
+	// show the limit is almost exhausted, nil when disabled
+	throttle                  *throttle
+	disableAdaptiveThrottling bool
+
+	// Connection pool, the transport defaults are used when zero
+	maxIdleConns      int
+	idleConnTimeout   time.Duration
+	disableKeepAlives bool
+
+	// dnsServer resolves the BindPlane hostname, and addresses of
+	// ipFamily are connected to first. Unset uses the system defaults.
+	dnsServer string
+	ipFamily  string
+
+	// proxyURL is the proxy requests are sent through, the
+	// proxy environment variables are used when empty
+	proxyURL string
+
+	// recorder is called with every finished request, nil when
+	// requests are not recorded
+	recorder func(Request)
+
+	// etags caches the ETag and body of conditional GET
+	// responses by endpoint
+	etags   map[string]cachedResponse
+	etagsMu sync.Mutex
+}
+
+// cachedResponse is a response body and the ETag it was served with
+type cachedResponse struct {
+	etag string
+	body []byte
+}
+
+// NewBindPlane takes a config and logger and returns a configured BindPlane client
+func NewBindPlane(config *config.Config, logger *zap.Logger, opts ...Option) (*BindPlane, error) {
+	bindplane := &BindPlane{
+		logger:              logger,
+		config:              config,
+		retryMaxAttempts:    DefaultRetryMaxAttempts,
+		retryMaxElapsedTime: DefaultRetryMaxElapsedTime,
+		etags:               map[string]cachedResponse{},
+		userAgent:           buildinfo.UserAgent(),
+		apiVersion:          APIVersionAuto,
+	}
+	for _, opt := range opts {
+		opt(bindplane)
+	}
+
+	if err := ValidateAPIVersion(bindplane.apiVersion); err != nil {
+		return nil, err
+	}
+	bindplane.redactor = newRedactor(config, bindplane.headers)
+
+	restryClient := resty.New()
+	restryClient.SetDisableWarn(true)
+	// Operation timeouts are enforced with request contexts, the
+	// attempt timeout must not cut them short.
+	restryClient.SetTimeout(max(DefaultTimeout, bindplane.applyTimeout, bindplane.fetchTimeout))
+
+	// The backoff is exponential with jitter, bounded by the max
+	// wait time. Retry-After delays may be longer, up to the max
+	// elapsed time, so maintenance windows are waited out.
+	restryClient.SetRetryCount(bindplane.retryMaxAttempts - 1)
+	restryClient.SetRetryWaitTime(DefaultRetryWaitTime)
+	restryClient.SetRetryMaxWaitTime(max(bindplane.retryMaxElapsedTime, DefaultRetryMaxWaitTime))
+	restryClient.SetRetryAfter(bindplane.retryAfter)
+	restryClient.AddRetryCondition(bindplane.retryCondition)
+
+	// Record the start time of the first attempt so retries
+	// can be bounded by the max elapsed time.
+	restryClient.OnBeforeRequest(func(_ *resty.Client, r *resty.Request) error {
+		if r.Context().Value(requestStartKey{}) == nil {
+			r.SetContext(context.WithValue(r.Context(), requestStartKey{}, time.Now()))
+		}
+		return nil
+	})
+
+	// Requests are traced when the context has a span. Attempts share
+	// a span, which ends once the request succeeds or fails.
+	restryClient.OnBeforeRequest(startRequestSpan)
+	restryClient.OnSuccess(func(_ *resty.Client, r *resty.Response) {
+		endRequestSpan(r.Request.Context(), r, nil)
+	})
+	restryClient.OnError(func(r *resty.Request, err error) {
+		endRequestSpan(r.Context(), nil, err)
+	})
+
+	if bindplane.recorder != nil {
+		restryClient.OnSuccess(func(_ *resty.Client, r *resty.Response) {
+			bindplane.recordRequest(r.Request, r, nil)
+		})
+		restryClient.OnError(func(r *resty.Request, err error) {
+			bindplane.recordRequest(r, nil, err)
+		})
+	}
+
+	// Each attempt, including retries, waits for the rate limiter
+	if bindplane.rateLimiter != nil {
+		restryClient.OnBeforeRequest(func(_ *resty.Client, r *resty.Request) error {
+			if err := bindplane.rateLimiter.Wait(r.Context()); err != nil {
+				return fmt.Errorf("rate limit: %w", err)
+			}
+			return nil
+		})
+	}
+	if !bindplane.disableAdaptiveThrottling {
+		bindplane.throttle = &throttle{logger: logger}
+		restryClient.OnBeforeRequest(func(_ *resty.Client, r *resty.Request) error {
+			if err := bindplane.throttle.wait(r.Context()); err != nil {
+				return fmt.Errorf("rate limit: %w", err)
+			}
+			return nil
+		})
+		restryClient.OnAfterResponse(func(_ *resty.Client, r *resty.Response) error {
+			bindplane.throttle.update(r.Header(), time.Now())
+			return nil
+		})
+	}
+	restryClient.AddRetryHook(func(r *resty.Response, err error) {
+		fields := []zap.Field{zap.Error(err)}
+		if r != nil && r.Request != nil {
+			fields = append(fields,
+				zap.String("method", r.Request.Method),
+				zap.String("url", r.Request.URL),
+				zap.Int("status", r.StatusCode()),
+				zap.Int("attempt", r.Request.Attempt),
+			)
+			if id := requestID(r.Header()); id != "" {
+				fields = append(fields, zap.String("request_id", id))
+			}
+			if retryAfterStatus(r) {
+				fields = append(fields, zap.String("retry_after", r.Header().Get("Retry-After")))
+			}
+		}
+		logger.Warn("Retrying BindPlane API request", fields...)
+
+		// Streamed responses are not read by resty, the body of
+		// the attempt being retried must be closed
+		if r != nil && r.RawResponse != nil {
+			_ = r.RawBody().Close()
+		}
+	})
+
+	// Debug logging for troubleshooting API requests
+	restryClient.OnAfterResponse(func(_ *resty.Client, r *resty.Response) error {
+		logger.Debug(
+			"BindPlane API request",
+			zap.String("method", r.Request.Method),
+			zap.String("url", r.Request.URL),
+			zap.Int("status", r.StatusCode()),
+			zap.Duration("duration", r.Time()),
+			zap.Int("attempt", r.Request.Attempt),
+			zap.String("request_id", requestID(r.Header())),
+		)
+		if bindplane.httpTrace {
+			traceResponse(logger, r, bindplane.redactor, sensitiveHeaders(bindplane.headers)...)
+		}
+		return nil
+	})
+	restryClient.OnError(func(r *resty.Request, err error) {
+		logger.Debug(
+			"BindPlane API request failed",
+			zap.String("method", r.Method),
+			zap.String("url", r.URL),
+			zap.Int("attempt", r.Attempt),
+			zap.Error(err),
+		)
+	})
+
+	restryClient.SetHeaders(bindplane.headers)
+	restryClient.SetHeader("User-Agent", bindplane.userAgent)
+
+	if config.Auth.Username != "" && config.Auth.Password != "" {
+		restryClient.SetBasicAuth(config.Auth.Username, config.Auth.Password)
+	}
+
+	if config.Auth.APIKey != "" {
+		restryClient.SetHeader(KeyHeader, config.Auth.APIKey)
+	}
+
+	if config.Auth.AccountID != "" {
+		restryClient.SetHeader(AccountHeader, config.Auth.AccountID)
+	}
+
+	if config.Auth.ProjectID != "" {
+		restryClient.SetHeader(ProjectHeader, config.Auth.ProjectID)
+	}
+
+	// Auto negotiated API versions use v1 until negotiated
+	if bindplane.apiVersion == APIVersionAuto {
+		restryClient.SetBaseURL(bindplane.apiURL(APIVersionV1))
+	} else {
+		restryClient.SetBaseURL(bindplane.apiURL(bindplane.apiVersion))
+	}
+
+	minVersion, err := TLSVersion(config.Network.TLS.MinVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	cipherSuites, err := CipherSuites(config.Network.TLS.CipherSuites)
+	if err != nil {
+		return nil, err
+	}
+	// Cipher suites only apply to TLS 1.2, Go ignores them for TLS 1.3
+	if len(cipherSuites) > 0 && minVersion != tls.VersionTLS12 {
+		return nil, errors.New("cipher suites require TLS minimum version 1.2, TLS 1.3 cipher suites are not configurable")
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:   minVersion,
+		CipherSuites: cipherSuites,
+	}
+	if len(config.Network.CertificateAuthority) > 0 {
+		tlsConfig.RootCAs = x509.NewCertPool()
+		for _, ca := range config.Network.CertificateAuthority {
+			if err := appendCertificateAuthority(tlsConfig.RootCAs, ca); err != nil {
+				return nil, fmt.Errorf("failed to append certificate authority: %w", err)
+			}
+		}
+	}
+
+	if config.Network.TLS.InsecureSkipVerify {
+		logger.Warn("TLS certificate verification is disabled, connections to BindPlane are not secure. Do not use insecure_skip_verify in production.")
+		tlsConfig.InsecureSkipVerify = true // #nosec G402 user opted in to skip verification
+	}
+
+	if config.Network.TLS.Certificate != "" || config.Network.TLS.PrivateKey != "" {
+		cert, err := loadKeyPair(config.Network.TLS.Certificate, config.Network.TLS.PrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	restryClient.SetTLSClientConfig(tlsConfig)
+
+	// Every request is sent to the same host, so the idle
+	// connection limit applies to the pool of the host
+	transport, err := restryClient.Transport()
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure transport: %w", err)
+	}
+	if bindplane.maxIdleConns > 0 {
+		transport.MaxIdleConns = bindplane.maxIdleConns
+		transport.MaxIdleConnsPerHost = bindplane.maxIdleConns
+	}
+	if bindplane.idleConnTimeout > 0 {
+		transport.IdleConnTimeout = bindplane.idleConnTimeout
+	}
+	transport.DisableKeepAlives = bindplane.disableKeepAlives
+
+	dial, err := bindplane.dialContext()
+	if err != nil {
+		return nil, err
+	}
+	if dial != nil {
+		transport.DialContext = dial
+	}
+
+	proxy, proxyPassword, err := bindplane.proxy()
+	if err != nil {
+		return nil, err
+	}
+	transport.Proxy = proxy
+	if proxyPassword != "" {
+		bindplane.redactor.secrets = append(bindplane.redactor.secrets, proxyPassword)
+	}
+
+	bindplane.client = restryClient
+	return bindplane, nil
+}
+
+// TLSVersion returns the TLS version for the given version string.
+// Supported versions are 1.2 and 1.3. An empty string returns TLS 1.3.
+func TLSVersion(v string) (uint16, error) {
+	switch v {
+	case "", "1.3":
+		return tls.VersionTLS13, nil
+	case "1.2":
+		return tls.VersionTLS12, nil
+	default:
+		return 0, fmt.Errorf("unsupported TLS version '%s', must be 1.2 or 1.3", v)
+	}
+}
+
+// CipherSuites returns the cipher suite IDs for the given TLS 1.2 cipher
+// suite names, such as TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256. Only cipher
+// suites without known security issues are supported. TLS 1.3 cipher
+// suites are rejected, because they are not configurable. An empty list
+// returns nil.
+func CipherSuites(names []string) ([]uint16, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	supported := map[string]*tls.CipherSuite{}
+	for _, s := range tls.CipherSuites() {
+		supported[s.Name] = s
+	}
+
+	ids := make([]uint16, 0, len(names))
+	for _, name := range names {
+		s, ok := supported[name]
+		if !ok {
+			return nil, fmt.Errorf("unsupported cipher suite '%s'", name)
+		}
+		if !slices.Contains(s.SupportedVersions, tls.VersionTLS12) {
+			return nil, fmt.Errorf("cipher suite '%s' is a TLS 1.3 cipher suite, which is not configurable", name)
+		}
+		ids = append(ids, s.ID)
+	}
+	return ids, nil
+}
+
+// appendCertificateAuthority adds a certificate authority to the pool. The
+// certificate authority can be PEM encoded content, a path to a PEM encoded
+// file, or a path to a directory of PEM encoded files. When a directory is
+// given, hidden files and files without certificates are skipped, but at
+// least one certificate must be found.
+func appendCertificateAuthority(pool *x509.CertPool, ca string) error {
+	if strings.Contains(ca, "-----BEGIN") {
+		if ok := pool.AppendCertsFromPEM([]byte(ca)); !ok {
+			return fmt.Errorf("no certificates found in PEM content")
+		}
+		return nil
+	}
+
+	info, err := os.Stat(ca)
+	if err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		data, err := os.ReadFile(ca) // #nosec G304 user defined filepath
+		if err != nil {
+			return fmt.Errorf("read file: %w", err)
+		}
+		if ok := pool.AppendCertsFromPEM(data); !ok {
+			return fmt.Errorf("no certificates found in file %s", ca)
+		}
+		return nil
+	}
+
+	entries, err := os.ReadDir(ca)
+	if err != nil {
+		return fmt.Errorf("read directory: %w", err)
+	}
+
+	found := false
+	for _, e := range entries {
+		// Skip hidden files, such as the ..data directory
+		// created by Kubernetes secret volume mounts
+		if strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+
+		path := filepath.Join(ca, e.Name())
+
+		// Stat follows symlinks, which are commonly
+		// used by mounted secrets
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() {
+			continue
+		}
+
+		data, err := os.ReadFile(path) // #nosec G304 user defined filepath
+		if err != nil {
+			return fmt.Errorf("read file: %w", err)
+		}
+		if pool.AppendCertsFromPEM(data) {
+			found = true
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("no certificates found in directory %s", ca)
+	}
+
+	return nil
+}
+
+// loadKeyPair loads a certificate and private key. Each can be
+// PEM encoded content or a path to a PEM encoded file.
+func loadKeyPair(cert, key string) (tls.Certificate, error) {
+	if cert == "" || key == "" {
+		return tls.Certificate{}, fmt.Errorf("both certificate and private key are required")
+	}
+
+	certPEM, err := loadPEM(cert)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("certificate: %w", err)
+	}
+
+	keyPEM, err := loadPEM(key)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("private key: %w", err)
+	}
+
+	return tls.X509KeyPair(certPEM, keyPEM)
+}
+
+// loadPEM returns s if it is PEM encoded content, otherwise s is
+// treated as a file path and the file's contents are returned.
+func loadPEM(s string) ([]byte, error) {
+	if strings.Contains(s, "-----BEGIN") {
+		return []byte(s), nil
+	}
+
+	data, err := os.ReadFile(s) // #nosec G304 user defined filepath
+	if err != nil {
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+	return data, nil
+}
+
+// Health queries the BindPlane health endpoint, which is served at the
+// root of the remote URL rather than under the API version. A *StatusError is returned
+// when the server responds, but is not healthy.
+func (c *BindPlane) Health(ctx context.Context) error {
+	req, cancel := c.request(ctx, HealthTimeout)
+	defer cancel()
+
+	resp, err := req.Get(c.remoteURL() + "/health")
+	if err != nil {
+		return err
+	}
+
+	if status := resp.StatusCode(); status > 399 {
+		return c.statusError(resp)
+	}
+
+	return nil
+}
+
+// Version queries the BindPlane API for the version information
+func (b *BindPlane) Version(_ context.Context) (version.Version, error) {
+	v := version.Version{}
+	r, err := b.client.R().SetResult(&v).Get("/version")
+	if err != nil {
+		return v, fmt.Errorf("failed to get version: %w", err)
+	}
+
+	if r.StatusCode() != 200 {
+		return v, fmt.Errorf("failed to get version: %w", b.statusError(r))
+	}
+
+	return v, nil
+}
+
+// Apply applies a list of resources to the BindPlane API
+func (c *BindPlane) Apply(ctx context.Context, resources []*model.AnyResource) ([]*model.AnyResourceStatus, error) {
+	payload := model.ApplyPayload{
+		Resources: resources,
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("client apply: %w", err)
+	}
+
+	req, cancel := c.idempotentRequest(ctx, c.applyTimeout)
+	defer cancel()
+
+	ar := &model.ApplyResponseClientSide{}
+	resp, err := req.SetHeader("Content-Type", "application/json").SetBody(data).SetResult(ar).Post("/apply")
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply file: %w", err)
+	}
+
+	status := resp.StatusCode()
+	if status > 399 {
+		return nil, c.statusError(resp)
+	}
+
+	return ar.Updates, nil
+}
+
+// Delete deletes resources from BindPlane. Resources are matched by
+// kind and name. The status of each resource is returned.
+func (c *BindPlane) Delete(ctx context.Context, resources []*model.AnyResource) ([]*model.AnyResourceStatus, error) {
+	payload := model.ApplyPayload{
+		Resources: resources,
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("client delete: %w", err)
+	}
+
+	req, cancel := c.idempotentRequest(ctx, c.applyTimeout)
+	defer cancel()
+
+	ar := &model.ApplyResponseClientSide{}
+	resp, err := req.SetHeader("Content-Type", "application/json").SetBody(data).SetResult(ar).Post("/delete")
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete resources: %w", err)
+	}
+
+	status := resp.StatusCode()
+	if status > 399 {
+		return nil, c.statusError(resp)
+	}
+
+	return ar.Updates, nil
+}
+
+// Configuration queries the BindPlane API and returns a configuration by name
+func (c *BindPlane) Configuration(ctx context.Context, name string) (*model.Configuration, error) {
+	pr, err := c.configuration(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return pr.Configuration, nil
+}
+
+// RawConfiguration queries the BindPlane API and returns a raw configuration by name
+func (c *BindPlane) RawConfiguration(ctx context.Context, name string) (string, error) {
+	pr, err := c.configuration(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	return pr.Raw, nil
+}
+
+// Configurations queries the BindPlane API and returns all configurations
+func (c *BindPlane) Configurations(ctx context.Context) ([]*model.Configuration, error) {
+	r := &model.ConfigurationsResponse{}
+	if err := c.get(ctx, "/configurations", r); err != nil {
+		return nil, err
+	}
+	return r.Configurations, nil
+}
+
+// ConfigurationsBySelector queries the BindPlane API and returns the
+// configurations whose labels match the label selector, such as team=payments
+func (c *BindPlane) ConfigurationsBySelector(ctx context.Context, selector string) ([]*model.Configuration, error) {
+	req, cancel := c.request(ctx, c.fetchTimeout)
+	defer cancel()
+
+	r := &model.ConfigurationsResponse{}
+	resp, err := req.SetQueryParam("selector", selector).SetResult(r).Get("/configurations")
+	if err != nil {
+		return nil, err
+	}
+
+	if status := resp.StatusCode(); status > 399 {
+		return nil, c.statusError(resp)
+	}
+
+	return r.Configurations, nil
+}
+
+// configurationMetricsQuery queries the throughput of each component of a
+// configuration, averaged over the period, such as 1m
+const configurationMetricsQuery = `query configurationMetrics($period: String!, $name: String!) {
+  configurationMetrics(period: $period, name: $name) {
+    metrics { name nodeID pipelineType value unit agentID }
+  }
+}`
+
+// ConfigurationMetrics queries the BindPlane API and returns the
+// throughput of each component of a configuration over the period,
+// such as 1m. Metrics are only available from the graphql endpoint.
+func (c *BindPlane) ConfigurationMetrics(ctx context.Context, name, period string) ([]*model.Metric, error) {
+	req, cancel := c.idempotentRequest(ctx, c.fetchTimeout)
+	defer cancel()
+
+	payload := model.GraphQLPayload{
+		Query:     configurationMetricsQuery,
+		Variables: map[string]any{"name": name, "period": period},
+	}
+
+	r := &model.ConfigurationMetricsResponse{}
+	resp, err := req.SetBody(payload).SetResult(r).Post("/graphql")
+	if err != nil {
+		return nil, err
+	}
+
+	if status := resp.StatusCode(); status > 399 {
+		return nil, c.statusError(resp)
+	}
+
+	if len(r.Errors) > 0 {
+		messages := []string{}
+		for _, e := range r.Errors {
+			messages = append(messages, e.Message)
+		}
+		return nil, fmt.Errorf("configuration metrics: %s", strings.Join(messages, ", "))
+	}
+
+	return r.Data.ConfigurationMetrics.Metrics, nil
+}
+
+// Agents queries the BindPlane API and returns every agent
+func (c *BindPlane) Agents(ctx context.Context) ([]*model.Agent, error) {
+	r := &model.AgentsResponse{}
+	if err := c.get(ctx, "/agents", r); err != nil {
+		return nil, err
+	}
+	return r.Agents, nil
+}
+
+// AgentsBySelector queries the BindPlane API and returns the
+// agents matching selector, such as configuration=gateway
+func (c *BindPlane) AgentsBySelector(ctx context.Context, selector string) ([]*model.Agent, error) {
+	req, cancel := c.request(ctx, c.fetchTimeout)
+	defer cancel()
+
+	r := &model.AgentsResponse{}
+	resp, err := req.SetQueryParam("selector", selector).SetResult(r).Get("/agents")
+	if err != nil {
+		return nil, err
+	}
+
+	if status := resp.StatusCode(); status > 399 {
+		return nil, c.statusError(resp)
+	}
+
+	return r.Agents, nil
+}
+
+// AuditEvents queries the BindPlane API and returns the audit log
+// entries recorded at or after since
+func (c *BindPlane) AuditEvents(ctx context.Context, since time.Time) ([]*model.AuditEvent, error) {
+	req, cancel := c.request(ctx, c.fetchTimeout)
+	defer cancel()
+
+	r := &model.AuditEventsResponse{}
+	resp, err := req.SetQueryParam("minDate", since.UTC().Format(time.RFC3339)).SetResult(r).Get("/audit-events")
+	if err != nil {
+		return nil, err
+	}
+
+	if status := resp.StatusCode(); status > 399 {
+		return nil, c.statusError(resp)
+	}
+
+	return r.AuditEvents, nil
+}
+
+func (c *BindPlane) configuration(ctx context.Context, name string) (*model.ConfigurationResponse, error) {
+	req, cancel := c.request(ctx, c.fetchTimeout)
+	defer cancel()
+
+	pr := &model.ConfigurationResponse{}
+	if err := c.conditionalGet(req, fmt.Sprintf("/configurations/%s", name), pr); err != nil {
+		return nil, err
+	}
+	return pr, nil
+}
+
+// resourceEndpoints maps resource kinds to the endpoint which lists them,
+// the field of the list response which contains the resources, and the
+// field of the get response which contains a single resource
+var resourceEndpoints = map[model.Kind]struct{ endpoint, field, item string }{
+	model.KindConfiguration: {"/configurations", "configurations", "configuration"},
+	model.KindSource:        {"/sources", "sources", "source"},
+	model.KindProcessor:     {"/processors", "processors", "processor"},
+	model.KindDestination:   {"/destinations", "destinations", "destination"},
+}
+
+// Resources queries the BindPlane API and returns all resources of the
+// given kind. Configurations, sources, processors, and destinations
+// are supported.
+func (c *BindPlane) Resources(ctx context.Context, kind model.Kind) ([]*model.AnyResource, error) {
+	e, ok := resourceEndpoints[kind]
+	if !ok {
+		return nil, fmt.Errorf("listing %s resources is not supported", kind)
+	}
+
+	r := map[string][]*model.AnyResource{}
+	if err := c.get(ctx, e.endpoint, &r); err != nil {
+		return nil, err
+	}
+	return r[e.field], nil
+}
+
+// GetResource queries the BindPlane API and returns a resource by kind and
+// name. Configurations, sources, processors, and destinations are supported.
+// The returned error matches ErrNotFound if the resource does not exist.
+func (c *BindPlane) GetResource(ctx context.Context, kind model.Kind, name string) (*model.AnyResource, error) {
+	e, ok := resourceEndpoints[kind]
+	if !ok {
+		return nil, fmt.Errorf("getting %s resources is not supported", kind)
+	}
+
+	r := map[string]*model.AnyResource{}
+	if err := c.get(ctx, fmt.Sprintf("%s/%s", e.endpoint, name), &r); err != nil {
+		return nil, err
+	}
+	return r[e.item], nil
+}
+
+// ConfigurationVersion queries the BindPlane API and returns a version of
+// a configuration, using the name:version form BindPlane accepts in place of
+// a name. The returned error matches ErrNotFound if the version does not exist.
+func (c *BindPlane) ConfigurationVersion(ctx context.Context, name string, version int) (*model.AnyResource, error) {
+	return c.GetResource(ctx, model.KindConfiguration, fmt.Sprintf("%s:%d", name, version))
+}
+
+// RollbackConfiguration applies a previous version of a configuration, which
+// creates a new pending version with the same content. The rollout of the
+// new version must be started separately.
+func (c *BindPlane) RollbackConfiguration(ctx context.Context, name string, version int) (*model.AnyResourceStatus, error) {
+	r, err := c.ConfigurationVersion(ctx, name, version)
+	if err != nil {
+		return nil, fmt.Errorf("get version %d of configuration %s: %w", version, name, err)
+	}
+	if r == nil {
+		return nil, fmt.Errorf("get version %d of configuration %s: %w", version, name, ErrNotFound)
+	}
+
+	// Fields managed by the server identify the previous version,
+	// and are assigned again when the version is applied
+	r.Metadata.ID = ""
+	r.Metadata.Hash = ""
+	r.Metadata.Version = 0
+	r.Metadata.DateModified = nil
+
+	updates, err := c.Apply(ctx, []*model.AnyResource{r})
+	if err != nil {
+		return nil, err
+	}
+	if len(updates) != 1 {
+		return nil, fmt.Errorf("apply version %d of configuration %s: expected 1 update, got %d", version, name, len(updates))
+	}
+	return updates[0], nil
+}
+
+// Destination queries the BindPlane API and returns a destination by name
+func (c *BindPlane) Destination(ctx context.Context, name string) (*model.Destination, error) {
+	r := &model.DestinationResponse{}
+	if err := c.get(ctx, fmt.Sprintf("/destinations/%s", name), r); err != nil {
+		return nil, err
+	}
+	return r.Destination, nil
+}
+
+// Source queries the BindPlane API and returns a source by name
+func (c *BindPlane) Source(ctx context.Context, name string) (*model.Source, error) {
+	r := &model.SourceResponse{}
+	if err := c.get(ctx, fmt.Sprintf("/sources/%s", name), r); err != nil {
+		return nil, err
+	}
+	return r.Source, nil
+}
+
+// Processor queries the BindPlane API and returns a processor by name
+func (c *BindPlane) Processor(ctx context.Context, name string) (*model.Processor, error) {
+	r := &model.ProcessorResponse{}
+	if err := c.get(ctx, fmt.Sprintf("/processors/%s", name), r); err != nil {
+		return nil, err
+	}
+	return r.Processor, nil
+}
+
+// AgentVersions queries the BindPlane API and returns all agent versions
+func (c *BindPlane) AgentVersions(ctx context.Context) ([]*model.AgentVersion, error) {
+	r := &model.AgentVersionsResponse{}
+	if err := c.get(ctx, "/agent-versions", r); err != nil {
+		return nil, err
+	}
+	return r.AgentVersions, nil
+}
+
+// AgentVersion queries the BindPlane API and returns an agent version by name
+func (c *BindPlane) AgentVersion(ctx context.Context, name string) (*model.AgentVersion, error) {
+	r := &model.AgentVersionResponse{}
+	if err := c.get(ctx, fmt.Sprintf("/agent-versions/%s", name), r); err != nil {
+		return nil, err
+	}
+	return r.AgentVersion, nil
+}
+
+// Agent queries the BindPlane API and returns an agent by ID, including its
+// connection status, running configuration version, and last error. The
+// returned error matches ErrNotFound if the agent does not exist.
+func (c *BindPlane) Agent(ctx context.Context, id string) (*model.Agent, error) {
+	r := &model.AgentResponse{}
+	if err := c.get(ctx, fmt.Sprintf("/agents/%s", id), r); err != nil {
+		return nil, err
+	}
+	return r.Agent, nil
+}
+
+// AgentConfiguration queries the BindPlane API and returns the
+// configuration served to an agent by ID, rendered for the agent. Unlike
+// RawConfiguration, the raw configuration includes values specific to the
+// agent, so it is what the agent actually runs. The returned error matches
+// ErrNotFound if the agent does not exist or has no configuration.
+func (c *BindPlane) AgentConfiguration(ctx context.Context, id string) (*model.AgentConfigurationResponse, error) {
+	r := &model.AgentConfigurationResponse{}
+	if err := c.get(ctx, fmt.Sprintf("/agents/%s/configuration", id), r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// DeleteAgents deletes agents by ID and returns the deleted agents.
+// Connected agents are added back when they next connect.
+func (c *BindPlane) DeleteAgents(ctx context.Context, ids []string) ([]*model.Agent, error) {
+	req, cancel := c.idempotentRequest(ctx, c.applyTimeout)
+	defer cancel()
+
+	r := &model.AgentsResponse{}
+	resp, err := req.SetBody(model.DeleteAgentsPayload{IDs: ids}).SetResult(r).Delete("/agents")
+	if err != nil {
+		return nil, fmt.Errorf("delete agents: %w", err)
+	}
+
+	if status := resp.StatusCode(); status > 399 {
+		return nil, c.statusError(resp)
+	}
+
+	return r.Agents, nil
+}
+
+// AddAgentLabels adds labels to every agent matching selector, replacing
+// existing values of the same labels. The labeled agents are returned.
+func (c *BindPlane) AddAgentLabels(ctx context.Context, selector string, add map[string]string) ([]*model.Agent, error) {
+	agents, err := c.AgentsBySelector(ctx, selector)
+	if err != nil {
+		return nil, fmt.Errorf("get agents: %w", err)
+	}
+	if len(agents) == 0 {
+		return agents, nil
+	}
+
+	payload := model.BulkAgentLabelsPayload{
+		Labels:    add,
+		Overwrite: true,
+	}
+	for _, agent := range agents {
+		payload.IDs = append(payload.IDs, agent.ID)
+	}
+
+	req, cancel := c.idempotentRequest(ctx, c.applyTimeout)
+	defer cancel()
+
+	r := &model.BulkAgentLabelsResponse{}
+	resp, err := req.SetBody(payload).SetResult(r).Patch("/agents/labels")
+	if err != nil {
+		return nil, fmt.Errorf("label agents: %w", err)
+	}
+
+	if status := resp.StatusCode(); status > 399 {
+		return nil, c.statusError(resp)
+	}
+
+	if len(r.Errors) > 0 {
+		return nil, fmt.Errorf("label agents: %s", strings.Join(r.Errors, ", "))
+	}
+
+	return agents, nil
+}
+
+// RemoveAgentLabels removes labels from every agent matching selector.
+// BindPlane replaces the labels of one agent at a time, so each agent
+// is updated with its remaining labels. The updated agents are returned.
+func (c *BindPlane) RemoveAgentLabels(ctx context.Context, selector string, remove []string) ([]*model.Agent, error) {
+	agents, err := c.AgentsBySelector(ctx, selector)
+	if err != nil {
+		return nil, fmt.Errorf("get agents: %w", err)
+	}
+
+	updated := []*model.Agent{}
+	for _, agent := range agents {
+		remaining := map[string]string{}
+		for k, v := range agent.Labels {
+			if !slices.Contains(remove, k) {
+				remaining[k] = v
+			}
+		}
+		if len(remaining) == len(agent.Labels) {
+			continue
+		}
+
+		if err := c.setAgentLabels(ctx, agent.ID, remaining); err != nil {
+			return updated, fmt.Errorf("remove labels from agent %s: %w", agent.ID, err)
+		}
+		agent.Labels = remaining
+		updated = append(updated, agent)
+	}
+
+	return updated, nil
+}
+
+// setAgentLabels replaces the labels of an agent
+func (c *BindPlane) setAgentLabels(ctx context.Context, id string, set map[string]string) error {
+	req, cancel := c.idempotentRequest(ctx, c.applyTimeout)
+	defer cancel()
+
+	resp, err := req.SetBody(model.AgentLabelsPayload{Labels: set}).Put(fmt.Sprintf("/agents/%s/labels", id))
+	if err != nil {
+		return err
+	}
+
+	if status := resp.StatusCode(); status > 399 {
+		return c.statusError(resp)
+	}
+
+	return nil
+}
+
+// SourceTypes queries the BindPlane API and returns all source types
+func (c *BindPlane) SourceTypes(ctx context.Context) ([]*model.ResourceType, error) {
+	r := &model.SourceTypesResponse{}
+	if err := c.get(ctx, "/source-types", r); err != nil {
+		return nil, err
+	}
+	return r.SourceTypes, nil
+}
+
+// DestinationTypes queries the BindPlane API and returns all destination types
+func (c *BindPlane) DestinationTypes(ctx context.Context) ([]*model.ResourceType, error) {
+	r := &model.DestinationTypesResponse{}
+	if err := c.get(ctx, "/destination-types", r); err != nil {
+		return nil, err
+	}
+	return r.DestinationTypes, nil
+}
+
+// get performs a GET request, limited to the fetch timeout, and
+// decodes the response into result
+func (c *BindPlane) get(ctx context.Context, endpoint string, result any) error {
+	req, cancel := c.request(ctx, c.fetchTimeout)
+	defer cancel()
+
+	resp, err := req.SetResult(result).Get(endpoint)
+	if err != nil {
+		return err
+	}
+
+	if status := resp.StatusCode(); status > 399 {
+		return c.statusError(resp)
+	}
+
+	return nil
+}
+
+// conditionalGet performs a GET request with If-None-Match set to the ETag
+// of the last response from endpoint. When the API responds 304 Not Modified,
+// the cached body is decoded into result. Configurations are polled during
+// rollouts and drift detection, and are often large, so unchanged
+// configurations are not downloaded again.
+func (c *BindPlane) conditionalGet(req *resty.Request, endpoint string, result any) error {
+	c.etagsMu.Lock()
+	cached, ok := c.etags[endpoint]
+	c.etagsMu.Unlock()
+	if ok {
+		req.SetHeader("If-None-Match", cached.etag)
+	}
+
+	resp, err := req.SetResult(result).Get(endpoint)
+	if err != nil {
+		return err
+	}
+
+	status := resp.StatusCode()
+	switch {
+	case status == http.StatusNotModified && ok:
+		if err := json.Unmarshal(cached.body, result); err != nil {
+			return fmt.Errorf("decode cached response: %w", err)
+		}
+	case status > 399:
+		return c.statusError(resp)
+	default:
+		if etag := resp.Header().Get("ETag"); etag != "" {
+			c.etagsMu.Lock()
+			c.etags[endpoint] = cachedResponse{etag: etag, body: resp.Body()}
+			c.etagsMu.Unlock()
+		}
+	}
+
+	return nil
+}
+
+// request returns a request bound to ctx. When timeout is greater than
+// zero, the request and its retries are limited to it. The returned
+// cancel func must be called once the response has been read.
+func (c *BindPlane) request(ctx context.Context, timeout time.Duration) (*resty.Request, context.CancelFunc) {
+	cancel := context.CancelFunc(func() {})
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	}
+	return c.client.R().SetContext(ctx), cancel
+}
+
+// idempotentRequest returns a request like request, for requests other
+// than GETs which are safe to send again, because sending them twice has
+// the same effect as once, such as apply. They are retried like GET
+// requests. Other requests, such as starting a rollout, are not retried
+// once they may have been received.
+func (c *BindPlane) idempotentRequest(ctx context.Context, timeout time.Duration) (*resty.Request, context.CancelFunc) {
+	return c.request(context.WithValue(ctx, idempotentKey{}, true), timeout)
+}
+
+// StartRollout starts a rollout by name
+// NOTE: Does not use context or rollout options unlike the original client implementation
+// NOTE: Returns only an error, not a configuration
+func (c *BindPlane) StartRollout(ctx context.Context, name string) error {
+	return c.StartRolloutVersion(ctx, name, 0)
+}
+
+// StartRolloutVersion starts the rollout of a specific version of a
+// configuration, instead of its latest pending version. A version of 0
+// starts the latest pending version.
+func (c *BindPlane) StartRolloutVersion(ctx context.Context, name string, version int) error {
+	return c.StartRolloutStages(ctx, name, version, nil)
+}
+
+// StartRolloutStages starts a rollout which rolls out to the agents
+// matching the labels of each stage in order. BindPlane pauses the
+// rollout between stages, until it is resumed with ResumeRollout.
+// Without stages, the rollout includes every agent.
+func (c *BindPlane) StartRolloutStages(ctx context.Context, name string, version int, stages []model.RolloutStage) error {
+	if version > 0 {
+		name = fmt.Sprintf("%s:%d", name, version)
+	}
+	endpoint := fmt.Sprintf("/rollouts/%s/start", name)
+
+	body := model.StartRolloutPayload{
+		Options: &model.RolloutOptions{Stages: stages},
+	}
+
+	req, cancel := c.request(ctx, c.applyTimeout)
+	defer cancel()
+
+	resp, err := req.
+		SetBody(body).
+		Post(endpoint)
+	if err != nil {
+		return err
+	}
+
+	status := resp.StatusCode()
+	if status > 399 {
+		return c.statusError(resp)
+	}
+
+	return nil
+}
+
+// Rollouts queries the BindPlane API and returns every configuration with
+// a rollout. The rollout is in the status of each configuration.
+func (c *BindPlane) Rollouts(ctx context.Context) ([]*model.Configuration, error) {
+	req, cancel := c.request(ctx, c.fetchTimeout)
+	defer cancel()
+
+	r := &model.RolloutsResponse{}
+	if err := c.conditionalGet(req, "/rollouts", r); err != nil {
+		return nil, err
+	}
+	return r.Configurations, nil
+}
+
+// PauseRollout pauses a rollout by configuration name. Pausing a
+// paused rollout has no effect, so it is retried like a read.
+func (c *BindPlane) PauseRollout(ctx context.Context, name string) error {
+	endpoint := fmt.Sprintf("/rollouts/%s/pause", name)
+
+	req, cancel := c.idempotentRequest(ctx, c.applyTimeout)
+	defer cancel()
+
+	resp, err := req.Put(endpoint)
+	if err != nil {
+		return err
+	}
+
+	status := resp.StatusCode()
+	if status > 399 {
+		return c.statusError(resp)
+	}
+
+	return nil
+}
+
+// ResumeRollout resumes a paused rollout by configuration name. A staged
+// rollout continues with its next stage.
+func (c *BindPlane) ResumeRollout(ctx context.Context, name string) error {
+	endpoint := fmt.Sprintf("/rollouts/%s/resume", name)
+
+	req, cancel := c.request(ctx, c.applyTimeout)
+	defer cancel()
+
+	resp, err := req.Put(endpoint)
+	if err != nil {
+		return err
+	}
+
+	status := resp.StatusCode()
+	if status > 399 {
+		return c.statusError(resp)
+	}
+
+	return nil
+}
+
+// RolloutStatus queries the BindPlane API for the status of a rollout by configuration name
+func (c *BindPlane) RolloutStatus(ctx context.Context, name string) (*model.Configuration, error) {
+	var response model.ConfigurationResponse
+	endpoint := fmt.Sprintf("/rollouts/%s/status", name)
+
+	req, cancel := c.request(ctx, c.fetchTimeout)
+	defer cancel()
+
+	if err := c.conditionalGet(req, endpoint, &response); err != nil {
+		return nil, err
+	}
+
+	return response.Configuration, nil
+}
+
+// retryCondition returns true when a request failed with a transient
+// error that is likely to succeed if retried. Connection resets, timeouts,
+// and retryable status codes are considered transient, as are 429 and 503
+// responses with a Retry-After header. Requests which are not idempotent,
+// such as starting a rollout, may have been received by the server, so
+// they are only retried when the connection failed before they were sent,
+// or when the server asked for them to be retried with Retry-After.
+// Requests are not retried once the max elapsed time has passed.
+func (c *BindPlane) retryCondition(r *resty.Response, err error) bool {
+	if r != nil && r.Request != nil && c.retryMaxElapsedTime > 0 {
+		if start, ok := r.Request.Context().Value(requestStartKey{}).(time.Time); ok {
+			if time.Since(start) >= c.retryMaxElapsedTime {
+				return false
+			}
+		}
+	}
+
+	safe := r == nil || r.Request == nil || isIdempotent(r.Request)
+	if err != nil {
+		if !safe {
+			return isDialError(err)
+		}
+		return isTransientError(err)
+	}
+
+	if r == nil {
+		return false
+	}
+
+	return retryAfterStatus(r) || (safe && c.retryableStatus(r.StatusCode()))
+}
+
+// isIdempotent returns true if a request is safe to send again, either
+// because it does not change anything or it is an idempotentRequest
+func isIdempotent(r *resty.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	ok, _ := r.Context().Value(idempotentKey{}).(bool)
+	return ok
+}
+
+// isDialError returns true if the error happened while connecting,
+// before any of the request was sent
+func isDialError(err error) bool {
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// retryableStatus returns true if the status code should be retried
+func (c *BindPlane) retryableStatus(status int) bool {
+	if len(c.retryStatusCodes) == 0 {
+		return status >= http.StatusInternalServerError
+	}
+
+	return slices.Contains(c.retryStatusCodes, status)
+}
+
+// isTransientError returns true if the error is the result of a
+// network failure that may be resolved by retrying the request.
+func isTransientError(err error) bool {
+	switch {
+	case errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, syscall.ECONNREFUSED),
+		errors.Is(err, syscall.ECONNABORTED),
+		errors.Is(err, syscall.EPIPE),
+		errors.Is(err, io.EOF),
+		errors.Is(err, io.ErrUnexpectedEOF):
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,1509 @@
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/observiq/bindplane-op-action/internal/buildinfo"
+	"github.com/observiq/bindplane-op-action/pkg/client/config"
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestRetryCondition(t *testing.T) {
+	cases := []struct {
+		name   string
+		codes  []int
+		status int
+		err    error
+		expect bool
+	}{
+		{
+			"Success",
+			nil,
+			http.StatusOK,
+			nil,
+			false,
+		},
+		{
+			"Bad request",
+			nil,
+			http.StatusBadRequest,
+			nil,
+			false,
+		},
+		{
+			"Internal server error",
+			nil,
+			http.StatusInternalServerError,
+			nil,
+			true,
+		},
+		{
+			"Service unavailable",
+			nil,
+			http.StatusServiceUnavailable,
+			nil,
+			true,
+		},
+		{
+			"Connection reset",
+			nil,
+			0,
+			fmt.Errorf("read: %w", syscall.ECONNRESET),
+			true,
+		},
+		{
+			"Connection refused",
+			nil,
+			0,
+			fmt.Errorf("dial: %w", syscall.ECONNREFUSED),
+			true,
+		},
+		{
+			"Unexpected EOF",
+			nil,
+			0,
+			fmt.Errorf("read: %w", io.ErrUnexpectedEOF),
+			true,
+		},
+		{
+			"Timeout",
+			nil,
+			0,
+			fmt.Errorf("request: %w", timeoutError{}),
+			true,
+		},
+		{
+			"Custom status code",
+			[]int{http.StatusTooManyRequests},
+			http.StatusTooManyRequests,
+			nil,
+			true,
+		},
+		{
+			"Custom status code excludes 5xx",
+			[]int{http.StatusTooManyRequests},
+			http.StatusInternalServerError,
+			nil,
+			false,
+		},
+		{
+			"Non transient error",
+			nil,
+			0,
+			errors.New("x509: certificate signed by unknown authority"),
+			false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var r *resty.Response
+			if tc.status != 0 {
+				r = &resty.Response{
+					RawResponse: &http.Response{StatusCode: tc.status},
+				}
+			}
+			c := &BindPlane{retryStatusCodes: tc.codes}
+			require.Equal(t, tc.expect, c.retryCondition(r, tc.err))
+		})
+	}
+}
+
+func TestStatusErrorIs(t *testing.T) {
+	targets := []error{ErrNotFound, ErrUnauthorized, ErrConflict, ErrServer}
+
+	cases := []struct {
+		status int
+		expect error
+	}{
+		{http.StatusBadRequest, nil},
+		{http.StatusUnauthorized, ErrUnauthorized},
+		{http.StatusForbidden, ErrUnauthorized},
+		{http.StatusNotFound, ErrNotFound},
+		{http.StatusConflict, ErrConflict},
+		{http.StatusInternalServerError, ErrServer},
+		{http.StatusServiceUnavailable, ErrServer},
+	}
+
+	for _, tc := range cases {
+		t.Run(http.StatusText(tc.status), func(t *testing.T) {
+			err := fmt.Errorf("apply: %w", &StatusError{StatusCode: tc.status})
+			for _, target := range targets {
+				require.Equal(t, target == tc.expect, errors.Is(err, target), target)
+			}
+		})
+	}
+}
+
+func TestStatusErrorRequestID(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/apply", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("X-Correlation-Id", "abc-123")
+		w.WriteHeader(http.StatusConflict)
+		_, _ = w.Write([]byte("resource modified"))
+	})
+	mux.HandleFunc("/v1/configurations/{name}", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("X-Request-Id", "def-456")
+		w.Header().Set("X-Correlation-Id", "abc-123")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c, err := NewBindPlane(&config.Config{
+		Network: config.Network{
+			RemoteURL: server.URL,
+		},
+	}, zap.NewNop(), WithRetryMaxAttempts(1))
+	require.NoError(t, err)
+
+	_, err = c.Apply(context.Background(), []*model.AnyResource{})
+	require.ErrorIs(t, err, ErrConflict)
+	require.EqualError(t, err, "BindPlane API returned status 409 (request ID abc-123): resource modified")
+
+	// X-Request-Id is preferred, and streamed responses have the ID as well
+	_, err = c.Configuration(context.Background(), "gateway")
+	statusErr := &StatusError{}
+	require.ErrorAs(t, err, &statusErr)
+	require.Equal(t, "def-456", statusErr.RequestID)
+
+	_, err = c.RawConfigurationReader(context.Background(), "gateway")
+	require.ErrorAs(t, err, &statusErr)
+	require.Equal(t, "def-456", statusErr.RequestID)
+}
+
+func TestVersionRetry(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if attempts.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"tag":"v1.0.0"}`))
+	}))
+	defer server.Close()
+
+	c, err := NewBindPlane(&config.Config{
+		Network: config.Network{
+			RemoteURL: server.URL,
+		},
+	}, zap.NewNop())
+	require.NoError(t, err)
+
+	// Keep the test fast
+	c.client.SetRetryWaitTime(time.Millisecond)
+	c.client.SetRetryMaxWaitTime(time.Millisecond * 10)
+
+	v, err := c.Version(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "v1.0.0", v.Tag)
+	require.Equal(t, int32(3), attempts.Load())
+}
+
+func TestAccountProjectHeaders(t *testing.T) {
+	var header http.Header
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		path = r.URL.Path
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"tag":"v1.0.0"}`))
+	}))
+	defer server.Close()
+
+	c, err := NewBindPlane(&config.Config{
+		Auth: config.Auth{
+			APIKey:    "key",
+			AccountID: "account-1",
+			ProjectID: "project-1",
+		},
+		Network: config.Network{
+			RemoteURL: server.URL + "/bindplane/",
+		},
+	}, zap.NewNop())
+	require.NoError(t, err)
+
+	_, err = c.Version(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "/bindplane/v1/version", path)
+	require.Equal(t, "key", header.Get(KeyHeader))
+	require.Equal(t, "account-1", header.Get(AccountHeader))
+	require.Equal(t, "project-1", header.Get(ProjectHeader))
+}
+
+func TestWithHeaders(t *testing.T) {
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"tag":"v1.0.0"}`))
+	}))
+	defer server.Close()
+
+	c, err := NewBindPlane(&config.Config{
+		Auth: config.Auth{
+			APIKey: "key",
+		},
+		Network: config.Network{
+			RemoteURL: server.URL,
+		},
+	}, zap.NewNop(), WithHeaders(map[string]string{
+		"X-Gateway-Auth": "gateway-token",
+		"X-Tenant":       "payments",
+		KeyHeader:        "overridden",
+	}))
+	require.NoError(t, err)
+
+	_, err = c.Version(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "gateway-token", header.Get("X-Gateway-Auth"))
+	require.Equal(t, "payments", header.Get("X-Tenant"))
+	require.Equal(t, "key", header.Get(KeyHeader), "client headers take precedence")
+
+	require.NoError(t, c.Health(context.Background()))
+	require.Equal(t, "payments", header.Get("X-Tenant"))
+}
+
+func TestUserAgent(t *testing.T) {
+	var userAgent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.UserAgent()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"tag":"v1.0.0"}`))
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{Network: config.Network{RemoteURL: server.URL}}
+
+	c, err := NewBindPlane(cfg, zap.NewNop())
+	require.NoError(t, err)
+	_, err = c.Version(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, buildinfo.UserAgent(), userAgent)
+	require.Regexp(t, "^bindplane-op-action/", userAgent)
+
+	c, err = NewBindPlane(cfg, zap.NewNop(), WithUserAgent("platform-sync/2.0"), WithHeaders(map[string]string{"User-Agent": "ignored"}))
+	require.NoError(t, err)
+	_, err = c.Version(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "platform-sync/2.0", userAgent)
+}
+
+func TestAgentVersions(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/agent-versions", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"agentVersions":[{"kind":"AgentVersion","metadata":{"name":"observiq-otel-collector-v1.50.0"},"spec":{"type":"observiq-otel-collector","version":"v1.50.0"}}]}`))
+	})
+	mux.HandleFunc("/v1/agent-versions/{name}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("name") != "observiq-otel-collector-v1.50.0" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"agentVersion":{"kind":"AgentVersion","metadata":{"name":"observiq-otel-collector-v1.50.0"},"spec":{"type":"observiq-otel-collector","version":"v1.50.0","download":{"linux-amd64":{"url":"https://example.com/agent.tar.gz","hash":"abc"}}}}}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c, err := NewBindPlane(&config.Config{
+		Network: config.Network{
+			RemoteURL: server.URL,
+		},
+	}, zap.NewNop(), WithRetryMaxAttempts(1))
+	require.NoError(t, err)
+
+	versions, err := c.AgentVersions(context.Background())
+	require.NoError(t, err)
+	require.Len(t, versions, 1)
+	require.Equal(t, "v1.50.0", versions[0].Spec.Version)
+
+	v, err := c.AgentVersion(context.Background(), "observiq-otel-collector-v1.50.0")
+	require.NoError(t, err)
+	require.Equal(t, string(model.KindAgentVersion), v.Kind)
+	require.Equal(t, "abc", v.Spec.Download["linux-amd64"].Hash)
+
+	_, err = c.AgentVersion(context.Background(), "missing")
+	require.EqualError(t, err, "BindPlane API returned status 404: ")
+}
+
+func TestResourceTypes(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/source-types", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"sourceTypes":[{"kind":"SourceType","metadata":{"name":"host"}},{"kind":"SourceType","metadata":{"name":"journald"}}]}`))
+	})
+	mux.HandleFunc("/v1/destination-types", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c, err := NewBindPlane(&config.Config{
+		Network: config.Network{
+			RemoteURL: server.URL,
+		},
+	}, zap.NewNop(), WithRetryMaxAttempts(1))
+	require.NoError(t, err)
+
+	types, err := c.SourceTypes(context.Background())
+	require.NoError(t, err)
+	require.Len(t, types, 2)
+	require.Equal(t, "journald", types[1].Metadata.Name)
+
+	_, err = c.DestinationTypes(context.Background())
+	require.EqualError(t, err, "BindPlane API returned status 403: ")
+}
+
+func TestResources(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/destinations", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"destinations":[{"apiVersion":"bindplane.observiq.com/v1","kind":"Destination","metadata":{"name":"otlp"},"spec":{"type":"otlp_grpc"}}]}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c, err := NewBindPlane(&config.Config{
+		Network: config.Network{
+			RemoteURL: server.URL,
+		},
+	}, zap.NewNop(), WithRetryMaxAttempts(1))
+	require.NoError(t, err)
+
+	resources, err := c.Resources(context.Background(), model.KindDestination)
+	require.NoError(t, err)
+	require.Len(t, resources, 1)
+	require.Equal(t, "otlp", resources[0].Metadata.Name)
+	require.Equal(t, "otlp_grpc", resources[0].Spec["type"])
+
+	_, err = c.Resources(context.Background(), model.KindSource)
+	require.EqualError(t, err, "BindPlane API returned status 404: 404 page not found")
+
+	_, err = c.Resources(context.Background(), model.KindAgentVersion)
+	require.EqualError(t, err, "listing AgentVersion resources is not supported")
+}
+
+func TestGetResource(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/destinations/{name}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("name") != "otlp" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"destination":{"apiVersion":"bindplane.observiq.com/v1","kind":"Destination","metadata":{"name":"otlp"},"spec":{"type":"otlp_grpc","parameters":[{"name":"hostname","value":"collector"}]}}}`))
+	})
+	mux.HandleFunc("/v1/sources/{name}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"source":{"kind":"Source","metadata":{"name":"` + r.PathValue("name") + `"},"spec":{"type":"hostmetrics"}}}`))
+	})
+	mux.HandleFunc("/v1/processors/{name}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"processor":{"kind":"Processor","metadata":{"name":"` + r.PathValue("name") + `"},"spec":{"type":"batch","disabled":true}}}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c, err := NewBindPlane(&config.Config{
+		Network: config.Network{
+			RemoteURL: server.URL,
+		},
+	}, zap.NewNop(), WithRetryMaxAttempts(1))
+	require.NoError(t, err)
+
+	r, err := c.GetResource(context.Background(), model.KindDestination, "otlp")
+	require.NoError(t, err)
+	require.Equal(t, "otlp", r.Metadata.Name)
+	require.Equal(t, "otlp_grpc", r.Spec["type"])
+
+	d, err := c.Destination(context.Background(), "otlp")
+	require.NoError(t, err)
+	require.Equal(t, "otlp_grpc", d.Spec.Type)
+	require.Equal(t, []model.Parameter{{Name: "hostname", Value: "collector"}}, d.Spec.Parameters)
+
+	s, err := c.Source(context.Background(), "host")
+	require.NoError(t, err)
+	require.Equal(t, "host", s.Metadata.Name)
+	require.Equal(t, "hostmetrics", s.Spec.Type)
+
+	p, err := c.Processor(context.Background(), "batch")
+	require.NoError(t, err)
+	require.True(t, p.Spec.Disabled)
+
+	_, err = c.Destination(context.Background(), "missing")
+	require.ErrorIs(t, err, ErrNotFound)
+	require.EqualError(t, err, "BindPlane API returned status 404: 404 page not found")
+
+	_, err = c.GetResource(context.Background(), model.KindAgentVersion, "v1")
+	require.EqualError(t, err, "getting AgentVersion resources is not supported")
+}
+
+func TestRollbackConfiguration(t *testing.T) {
+	var applied model.ApplyPayload
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/configurations/{name}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("name") != "gateway:2" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"configuration":{"kind":"Configuration","metadata":{"id":"01H","name":"gateway","hash":"abc","version":2},"spec":{"contentType":"text/yaml"}}}`))
+	})
+	mux.HandleFunc("POST /v1/apply", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&applied))
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(model.ApplyResponseClientSide{
+			Updates: []*model.AnyResourceStatus{{Resource: *applied.Resources[0], Status: model.StatusConfigured}},
+		})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c, err := NewBindPlane(&config.Config{
+		Network: config.Network{
+			RemoteURL: server.URL,
+		},
+	}, zap.NewNop(), WithRetryMaxAttempts(1))
+	require.NoError(t, err)
+
+	status, err := c.RollbackConfiguration(context.Background(), "gateway", 2)
+	require.NoError(t, err)
+	require.Equal(t, model.StatusConfigured, status.Status)
+	require.Len(t, applied.Resources, 1)
+	require.Equal(t, model.Metadata{Name: "gateway"}, applied.Resources[0].Metadata)
+	require.Equal(t, "text/yaml", applied.Resources[0].Spec["contentType"])
+
+	_, err = c.RollbackConfiguration(context.Background(), "gateway", 9)
+	require.ErrorIs(t, err, ErrNotFound)
+	require.EqualError(t, err, "get version 9 of configuration gateway: BindPlane API returned status 404: 404 page not found")
+}
+
+func TestAuditEvents(t *testing.T) {
+	minDate := ""
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/audit-events", func(w http.ResponseWriter, r *http.Request) {
+		minDate = r.URL.Query().Get("minDate")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"auditEvents":[{"id":"1","timestamp":"2024-05-01T12:00:05Z","resourceName":"gateway","resourceKind":"Configuration","configuration":"gateway","action":"Modified","user":"alice"}]}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c, err := NewBindPlane(&config.Config{
+		Network: config.Network{
+			RemoteURL: server.URL,
+		},
+	}, zap.NewNop(), WithRetryMaxAttempts(1))
+	require.NoError(t, err)
+
+	since := time.Date(2024, 5, 1, 8, 0, 0, 0, time.FixedZone("EDT", -4*60*60))
+	events, err := c.AuditEvents(context.Background(), since)
+	require.NoError(t, err)
+	require.Equal(t, "2024-05-01T12:00:00Z", minDate)
+
+	timestamp := time.Date(2024, 5, 1, 12, 0, 5, 0, time.UTC)
+	require.Equal(t, []*model.AuditEvent{{
+		ID:            "1",
+		Timestamp:     &timestamp,
+		ResourceName:  "gateway",
+		ResourceKind:  model.KindConfiguration,
+		Configuration: "gateway",
+		Action:        model.AuditEventActionModified,
+		User:          "alice",
+	}}, events)
+}
+
+func TestAgentsBySelector(t *testing.T) {
+	selector := ""
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/agents", func(w http.ResponseWriter, r *http.Request) {
+		selector = r.URL.Query().Get("selector")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"agents":[{"id":"01","name":"collector-1","labels":{"configuration":"gateway"}}]}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c, err := NewBindPlane(&config.Config{
+		Network: config.Network{
+			RemoteURL: server.URL,
+		},
+	}, zap.NewNop(), WithRetryMaxAttempts(1))
+	require.NoError(t, err)
+
+	agents, err := c.AgentsBySelector(context.Background(), "configuration=gateway")
+	require.NoError(t, err)
+	require.Equal(t, "configuration=gateway", selector)
+	require.Equal(t, []*model.Agent{{ID: "01", Name: "collector-1", Labels: map[string]string{"configuration": "gateway"}}}, agents)
+}
+
+func TestAgent(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/agents/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") != "01" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"agent":{"id":"01","name":"collector-1","status":2,"version":"v1.60.0","errorMessage":"failed to start receiver","connectedAt":"2024-05-01T12:00:00Z","configurationStatus":{"current":"gateway:2","pending":"gateway:3"}}}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c, err := NewBindPlane(&config.Config{
+		Network: config.Network{
+			RemoteURL: server.URL,
+		},
+	}, zap.NewNop(), WithRetryMaxAttempts(1))
+	require.NoError(t, err)
+
+	agent, err := c.Agent(context.Background(), "01")
+	require.NoError(t, err)
+	connected := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	require.Equal(t, &model.Agent{
+		ID:                  "01",
+		Name:                "collector-1",
+		Status:              model.AgentStatusError,
+		ConfigurationStatus: model.AgentConfigurationStatus{Current: "gateway:2", Pending: "gateway:3"},
+		Version:             "v1.60.0",
+		ErrorMessage:        "failed to start receiver",
+		ConnectedAt:         &connected,
+	}, agent)
+	require.Equal(t, "error", agent.Status.String())
+
+	_, err = c.Agent(context.Background(), "02")
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestAgentConfiguration(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/agents/{id}/configuration", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") != "01" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"configuration":{"metadata":{"name":"gateway","version":3}},"raw":"receivers:\n  hostmetrics:\n    collection_interval: 30s\n"}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c, err := NewBindPlane(&config.Config{
+		Network: config.Network{
+			RemoteURL: server.URL,
+		},
+	}, zap.NewNop(), WithRetryMaxAttempts(1))
+	require.NoError(t, err)
+
+	r, err := c.AgentConfiguration(context.Background(), "01")
+	require.NoError(t, err)
+	require.Equal(t, "gateway", r.Configuration.Metadata.Name)
+	require.Equal(t, 3, r.Configuration.Metadata.Version)
+	require.Equal(t, "receivers:\n  hostmetrics:\n    collection_interval: 30s\n", r.Raw)
+
+	_, err = c.AgentConfiguration(context.Background(), "02")
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestConfigurationMetrics(t *testing.T) {
+	cases := []struct {
+		name      string
+		response  string
+		expect    []*model.Metric
+		expectErr string
+	}{
+		{
+			"metrics",
+			`{"data":{"configurationMetrics":{"metrics":[{"name":"log_data_size","nodeID":"destination/otlp","pipelineType":"logs","value":512.5,"unit":"B/s"}]}}}`,
+			[]*model.Metric{{Name: "log_data_size", NodeID: "destination/otlp", PipelineType: "logs", Value: 512.5, Unit: "B/s"}},
+			"",
+		},
+		{
+			"errors",
+			`{"data":null,"errors":[{"message":"configuration not found"}]}`,
+			nil,
+			"configuration metrics: configuration not found",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			payload := model.GraphQLPayload{}
+			mux := http.NewServeMux()
+			mux.HandleFunc("POST /v1/graphql", func(w http.ResponseWriter, r *http.Request) {
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(tc.response))
+			})
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			c, err := NewBindPlane(&config.Config{
+				Network: config.Network{
+					RemoteURL: server.URL,
+				},
+			}, zap.NewNop(), WithRetryMaxAttempts(1))
+			require.NoError(t, err)
+
+			metrics, err := c.ConfigurationMetrics(context.Background(), "gateway", "1m")
+			require.Equal(t, map[string]any{"name": "gateway", "period": "1m"}, payload.Variables)
+			if tc.expectErr != "" {
+				require.EqualError(t, err, tc.expectErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, metrics)
+		})
+	}
+}
+
+func TestDeleteAgents(t *testing.T) {
+	payload := model.DeleteAgentsPayload{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("DELETE /v1/agents", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"agents":[{"id":"01"},{"id":"02"}]}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c, err := NewBindPlane(&config.Config{
+		Network: config.Network{
+			RemoteURL: server.URL,
+		},
+	}, zap.NewNop(), WithRetryMaxAttempts(1))
+	require.NoError(t, err)
+
+	deleted, err := c.DeleteAgents(context.Background(), []string{"01", "02"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"01", "02"}, payload.IDs)
+	require.Equal(t, []*model.Agent{{ID: "01"}, {ID: "02"}}, deleted)
+}
+
+func TestAgentLabels(t *testing.T) {
+	bulk := model.BulkAgentLabelsPayload{}
+	replaced := map[string]map[string]string{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/agents", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "canary=true", r.URL.Query().Get("selector"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"agents":[{"id":"01","labels":{"canary":"true","region":"us"}},{"id":"02","labels":{"canary":"true"}}]}`))
+	})
+	mux.HandleFunc("PATCH /v1/agents/labels", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&bulk))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"errors":[]}`))
+	})
+	mux.HandleFunc("PUT /v1/agents/{id}/labels", func(w http.ResponseWriter, r *http.Request) {
+		payload := model.AgentLabelsPayload{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		replaced[r.PathValue("id")] = payload.Labels
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c, err := NewBindPlane(&config.Config{
+		Network: config.Network{
+			RemoteURL: server.URL,
+		},
+	}, zap.NewNop(), WithRetryMaxAttempts(1))
+	require.NoError(t, err)
+
+	agents, err := c.AddAgentLabels(context.Background(), "canary=true", map[string]string{"pool": "main"})
+	require.NoError(t, err)
+	require.Len(t, agents, 2)
+	require.Equal(t, model.BulkAgentLabelsPayload{IDs: []string{"01", "02"}, Labels: map[string]string{"pool": "main"}, Overwrite: true}, bulk)
+
+	agents, err = c.RemoveAgentLabels(context.Background(), "canary=true", []string{"canary"})
+	require.NoError(t, err)
+	require.Len(t, agents, 2)
+	require.Equal(t, map[string]map[string]string{
+		"01": {"region": "us"},
+		"02": {},
+	}, replaced)
+}
+
+func TestDelete(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/delete", func(w http.ResponseWriter, r *http.Request) {
+		payload := model.ApplyPayload{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		require.Len(t, payload.Resources, 1)
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(model.ApplyResponseClientSide{
+			Updates: []*model.AnyResourceStatus{{Resource: *payload.Resources[0], Status: model.StatusDeleted}},
+		})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c, err := NewBindPlane(&config.Config{
+		Network: config.Network{
+			RemoteURL: server.URL,
+		},
+	}, zap.NewNop(), WithRetryMaxAttempts(1))
+	require.NoError(t, err)
+
+	r := &model.AnyResource{}
+	r.Kind = string(model.KindConfiguration)
+	r.Metadata.Name = "old"
+
+	statuses, err := c.Delete(context.Background(), []*model.AnyResource{r})
+	require.NoError(t, err)
+	require.Len(t, statuses, 1)
+	require.Equal(t, model.StatusDeleted, statuses[0].Status)
+	require.Equal(t, "old", statuses[0].Resource.Metadata.Name)
+}
+
+func TestOperationTimeouts(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/apply", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(200 * time.Millisecond):
+		}
+	})
+	mux.HandleFunc("POST /v1/rollouts/{name}/start", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(200 * time.Millisecond):
+		}
+	})
+	mux.HandleFunc("/v1/configurations/{name}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(model.ConfigurationResponse{Raw: "receivers:"})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c, err := NewBindPlane(&config.Config{
+		Network: config.Network{
+			RemoteURL: server.URL,
+		},
+	}, zap.NewNop(), WithRetryMaxAttempts(1), WithApplyTimeout(10*time.Millisecond), WithFetchTimeout(time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, DefaultTimeout, c.client.GetClient().Timeout)
+
+	_, err = c.Apply(context.Background(), []*model.AnyResource{})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// Starting a rollout is limited by the apply timeout
+	require.ErrorIs(t, c.StartRollout(context.Background(), "test"), context.DeadlineExceeded)
+
+	// Canceled rollout status polls are not sent
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = c.RolloutStatus(ctx, "test")
+	require.ErrorIs(t, err, context.Canceled)
+
+	// The apply timeout does not limit fetches
+	raw, err := c.RawConfiguration(context.Background(), "test")
+	require.NoError(t, err)
+	require.Equal(t, "receivers:", raw)
+
+	c, err = NewBindPlane(&config.Config{}, zap.NewNop(), WithApplyTimeout(10*time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, 10*time.Minute, c.client.GetClient().Timeout)
+}
+
+func TestRateLimit(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/source-types", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(model.SourceTypesResponse{})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c, err := NewBindPlane(&config.Config{
+		Network: config.Network{
+			RemoteURL: server.URL,
+		},
+	}, zap.NewNop(), WithRetryMaxAttempts(1), WithRateLimit(20))
+	require.NoError(t, err)
+
+	// The first request uses the burst, the remaining
+	// requests are spaced 50ms apart.
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		_, err := c.SourceTypes(context.Background())
+		require.NoError(t, err)
+	}
+	require.GreaterOrEqual(t, time.Since(start), 140*time.Millisecond)
+
+	// A canceled request does not wait for the limiter
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = c.SourceTypes(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestStartRolloutVersion(t *testing.T) {
+	started := []string{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/rollouts/{name}/start", func(w http.ResponseWriter, r *http.Request) {
+		started = append(started, r.PathValue("name"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c, err := NewBindPlane(&config.Config{
+		Network: config.Network{
+			RemoteURL: server.URL,
+		},
+	}, zap.NewNop(), WithRetryMaxAttempts(1))
+	require.NoError(t, err)
+
+	require.NoError(t, c.StartRolloutVersion(context.Background(), "my-config", 3))
+	require.NoError(t, c.StartRolloutVersion(context.Background(), "my-config", 0))
+	require.NoError(t, c.StartRollout(context.Background(), "my-config"))
+	require.Equal(t, []string{"my-config:3", "my-config", "my-config"}, started)
+}
+
+func TestStartRolloutStages(t *testing.T) {
+	body := map[string]any{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/rollouts/{name}/start", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "my-config:2", r.PathValue("name"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c, err := NewBindPlane(&config.Config{
+		Network: config.Network{
+			RemoteURL: server.URL,
+		},
+	}, zap.NewNop(), WithRetryMaxAttempts(1))
+	require.NoError(t, err)
+
+	require.NoError(t, c.StartRolloutStages(context.Background(), "my-config", 2, []model.RolloutStage{
+		{Name: "canary", Labels: model.Labels{Set: map[string]string{"canary": "true"}}},
+		{Name: "all"},
+	}))
+
+	stages := body["options"].(map[string]any)["stages"].([]any)
+	require.Len(t, stages, 2)
+	require.Equal(t, "canary", stages[0].(map[string]any)["name"])
+	require.Equal(t, map[string]any{"canary": "true"}, stages[0].(map[string]any)["labels"])
+	require.Equal(t, map[string]any{}, stages[1].(map[string]any)["labels"])
+}
+
+func TestResumeRollout(t *testing.T) {
+	resumed := ""
+	mux := http.NewServeMux()
+	mux.HandleFunc("PUT /v1/rollouts/{name}/resume", func(w http.ResponseWriter, r *http.Request) {
+		resumed = r.PathValue("name")
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c, err := NewBindPlane(&config.Config{
+		Network: config.Network{
+			RemoteURL: server.URL,
+		},
+	}, zap.NewNop(), WithRetryMaxAttempts(1))
+	require.NoError(t, err)
+
+	require.NoError(t, c.ResumeRollout(context.Background(), "my-config"))
+	require.Equal(t, "my-config", resumed)
+
+	err = c.ResumeRollout(context.Background(), "missing/name")
+	require.EqualError(t, err, "BindPlane API returned status 404: 404 page not found")
+}
+
+func TestPauseRollout(t *testing.T) {
+	paused := ""
+	mux := http.NewServeMux()
+	mux.HandleFunc("PUT /v1/rollouts/{name}/pause", func(w http.ResponseWriter, r *http.Request) {
+		paused = r.PathValue("name")
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c, err := NewBindPlane(&config.Config{
+		Network: config.Network{
+			RemoteURL: server.URL,
+		},
+	}, zap.NewNop(), WithRetryMaxAttempts(1))
+	require.NoError(t, err)
+
+	require.NoError(t, c.PauseRollout(context.Background(), "my-config"))
+	require.Equal(t, "my-config", paused)
+
+	err = c.PauseRollout(context.Background(), "missing/name")
+	require.ErrorIs(t, err, ErrNotFound)
+	require.EqualError(t, err, "BindPlane API returned status 404: 404 page not found")
+}
+
+func TestConditionalRequests(t *testing.T) {
+	var full, notModified atomic.Int32
+	etag := `"v1"`
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/rollouts/{name}/status", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == etag {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		full.Add(1)
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"configuration": {"metadata": {"name": %q}, "status": {"rollout": {"status": 1}}}}`, r.PathValue("name"))
+	})
+	mux.HandleFunc("GET /v1/configurations/{name}", func(w http.ResponseWriter, r *http.Request) {
+		require.Empty(t, r.Header.Get("If-None-Match"), "responses without an ETag are not cached")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"raw": "raw config"}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c, err := NewBindPlane(&config.Config{
+		Network: config.Network{
+			RemoteURL: server.URL,
+		},
+	}, zap.NewNop(), WithRetryMaxAttempts(1))
+	require.NoError(t, err)
+
+	for range 3 {
+		status, err := c.RolloutStatus(context.Background(), "my-config")
+		require.NoError(t, err)
+		require.Equal(t, "my-config", status.Metadata.Name)
+		require.Equal(t, model.RolloutStatusStarted, status.Status.Rollout.Status)
+	}
+	require.Equal(t, int32(1), full.Load())
+	require.Equal(t, int32(2), notModified.Load())
+
+	// The cache is per endpoint
+	_, err = c.RolloutStatus(context.Background(), "other-config")
+	require.NoError(t, err)
+	require.Equal(t, int32(2), full.Load())
+
+	// A changed ETag downloads the configuration again
+	etag = `"v2"`
+	_, err = c.RolloutStatus(context.Background(), "my-config")
+	require.NoError(t, err)
+	require.Equal(t, int32(3), full.Load())
+
+	for range 2 {
+		raw, err := c.RawConfiguration(context.Background(), "my-config")
+		require.NoError(t, err)
+		require.Equal(t, "raw config", raw)
+	}
+}
+
+func TestRetryOptions(t *testing.T) {
+	cases := []struct {
+		name                string
+		opts                []Option
+		expectAttempts      int
+		expectElapsed       time.Duration
+		expectRetryStatuses []int
+	}{
+		{
+			"Defaults",
+			nil,
+			DefaultRetryMaxAttempts,
+			DefaultRetryMaxElapsedTime,
+			nil,
+		},
+		{
+			"Custom",
+			[]Option{
+				WithRetryMaxAttempts(1),
+				WithRetryMaxElapsedTime(time.Second),
+				WithRetryStatusCodes([]int{429, 503}),
+			},
+			1,
+			time.Second,
+			[]int{429, 503},
+		},
+		{
+			"Invalid values ignored",
+			[]Option{
+				WithRetryMaxAttempts(0),
+				WithRetryMaxElapsedTime(-time.Second),
+			},
+			DefaultRetryMaxAttempts,
+			DefaultRetryMaxElapsedTime,
+			nil,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := NewBindPlane(&config.Config{}, zap.NewNop(), tc.opts...)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectAttempts, c.retryMaxAttempts)
+			require.Equal(t, tc.expectAttempts-1, c.client.RetryCount)
+			require.Equal(t, tc.expectElapsed, c.retryMaxElapsedTime)
+			require.Equal(t, tc.expectRetryStatuses, c.retryStatusCodes)
+		})
+	}
+}
+
+func TestRetryMaxElapsedTime(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		attempts.Add(1)
+		time.Sleep(time.Millisecond * 20)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	c, err := NewBindPlane(&config.Config{
+		Network: config.Network{
+			RemoteURL: server.URL,
+		},
+	}, zap.NewNop(), WithRetryMaxAttempts(100), WithRetryMaxElapsedTime(time.Millisecond*50))
+	require.NoError(t, err)
+
+	c.client.SetRetryWaitTime(time.Millisecond)
+	c.client.SetRetryMaxWaitTime(time.Millisecond)
+
+	_, err = c.Version(context.Background())
+	require.Error(t, err)
+	require.Less(t, attempts.Load(), int32(10))
+}
+
+func TestRetryIdempotent(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	c, err := NewBindPlane(&config.Config{
+		Network: config.Network{
+			RemoteURL: server.URL,
+		},
+	}, zap.NewNop(), WithRetryMaxAttempts(3))
+	require.NoError(t, err)
+
+	c.client.SetRetryWaitTime(time.Millisecond)
+	c.client.SetRetryMaxWaitTime(time.Millisecond)
+
+	cases := []struct {
+		name           string
+		request        func() error
+		expectAttempts int32
+	}{
+		{
+			"Starting a rollout is not retried",
+			func() error { return c.StartRollout(context.Background(), "gateway") },
+			1,
+		},
+		{
+			"Apply is retried",
+			func() error {
+				_, err := c.Apply(context.Background(), []*model.AnyResource{{}})
+				return err
+			},
+			3,
+		},
+		{
+			"GET is retried",
+			func() error {
+				_, err := c.Version(context.Background())
+				return err
+			},
+			3,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			attempts.Store(0)
+			require.ErrorIs(t, tc.request(), ErrServer)
+			require.Equal(t, tc.expectAttempts, attempts.Load())
+		})
+	}
+}
+
+func TestRetryConditionNotIdempotent(t *testing.T) {
+	c := &BindPlane{}
+	post := &resty.Request{Method: http.MethodPost}
+	post.SetContext(context.Background())
+	response := func(status int, retryAfter string) *resty.Response {
+		header := http.Header{}
+		if retryAfter != "" {
+			header.Set("Retry-After", retryAfter)
+		}
+		return &resty.Response{Request: post, RawResponse: &http.Response{StatusCode: status, Header: header}}
+	}
+
+	require.False(t, c.retryCondition(response(http.StatusBadGateway, ""), nil))
+	require.False(t, c.retryCondition(response(http.StatusServiceUnavailable, ""), nil))
+	require.True(t, c.retryCondition(response(http.StatusServiceUnavailable, "1"), nil))
+	require.True(t, c.retryCondition(response(http.StatusTooManyRequests, "1"), nil))
+
+	// Only connection errors before the request was sent are retried
+	require.True(t, c.retryCondition(&resty.Response{Request: post}, fmt.Errorf("dial: %w", syscall.ECONNREFUSED)))
+	require.True(t, c.retryCondition(&resty.Response{Request: post}, &net.OpError{Op: "dial", Err: timeoutError{}}))
+	require.False(t, c.retryCondition(&resty.Response{Request: post}, fmt.Errorf("read: %w", syscall.ECONNRESET)))
+	require.False(t, c.retryCondition(&resty.Response{Request: post}, fmt.Errorf("request: %w", timeoutError{})))
+}
+
+// testKeyPair returns a PEM encoded self signed certificate and private key
+func testKeyPair(t *testing.T) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return string(certPEM), string(keyPEM)
+}
+
+func TestLoadKeyPair(t *testing.T) {
+	cert, key := testKeyPair(t)
+
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "client.crt")
+	keyPath := filepath.Join(dir, "client.key")
+	require.NoError(t, os.WriteFile(certPath, []byte(cert), 0600))
+	require.NoError(t, os.WriteFile(keyPath, []byte(key), 0600))
+
+	cases := []struct {
+		name   string
+		cert   string
+		key    string
+		errStr string
+	}{
+		{
+			"PEM content",
+			cert,
+			key,
+			"",
+		},
+		{
+			"File paths",
+			certPath,
+			keyPath,
+			"",
+		},
+		{
+			"Mixed",
+			certPath,
+			key,
+			"",
+		},
+		{
+			"Missing key",
+			cert,
+			"",
+			"both certificate and private key are required",
+		},
+		{
+			"Missing file",
+			filepath.Join(dir, "missing.crt"),
+			key,
+			"certificate: read file",
+		},
+		{
+			"Mismatched pair",
+			key,
+			cert,
+			"tls:",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := loadKeyPair(tc.cert, tc.key)
+			if tc.errStr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.errStr)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, c.Certificate, 1)
+		})
+	}
+}
+
+func TestNewBindPlaneClientCertificate(t *testing.T) {
+	cert, key := testKeyPair(t)
+
+	c, err := NewBindPlane(&config.Config{
+		Network: config.Network{
+			RemoteURL: "https://localhost:3001",
+			TLS: config.TLS{
+				Certificate: cert,
+				PrivateKey:  key,
+			},
+		},
+	}, zap.NewNop())
+	require.NoError(t, err)
+
+	transport, err := c.client.Transport()
+	require.NoError(t, err)
+	require.Len(t, transport.TLSClientConfig.Certificates, 1)
+}
+
+func TestNewBindPlaneInsecureSkipVerify(t *testing.T) {
+	c, err := NewBindPlane(&config.Config{
+		Network: config.Network{
+			RemoteURL: "https://localhost:3001",
+			TLS: config.TLS{
+				InsecureSkipVerify: true,
+			},
+		},
+	}, zap.NewNop())
+	require.NoError(t, err)
+
+	transport, err := c.client.Transport()
+	require.NoError(t, err)
+	require.True(t, transport.TLSClientConfig.InsecureSkipVerify)
+}
+
+func TestTLSVersion(t *testing.T) {
+	cases := []struct {
+		input  string
+		expect uint16
+		errStr string
+	}{
+		{"", tls.VersionTLS13, ""},
+		{"1.3", tls.VersionTLS13, ""},
+		{"1.2", tls.VersionTLS12, ""},
+		{"1.1", 0, "unsupported TLS version '1.1', must be 1.2 or 1.3"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.input, func(t *testing.T) {
+			v, err := TLSVersion(tc.input)
+			if tc.errStr != "" {
+				require.Error(t, err)
+				require.Equal(t, tc.errStr, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, v)
+		})
+	}
+}
+
+func TestCipherSuites(t *testing.T) {
+	ids, err := CipherSuites(nil)
+	require.NoError(t, err)
+	require.Nil(t, ids)
+
+	ids, err = CipherSuites([]string{
+		"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256",
+		"TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384",
+	})
+	require.NoError(t, err)
+	require.Equal(t, []uint16{
+		tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+		tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	}, ids)
+
+	_, err = CipherSuites([]string{"TLS_RSA_WITH_RC4_128_SHA"})
+	require.Error(t, err)
+	require.Equal(t, "unsupported cipher suite 'TLS_RSA_WITH_RC4_128_SHA'", err.Error())
+
+	_, err = CipherSuites([]string{"TLS_AES_128_GCM_SHA256"})
+	require.Error(t, err)
+	require.Equal(t, "cipher suite 'TLS_AES_128_GCM_SHA256' is a TLS 1.3 cipher suite, which is not configurable", err.Error())
+}
+
+func TestNewBindPlaneTLSVersion(t *testing.T) {
+	c, err := NewBindPlane(&config.Config{
+		Network: config.Network{
+			RemoteURL: "https://localhost:3001",
+			TLS: config.TLS{
+				MinVersion:   "1.2",
+				CipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"},
+			},
+		},
+	}, zap.NewNop())
+	require.NoError(t, err)
+
+	transport, err := c.client.Transport()
+	require.NoError(t, err)
+	require.Equal(t, uint16(tls.VersionTLS12), transport.TLSClientConfig.MinVersion)
+	require.Equal(t, []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256}, transport.TLSClientConfig.CipherSuites)
+
+	_, err = NewBindPlane(&config.Config{
+		Network: config.Network{
+			TLS: config.TLS{
+				MinVersion: "1.0",
+			},
+		},
+	}, zap.NewNop())
+	require.Error(t, err)
+
+	// Cipher suites are ignored by TLS 1.3, the default minimum version
+	_, err = NewBindPlane(&config.Config{
+		Network: config.Network{
+			TLS: config.TLS{
+				CipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"},
+			},
+		},
+	}, zap.NewNop())
+	require.ErrorContains(t, err, "cipher suites require TLS minimum version 1.2")
+}
+
+func TestConnectionPool(t *testing.T) {
+	c, err := NewBindPlane(&config.Config{}, zap.NewNop())
+	require.NoError(t, err)
+	transport, err := c.client.Transport()
+	require.NoError(t, err)
+	defaultIdleConnTimeout := transport.IdleConnTimeout
+	require.False(t, transport.DisableKeepAlives)
+
+	c, err = NewBindPlane(&config.Config{}, zap.NewNop(),
+		WithMaxIdleConns(4),
+		WithIdleConnTimeout(15*time.Second),
+		WithDisableKeepAlives(true),
+	)
+	require.NoError(t, err)
+	transport, err = c.client.Transport()
+	require.NoError(t, err)
+	require.Equal(t, 4, transport.MaxIdleConns)
+	require.Equal(t, 4, transport.MaxIdleConnsPerHost)
+	require.Equal(t, 15*time.Second, transport.IdleConnTimeout)
+	require.True(t, transport.DisableKeepAlives)
+
+	// Values less than or equal to 0 use the transport defaults
+	c, err = NewBindPlane(&config.Config{}, zap.NewNop(), WithMaxIdleConns(-1), WithIdleConnTimeout(0))
+	require.NoError(t, err)
+	transport, err = c.client.Transport()
+	require.NoError(t, err)
+	require.Positive(t, transport.MaxIdleConns)
+	require.Equal(t, defaultIdleConnTimeout, transport.IdleConnTimeout)
+}
+
+func TestAppendCertificateAuthority(t *testing.T) {
+	ca, key := testKeyPair(t)
+	ca2, _ := testKeyPair(t)
+
+	dir := t.TempDir()
+	caPath := filepath.Join(dir, "ca.crt")
+	require.NoError(t, os.WriteFile(caPath, []byte(ca), 0600))
+
+	caDir := filepath.Join(dir, "cas")
+	require.NoError(t, os.MkdirAll(filepath.Join(caDir, "..data"), 0750))
+	require.NoError(t, os.WriteFile(filepath.Join(caDir, "a.crt"), []byte(ca), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(caDir, "b.pem"), []byte(ca2), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(caDir, "tls.key"), []byte(key), 0600))
+
+	emptyDir := filepath.Join(dir, "empty")
+	require.NoError(t, os.MkdirAll(emptyDir, 0750))
+
+	keyPath := filepath.Join(dir, "tls.key")
+	require.NoError(t, os.WriteFile(keyPath, []byte(key), 0600))
+
+	cases := []struct {
+		name        string
+		input       string
+		expectCount int
+		errStr      string
+	}{
+		{
+			"PEM content",
+			ca,
+			1,
+			"",
+		},
+		{
+			"File",
+			caPath,
+			1,
+			"",
+		},
+		{
+			"Directory",
+			caDir,
+			2,
+			"",
+		},
+		{
+			"Empty directory",
+			emptyDir,
+			0,
+			"no certificates found in directory",
+		},
+		{
+			"File without certificates",
+			keyPath,
+			0,
+			"no certificates found in file",
+		},
+		{
+			"Missing path",
+			filepath.Join(dir, "missing"),
+			0,
+			"no such file or directory",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			pool := x509.NewCertPool()
+			err := appendCertificateAuthority(pool, tc.input)
+			if tc.errStr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.errStr)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, pool.Subjects(), tc.expectCount)
+		})
+	}
+}
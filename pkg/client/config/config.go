@@ -0,0 +1,45 @@
+package config
+
+type Config struct {
+	Auth    Auth
+	Network Network
+}
+
+type Auth struct {
+	APIKey   string
+	Username string
+	Password string
+
+	// AccountID and ProjectID select the account and project used by
+	// BindPlane Cloud and multi-project deployments. Optional.
+	AccountID string
+	ProjectID string
+}
+
+type Network struct {
+	RemoteURL            string
+	CertificateAuthority []string
+	TLS
+}
+
+type TLS struct {
+	CertificateAuthority []string
+
+	// Certificate and PrivateKey are used for mutual TLS. Each can
+	// be PEM encoded content or a path to a PEM encoded file.
+	Certificate string
+	PrivateKey  string
+
+	// MinVersion is the minimum TLS version, either 1.2 or 1.3.
+	// Defaults to 1.3.
+	MinVersion string
+
+	// CipherSuites is a list of cipher suite names used with TLS 1.2. When
+	// empty, Go's default cipher suites are used. TLS 1.3 cipher suites are
+	// not configurable.
+	CipherSuites []string
+
+	// InsecureSkipVerify disables server certificate verification. This
+	// should only be used with ephemeral test environments.
+	InsecureSkipVerify bool
+}
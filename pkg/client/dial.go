@@ -0,0 +1,150 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+)
+
+// IP families, see WithIPFamily
+const (
+	// IPFamilyIPv4 connects to the IPv4 addresses of BindPlane first
+	IPFamilyIPv4 = "ipv4"
+
+	// IPFamilyIPv6 connects to the IPv6 addresses of BindPlane first
+	IPFamilyIPv6 = "ipv6"
+)
+
+const (
+	// dialTimeout and dialKeepAlive match the dialer of the default transport
+	dialTimeout   = 30 * time.Second
+	dialKeepAlive = 30 * time.Second
+
+	// dnsTimeout is the timeout of a connection to the DNS server
+	dnsTimeout = 5 * time.Second
+)
+
+// WithDNSServer sets the DNS server which resolves the BindPlane hostname,
+// such as 10.0.0.2 or 10.0.0.2:53, instead of the system resolver. The port
+// defaults to 53.
+func WithDNSServer(addr string) Option {
+	return func(b *BindPlane) {
+		b.dnsServer = addr
+	}
+}
+
+// WithIPFamily sets the IP family, ipv4 or ipv6, whose addresses are
+// connected to first when the BindPlane hostname resolves to both.
+// Addresses of the other family are tried if none can be connected to.
+// When empty, the order of the resolver is used.
+func WithIPFamily(f string) Option {
+	return func(b *BindPlane) {
+		b.ipFamily = f
+	}
+}
+
+// ValidateIPFamily returns an error if f is not empty, ipv4, or ipv6
+func ValidateIPFamily(f string) error {
+	switch f {
+	case "", IPFamilyIPv4, IPFamilyIPv6:
+		return nil
+	}
+	return fmt.Errorf("invalid IP family %s, must be %s or %s", f, IPFamilyIPv4, IPFamilyIPv6)
+}
+
+// DNSServerAddr returns the address of a DNS server, adding
+// the default port 53 when addr does not have a port
+func DNSServerAddr(addr string) (string, error) {
+	if _, _, err := net.SplitHostPort(addr); err == nil {
+		return addr, nil
+	}
+	host := strings.Trim(addr, "[]")
+	if net.ParseIP(host) == nil {
+		return "", fmt.Errorf("invalid DNS server %s, must be an IP address with an optional port, such as 10.0.0.2:53", addr)
+	}
+	return net.JoinHostPort(host, "53"), nil
+}
+
+// dialContext returns the function the transport opens connections with,
+// or nil when neither a DNS server nor an IP family is set, so the
+// transport's default dialer is used
+func (c *BindPlane) dialContext() (func(ctx context.Context, network, addr string) (net.Conn, error), error) {
+	if c.dnsServer == "" && c.ipFamily == "" {
+		return nil, nil
+	}
+	if err := ValidateIPFamily(c.ipFamily); err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   dialTimeout,
+		KeepAlive: dialKeepAlive,
+		Resolver:  net.DefaultResolver,
+	}
+	if c.dnsServer != "" {
+		server, err := DNSServerAddr(c.dnsServer)
+		if err != nil {
+			return nil, err
+		}
+		dialer.Resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				d := net.Dialer{Timeout: dnsTimeout}
+				return d.DialContext(ctx, network, server)
+			},
+		}
+	}
+
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil || net.ParseIP(host) != nil {
+			return dialer.DialContext(ctx, network, addr)
+		}
+
+		ips, err := dialer.Resolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		sortByFamily(ips, c.ipFamily)
+
+		// Addresses are tried in order, like the default dialer
+		// does for addresses of the same family
+		errs := []error{}
+		for _, ip := range ips {
+			conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), port))
+			if err == nil {
+				return conn, nil
+			}
+			errs = append(errs, err)
+			if ctx.Err() != nil {
+				break
+			}
+		}
+		if len(errs) == 0 {
+			return nil, fmt.Errorf("no addresses found for %s", host)
+		}
+		return nil, errors.Join(errs...)
+	}, nil
+}
+
+// sortByFamily moves the addresses of family before the others,
+// keeping the order of the resolver within each family
+func sortByFamily(ips []net.IPAddr, family string) {
+	if family == "" {
+		return
+	}
+	rank := func(ip net.IPAddr) int {
+		isIPv4 := ip.IP.To4() != nil
+		if isIPv4 == (family == IPFamilyIPv4) {
+			return 0
+		}
+		return 1
+	}
+	slices.SortStableFunc(ips, func(a, b net.IPAddr) int {
+		return rank(a) - rank(b)
+	})
+}
@@ -0,0 +1,178 @@
+package client
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/observiq/bindplane-op-action/pkg/client/config"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestValidateIPFamily(t *testing.T) {
+	for _, f := range []string{"", IPFamilyIPv4, IPFamilyIPv6} {
+		require.NoError(t, ValidateIPFamily(f))
+	}
+	require.EqualError(t, ValidateIPFamily("ip4"), "invalid IP family ip4, must be ipv4 or ipv6")
+}
+
+func TestDNSServerAddr(t *testing.T) {
+	cases := []struct {
+		addr      string
+		expect    string
+		expectErr bool
+	}{
+		{"10.0.0.2", "10.0.0.2:53", false},
+		{"10.0.0.2:5353", "10.0.0.2:5353", false},
+		{"fd00::2", "[fd00::2]:53", false},
+		{"[fd00::2]:5353", "[fd00::2]:5353", false},
+		{"dns.example.com", "", true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.addr, func(t *testing.T) {
+			addr, err := DNSServerAddr(tc.addr)
+			if tc.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, addr)
+		})
+	}
+}
+
+func TestSortByFamily(t *testing.T) {
+	addrs := func(ips ...string) []net.IPAddr {
+		out := []net.IPAddr{}
+		for _, ip := range ips {
+			out = append(out, net.IPAddr{IP: net.ParseIP(ip)})
+		}
+		return out
+	}
+
+	ips := addrs("fd00::1", "10.0.0.1", "fd00::2", "10.0.0.2")
+	sortByFamily(ips, IPFamilyIPv4)
+	require.Equal(t, addrs("10.0.0.1", "10.0.0.2", "fd00::1", "fd00::2"), ips)
+
+	sortByFamily(ips, IPFamilyIPv6)
+	require.Equal(t, addrs("fd00::1", "fd00::2", "10.0.0.1", "10.0.0.2"), ips)
+
+	sortByFamily(ips, "")
+	require.Equal(t, addrs("fd00::1", "fd00::2", "10.0.0.1", "10.0.0.2"), ips)
+}
+
+func TestDNSServer(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/version", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"tag":"v1.80.0"}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+
+	// bindplane.test resolves to ::1, which the server does not
+	// listen on, so the preferred IPv6 address falls back to IPv4
+	queries := &atomic.Int32{}
+	dns := newTestDNSServer(t, "bindplane.test.", queries)
+
+	c, err := NewBindPlane(&config.Config{
+		Network: config.Network{
+			RemoteURL: "http://bindplane.test:" + port,
+		},
+	}, zap.NewNop(), WithRetryMaxAttempts(1), WithDNSServer(dns), WithIPFamily(IPFamilyIPv6))
+	require.NoError(t, err)
+
+	v, err := c.Version(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "v1.80.0", v.Tag)
+	require.Positive(t, queries.Load())
+
+	_, err = NewBindPlane(&config.Config{}, zap.NewNop(), WithDNSServer("dns.example.com"))
+	require.ErrorContains(t, err, "invalid DNS server")
+
+	_, err = NewBindPlane(&config.Config{}, zap.NewNop(), WithIPFamily("ip4"))
+	require.ErrorContains(t, err, "invalid IP family")
+}
+
+// newTestDNSServer starts a UDP DNS server which answers A queries for
+// name with 127.0.0.1 and AAAA queries with ::1, and returns its address
+func newTestDNSServer(t *testing.T, name string, queries *atomic.Int32) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if resp := dnsResponse(buf[:n], name); resp != nil {
+				queries.Add(1)
+				_, _ = conn.WriteTo(resp, addr)
+			}
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+// dnsResponse answers a DNS query for name, or returns nil if the query is invalid
+func dnsResponse(query []byte, name string) []byte {
+	if len(query) < 12 {
+		return nil
+	}
+
+	// The question is a sequence of labels, followed by the type and class
+	labels := []string{}
+	i := 12
+	for i < len(query) && query[i] != 0 {
+		l := int(query[i])
+		if i+1+l > len(query) {
+			return nil
+		}
+		labels = append(labels, string(query[i+1:i+1+l]))
+		i += 1 + l
+	}
+	end := i + 5
+	if end > len(query) {
+		return nil
+	}
+	qtype := binary.BigEndian.Uint16(query[i+1:])
+
+	var rdata []byte
+	if strings.EqualFold(strings.Join(labels, ".")+".", name) {
+		switch qtype {
+		case 1:
+			rdata = net.ParseIP("127.0.0.1").To4()
+		case 28:
+			rdata = net.ParseIP("::1").To16()
+		}
+	}
+
+	resp := append([]byte{}, query[:end]...)
+	binary.BigEndian.PutUint16(resp[2:], 0x8180)
+	binary.BigEndian.PutUint16(resp[8:], 0)
+	binary.BigEndian.PutUint16(resp[10:], 0)
+	if rdata == nil {
+		binary.BigEndian.PutUint16(resp[6:], 0)
+		return resp
+	}
+
+	binary.BigEndian.PutUint16(resp[6:], 1)
+	resp = append(resp, 0xc0, 0x0c)
+	resp = binary.BigEndian.AppendUint16(resp, qtype)
+	resp = binary.BigEndian.AppendUint16(resp, 1)
+	resp = binary.BigEndian.AppendUint32(resp, 60)
+	resp = binary.BigEndian.AppendUint16(resp, uint16(len(rdata)))
+	return append(resp, rdata...)
+}
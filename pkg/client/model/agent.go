@@ -0,0 +1,123 @@
+package model
+
+import "time"
+
+type AgentSelector struct {
+	MatchLabels `json:"matchLabels" yaml:"matchLabels" mapstructure:"matchLabels"`
+}
+
+// AgentsResponse is the response from the agents endpoint
+type AgentsResponse struct {
+	Agents []*Agent `json:"agents"`
+}
+
+// AgentResponse is the response from the agents/{id} endpoint
+type AgentResponse struct {
+	Agent *Agent `json:"agent"`
+}
+
+// Agent is an agent connected to BindPlane
+type Agent struct {
+	ID                  string                   `json:"id" yaml:"id" mapstructure:"id"`
+	Name                string                   `json:"name" yaml:"name" mapstructure:"name"`
+	Labels              map[string]string        `json:"labels,omitempty" yaml:"labels,omitempty" mapstructure:"labels"`
+	Status              AgentStatus              `json:"status" yaml:"status" mapstructure:"status"`
+	ConfigurationStatus AgentConfigurationStatus `json:"configurationStatus" yaml:"configurationStatus" mapstructure:"configurationStatus"`
+
+	// Version is the version of the agent, such as v1.60.0
+	Version string `json:"version,omitempty" yaml:"version,omitempty" mapstructure:"version"`
+
+	// ErrorMessage is the last error reported by the agent
+	ErrorMessage string `json:"errorMessage,omitempty" yaml:"errorMessage,omitempty" mapstructure:"errorMessage"`
+
+	ConnectedAt    *time.Time `json:"connectedAt,omitempty" yaml:"connectedAt,omitempty" mapstructure:"connectedAt"`
+	DisconnectedAt *time.Time `json:"disconnectedAt,omitempty" yaml:"disconnectedAt,omitempty" mapstructure:"disconnectedAt"`
+}
+
+// AgentConfigurationResponse is the response from the agents/{id}/configuration
+// endpoint. Raw is the configuration rendered for the agent, which includes
+// agent specific values, such as overrides set by the agent's labels.
+type AgentConfigurationResponse struct {
+	Configuration *Configuration `json:"configuration"`
+	Raw           string         `json:"raw"`
+}
+
+// DeleteAgentsPayload is the request body of the agents endpoint's
+// DELETE method. The response is an AgentsResponse of the deleted agents.
+type DeleteAgentsPayload struct {
+	IDs []string `json:"ids"`
+}
+
+// BulkAgentLabelsPayload is the request body of the agents/labels
+// endpoint, which adds labels to many agents
+type BulkAgentLabelsPayload struct {
+	IDs       []string          `json:"ids"`
+	Labels    map[string]string `json:"labels"`
+	Overwrite bool              `json:"overwrite"`
+}
+
+// BulkAgentLabelsResponse is the response from the agents/labels endpoint
+type BulkAgentLabelsResponse struct {
+	Errors []string `json:"errors"`
+}
+
+// AgentLabelsPayload is the request body of the agents/{id}/labels
+// endpoint, which replaces the labels of an agent
+type AgentLabelsPayload struct {
+	Labels map[string]string `json:"labels"`
+}
+
+// AgentConfigurationStatus is the configuration version, such as
+// gateway:3, an agent is running, and the version being rolled out to it
+type AgentConfigurationStatus struct {
+	Current string `json:"current" yaml:"current" mapstructure:"current"`
+	Pending string `json:"pending,omitempty" yaml:"pending,omitempty" mapstructure:"pending"`
+	Future  string `json:"future,omitempty" yaml:"future,omitempty" mapstructure:"future"`
+}
+
+const (
+	// AgentStatusDisconnected is an agent which is not connected to BindPlane
+	AgentStatusDisconnected AgentStatus = 0
+
+	// AgentStatusConnected is a connected and healthy agent
+	AgentStatusConnected AgentStatus = 1
+
+	// AgentStatusError is an agent which reported an error
+	AgentStatusError AgentStatus = 2
+
+	// AgentStatusComponentFailed is an agent with a failed component
+	AgentStatusComponentFailed AgentStatus = 4
+
+	// AgentStatusDeleted is an agent which was deleted
+	AgentStatusDeleted AgentStatus = 5
+
+	// AgentStatusConfiguring is an agent receiving a new configuration
+	AgentStatusConfiguring AgentStatus = 6
+
+	// AgentStatusUpgrading is an agent being upgraded
+	AgentStatusUpgrading AgentStatus = 7
+)
+
+type AgentStatus int
+
+// String returns the name of the agent status
+func (s AgentStatus) String() string {
+	switch s {
+	case AgentStatusDisconnected:
+		return "disconnected"
+	case AgentStatusConnected:
+		return "connected"
+	case AgentStatusError:
+		return "error"
+	case AgentStatusComponentFailed:
+		return "component failed"
+	case AgentStatusDeleted:
+		return "deleted"
+	case AgentStatusConfiguring:
+		return "configuring"
+	case AgentStatusUpgrading:
+		return "upgrading"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,43 @@
+package model
+
+// AgentVersionsResponse is the response from the agent-versions endpoint
+type AgentVersionsResponse struct {
+	AgentVersions []*AgentVersion `json:"agentVersions"`
+}
+
+// AgentVersionResponse is the response from the agent-versions/{name} endpoint
+type AgentVersionResponse struct {
+	AgentVersion *AgentVersion `json:"agentVersion"`
+}
+
+// AgentVersion is a version of the collector which can be installed on agents
+type AgentVersion struct {
+	ResourceMeta `yaml:",inline" mapstructure:",squash"`
+	Spec         AgentVersionSpec `json:"spec" yaml:"spec" mapstructure:"spec"`
+}
+
+// AgentVersionSpec is the specification of an agent version
+type AgentVersionSpec struct {
+	// Type is the agent type, such as observiq-otel-collector
+	Type            string `json:"type" yaml:"type" mapstructure:"type"`
+	Version         string `json:"version" yaml:"version" mapstructure:"version"`
+	ReleaseNotesURL string `json:"releaseNotesURL,omitempty" yaml:"releaseNotesURL,omitempty" mapstructure:"releaseNotesURL"`
+	ReleaseDate     string `json:"releaseDate,omitempty" yaml:"releaseDate,omitempty" mapstructure:"releaseDate"`
+	Draft           bool   `json:"draft,omitempty" yaml:"draft,omitempty" mapstructure:"draft"`
+	Prerelease      bool   `json:"prerelease,omitempty" yaml:"prerelease,omitempty" mapstructure:"prerelease"`
+
+	// Installer and Download are keyed by platform, such as linux-amd64
+	Installer map[string]AgentVersionInstaller `json:"installer,omitempty" yaml:"installer,omitempty" mapstructure:"installer"`
+	Download  map[string]AgentVersionDownload  `json:"download,omitempty" yaml:"download,omitempty" mapstructure:"download"`
+}
+
+// AgentVersionInstaller is the installer for a platform
+type AgentVersionInstaller struct {
+	URL string `json:"url,omitempty" yaml:"url,omitempty" mapstructure:"url"`
+}
+
+// AgentVersionDownload is the agent package for a platform
+type AgentVersionDownload struct {
+	URL  string `json:"url,omitempty" yaml:"url,omitempty" mapstructure:"url"`
+	Hash string `json:"hash,omitempty" yaml:"hash,omitempty" mapstructure:"hash"`
+}
@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+// AuditEventAction is the change recorded by an audit event
+type AuditEventAction string
+
+const (
+	AuditEventActionCreated        AuditEventAction = "Created"
+	AuditEventActionModified       AuditEventAction = "Modified"
+	AuditEventActionDeleted        AuditEventAction = "Deleted"
+	AuditEventActionPending        AuditEventAction = "Pending"
+	AuditEventActionDeployed       AuditEventAction = "Deployed"
+	AuditEventActionRolloutStarted AuditEventAction = "RolloutStarted"
+	AuditEventActionRolloutPaused  AuditEventAction = "RolloutPaused"
+	AuditEventActionRolloutResumed AuditEventAction = "RolloutResumed"
+)
+
+// AuditEvent is an entry in the BindPlane audit log
+type AuditEvent struct {
+	ID            string           `json:"id" yaml:"id" mapstructure:"id"`
+	Timestamp     *time.Time       `json:"timestamp" yaml:"timestamp" mapstructure:"timestamp"`
+	ResourceName  string           `json:"resourceName" yaml:"resourceName" mapstructure:"resourceName"`
+	ResourceKind  Kind             `json:"resourceKind" yaml:"resourceKind" mapstructure:"resourceKind"`
+	Configuration string           `json:"configuration" yaml:"configuration" mapstructure:"configuration"`
+	Action        AuditEventAction `json:"action" yaml:"action" mapstructure:"action"`
+	User          string           `json:"user" yaml:"user" mapstructure:"user"`
+}
+
+// AuditEventsResponse is the response from the audit events endpoint
+type AuditEventsResponse struct {
+	AuditEvents []*AuditEvent `json:"auditEvents"`
+}
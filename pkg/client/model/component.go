@@ -0,0 +1,34 @@
+package model
+
+// Destination is a destination resource
+type Destination struct {
+	ResourceMeta `yaml:",inline" mapstructure:",squash"`
+	Spec         ParameterizedSpec `json:"spec" yaml:"spec" mapstructure:"spec"`
+}
+
+// DestinationResponse is the response from the destinations/{name} endpoint
+type DestinationResponse struct {
+	Destination *Destination `json:"destination"`
+}
+
+// Source is a source resource
+type Source struct {
+	ResourceMeta `yaml:",inline" mapstructure:",squash"`
+	Spec         ParameterizedSpec `json:"spec" yaml:"spec" mapstructure:"spec"`
+}
+
+// SourceResponse is the response from the sources/{name} endpoint
+type SourceResponse struct {
+	Source *Source `json:"source"`
+}
+
+// Processor is a processor resource
+type Processor struct {
+	ResourceMeta `yaml:",inline" mapstructure:",squash"`
+	Spec         ParameterizedSpec `json:"spec" yaml:"spec" mapstructure:"spec"`
+}
+
+// ProcessorResponse is the response from the processors/{name} endpoint
+type ProcessorResponse struct {
+	Processor *Processor `json:"processor"`
+}
@@ -7,4 +7,5 @@ const (
 	KindSource        Kind = "Source"
 	KindProcessor     Kind = "Processor"
 	KindDestination   Kind = "Destination"
+	KindAgentVersion  Kind = "AgentVersion"
 )
@@ -0,0 +1,31 @@
+package model
+
+import (
+	"encoding/json"
+
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+type MatchLabels map[string]string
+
+type Labels struct {
+	labels.Set `json:"-" yaml:",inline"`
+}
+
+// MarshalJSON encodes the labels as an object of label names to values
+func (l Labels) MarshalJSON() ([]byte, error) {
+	if l.Set == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(map[string]string(l.Set))
+}
+
+// UnmarshalJSON decodes an object of label names to values
+func (l *Labels) UnmarshalJSON(data []byte) error {
+	set := map[string]string{}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return err
+	}
+	l.Set = set
+	return nil
+}
@@ -0,0 +1,34 @@
+package model
+
+// Metric is a throughput measurement of a component of a configuration,
+// such as the bytes per second of logs sent to a destination
+type Metric struct {
+	Name         string  `json:"name" yaml:"name" mapstructure:"name"`
+	NodeID       string  `json:"nodeID" yaml:"nodeID" mapstructure:"nodeID"`
+	PipelineType string  `json:"pipelineType" yaml:"pipelineType" mapstructure:"pipelineType"`
+	Value        float64 `json:"value" yaml:"value" mapstructure:"value"`
+	Unit         string  `json:"unit" yaml:"unit" mapstructure:"unit"`
+	AgentID      string  `json:"agentID,omitempty" yaml:"agentID,omitempty" mapstructure:"agentID"`
+}
+
+// GraphQLPayload is the request body of the graphql endpoint
+type GraphQLPayload struct {
+	Query     string         `json:"query"`
+	Variables map[string]any `json:"variables,omitempty"`
+}
+
+// GraphQLError is an error returned by the graphql endpoint
+type GraphQLError struct {
+	Message string `json:"message"`
+}
+
+// ConfigurationMetricsResponse is the response from the graphql
+// endpoint to the configurationMetrics query
+type ConfigurationMetricsResponse struct {
+	Data struct {
+		ConfigurationMetrics struct {
+			Metrics []*Metric `json:"metrics"`
+		} `json:"configurationMetrics"`
+	} `json:"data"`
+	Errors []GraphQLError `json:"errors"`
+}
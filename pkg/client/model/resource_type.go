@@ -0,0 +1,24 @@
+package model
+
+// SourceTypesResponse is the response from the source-types endpoint
+type SourceTypesResponse struct {
+	SourceTypes []*ResourceType `json:"sourceTypes"`
+}
+
+// DestinationTypesResponse is the response from the destination-types endpoint
+type DestinationTypesResponse struct {
+	DestinationTypes []*ResourceType `json:"destinationTypes"`
+}
+
+// ResourceType is a source or destination type. The name is the type
+// referenced by resources.
+type ResourceType struct {
+	ResourceMeta `yaml:",inline" mapstructure:",squash"`
+	Spec         ResourceTypeSpec `json:"spec" yaml:"spec" mapstructure:"spec"`
+}
+
+// ResourceTypeSpec is the specification of a resource type. Only the
+// telemetry types, such as Logs, the type sends or accepts are decoded.
+type ResourceTypeSpec struct {
+	TelemetryTypes []string `json:"telemetryTypes,omitempty" yaml:"telemetryTypes,omitempty" mapstructure:"telemetryTypes"`
+}
@@ -27,6 +27,32 @@ const (
 
 type RolloutStatus int
 
+// String returns the name of the rollout status
+func (s RolloutStatus) String() string {
+	switch s {
+	case RolloutStatusPending:
+		return "pending"
+	case RolloutStatusStarted:
+		return "started"
+	case RolloutStatusPaused:
+		return "paused"
+	case RolloutStatusError:
+		return "error"
+	case RolloutStatusStable:
+		return "stable"
+	case RolloutStatusReplaced:
+		return "replaced"
+	default:
+		return "unknown"
+	}
+}
+
+// RolloutsResponse is the response from the rollouts endpoint. Each
+// configuration's rollout is in its status.
+type RolloutsResponse struct {
+	Configurations []*Configuration `json:"configurations"`
+}
+
 type StartRolloutPayload struct {
 	Options *RolloutOptions `json:"options"`
 }
@@ -47,6 +73,9 @@ type RolloutOptions struct {
 	RollbackOnFailure  bool            `json:"rollbackOnFailure" yaml:"rollbackOnFailure" mapstructure:"rollbackOnFailure"`
 	PhaseAgentCount    PhaseAgentCount `json:"phaseAgentCount" yaml:"phaseAgentCount" mapstructure:"phaseAgentCount"`
 	MaxErrors          int             `json:"maxErrors" yaml:"maxErrors" mapstructure:"maxErrors"`
+
+	// Stages roll out to the agents matching each stage's labels in order
+	Stages []RolloutStage `json:"stages,omitempty" yaml:"stages,omitempty" mapstructure:"stages"`
 }
 
 type PhaseAgentCount struct {
@@ -34,6 +34,40 @@ const (
 	StatusDeprecated UpdateStatus = "deprecated"
 )
 
+// UpdateStatuses is the list of all known update statuses
+var UpdateStatuses = []UpdateStatus{
+	StatusUnchanged,
+	StatusConfigured,
+	StatusCreated,
+	StatusDeleted,
+	StatusNotFound,
+	StatusInvalid,
+	StatusError,
+	StatusInUse,
+	StatusForbidden,
+	StatusDeprecated,
+}
+
+// Valid returns true if the status is a known update status
+func (s UpdateStatus) Valid() bool {
+	for _, status := range UpdateStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Succeeded returns true if the status indicates the resource was applied
+func (s UpdateStatus) Succeeded() bool {
+	switch s {
+	case StatusUnchanged, StatusConfigured, StatusCreated:
+		return true
+	default:
+		return false
+	}
+}
+
 type AdditionalInfo struct {
 	Message       string              `json:"message" yaml:"message" mapstructure:"message"`
 	Documentation []DocumentationLink `json:"documentation" yaml:"documentation"`
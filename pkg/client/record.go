@@ -0,0 +1,58 @@
+package client
+
+import (
+	"errors"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// Request is a BindPlane API request, recorded once all of its attempts finish
+type Request struct {
+	Method     string    `json:"method"`
+	URL        string    `json:"url"`
+	StatusCode int       `json:"status_code,omitempty"`
+	RequestID  string    `json:"request_id,omitempty"`
+	Attempts   int       `json:"attempts"`
+	Start      time.Time `json:"start"`
+	DurationMS int64     `json:"duration_ms"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// WithRequestRecorder sets a func which is called with every request once
+// all of its attempts finish, such as to keep an audit record of the API
+// calls made. Requests may be sent concurrently, so f must be safe for
+// concurrent use.
+func WithRequestRecorder(f func(Request)) Option {
+	return func(b *BindPlane) {
+		b.recorder = f
+	}
+}
+
+// recordRequest calls the recorder with a finished request. The response
+// is nil when the request failed without one.
+func (c *BindPlane) recordRequest(r *resty.Request, resp *resty.Response, err error) {
+	var respErr *resty.ResponseError
+	if errors.As(err, &respErr) {
+		resp = respErr.Response
+	}
+
+	req := Request{
+		Method:   r.Method,
+		URL:      r.URL,
+		Attempts: r.Attempt,
+		Start:    time.Now(),
+	}
+	if start, ok := r.Context().Value(requestStartKey{}).(time.Time); ok {
+		req.Start = start
+	}
+	req.DurationMS = time.Since(req.Start).Milliseconds()
+	if resp != nil {
+		req.StatusCode = resp.StatusCode()
+		req.RequestID = requestID(resp.Header())
+	}
+	if err != nil {
+		req.Error = err.Error()
+	}
+	c.recorder(req)
+}
@@ -0,0 +1,70 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/observiq/bindplane-op-action/pkg/client/config"
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRequestRecorder(t *testing.T) {
+	attempts := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/source-types", func(w http.ResponseWriter, _ *http.Request) {
+		attempts++
+		w.Header().Set("X-Request-Id", "req-1")
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(model.SourceTypesResponse{})
+	})
+	server := httptest.NewServer(mux)
+
+	requests := []Request{}
+	c, err := NewBindPlane(&config.Config{
+		Network: config.Network{
+			RemoteURL: server.URL,
+		},
+	}, zap.NewNop(), WithRetryMaxAttempts(2), WithRequestRecorder(func(r Request) {
+		requests = append(requests, r)
+	}))
+	require.NoError(t, err)
+
+	_, err = c.SourceTypes(context.Background())
+	require.NoError(t, err)
+
+	_, err = c.RawConfiguration(context.Background(), "missing")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	server.Close()
+	_, err = c.SourceTypes(context.Background())
+	require.Error(t, err)
+
+	require.Len(t, requests, 3)
+	for _, r := range requests {
+		require.Equal(t, http.MethodGet, r.Method)
+		require.False(t, r.Start.IsZero())
+	}
+
+	require.Equal(t, server.URL+"/v1/source-types", requests[0].URL)
+	require.Equal(t, http.StatusOK, requests[0].StatusCode)
+	require.Equal(t, 2, requests[0].Attempts, "retries are recorded as one request")
+	require.Equal(t, "req-1", requests[0].RequestID)
+	require.Empty(t, requests[0].Error)
+
+	require.Equal(t, server.URL+"/v1/configurations/missing", requests[1].URL)
+	require.Equal(t, http.StatusNotFound, requests[1].StatusCode)
+	require.Equal(t, 1, requests[1].Attempts)
+	require.Empty(t, requests[1].RequestID)
+
+	require.Zero(t, requests[2].StatusCode)
+	require.Contains(t, requests[2].Error, "connection refused")
+}
@@ -0,0 +1,75 @@
+package client
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/observiq/bindplane-op-action/pkg/client/config"
+)
+
+// sensitiveName matches the names of fields and parameters
+// whose values are credentials
+const sensitiveName = `[^"]*(?i:password|passwd|secret|token|api_?key|apikey|credential|private_?key|authorization)[^"]*`
+
+var (
+	// sensitiveField matches a JSON field with a sensitive name
+	// and a string value, such as "password": "abc"
+	sensitiveField = regexp.MustCompile(`("` + sensitiveName + `"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+
+	// sensitiveParameter matches a BindPlane resource parameter with a
+	// sensitive name, such as {"name": "api_key", "value": "abc"}
+	sensitiveParameter = regexp.MustCompile(`("name"\s*:\s*"` + sensitiveName + `"\s*,\s*"value"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+
+	// sensitiveHeader matches a header with a sensitive name,
+	// such as X-Gateway-Token
+	sensitiveHeader = regexp.MustCompile(`^` + sensitiveName + `$`)
+)
+
+// sensitiveHeaders returns the names of the headers whose values
+// are credentials. Dashes are treated as underscores, so X-Api-Key
+// is sensitive.
+func sensitiveHeaders(headers map[string]string) []string {
+	names := []string{}
+	for name := range headers {
+		if sensitiveHeader.MatchString(strings.ReplaceAll(name, "-", "_")) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// redactor removes credentials from response bodies and other text
+// which is included in errors and logs
+type redactor struct {
+	// secrets are the credentials of the client, and the values of
+	// the headers with sensitive names it sends with every request
+	secrets []string
+}
+
+// newRedactor returns a redactor for the credentials of config and the
+// headers with sensitive names. Other header values, such as a tenant
+// name, are not credentials, and are not replaced wherever they appear.
+func newRedactor(c *config.Config, headers map[string]string) *redactor {
+	r := &redactor{}
+	for _, s := range []string{c.Auth.APIKey, c.Auth.Password} {
+		if s != "" {
+			r.secrets = append(r.secrets, s)
+		}
+	}
+	for _, name := range sensitiveHeaders(headers) {
+		if v := headers[name]; v != "" {
+			r.secrets = append(r.secrets, v)
+		}
+	}
+	return r
+}
+
+// redact returns s with the client's credentials, and the values of
+// fields and parameters with sensitive names, replaced by [REDACTED]
+func (r *redactor) redact(s string) string {
+	for _, secret := range r.secrets {
+		s = strings.ReplaceAll(s, secret, redacted)
+	}
+	s = sensitiveParameter.ReplaceAllString(s, `${1}"`+redacted+`"`)
+	return sensitiveField.ReplaceAllString(s, `${1}"`+redacted+`"`)
+}
@@ -0,0 +1,58 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/observiq/bindplane-op-action/pkg/client/config"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRedact(t *testing.T) {
+	r := newRedactor(&config.Config{Auth: config.Auth{APIKey: "key-123", Password: "hunter2"}}, map[string]string{"x-gateway-token": "gateway-token", "X-Api-Key": "gateway-key", "x-tenant": "payments", "x-shard": "1"})
+
+	cases := []struct {
+		name   string
+		input  string
+		expect string
+	}{
+		{"no credentials", `{"error":"configuration gateway not found"}`, `{"error":"configuration gateway not found"}`},
+		{"api key", "invalid api key key-123", "invalid api key [REDACTED]"},
+		{"password and header", "user admin:hunter2 via gateway-token", "user admin:[REDACTED] via [REDACTED]"},
+		{"sensitive field", `{"username":"admin","password":"p@ss\"word","apiKey": "abc"}`, `{"username":"admin","password":"[REDACTED]","apiKey": "[REDACTED]"}`},
+		{"dashed header", "invalid gateway-key", "invalid [REDACTED]"},
+		{"header which is not a credential", `{"error":"tenant payments not found on shard 1"}`, `{"error":"tenant payments not found on shard 1"}`},
+		{"sensitive parameter", `{"parameters":[{"name":"endpoint","value":"otlp:4317"},{"name":"bearer_token","value":"abc"}]}`, `{"parameters":[{"name":"endpoint","value":"otlp:4317"},{"name":"bearer_token","value":"[REDACTED]"}]}`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expect, r.redact(tc.input))
+		})
+	}
+}
+
+func TestStatusErrorRedacted(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid api key "+r.Header.Get(KeyHeader), http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	c, err := NewBindPlane(&config.Config{
+		Auth:    config.Auth{APIKey: "key-123"},
+		Network: config.Network{RemoteURL: server.URL},
+	}, zap.NewNop(), WithRetryMaxAttempts(1))
+	require.NoError(t, err)
+
+	_, err = c.Resources(context.Background(), "Destination")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid api key [REDACTED]")
+	require.NotContains(t, err.Error(), "key-123")
+
+	err = c.StartRollout(context.Background(), "gateway")
+	require.Error(t, err)
+	require.NotContains(t, err.Error(), "key-123")
+}
@@ -0,0 +1,66 @@
+package client
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// retryAfterStatus returns true if the response asks to be retried
+// later with a Retry-After header. Only 429 and 503 responses are
+// considered, as these are the responses the header is defined for.
+func retryAfterStatus(r *resty.Response) bool {
+	switch r.StatusCode() {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return r.Header().Get("Retry-After") != ""
+	}
+	return false
+}
+
+// parseRetryAfter parses the value of a Retry-After header, which is either
+// a number of seconds or an HTTP date, and returns the time to wait from now
+func parseRetryAfter(v string, now time.Time) (time.Duration, bool) {
+	v = strings.TrimSpace(v)
+	if seconds, err := strconv.Atoi(v); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(t.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// retryAfter returns the time to wait before a request is retried. When the
+// response has a Retry-After header, its delay is used, bounded by the time
+// remaining of the max elapsed time. Otherwise, the wait time grows
+// exponentially with jitter, like resty's default backoff.
+func (c *BindPlane) retryAfter(client *resty.Client, r *resty.Response) (time.Duration, error) {
+	if retryAfterStatus(r) {
+		if d, ok := parseRetryAfter(r.Header().Get("Retry-After"), time.Now()); ok {
+			if start, ok := r.Request.Context().Value(requestStartKey{}).(time.Time); ok {
+				d = min(d, c.retryMaxElapsedTime-time.Since(start))
+			}
+			// Resty uses the backoff when the wait time is 0
+			return max(d, time.Nanosecond), nil
+		}
+	}
+
+	// The client's max wait time bounds Retry-After delays, the
+	// backoff is bounded by the default max wait time as well
+	limit := min(client.RetryMaxWaitTime, DefaultRetryMaxWaitTime)
+	d := limit
+	if attempt := r.Request.Attempt - 1; attempt < 16 {
+		d = min(client.RetryWaitTime<<attempt, limit)
+	}
+	if d < 2 {
+		return max(d, time.Nanosecond), nil
+	}
+	return d/2 + rand.N(d/2), nil
+}
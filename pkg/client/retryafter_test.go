@@ -0,0 +1,117 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/observiq/bindplane-op-action/pkg/client/config"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		value    string
+		expect   time.Duration
+		expectOK bool
+	}{
+		{"120", 2 * time.Minute, true},
+		{" 0 ", 0, true},
+		{"Mon, 01 Jan 2024 00:00:30 GMT", 30 * time.Second, true},
+		{"Sun, 31 Dec 2023 23:59:00 GMT", 0, true},
+		{"-1", 0, false},
+		{"soon", 0, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.value, func(t *testing.T) {
+			d, ok := parseRetryAfter(tc.value, now)
+			require.Equal(t, tc.expectOK, ok)
+			require.Equal(t, tc.expect, d)
+		})
+	}
+}
+
+func TestRetryAfterStatus(t *testing.T) {
+	cases := []struct {
+		status     int
+		retryAfter string
+		expect     bool
+	}{
+		{http.StatusTooManyRequests, "1", true},
+		{http.StatusServiceUnavailable, "1", true},
+		{http.StatusTooManyRequests, "", false},
+		{http.StatusBadGateway, "1", false},
+	}
+
+	for _, tc := range cases {
+		header := http.Header{}
+		if tc.retryAfter != "" {
+			header.Set("Retry-After", tc.retryAfter)
+		}
+		r := &resty.Response{RawResponse: &http.Response{StatusCode: tc.status, Header: header}}
+		require.Equal(t, tc.expect, retryAfterStatus(r), tc.status)
+
+		// Retry-After responses are retried even when their
+		// status code is not a retry status code
+		c := &BindPlane{retryStatusCodes: []int{http.StatusBadGateway}}
+		require.Equal(t, tc.expect || tc.status == http.StatusBadGateway, c.retryCondition(r, nil))
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	cases := []struct {
+		name           string
+		retryAfter     string
+		maxElapsedTime time.Duration
+		expectMinWait  time.Duration
+		expectAttempts int32
+		expectErr      bool
+	}{
+		{"delay", "1", DefaultRetryMaxElapsedTime, time.Second, 2, false},
+		{"bounded by max elapsed time", "3600", time.Millisecond * 200, time.Millisecond * 150, 2, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				if attempts.Add(1) == 1 || tc.expectErr {
+					w.Header().Set("Retry-After", tc.retryAfter)
+					w.WriteHeader(http.StatusTooManyRequests)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"tag":"v1.0.0"}`))
+			}))
+			defer server.Close()
+
+			c, err := NewBindPlane(&config.Config{
+				Network: config.Network{
+					RemoteURL: server.URL,
+				},
+			}, zap.NewNop(), WithRetryMaxElapsedTime(tc.maxElapsedTime))
+			require.NoError(t, err)
+			c.client.SetRetryWaitTime(time.Millisecond)
+
+			start := time.Now()
+			_, err = c.Version(context.Background())
+			elapsed := time.Since(start)
+			if tc.expectErr {
+				require.Error(t, err)
+				require.Less(t, elapsed, time.Second)
+			} else {
+				require.NoError(t, err)
+			}
+			require.GreaterOrEqual(t, elapsed, tc.expectMinWait)
+			require.Equal(t, tc.expectAttempts, attempts.Load())
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-resty/resty/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer creates request spans. Spans are only exported when a tracer
+// provider is registered with otel.SetTracerProvider.
+var tracer = otel.Tracer("github.com/observiq/bindplane-op-action/pkg/client")
+
+// requestSpanKey is the context key for the span of a request
+type requestSpanKey struct{}
+
+// startRequestSpan starts a span for a request on its first attempt, and
+// records retries as span events. Requests without a parent span, such as
+// those sent without a context, are not traced.
+func startRequestSpan(_ *resty.Client, r *resty.Request) error {
+	if span, ok := r.Context().Value(requestSpanKey{}).(trace.Span); ok {
+		span.AddEvent("retry", trace.WithAttributes(attribute.Int("http.request.resend_count", r.Attempt-1)))
+		return nil
+	}
+
+	if !trace.SpanContextFromContext(r.Context()).IsValid() {
+		return nil
+	}
+
+	ctx, span := tracer.Start(
+		r.Context(),
+		fmt.Sprintf("%s %s", r.Method, r.URL),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", r.Method),
+			attribute.String("url.path", r.URL),
+		),
+	)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(r.Header))
+	r.SetContext(context.WithValue(ctx, requestSpanKey{}, span))
+	return nil
+}
+
+// endRequestSpan ends the span of a request once all attempts are
+// finished. Error responses mark the span as failed.
+func endRequestSpan(ctx context.Context, resp *resty.Response, err error) {
+	span, ok := ctx.Value(requestSpanKey{}).(trace.Span)
+	if !ok {
+		return
+	}
+	defer span.End()
+
+	var respErr *resty.ResponseError
+	if errors.As(err, &respErr) {
+		resp = respErr.Response
+	}
+
+	if resp != nil {
+		span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode()))
+		if resp.StatusCode() > 399 {
+			span.SetStatus(codes.Error, fmt.Sprintf("status %d", resp.StatusCode()))
+		}
+	}
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
@@ -0,0 +1,102 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/observiq/bindplane-op-action/pkg/client/config"
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/zap"
+)
+
+func TestRequestSpans(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	attempts := 0
+	traceparents := []string{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/source-types", func(w http.ResponseWriter, r *http.Request) {
+		traceparents = append(traceparents, r.Header.Get("Traceparent"))
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(model.SourceTypesResponse{})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c, err := NewBindPlane(&config.Config{
+		Network: config.Network{
+			RemoteURL: server.URL,
+		},
+	}, zap.NewNop(), WithRetryMaxAttempts(2))
+	require.NoError(t, err)
+
+	t.Run("without parent", func(t *testing.T) {
+		exporter.Reset()
+		attempts, traceparents = 1, nil
+
+		_, err := c.SourceTypes(context.Background())
+		require.NoError(t, err)
+		require.Empty(t, exporter.GetSpans())
+		require.Equal(t, []string{""}, traceparents)
+	})
+
+	t.Run("retried", func(t *testing.T) {
+		exporter.Reset()
+		attempts, traceparents = 0, nil
+
+		ctx, parent := otel.Tracer("test").Start(context.Background(), "parent")
+		_, err := c.SourceTypes(ctx)
+		require.NoError(t, err)
+		parent.End()
+
+		spans := exporter.GetSpans()
+		require.Len(t, spans, 2)
+
+		span := spans[0]
+		require.Equal(t, "GET /source-types", span.Name)
+		require.Equal(t, parent.SpanContext().SpanID(), span.Parent.SpanID())
+		require.Contains(t, span.Attributes, attribute.Int("http.response.status_code", http.StatusOK))
+		require.Equal(t, codes.Unset, span.Status.Code)
+		require.Len(t, span.Events, 1)
+		require.Equal(t, "retry", span.Events[0].Name)
+
+		// Both attempts propagate the request span
+		require.Len(t, traceparents, 2)
+		require.Contains(t, traceparents[0], span.SpanContext.SpanID().String())
+		require.Equal(t, traceparents[0], traceparents[1])
+	})
+
+	t.Run("error status", func(t *testing.T) {
+		exporter.Reset()
+
+		ctx, parent := otel.Tracer("test").Start(context.Background(), "parent")
+		_, err := c.Source(ctx, "missing")
+		require.ErrorIs(t, err, ErrNotFound)
+		parent.End()
+
+		spans := exporter.GetSpans()
+		require.Len(t, spans, 2)
+		require.Equal(t, "GET /sources/missing", spans[0].Name)
+		require.Contains(t, spans[0].Attributes, attribute.Int("http.response.status_code", http.StatusNotFound))
+		require.Equal(t, codes.Error, spans[0].Status.Code)
+	})
+}
@@ -0,0 +1,262 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// maxStreamErrorBody is the size of an error response read
+// from a streamed request, larger bodies are truncated
+const maxStreamErrorBody = 64 * 1024
+
+// RawConfigurationReader queries the BindPlane API and returns a reader of
+// the raw configuration by name. Unlike RawConfiguration, the response is
+// streamed instead of buffered, so memory stays bounded when downloading
+// large configurations. The reader must be closed.
+func (c *BindPlane) RawConfigurationReader(ctx context.Context, name string) (io.ReadCloser, error) {
+	req, cancel := c.request(ctx, c.fetchTimeout)
+
+	resp, err := req.SetDoNotParseResponse(true).Get(fmt.Sprintf("/configurations/%s", name))
+	if resp != nil && resp.RawResponse != nil && err != nil {
+		_ = resp.RawBody().Close()
+	}
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	body := resp.RawBody()
+	if status := resp.StatusCode(); status > 399 {
+		defer cancel()
+		defer body.Close()
+		b, _ := io.ReadAll(io.LimitReader(body, maxStreamErrorBody))
+		return nil, &StatusError{StatusCode: status, Body: c.redactor.redact(string(b)), RequestID: requestID(resp.Header())}
+	}
+
+	raw, err := rawField(body)
+	if err != nil {
+		_ = body.Close()
+		cancel()
+		return nil, fmt.Errorf("read configuration %s: %w", name, err)
+	}
+	return &streamReader{Reader: raw, body: body, cancel: cancel}, nil
+}
+
+// streamReader reads a streamed response, and releases
+// the response and its request context when closed
+type streamReader struct {
+	io.Reader
+	body   io.Closer
+	cancel context.CancelFunc
+}
+
+// Close closes the response body and cancels the request
+func (s *streamReader) Close() error {
+	defer s.cancel()
+	return s.body.Close()
+}
+
+// rawField returns a reader of the raw field of a configuration response.
+// Other fields are skipped a token at a time, so the response is never
+// held in memory.
+func rawField(body io.Reader) (io.Reader, error) {
+	dec := json.NewDecoder(body)
+	if err := expectDelim(dec, '{'); err != nil {
+		return nil, err
+	}
+
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		if key, _ := t.(string); key == "raw" {
+			// The decoder has buffered part of the body, the
+			// value starts in the buffer
+			return newStringReader(bufio.NewReader(io.MultiReader(dec.Buffered(), body)))
+		}
+		if err := skipValue(dec); err != nil {
+			return nil, err
+		}
+	}
+	return nil, errors.New("response does not contain a raw configuration")
+}
+
+// expectDelim reads the next token from dec, which must be delim
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	t, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if d, ok := t.(json.Delim); !ok || d != delim {
+		return fmt.Errorf("expected %s, got %v", delim, t)
+	}
+	return nil
+}
+
+// skipValue reads the next value from dec, including
+// every token of objects and arrays, and discards it
+func skipValue(dec *json.Decoder) error {
+	depth := 0
+	for {
+		t, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch t {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+// stringReader decodes a JSON string as it is read
+type stringReader struct {
+	r *bufio.Reader
+
+	// pending are decoded bytes which did not fit in the last read
+	pending []byte
+	done    bool
+}
+
+// newStringReader returns a reader of the JSON string value
+// at the start of r, which may be preceded by the colon of
+// its key. A null value is read as an empty string.
+func newStringReader(r *bufio.Reader) (*stringReader, error) {
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		switch b {
+		case ' ', '\t', '\n', '\r', ':':
+			continue
+		case '"':
+			return &stringReader{r: r}, nil
+		case 'n':
+			if rest, err := r.Peek(3); err == nil && string(rest) == "ull" {
+				return &stringReader{r: r, done: true}, nil
+			}
+		}
+		return nil, fmt.Errorf("raw configuration is not a string")
+	}
+}
+
+// Read decodes the string into p, returning io.EOF
+// once the closing quote has been read
+func (s *stringReader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if len(s.pending) > 0 {
+			c := copy(p[n:], s.pending)
+			s.pending = s.pending[c:]
+			n += c
+			continue
+		}
+		// Return what has been decoded instead of
+		// blocking on the network for more
+		if s.done || (n > 0 && s.r.Buffered() == 0) {
+			break
+		}
+
+		b, err := s.r.ReadByte()
+		if err != nil {
+			return n, unexpectedEOF(err)
+		}
+		switch b {
+		case '"':
+			s.done = true
+		case '\\':
+			if err := s.escape(); err != nil {
+				return n, err
+			}
+		default:
+			p[n] = b
+			n++
+		}
+	}
+
+	if n == 0 && s.done {
+		return 0, io.EOF
+	}
+	return n, nil
+}
+
+// escape decodes the escape sequence following a backslash into pending
+func (s *stringReader) escape() error {
+	b, err := s.r.ReadByte()
+	if err != nil {
+		return unexpectedEOF(err)
+	}
+	switch b {
+	case '"', '\\', '/':
+		s.pending = append(s.pending, b)
+	case 'b':
+		s.pending = append(s.pending, '\b')
+	case 'f':
+		s.pending = append(s.pending, '\f')
+	case 'n':
+		s.pending = append(s.pending, '\n')
+	case 'r':
+		s.pending = append(s.pending, '\r')
+	case 't':
+		s.pending = append(s.pending, '\t')
+	case 'u':
+		r, err := s.hex()
+		if err != nil {
+			return err
+		}
+		// Characters outside of the basic multilingual
+		// plane are escaped as a surrogate pair
+		if utf16.IsSurrogate(r) {
+			if next, err := s.r.Peek(2); err == nil && string(next) == `\u` {
+				_, _ = s.r.Discard(2)
+				low, err := s.hex()
+				if err != nil {
+					return err
+				}
+				r = utf16.DecodeRune(r, low)
+			} else {
+				r = utf8.RuneError
+			}
+		}
+		s.pending = utf8.AppendRune(s.pending, r)
+	default:
+		return fmt.Errorf("invalid escape sequence \\%c", b)
+	}
+	return nil
+}
+
+// hex reads the four hex digits of a \u escape sequence
+func (s *stringReader) hex() (rune, error) {
+	digits := make([]byte, 4)
+	if _, err := io.ReadFull(s.r, digits); err != nil {
+		return 0, unexpectedEOF(err)
+	}
+	v, err := strconv.ParseUint(string(digits), 16, 16)
+	if err != nil {
+		return 0, fmt.Errorf("invalid escape sequence \\u%s", digits)
+	}
+	return rune(v), nil
+}
+
+// unexpectedEOF returns io.ErrUnexpectedEOF for a body which
+// ended before the string was closed
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,92 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/observiq/bindplane-op-action/pkg/client/config"
+	"github.com/observiq/bindplane-op-action/pkg/client/model"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRawField(t *testing.T) {
+	cases := []struct {
+		name      string
+		body      string
+		expect    string
+		expectErr string
+	}{
+		{"raw last", `{"configuration":{"metadata":{"name":"a","labels":{"x":"y"}},"spec":{"sources":[{"name":"s"}]}},"raw":"receivers:\n  otlp:"}`, "receivers:\n  otlp:", ""},
+		{"raw first", `{ "raw" : "exporters:", "configuration": {} }`, "exporters:", ""},
+		{"escapes", `{"raw":"quote \" slash \\ \/ tab \t \u00e9 \ud83d\ude00"}`, "quote \" slash \\ / tab \t é 😀", ""},
+		{"null", `{"raw":null}`, "", ""},
+		{"missing", `{"configuration":{}}`, "", "response does not contain a raw configuration"},
+		{"not a string", `{"raw":1}`, "", "raw configuration is not a string"},
+		{"not an object", `[]`, "", "expected {, got ["},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Read a byte at a time, so decoding
+			// does not rely on the buffer size
+			r, err := rawField(iotest.OneByteReader(strings.NewReader(tc.body)))
+			if tc.expectErr != "" {
+				require.EqualError(t, err, tc.expectErr)
+				return
+			}
+			require.NoError(t, err)
+
+			data, err := io.ReadAll(iotest.OneByteReader(r))
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, string(data))
+		})
+	}
+}
+
+func TestRawFieldTruncated(t *testing.T) {
+	r, err := rawField(strings.NewReader(`{"raw":"receivers:`))
+	require.NoError(t, err)
+	_, err = io.ReadAll(r)
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
+
+func TestRawConfigurationReader(t *testing.T) {
+	raw := strings.Repeat("receivers:\n  otlp: {}\n", 10000)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/configurations/{name}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("name") != "gateway" {
+			http.Error(w, `{"errors":["not found"]}`, http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(model.ConfigurationResponse{Configuration: &model.Configuration{}, Raw: raw})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c, err := NewBindPlane(&config.Config{
+		Network: config.Network{
+			RemoteURL: server.URL,
+		},
+	}, zap.NewNop(), WithRetryMaxAttempts(1))
+	require.NoError(t, err)
+
+	r, err := c.RawConfigurationReader(context.Background(), "gateway")
+	require.NoError(t, err)
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	require.Equal(t, raw, string(data))
+
+	_, err = c.RawConfigurationReader(context.Background(), "missing")
+	require.ErrorIs(t, err, ErrNotFound)
+	require.ErrorContains(t, err, "not found")
+}
@@ -0,0 +1,153 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// throttleRemainingRatio is the fraction of the rate limit remaining at
+// which requests are slowed, so the rest of the limit lasts until it resets
+const throttleRemainingRatio = 0.1
+
+// throttleMinRemaining is the remaining number of requests at which
+// requests are slowed when the limit is unknown
+const throttleMinRemaining = 10
+
+// WithDisableAdaptiveThrottling disables slowing requests when the
+// rate limit headers of BindPlane responses show the limit is almost
+// exhausted. See throttle.
+func WithDisableAdaptiveThrottling(b bool) Option {
+	return func(c *BindPlane) {
+		c.disableAdaptiveThrottling = b
+	}
+}
+
+// rateLimitStatus is the rate limit reported by the headers of a response
+type rateLimitStatus struct {
+	limit     int
+	remaining int
+	reset     time.Time
+}
+
+// parseRateLimit returns the rate limit in the X-RateLimit-Limit,
+// X-RateLimit-Remaining, and X-RateLimit-Reset headers, or their
+// RateLimit-* equivalents. The reset is either a number of seconds or
+// a Unix timestamp. False is returned when the remaining requests or
+// reset are missing. The limit is 0 when it is missing.
+func parseRateLimit(h http.Header, now time.Time) (rateLimitStatus, bool) {
+	header := func(name string) (int64, bool) {
+		for _, prefix := range []string{"X-RateLimit-", "RateLimit-"} {
+			if v := strings.TrimSpace(h.Get(prefix + name)); v != "" {
+				n, err := strconv.ParseInt(v, 10, 64)
+				return n, err == nil && n >= 0
+			}
+		}
+		return 0, false
+	}
+
+	remaining, ok := header("Remaining")
+	if !ok {
+		return rateLimitStatus{}, false
+	}
+	reset, ok := header("Reset")
+	if !ok {
+		return rateLimitStatus{}, false
+	}
+	limit, _ := header("Limit")
+
+	s := rateLimitStatus{limit: int(limit), remaining: int(remaining)}
+	// Delays are far smaller than Unix timestamps of the present
+	if reset > now.Unix()/2 {
+		s.reset = time.Unix(reset, 0)
+	} else {
+		s.reset = now.Add(time.Duration(reset) * time.Second)
+	}
+	return s, true
+}
+
+// throttle spaces requests out when BindPlane reports its rate limit is
+// almost exhausted, so the remaining requests last until the limit resets
+// instead of failing with 429 responses. Once the limit is exhausted,
+// requests wait until it resets.
+type throttle struct {
+	logger *zap.Logger
+
+	mu sync.Mutex
+	// interval is the minimum time between requests until reset
+	interval time.Duration
+	// reset is when the rate limit resets and requests are no longer slowed
+	reset time.Time
+	// next is the earliest time the next request can be sent
+	next time.Time
+}
+
+// update slows requests according to the rate limit headers of a response
+func (t *throttle) update(h http.Header, now time.Time) {
+	s, ok := parseRateLimit(h, now)
+	if !ok {
+		return
+	}
+
+	low := s.remaining < throttleMinRemaining
+	if s.limit > 0 {
+		low = float64(s.remaining) < float64(s.limit)*throttleRemainingRatio
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !low || !s.reset.After(now) {
+		t.interval = 0
+		return
+	}
+
+	throttled := t.interval > 0 || t.next.After(now)
+	t.reset = s.reset
+	if s.remaining == 0 {
+		t.interval = 0
+		t.next = s.reset
+	} else {
+		t.interval = s.reset.Sub(now) / time.Duration(s.remaining)
+	}
+
+	if !throttled {
+		t.logger.Info("BindPlane rate limit is almost exhausted, slowing requests until it resets",
+			zap.Int("remaining", s.remaining),
+			zap.Int("limit", s.limit),
+			zap.Time("reset", s.reset),
+		)
+	}
+}
+
+// wait blocks until the next request can be sent
+func (t *throttle) wait(ctx context.Context) error {
+	t.mu.Lock()
+	now := time.Now()
+	if !now.Before(t.reset) {
+		t.interval = 0
+	}
+	slot := now
+	if t.next.After(now) {
+		slot = t.next
+	}
+	t.next = slot.Add(t.interval)
+	t.mu.Unlock()
+
+	if !slot.After(now) {
+		return nil
+	}
+	timer := time.NewTimer(slot.Sub(now))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,154 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/observiq/bindplane-op-action/pkg/client/config"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	cases := []struct {
+		name     string
+		headers  map[string]string
+		expect   rateLimitStatus
+		expectOK bool
+	}{
+		{
+			"seconds",
+			map[string]string{"X-RateLimit-Limit": "100", "X-RateLimit-Remaining": "5", "X-RateLimit-Reset": "30"},
+			rateLimitStatus{limit: 100, remaining: 5, reset: now.Add(30 * time.Second)},
+			true,
+		},
+		{
+			"timestamp",
+			map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": "1700000060"},
+			rateLimitStatus{remaining: 0, reset: now.Add(time.Minute)},
+			true,
+		},
+		{
+			"standard headers",
+			map[string]string{"RateLimit-Limit": "10", "RateLimit-Remaining": "1", "RateLimit-Reset": "2"},
+			rateLimitStatus{limit: 10, remaining: 1, reset: now.Add(2 * time.Second)},
+			true,
+		},
+		{
+			"missing reset",
+			map[string]string{"X-RateLimit-Remaining": "5"},
+			rateLimitStatus{},
+			false,
+		},
+		{
+			"invalid",
+			map[string]string{"X-RateLimit-Remaining": "many", "X-RateLimit-Reset": "30"},
+			rateLimitStatus{},
+			false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := http.Header{}
+			for k, v := range tc.headers {
+				h.Set(k, v)
+			}
+			s, ok := parseRateLimit(h, now)
+			require.Equal(t, tc.expectOK, ok)
+			require.Equal(t, tc.expect, s)
+		})
+	}
+}
+
+func TestThrottle(t *testing.T) {
+	header := func(limit, remaining int, reset time.Duration) http.Header {
+		h := http.Header{}
+		h.Set("X-RateLimit-Limit", strconv.Itoa(limit))
+		h.Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		h.Set("X-RateLimit-Reset", strconv.Itoa(int(reset.Seconds())))
+		return h
+	}
+	now := time.Now()
+
+	// Plenty of requests remaining
+	th := &throttle{logger: zap.NewNop()}
+	th.update(header(100, 50, time.Minute), now)
+	require.Zero(t, th.interval)
+
+	// The remaining requests are spread until the limit resets
+	th.update(header(100, 5, time.Minute), now)
+	require.Equal(t, 12*time.Second, th.interval)
+	require.Equal(t, now.Add(time.Minute), th.reset)
+
+	// The limit was raised or reset
+	th.update(header(100, 99, time.Minute), now)
+	require.Zero(t, th.interval)
+
+	// Requests wait for the exhausted limit to reset
+	th.update(header(100, 0, time.Minute), now)
+	require.Zero(t, th.interval)
+	require.Equal(t, now.Add(time.Minute), th.next)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, th.wait(ctx), context.DeadlineExceeded)
+}
+
+func TestAdaptiveThrottling(t *testing.T) {
+	var requests atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/version", func(w http.ResponseWriter, _ *http.Request) {
+		// The limit is exhausted by the first request,
+		// and resets a second later
+		if requests.Add(1) == 1 {
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", "1")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"tag":"v1.80.0"}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	cases := []struct {
+		name        string
+		disable     bool
+		expectDelay bool
+	}{
+		{"enabled", false, true},
+		{"disabled", true, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			requests.Store(0)
+			c, err := NewBindPlane(&config.Config{
+				Network: config.Network{
+					RemoteURL: server.URL,
+				},
+			}, zap.NewNop(), WithRetryMaxAttempts(1), WithDisableAdaptiveThrottling(tc.disable))
+			require.NoError(t, err)
+			require.Equal(t, tc.disable, c.throttle == nil)
+
+			_, err = c.Version(context.Background())
+			require.NoError(t, err)
+
+			start := time.Now()
+			_, err = c.Version(context.Background())
+			require.NoError(t, err)
+			if tc.expectDelay {
+				require.GreaterOrEqual(t, time.Since(start), 500*time.Millisecond)
+			} else {
+				require.Less(t, time.Since(start), 500*time.Millisecond)
+			}
+		})
+	}
+}
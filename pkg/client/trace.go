@@ -0,0 +1,92 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/go-resty/resty/v2"
+	"go.uber.org/zap"
+)
+
+// maxTraceBodySize is the maximum number of bytes of a
+// request or response body included in a trace log
+const maxTraceBodySize = 64 * 1024
+
+const redacted = "[REDACTED]"
+
+// redactedHeaders are headers whose values are never logged
+var redactedHeaders = []string{
+	KeyHeader,
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+}
+
+// traceResponse logs the request and response headers and bodies.
+// Credentials, and the redact headers, which are the extra headers with
+// sensitive names, are redacted from the headers,
+// and credentials are redacted from the bodies. Bodies are redacted before
+// they are truncated, so a credential cut by the truncation is not logged.
+func traceResponse(logger *zap.Logger, r *resty.Response, rd *redactor, redact ...string) {
+	var reqHeaders http.Header
+	if r.Request.RawRequest != nil {
+		reqHeaders = r.Request.RawRequest.Header
+	} else {
+		reqHeaders = r.Request.Header
+	}
+
+	logger.Info(
+		"HTTP trace",
+		zap.String("method", r.Request.Method),
+		zap.String("url", r.Request.URL),
+		zap.Any("request_headers", redactHeaders(reqHeaders, redact...)),
+		zap.String("request_body", truncateBody(rd.redact(traceBody(r.Request.Body)))),
+		zap.Int("status", r.StatusCode()),
+		zap.Any("response_headers", redactHeaders(r.Header(), redact...)),
+		zap.String("response_body", truncateBody(rd.redact(string(r.Body())))),
+		zap.Duration("duration", r.Time()),
+	)
+}
+
+// redactHeaders returns a copy of the headers with credentials, and
+// the extra headers, redacted
+func redactHeaders(h http.Header, extra ...string) http.Header {
+	out := h.Clone()
+	if out == nil {
+		return http.Header{}
+	}
+	for _, name := range append(extra, redactedHeaders...) {
+		if out.Get(name) != "" {
+			out.Set(name, redacted)
+		}
+	}
+	return out
+}
+
+// traceBody returns a string representation of a request body
+func traceBody(body any) string {
+	switch b := body.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return string(b)
+	case string:
+		return b
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			return fmt.Sprintf("unable to marshal request body: %s", err)
+		}
+		return string(data)
+	}
+}
+
+// truncateBody returns the body truncated to maxTraceBodySize
+func truncateBody(b string) string {
+	if len(b) <= maxTraceBodySize {
+		return b
+	}
+	return fmt.Sprintf("%s... (truncated %d bytes)", b[:maxTraceBodySize], len(b)-maxTraceBodySize)
+}
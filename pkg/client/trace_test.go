@@ -0,0 +1,113 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/observiq/bindplane-op-action/pkg/client/config"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRedactHeaders(t *testing.T) {
+	h := http.Header{}
+	h.Set(KeyHeader, "secret")
+	h.Set("Authorization", "Basic abc")
+	h.Set("Content-Type", "application/json")
+
+	out := redactHeaders(h)
+	require.Equal(t, redacted, out.Get(KeyHeader))
+	require.Equal(t, redacted, out.Get("Authorization"))
+	require.Equal(t, "application/json", out.Get("Content-Type"))
+
+	// The original headers are not modified
+	require.Equal(t, "secret", h.Get(KeyHeader))
+
+	require.Equal(t, http.Header{}, redactHeaders(nil))
+
+	h.Set("X-Gateway-Auth", "gateway-token")
+	require.Equal(t, redacted, redactHeaders(h, "X-Gateway-Auth").Get("X-Gateway-Auth"))
+}
+
+func TestTraceBody(t *testing.T) {
+	require.Equal(t, "", traceBody(nil))
+	require.Equal(t, "raw", traceBody([]byte("raw")))
+	require.Equal(t, "str", traceBody("str"))
+	require.Equal(t, `{"a":1}`, traceBody(map[string]int{"a": 1}))
+
+	large := strings.Repeat("a", maxTraceBodySize+10)
+	require.Equal(t, large, traceBody(large))
+	require.Equal(t, strings.Repeat("a", maxTraceBodySize)+"... (truncated 10 bytes)", truncateBody(large))
+}
+
+func TestHTTPTrace(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"tag":"v1.0.0"}`))
+	}))
+	defer server.Close()
+
+	core, logs := observer.New(zapcore.InfoLevel)
+
+	c, err := NewBindPlane(&config.Config{
+		Auth: config.Auth{
+			APIKey:   "api-key",
+			Username: "user",
+			Password: "pass",
+		},
+		Network: config.Network{
+			RemoteURL: server.URL,
+		},
+	}, zap.New(core), WithHTTPTrace(true))
+	require.NoError(t, err)
+
+	_, err = c.Version(context.Background())
+	require.NoError(t, err)
+
+	entries := logs.FilterMessage("HTTP trace").All()
+	require.Len(t, entries, 1)
+
+	fields := entries[0].ContextMap()
+	reqHeaders, ok := fields["request_headers"].(http.Header)
+	require.True(t, ok)
+	require.Equal(t, redacted, reqHeaders.Get(KeyHeader))
+	require.Equal(t, redacted, reqHeaders.Get("Authorization"))
+	require.Equal(t, `{"tag":"v1.0.0"}`, fields["response_body"])
+	require.Equal(t, int64(200), fields["status"])
+}
+
+func TestHTTPTraceTruncatedCredential(t *testing.T) {
+	// The truncation cuts the password and the API key in the body
+	prefix := `{"padding":"`
+	padding := strings.Repeat("a", maxTraceBodySize-len(prefix)-len(`","password":"hunter`))
+	body := prefix + padding + `","password":"hunter2-long-password","key":"api-key-123"}`
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	c, err := NewBindPlane(&config.Config{
+		Auth:    config.Auth{APIKey: "api-key-123"},
+		Network: config.Network{RemoteURL: server.URL},
+	}, zap.New(core), WithHTTPTrace(true))
+	require.NoError(t, err)
+
+	_, _ = c.Version(context.Background())
+
+	entries := logs.FilterMessage("HTTP trace").All()
+	require.Len(t, entries, 1)
+	logged, ok := entries[0].ContextMap()["response_body"].(string)
+	require.True(t, ok)
+	require.Contains(t, logged, "... (truncated")
+	require.NotContains(t, logged, "hunter")
+	require.NotContains(t, logged, "api-key")
+}
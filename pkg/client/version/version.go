@@ -0,0 +1,59 @@
+package version
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type Version struct {
+	Commit string `json:"commit"`
+	Tag    string `json:"tag"`
+}
+
+// AtLeast returns true if the version's tag is greater than or equal to
+// min. Both are semantic versions with an optional leading v, such as
+// v1.50.0. Pre-release and build suffixes are ignored.
+func (v Version) AtLeast(min string) (bool, error) {
+	current, err := Parse(v.Tag)
+	if err != nil {
+		return false, err
+	}
+
+	minimum, err := Parse(min)
+	if err != nil {
+		return false, err
+	}
+
+	for i := range current {
+		if current[i] != minimum[i] {
+			return current[i] > minimum[i], nil
+		}
+	}
+	return true, nil
+}
+
+// Parse parses a semantic version such as v1.50.0 into its
+// major, minor, and patch numbers
+func Parse(s string) ([3]int, error) {
+	parsed := [3]int{}
+
+	v := strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+
+	parts := strings.Split(v, ".")
+	if len(parts) != 3 {
+		return parsed, fmt.Errorf("version '%s' is not a semantic version such as v1.50.0", s)
+	}
+
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return parsed, fmt.Errorf("version '%s' is not a semantic version such as v1.50.0", s)
+		}
+		parsed[i] = n
+	}
+	return parsed, nil
+}
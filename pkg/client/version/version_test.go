@@ -0,0 +1,79 @@
+package version
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAtLeast(t *testing.T) {
+	cases := []struct {
+		name   string
+		tag    string
+		min    string
+		expect bool
+		errStr string
+	}{
+		{
+			"Equal",
+			"v1.50.0",
+			"v1.50.0",
+			true,
+			"",
+		},
+		{
+			"Newer patch",
+			"v1.50.2",
+			"1.50.0",
+			true,
+			"",
+		},
+		{
+			"Newer major",
+			"v2.0.0",
+			"v1.99.99",
+			true,
+			"",
+		},
+		{
+			"Older minor",
+			"v1.9.0",
+			"v1.10.0",
+			false,
+			"",
+		},
+		{
+			"Pre-release suffix",
+			"v1.50.0-beta.1+abc",
+			"v1.50.0",
+			true,
+			"",
+		},
+		{
+			"Invalid tag",
+			"latest",
+			"v1.50.0",
+			false,
+			"version 'latest' is not a semantic version such as v1.50.0",
+		},
+		{
+			"Invalid minimum",
+			"v1.50.0",
+			"v1.x.0",
+			false,
+			"version 'v1.x.0' is not a semantic version such as v1.50.0",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ok, err := Version{Tag: tc.tag}.AtLeast(tc.min)
+			if tc.errStr != "" {
+				require.EqualError(t, err, tc.errStr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, ok)
+		})
+	}
+}